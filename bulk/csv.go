@@ -0,0 +1,144 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+	mapping Mapping
+	row     int
+}
+
+func newCSVReader(r io.Reader, mapping Mapping) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read CSV header")
+	}
+	return &csvReader{
+		r:       cr,
+		columns: header,
+		mapping: mapping,
+	}, nil
+}
+
+func (r *csvReader) Read() (*Record, error) {
+	values, err := r.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	r.row++
+	if err != nil {
+		if _, ok := err.(*csv.ParseError); ok {
+			return &Record{Row: r.row, Err: err}, nil
+		}
+		return nil, err
+	}
+
+	record := Record{
+		Row: r.row,
+		User: model.User{
+			Meta: make(map[string]interface{}),
+		},
+	}
+	for i := range r.columns {
+		switch r.columns[i] {
+		case columnID:
+			record.User.ID = values[i]
+		case columnStatus:
+			record.User.Status = values[i]
+		case columnCreatedAt, columnUpdatedAt:
+			// timestamps are managed by the storage.
+		default:
+			if values[i] != "" {
+				record.User.Meta[r.mapping.metaKey(r.columns[i])] = values[i]
+			}
+		}
+	}
+	return &record, nil
+}
+
+type csvWriter struct {
+	w             *csv.Writer
+	metaKeys      []string
+	headerWritten bool
+	header        []string
+}
+
+func newCSVWriter(w io.Writer, mapping Mapping, metaKeys []string) (*csvWriter, error) {
+	if len(metaKeys) == 0 {
+		return nil, errors.New("meta keys are required for CSV")
+	}
+	header := []string{columnID, columnStatus, columnCreatedAt, columnUpdatedAt}
+	for i := range metaKeys {
+		header = append(header, mapping.column(metaKeys[i]))
+	}
+	return &csvWriter{
+		w:        csv.NewWriter(w),
+		metaKeys: metaKeys,
+		header:   header,
+	}, nil
+}
+
+func (w *csvWriter) Write(user export.User) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	values := []string{
+		user.ID,
+		user.Status,
+		user.CreatedAt.Format(time.RFC3339),
+		user.UpdatedAt.Format(time.RFC3339),
+	}
+	for i := range w.metaKeys {
+		value, err := csvValue(user.Meta[w.metaKeys[i]])
+		if err != nil {
+			return errors.Wrapf(err, "could not convert %s meta value of user %s", w.metaKeys[i], user.ID)
+		}
+		values = append(values, value)
+	}
+	return w.w.Write(values)
+}
+
+func (w *csvWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+func (w *csvWriter) writeHeader() error {
+	if w.headerWritten {
+		return nil
+	}
+	w.headerWritten = true
+	return w.w.Write(w.header)
+}
+
+// csvValue converts meta value to CSV cell, complex values are JSON encoded.
+func csvValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int32, int64, float64:
+		return fmt.Sprintf("%v", v), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
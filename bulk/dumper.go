@@ -0,0 +1,44 @@
+package bulk
+
+import (
+	"context"
+
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+const defaultBatchSize = 100
+
+// Dump writes all users matching the filter in batches.
+// Filter limit and offset are overridden by the batches.
+// Returns the number of written users.
+func Dump(ctx context.Context, userStorage storage.User, filter model.UserFindFilter, w Writer, batchSize int64) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+
+	var (
+		written int
+		offset  int64
+	)
+	filter.Limit = &batchSize
+	filter.Offset = &offset
+	for {
+		users, err := userStorage.Find(ctx, filter)
+		if err != nil {
+			return written, err
+		}
+		for i := range users {
+			if err := w.Write(export.NewUser(users[i])); err != nil {
+				return written, errors.Wrapf(err, "could not write user %s", users[i].ID)
+			}
+			written++
+		}
+		if int64(len(users)) < batchSize {
+			return written, w.Flush()
+		}
+		offset += int64(len(users))
+	}
+}
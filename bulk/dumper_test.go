@@ -0,0 +1,107 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Test_Dump(t *testing.T) {
+	at := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	filterMatcher := func(offset int64) interface{} {
+		return mock.MatchedBy(func(f model.UserFindFilter) bool {
+			return f.Statuses[0] == "ACCOUNT_STATUS_ACTIVE" && *f.Offset == offset && *f.Limit == 2
+		})
+	}
+	filter := model.UserFindFilter{
+		Statuses: []string{"ACCOUNT_STATUS_ACTIVE"},
+	}
+	users := []model.User{
+		{
+			ID:        "1",
+			Status:    "ACCOUNT_STATUS_ACTIVE",
+			CreatedAt: at,
+			UpdatedAt: at,
+			Meta: map[string]interface{}{
+				"email": primitive.Binary{Data: []byte("john@example.com")},
+				"tags":  primitive.A{"a", "b"},
+			},
+		},
+		{
+			ID:        "2",
+			Status:    "ACCOUNT_STATUS_ACTIVE",
+			CreatedAt: at,
+			UpdatedAt: at,
+		},
+		{
+			ID:        "3",
+			Status:    "ACCOUNT_STATUS_ACTIVE",
+			CreatedAt: at,
+			UpdatedAt: at,
+		},
+	}
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errMock)
+		w, err := NewWriter(FormatJSONL, new(bytes.Buffer), nil, nil)
+		require.NoError(t, err)
+		_, err = Dump(context.Background(), st, filter, w, 2)
+		require.Error(t, err)
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("csv without meta keys", func(t *testing.T) {
+		_, err := NewWriter(FormatCSV, new(bytes.Buffer), nil, nil)
+		require.Error(t, err)
+	})
+	t.Run("csv", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filterMatcher(0)).Return(users[:2], nil).Once()
+		st.On("Find", mock.Anything, filterMatcher(2)).Return(users[2:], nil).Once()
+		var buf bytes.Buffer
+		w, err := NewWriter(FormatCSV, &buf, Mapping{"mail": "email"}, []string{"email", "tags"})
+		require.NoError(t, err)
+		n, err := Dump(context.Background(), st, filter, w, 2)
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Equal(t, strings.Join([]string{
+			"id,status,created_at,updated_at,mail,tags",
+			`1,ACCOUNT_STATUS_ACTIVE,2020-11-01T00:00:00Z,2020-11-01T00:00:00Z,john@example.com,"[""a"",""b""]"`,
+			"2,ACCOUNT_STATUS_ACTIVE,2020-11-01T00:00:00Z,2020-11-01T00:00:00Z,,",
+			"3,ACCOUNT_STATUS_ACTIVE,2020-11-01T00:00:00Z,2020-11-01T00:00:00Z,,",
+		}, "\n")+"\n", buf.String())
+	})
+	t.Run("jsonl round trip", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filterMatcher(0)).Return(users[:1], nil).Once()
+		var buf bytes.Buffer
+		w, err := NewWriter(FormatJSONL, &buf, nil, nil)
+		require.NoError(t, err)
+		n, err := Dump(context.Background(), st, filter, w, 2)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+		r, err := NewReader(FormatJSONL, &buf, nil)
+		require.NoError(t, err)
+		record, err := r.Read()
+		require.NoError(t, err)
+		require.NoError(t, record.Err)
+		require.Equal(t, model.User{
+			ID:     "1",
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta: map[string]interface{}{
+				"email": "john@example.com",
+				"tags":  []interface{}{"a", "b"},
+			},
+		}, record.User)
+	})
+}
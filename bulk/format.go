@@ -0,0 +1,107 @@
+package bulk
+
+import (
+	"io"
+	"strings"
+
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// Format represents bulk file format.
+type Format string
+
+// There are supported bulk file formats.
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+// There are reserved CSV columns which are not mapped to meta keys.
+const (
+	columnID        = "id"
+	columnStatus    = "status"
+	columnCreatedAt = "created_at"
+	columnUpdatedAt = "updated_at"
+)
+
+// Record represents a single user read from the bulk file.
+type Record struct {
+	// Row is 1-based record number, CSV header is not counted.
+	Row  int
+	User model.User
+	// Err contains row parse error, the rest of the file can still be read.
+	Err error
+}
+
+// Reader represents bulk file reader.
+type Reader interface {
+	// Read returns the next record or io.EOF at the end of the file.
+	Read() (*Record, error)
+}
+
+// Writer represents bulk file writer.
+type Writer interface {
+	Write(user export.User) error
+	Flush() error
+}
+
+// Mapping maps bulk file columns to meta keys.
+// Columns which are not in the mapping use their own names as meta keys.
+type Mapping map[string]string
+
+// ParseMapping parses mapping in the column=key[,column=key] form.
+func ParseMapping(s string) (Mapping, error) {
+	m := make(Mapping)
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+			return nil, errors.Errorf("invalid mapping %q", pair)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, nil
+}
+
+func (m Mapping) metaKey(column string) string {
+	if key, ok := m[column]; ok {
+		return key
+	}
+	return column
+}
+
+func (m Mapping) column(metaKey string) string {
+	for column, key := range m {
+		if key == metaKey {
+			return column
+		}
+	}
+	return metaKey
+}
+
+// NewReader creates new bulk file reader for the format.
+func NewReader(format Format, r io.Reader, mapping Mapping) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r, mapping)
+	}
+	return nil, errors.Errorf("unknown format %q", format)
+}
+
+// NewWriter creates new bulk file writer for the format.
+// CSV files contain only the provided meta keys.
+func NewWriter(format Format, w io.Writer, mapping Mapping, metaKeys []string) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	case FormatCSV:
+		return newCSVWriter(w, mapping, metaKeys)
+	}
+	return nil, errors.Errorf("unknown format %q", format)
+}
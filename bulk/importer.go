@@ -0,0 +1,128 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/open-Q/user/meta"
+	"github.com/open-Q/user/status"
+	"github.com/open-Q/user/storage"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RowError represents failed row.
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportOptions represents import options.
+type ImportOptions struct {
+	// SkipRows is the number of rows processed by the previous run.
+	SkipRows int
+	// DefaultStatus is used for rows without status.
+	DefaultStatus string
+	// MetaSchema validates rows' meta, rows violating it fail regardless of the schema mode.
+	// Any meta is accepted if empty.
+	MetaSchema *meta.Registry
+	// Errors receives failed rows as JSON lines.
+	Errors io.Writer
+	// Checkpoint is called after each processed row.
+	Checkpoint func(row int) error
+}
+
+// ImportReport represents import results.
+type ImportReport struct {
+	Imported int
+	Failed   int
+	Skipped  int
+	// LastRow is the last processed row, it can be used to resume the import.
+	LastRow int
+}
+
+// Import reads users from the reader and adds them to the storage.
+// Invalid rows and rows rejected by the storage are reported and skipped.
+func Import(ctx context.Context, userStorage storage.User, r Reader, opts ImportOptions) (*ImportReport, error) {
+	var report ImportReport
+	var errEnc *json.Encoder
+	if opts.Errors != nil {
+		errEnc = json.NewEncoder(opts.Errors)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return &report, err
+		}
+
+		record, err := r.Read()
+		if err == io.EOF {
+			return &report, nil
+		}
+		if err != nil {
+			return &report, errors.Wrapf(err, "could not read row %d", report.LastRow+1)
+		}
+
+		if record.Row <= opts.SkipRows {
+			report.Skipped++
+			continue
+		}
+
+		rowErr := record.Err
+		if rowErr == nil {
+			if record.User.Status == "" {
+				record.User.Status = opts.DefaultStatus
+			}
+			rowErr = validateRecord(record, opts.MetaSchema)
+		}
+		if rowErr == nil {
+			_, rowErr = userStorage.Add(ctx, record.User)
+		}
+
+		report.LastRow = record.Row
+		if rowErr != nil {
+			report.Failed++
+			if errEnc != nil {
+				if err := errEnc.Encode(RowError{Row: record.Row, Error: rowErr.Error()}); err != nil {
+					return &report, errors.Wrap(err, "could not write row error")
+				}
+			}
+		} else {
+			report.Imported++
+		}
+
+		if opts.Checkpoint != nil {
+			if err := opts.Checkpoint(record.Row); err != nil {
+				return &report, errors.Wrap(err, "could not save checkpoint")
+			}
+		}
+	}
+}
+
+func validateRecord(record *Record, metaSchema *meta.Registry) error {
+	if record.User.ID != "" {
+		if _, err := primitive.ObjectIDFromHex(record.User.ID); err != nil {
+			return fmt.Errorf("invalid user ID %q", record.User.ID)
+		}
+	}
+	if record.User.Status == "" {
+		return errors.New("status is required")
+	}
+	if !status.Known(record.User.Status) {
+		return fmt.Errorf("unknown status %q", record.User.Status)
+	}
+	for k := range record.User.Meta {
+		if k == "" || strings.HasPrefix(k, "$") || strings.Contains(k, ".") {
+			return fmt.Errorf("invalid meta key %q", k)
+		}
+	}
+	if metaSchema != nil {
+		if violations := metaSchema.Validate(record.User.Meta); len(violations) != 0 {
+			return fmt.Errorf("invalid meta: %s", violations.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/open-Q/user/meta"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errMock = errors.New("error")
+
+func Test_ParseMapping(t *testing.T) {
+	t.Run("invalid mapping", func(t *testing.T) {
+		_, err := ParseMapping("email=contact_email,phone")
+		require.Error(t, err)
+		require.EqualError(t, err, `invalid mapping "phone"`)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		m, err := ParseMapping("email=contact_email, phone = mobile")
+		require.NoError(t, err)
+		require.Equal(t, Mapping{"email": "contact_email", "phone": "mobile"}, m)
+		require.Equal(t, "contact_email", m.metaKey("email"))
+		require.Equal(t, "name", m.metaKey("name"))
+		require.Equal(t, "email", m.column("contact_email"))
+	})
+}
+
+func Test_Import(t *testing.T) {
+	id := primitive.NewObjectID().Hex()
+	t.Run("csv", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		data := strings.Join([]string{
+			"id,status,email,name",
+			id + ",ACCOUNT_STATUS_SUSPENDED,john@example.com,john",
+			"invalid,,jane@example.com,jane",
+			",,bob@example.com",
+			",,alice@example.com,alice",
+			",,eve@example.com,eve",
+		}, "\n")
+		r, err := NewReader(FormatCSV, strings.NewReader(data), Mapping{"email": "contact_email"})
+		require.NoError(t, err)
+		st.On("Add", mock.Anything, model.User{
+			ID:     id,
+			Status: "ACCOUNT_STATUS_SUSPENDED",
+			Meta: map[string]interface{}{
+				"contact_email": "john@example.com",
+				"name":          "john",
+			},
+		}).Return(&model.User{}, nil).Once()
+		st.On("Add", mock.Anything, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta: map[string]interface{}{
+				"contact_email": "alice@example.com",
+				"name":          "alice",
+			},
+		}).Return(nil, errMock).Once()
+		var errs bytes.Buffer
+		var checkpoints []int
+		report, err := Import(context.Background(), st, r, ImportOptions{
+			SkipRows:      0,
+			DefaultStatus: "ACCOUNT_STATUS_ACTIVE",
+			Errors:        &errs,
+			Checkpoint: func(row int) error {
+				checkpoints = append(checkpoints, row)
+				if row == 4 {
+					return errMock
+				}
+				return nil
+			},
+		})
+		require.Error(t, err)
+		require.EqualError(t, err, "could not save checkpoint: "+errMock.Error())
+		require.Equal(t, ImportReport{Imported: 1, Failed: 3, LastRow: 4}, *report)
+		require.Equal(t, []int{1, 2, 3, 4}, checkpoints)
+		lines := strings.Split(strings.TrimSpace(errs.String()), "\n")
+		require.Len(t, lines, 3)
+		require.Equal(t, `{"row":2,"error":"invalid user ID \"invalid\""}`, lines[0])
+		require.Contains(t, lines[1], `"row":3`)
+		require.Equal(t, `{"row":4,"error":"error"}`, lines[2])
+	})
+	t.Run("jsonl with resume", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		data := strings.Join([]string{
+			`{"status":"ACCOUNT_STATUS_ACTIVE","meta":{"email":"john@example.com"}}`,
+			`{"status":"ACCOUNT_STATUS_ACTIVE","meta":{"email":"jane@example.com"}}`,
+			``,
+			`invalid`,
+			`{"status":"ACCOUNT_STATUS_ACTIVE","meta":{"$where":"1"}}`,
+			`{"status":"ACCOUNT_STATUS_BLOCKED","meta":{"email":"bob@example.com"}}`,
+		}, "\n")
+		r, err := NewReader(FormatJSONL, strings.NewReader(data), nil)
+		require.NoError(t, err)
+		st.On("Add", mock.Anything, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta: map[string]interface{}{
+				"email": "jane@example.com",
+			},
+		}).Return(&model.User{}, nil).Once()
+		report, err := Import(context.Background(), st, r, ImportOptions{
+			SkipRows: 1,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ImportReport{Imported: 1, Failed: 3, Skipped: 1, LastRow: 6}, *report)
+	})
+	t.Run("meta schema", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		data := strings.Join([]string{
+			`{"status":"ACCOUNT_STATUS_ACTIVE","meta":{"email":"john@example.com"}}`,
+			`{"status":"ACCOUNT_STATUS_ACTIVE","meta":{"email":"jane"}}`,
+			`{"status":"ACCOUNT_STATUS_ACTIVE","meta":{"name":"bob"}}`,
+		}, "\n")
+		r, err := NewReader(FormatJSONL, strings.NewReader(data), nil)
+		require.NoError(t, err)
+		registry, err := meta.NewRegistry(meta.RegistryConfig{
+			Fields: []model.MetaField{
+				{Key: "email", Type: meta.TypeString, Format: meta.FormatEmail, Required: true},
+			},
+		})
+		require.NoError(t, err)
+		st.On("Add", mock.Anything, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta: map[string]interface{}{
+				"email": "john@example.com",
+			},
+		}).Return(&model.User{}, nil).Once()
+		var errs bytes.Buffer
+		report, err := Import(context.Background(), st, r, ImportOptions{
+			MetaSchema: registry,
+			Errors:     &errs,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ImportReport{Imported: 1, Failed: 2, LastRow: 3}, *report)
+		lines := strings.Split(strings.TrimSpace(errs.String()), "\n")
+		require.Equal(t, []string{
+			`{"row":2,"error":"invalid meta: email: must be email formatted"}`,
+			`{"row":3,"error":"invalid meta: email: is required; name: is not allowed"}`,
+		}, lines)
+	})
+}
@@ -0,0 +1,74 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/storage/model"
+)
+
+// maxLineSize limits the size of a single JSONL record.
+const maxLineSize = 1024 * 1024
+
+type jsonlReader struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineSize)
+	return &jsonlReader{
+		scanner: scanner,
+	}
+}
+
+func (r *jsonlReader) Read() (*Record, error) {
+	for r.scanner.Scan() {
+		r.row++
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var user export.User
+		if err := json.Unmarshal(line, &user); err != nil {
+			return &Record{Row: r.row, Err: err}, nil
+		}
+		return &Record{
+			Row: r.row,
+			User: model.User{
+				ID:        user.ID,
+				Status:    user.Status,
+				Meta:      user.Meta,
+				MetaTypes: user.MetaTypes,
+			},
+		}, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	bw := bufio.NewWriter(w)
+	return &jsonlWriter{
+		w:   bw,
+		enc: json.NewEncoder(bw),
+	}
+}
+
+func (w *jsonlWriter) Write(user export.User) error {
+	return w.enc.Encode(user)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,244 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-Q/user/bulk"
+	"github.com/open-Q/user/meta"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+const checkpointSuffix = ".checkpoint"
+
+func runImport(ctx context.Context, args []string) error {
+	var (
+		sf            storageFlags
+		format        string
+		in            string
+		mapping       string
+		errorsPath    string
+		defaultStatus string
+		resume        bool
+		metaSchema    string
+		allowUnknown  bool
+	)
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	sf.register(fs)
+	fs.StringVar(&format, "format", string(bulk.FormatJSONL), "input format: jsonl or csv")
+	fs.StringVar(&in, "in", "", "input file path")
+	fs.StringVar(&mapping, "map", "", "column to meta key mapping: column=key[,column=key]")
+	fs.StringVar(&errorsPath, "errors", "", "file to write failed rows to, stderr by default")
+	fs.StringVar(&defaultStatus, "default-status", "ACCOUNT_STATUS_ACTIVE", "status of rows without status")
+	fs.BoolVar(&resume, "resume", false, "continue from the last checkpoint of the previous run")
+	fs.StringVar(&metaSchema, "meta-schema", "", "meta schema file, fields stored by the service take precedence")
+	fs.BoolVar(&allowUnknown, "allow-unknown-meta", false, "accept meta keys missing in the meta schema")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if in == "" {
+		return errors.New("input file is required")
+	}
+
+	m, err := bulk.ParseMapping(mapping)
+	if err != nil {
+		return err
+	}
+
+	checkpointPath := in + checkpointSuffix
+	var skipRows int
+	if resume {
+		if skipRows, err = readCheckpoint(checkpointPath); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		return errors.Wrapf(err, "could not open %s file", in)
+	}
+	defer f.Close()
+
+	r, err := bulk.NewReader(bulk.Format(format), f, m)
+	if err != nil {
+		return err
+	}
+
+	errorsFile := os.Stderr
+	if errorsPath != "" {
+		if errorsFile, err = os.OpenFile(errorsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.ModePerm); err != nil {
+			return errors.Wrapf(err, "could not open %s file", errorsPath)
+		}
+		defer errorsFile.Close()
+	}
+
+	mongoStorage, err := sf.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := mongoStorage.Disconnect(ctx); err != nil {
+			log.Printf("could not close storage connection: %v", err)
+		}
+	}()
+	userStorage, err := sf.encrypt(mongoStorage)
+	if err != nil {
+		return err
+	}
+
+	// rows are validated against the same schema as the service's writes.
+	var metaFields []model.MetaField
+	if metaSchema != "" {
+		if metaFields, err = meta.LoadFields(metaSchema); err != nil {
+			return err
+		}
+	}
+	registry, err := meta.NewRegistry(meta.RegistryConfig{
+		Fields:       metaFields,
+		Storage:      storage.NewMongoMetaSchemaStorage(mongoStorage),
+		AllowUnknown: allowUnknown,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not create meta schema")
+	}
+	if err := registry.Load(ctx); err != nil {
+		return err
+	}
+
+	report, err := bulk.Import(ctx, userStorage, r, bulk.ImportOptions{
+		SkipRows:      skipRows,
+		DefaultStatus: defaultStatus,
+		MetaSchema:    registry,
+		Errors:        errorsFile,
+		Checkpoint: func(row int) error {
+			return ioutil.WriteFile(checkpointPath, []byte(strconv.Itoa(row)), os.ModePerm)
+		},
+	})
+	log.Printf("imported: %d, failed: %d, skipped: %d, last row: %d", report.Imported, report.Failed, report.Skipped, report.LastRow)
+	if err != nil {
+		return errors.Wrapf(err, "import stopped, run with -resume to continue")
+	}
+
+	// the whole file is processed, next run starts from scratch.
+	if err := os.Remove(checkpointPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "could not remove checkpoint")
+	}
+	return nil
+}
+
+func readCheckpoint(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not read %s file data", path)
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrap(err, "could not parse checkpoint")
+	}
+	return row, nil
+}
+
+func runDump(ctx context.Context, args []string) error {
+	var (
+		sf            storageFlags
+		format        string
+		out           string
+		mapping       string
+		metaKeys      string
+		ids           string
+		statuses      string
+		metaPatterns  string
+		updatedBefore string
+	)
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	sf.register(fs)
+	fs.StringVar(&format, "format", string(bulk.FormatJSONL), "output format: jsonl or csv")
+	fs.StringVar(&out, "out", "-", "output file path, - means stdout")
+	fs.StringVar(&mapping, "map", "", "column to meta key mapping: column=key[,column=key]")
+	fs.StringVar(&metaKeys, "meta-keys", "", "comma separated meta keys to write into CSV")
+	fs.StringVar(&ids, "ids", "", "comma separated user IDs to dump")
+	fs.StringVar(&statuses, "statuses", "", "comma separated statuses to dump")
+	fs.StringVar(&metaPatterns, "meta", "", "meta patterns to match: key=regex[,key=regex]")
+	fs.StringVar(&updatedBefore, "updated-before", "", "dump users not updated since RFC3339 time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := bulk.ParseMapping(mapping)
+	if err != nil {
+		return err
+	}
+	filter := model.UserFindFilter{
+		IDs:      splitList(ids),
+		Statuses: splitList(statuses),
+	}
+	if metaPatterns != "" {
+		if filter.MetaPatterns, err = bulk.ParseMapping(metaPatterns); err != nil {
+			return errors.Wrap(err, "invalid meta patterns")
+		}
+	}
+	if updatedBefore != "" {
+		t, err := time.Parse(time.RFC3339, updatedBefore)
+		if err != nil {
+			return errors.Wrap(err, "invalid update time")
+		}
+		filter.UpdatedBefore = &t
+	}
+
+	f := os.Stdout
+	if out != "-" {
+		if f, err = os.Create(out); err != nil {
+			return errors.Wrapf(err, "could not create %s file", out)
+		}
+		defer f.Close()
+	}
+
+	w, err := bulk.NewWriter(bulk.Format(format), f, m, splitList(metaKeys))
+	if err != nil {
+		return err
+	}
+
+	userStorage, err := sf.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := userStorage.Disconnect(ctx); err != nil {
+			log.Printf("could not close storage connection: %v", err)
+		}
+	}()
+
+	n, err := bulk.Dump(ctx, userStorage, filter, w, 0)
+	if err != nil {
+		return errors.Wrapf(err, "dump stopped after %d users", n)
+	}
+	if out != "-" {
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	log.Printf("dumped: %d", n)
+	return nil
+}
+
+func splitList(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	res := strings.Split(s, ",")
+	for i := range res {
+		res[i] = strings.TrimSpace(res[i])
+	}
+	return res
+}
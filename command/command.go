@@ -0,0 +1,100 @@
+package command
+
+import (
+	"context"
+	"flag"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/encryption"
+	"github.com/pkg/errors"
+)
+
+// Command represents service binary subcommand.
+type Command struct {
+	Name  string
+	Usage string
+	Run   func(ctx context.Context, args []string) error
+}
+
+var commands = []Command{
+	{
+		Name:  "export",
+		Usage: "export all data of a single user",
+		Run:   runExport,
+	},
+	{
+		Name:  "import",
+		Usage: "import users from JSONL or CSV file",
+		Run:   runImport,
+	},
+	{
+		Name:  "dump",
+		Usage: "dump users matching the filter to JSONL or CSV file",
+		Run:   runDump,
+	},
+}
+
+// Lookup returns subcommand by its name.
+func Lookup(name string) (Command, bool) {
+	for i := range commands {
+		if commands[i].Name == name {
+			return commands[i], true
+		}
+	}
+	return Command{}, false
+}
+
+// storageFlags represents flags required to connect to the user storage.
+type storageFlags struct {
+	mongoConn     string
+	mongoDB       string
+	keyring       string
+	encryptedKeys string
+}
+
+func (f *storageFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.mongoConn, "mongo-conn", "mongodb://127.0.0.1:27017", "mongo connection string, may contain any driver options")
+	fs.StringVar(&f.mongoDB, "mongo-db", "user", "mongo database name")
+	fs.StringVar(&f.keyring, "keyring", "", "encryption keyring file path")
+	fs.StringVar(&f.encryptedKeys, "encrypted-keys", "", "comma separated list of encrypted meta keys")
+}
+
+// open connects to the user storage.
+// The returned storage must be disconnected by the caller.
+func (f *storageFlags) open(ctx context.Context) (storage.User, error) {
+	mongoStorage, err := f.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userStorage, err := f.encrypt(mongoStorage)
+	if err != nil {
+		_ = mongoStorage.Disconnect(ctx)
+		return nil, err
+	}
+	return userStorage, nil
+}
+
+// connect connects to the mongo storage without meta encryption.
+func (f *storageFlags) connect(ctx context.Context) (*storage.MongoStorage, error) {
+	mongoStorage, err := storage.NewMongoStorage(ctx, storage.MongoConfig{
+		ConnString: f.mongoConn,
+		DBName:     f.mongoDB,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create connection to storage")
+	}
+	return mongoStorage, nil
+}
+
+// encrypt wraps the mongo storage with meta encryption if the keyring is set.
+func (f *storageFlags) encrypt(mongoStorage *storage.MongoStorage) (storage.User, error) {
+	if f.keyring == "" {
+		return mongoStorage, nil
+	}
+
+	keyring, err := encryption.NewFileKeyring(f.keyring)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not load encryption keyring")
+	}
+	return encryption.NewStorage(mongoStorage, encryption.NewCipher(keyring), splitList(f.encryptedKeys)), nil
+}
@@ -0,0 +1,86 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/status"
+	"github.com/open-Q/user/storage"
+	"github.com/pkg/errors"
+)
+
+// HistorySources returns history sources of the user data exports.
+func HistorySources(userStorage storage.User, identityStorage storage.Identity, apiKeyStorage storage.APIKey) []export.HistorySource {
+	return []export.HistorySource{
+		status.NewHistorySource(userStorage),
+		export.NewIdentitySource(identityStorage),
+		export.NewAPIKeySource(apiKeyStorage),
+	}
+}
+
+func runExport(ctx context.Context, args []string) error {
+	var (
+		sf     storageFlags
+		userID string
+		out    string
+		zipped bool
+	)
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	sf.register(fs)
+	fs.StringVar(&userID, "id", "", "user ID to export")
+	fs.StringVar(&out, "out", "-", "output file path, - means stdout")
+	fs.BoolVar(&zipped, "zip", false, "wrap JSON archive into a zip file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if userID == "" {
+		return errors.New("user ID is required")
+	}
+
+	mongoStorage, err := sf.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := mongoStorage.Disconnect(ctx); err != nil {
+			log.Printf("could not close storage connection: %v", err)
+		}
+	}()
+	userStorage, err := sf.encrypt(mongoStorage)
+	if err != nil {
+		return err
+	}
+	identityStorage, err := storage.NewMongoIdentityStorage(ctx, mongoStorage)
+	if err != nil {
+		return errors.Wrap(err, "could not create identity storage")
+	}
+	apiKeyStorage, err := storage.NewMongoAPIKeyStorage(ctx, mongoStorage)
+	if err != nil {
+		return errors.Wrap(err, "could not create API key storage")
+	}
+
+	// the same sources as the service's exporter, so both archives are equal.
+	sources := HistorySources(userStorage, identityStorage, apiKeyStorage)
+	archive, err := export.New(userStorage, sources...).Export(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, "could not export user")
+	}
+
+	if out == "-" {
+		_, err = export.Write(os.Stdout, archive, zipped)
+		return err
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return errors.Wrapf(err, "could not create %s file", out)
+	}
+	if _, err := export.Write(f, archive, zipped); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
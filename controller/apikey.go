@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/status"
+	storageModel "github.com/open-Q/user/storage/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// CreateAPIKey creates user's API key, the secret is returned only once.
+func (s Service) CreateAPIKey(ctx context.Context, req *proto.CreateAPIKeyRequest, resp *proto.CreateAPIKeyResponse) error {
+	if _, err := s.findUser(ctx, "CreateAPIKey", req.UserId); err != nil {
+		return err
+	}
+	key := storageModel.APIKey{
+		UserID: req.UserId,
+		Name:   req.Name,
+		Scopes: req.Scopes,
+	}
+	if req.ExpiresAt != nil {
+		key.ExpiresAt = req.ExpiresAt.AsTime()
+	}
+	if err := credential.ValidateKey(key, time.Now()); err != nil {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	created, secret, err := s.apiKeys.CreateKey(ctx, key)
+	if err != nil {
+		s.requestLogger("CreateAPIKey", req.UserId).WithError(err).Error("could not create API key")
+		return err
+	}
+	s.requestLogger("CreateAPIKey", req.UserId).WithField("key_id", created.ID).Info("API key created")
+	resp.Key = newAPIKeyProto(*created)
+	resp.Secret = secret
+	return nil
+}
+
+// ListAPIKeys returns user's API keys without secrets, revoked ones included.
+func (s Service) ListAPIKeys(ctx context.Context, req *proto.ListAPIKeysRequest, resp *proto.ListAPIKeysResponse) error {
+	if _, err := s.findUser(ctx, "ListAPIKeys", req.UserId); err != nil {
+		return err
+	}
+	keys, err := s.apiKeys.ListKeys(ctx, req.UserId)
+	if err != nil {
+		s.requestLogger("ListAPIKeys", req.UserId).WithError(err).Error("could not list API keys")
+		return err
+	}
+	resp.Keys = make([]*proto.APIKey, len(keys))
+	for i := range keys {
+		resp.Keys[i] = newAPIKeyProto(keys[i])
+	}
+	return nil
+}
+
+// RevokeAPIKey revokes user's API key, it can't be used afterwards.
+func (s Service) RevokeAPIKey(ctx context.Context, req *proto.RevokeAPIKeyRequest, resp *proto.APIKeyResponse) error {
+	keys, err := s.apiKeys.ListKeys(ctx, req.UserId)
+	if err != nil {
+		s.requestLogger("RevokeAPIKey", req.UserId).WithError(err).Error("could not list API keys")
+		return err
+	}
+	key := findAPIKey(keys, req.Id)
+	if key == nil {
+		return microErrors.NotFound(errorID, "API key %s not found", req.Id)
+	}
+	if !key.RevokedAt.IsZero() {
+		return microErrors.Conflict(errorID, "API key %s is already revoked", req.Id)
+	}
+	revoked, err := s.apiKeys.RevokeKey(ctx, req.UserId, req.Id)
+	if err != nil {
+		s.requestLogger("RevokeAPIKey", req.UserId).WithField("key_id", req.Id).WithError(err).Error("could not revoke API key")
+		return err
+	}
+	s.requestLogger("RevokeAPIKey", req.UserId).WithField("key_id", req.Id).Info("API key revoked")
+	resp.Key = newAPIKeyProto(*revoked)
+	return nil
+}
+
+// VerifyAPIKey checks the API key and, if requested, its scope.
+// Keys of inactive users are not valid.
+func (s Service) VerifyAPIKey(ctx context.Context, req *proto.VerifyAPIKeyRequest, resp *proto.VerifyAPIKeyResponse) error {
+	key, err := s.apiKeys.VerifyKey(ctx, req.Secret)
+	if err == credential.ErrInvalidAPIKey {
+		return nil
+	}
+	if err != nil {
+		s.requestLogger("VerifyAPIKey", "").WithError(err).Error("could not verify API key")
+		return err
+	}
+	if req.Scope != "" && !credential.HasScope(*key, req.Scope) {
+		return nil
+	}
+	users, err := s.userStorage.Find(ctx, storageModel.UserFindFilter{
+		IDs: []string{key.UserID},
+	})
+	if err != nil {
+		s.requestLogger("VerifyAPIKey", key.UserID).WithError(err).Error("could not find user")
+		return err
+	}
+	if len(users) == 0 || users[0].Status != status.Active {
+		return nil
+	}
+	resp.Valid = true
+	resp.UserId = key.UserID
+	resp.Key = newAPIKeyProto(*key)
+	return nil
+}
+
+func findAPIKey(keys []storageModel.APIKey, id string) *storageModel.APIKey {
+	for i := range keys {
+		if keys[i].ID == id {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+func newAPIKeyProto(key storageModel.APIKey) *proto.APIKey {
+	return &proto.APIKey{
+		Id:         key.ID,
+		UserId:     key.UserID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  newTimestamp(key.ExpiresAt),
+		CreatedAt:  timestamppb.New(key.CreatedAt),
+		LastUsedAt: newTimestamp(key.LastUsedAt),
+		RevokedAt:  newTimestamp(key.RevokedAt),
+	}
+}
+
+// newTimestamp converts optional time, zero time is converted to nil.
+func newTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
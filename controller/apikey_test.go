@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestService_APIKeys(t *testing.T) {
+	logger := &commonLog.Logger{Logger: logrus.New()}
+	newService := func(st *storageMocks.User, kst *storageMocks.APIKey) Service {
+		return New(Config{
+			UserStorage: st,
+			APIKeys:     credential.NewKeyManager(credential.KeyConfig{Storage: kst, Logger: logger}),
+			Logger:      logger,
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	t.Run("create invalid key", func(t *testing.T) {
+		st, kst := new(storageMocks.User), new(storageMocks.APIKey)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		err := newService(st, kst).CreateAPIKey(context.Background(), &proto.CreateAPIKeyRequest{
+			UserId:    "1",
+			Name:      "ci",
+			ExpiresAt: timestamppb.New(time.Now().Add(-time.Hour)),
+		}, &proto.CreateAPIKeyResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("revoke unknown key", func(t *testing.T) {
+		st, kst := new(storageMocks.User), new(storageMocks.APIKey)
+		kst.On("FindAPIKeys", mock.Anything, storageModel.APIKeyFindFilter{UserIDs: []string{"1"}}).Return(nil, nil)
+		err := newService(st, kst).RevokeAPIKey(context.Background(), &proto.RevokeAPIKeyRequest{UserId: "1", Id: "k1"}, &proto.APIKeyResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st, kst := new(storageMocks.User), new(storageMocks.APIKey)
+		defer kst.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		var stored storageModel.APIKey
+		kst.On("AddAPIKey", mock.Anything, mock.Anything).Return(func(_ context.Context, key storageModel.APIKey) *storageModel.APIKey {
+			key.ID = "k1"
+			stored = key
+			return &key
+		}, nil)
+		s := newService(st, kst)
+		createResp := &proto.CreateAPIKeyResponse{}
+		require.NoError(t, s.CreateAPIKey(context.Background(), &proto.CreateAPIKeyRequest{
+			UserId: "1",
+			Name:   "ci",
+			Scopes: []string{"orders:read"},
+		}, createResp))
+		require.Equal(t, "k1", createResp.Key.Id)
+		require.NotEmpty(t, createResp.Secret)
+
+		kst.On("FindAPIKeys", mock.Anything, storageModel.APIKeyFindFilter{Prefixes: []string{stored.Prefix}}).Return(func(context.Context, storageModel.APIKeyFindFilter) []storageModel.APIKey {
+			return []storageModel.APIKey{stored}
+		}, nil)
+		verifyResp := &proto.VerifyAPIKeyResponse{}
+		require.NoError(t, s.VerifyAPIKey(context.Background(), &proto.VerifyAPIKeyRequest{Secret: createResp.Secret, Scope: "orders:read"}, verifyResp))
+		require.True(t, verifyResp.Valid)
+		require.Equal(t, "1", verifyResp.UserId)
+		verifyResp = &proto.VerifyAPIKeyResponse{}
+		require.NoError(t, s.VerifyAPIKey(context.Background(), &proto.VerifyAPIKeyRequest{Secret: createResp.Secret, Scope: "orders:write"}, verifyResp))
+		require.False(t, verifyResp.Valid)
+
+		kst.On("FindAPIKeys", mock.Anything, storageModel.APIKeyFindFilter{UserIDs: []string{"1"}}).Return(func(context.Context, storageModel.APIKeyFindFilter) []storageModel.APIKey {
+			return []storageModel.APIKey{stored}
+		}, nil)
+		kst.On("RevokeAPIKey", mock.Anything, "1", "k1").Return(func(context.Context, string, string) *storageModel.APIKey {
+			stored.RevokedAt = time.Now()
+			return &stored
+		}, nil)
+		require.NoError(t, s.RevokeAPIKey(context.Background(), &proto.RevokeAPIKeyRequest{UserId: "1", Id: "k1"}, &proto.APIKeyResponse{}))
+		verifyResp = &proto.VerifyAPIKeyResponse{}
+		require.NoError(t, s.VerifyAPIKey(context.Background(), &proto.VerifyAPIKeyRequest{Secret: createResp.Secret}, verifyResp))
+		require.False(t, verifyResp.Valid)
+	})
+	t.Run("inactive user", func(t *testing.T) {
+		st, kst := new(storageMocks.User), new(storageMocks.APIKey)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Suspended}}, nil)
+		var stored storageModel.APIKey
+		kst.On("AddAPIKey", mock.Anything, mock.Anything).Return(func(_ context.Context, key storageModel.APIKey) *storageModel.APIKey {
+			key.ID = "k1"
+			stored = key
+			return &key
+		}, nil)
+		kst.On("FindAPIKeys", mock.Anything, mock.Anything).Return(func(context.Context, storageModel.APIKeyFindFilter) []storageModel.APIKey {
+			return []storageModel.APIKey{stored}
+		}, nil)
+		s := newService(st, kst)
+		createResp := &proto.CreateAPIKeyResponse{}
+		require.NoError(t, s.CreateAPIKey(context.Background(), &proto.CreateAPIKeyRequest{UserId: "1", Name: "ci"}, createResp))
+		resp := &proto.VerifyAPIKeyResponse{}
+		require.NoError(t, s.VerifyAPIKey(context.Background(), &proto.VerifyAPIKeyRequest{Secret: createResp.Secret}, resp))
+		require.False(t, resp.Valid)
+	})
+}
@@ -3,18 +3,28 @@ package controller
 import (
 	"context"
 
+	microErrors "github.com/micro/go-micro/v2/errors"
 	proto "github.com/open-Q/common/golang/proto/user"
 	storageModel "github.com/open-Q/user/storage/model"
 )
 
 // Create creates new user.
 func (s Service) Create(ctx context.Context, req *proto.CreateRequest, resp *proto.UserResponse) error {
+	meta, metaTypes, err := newUserMeta(req.Meta)
+	if err != nil {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	if err := s.validateMeta("Create", "", meta); err != nil {
+		return err
+	}
 	user := storageModel.User{
-		Status: proto.AccountStatus_name[int32(proto.AccountStatus_ACCOUNT_STATUS_ACTIVE)],
-		Meta:   newUserMeta(req.Meta),
+		Status:    s.statusMachine.Initial(),
+		Meta:      meta,
+		MetaTypes: metaTypes,
 	}
 	createdUser, err := s.userStorage.Add(ctx, user)
 	if err != nil {
+		s.requestLogger("Create", "").WithError(err).Error("could not add user")
 		return err
 	}
 	return newUserResponse(resp, createdUser)
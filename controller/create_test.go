@@ -1,80 +1,97 @@
 package controller
 
 import (
-	"bytes"
 	"context"
 	"testing"
 
-	"github.com/golang/protobuf/ptypes"
-	"github.com/micro/go-micro/v2"
-	"github.com/open-Q/common/golang/proto/user"
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
 	proto "github.com/open-Q/common/golang/proto/user"
-	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/meta"
 	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 func TestService_Create(t *testing.T) {
-	service, err := New(Config{
-		Micro: micro.NewService(),
+	newService := func(st *storageMocks.User) Service {
+		return New(Config{
+			UserStorage: st,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	t.Run("invalid meta", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		err := newService(st).Create(context.Background(), &proto.CreateRequest{
+			Meta: []*proto.UserMeta{
+				{
+					Key: "key1",
+					Value: &anypb.Any{
+						TypeUrl: "type.googleapis.com/google.protobuf.StringValue",
+						Value:   []byte{0xff},
+					},
+				},
+			},
+		}, &proto.UserResponse{})
+		require.Error(t, err)
+	})
+	t.Run("meta schema violation", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		service := newService(st)
+		service.metaSchema = newTestMetaSchema(t, meta.ModeEnforce)
+		err := service.Create(context.Background(), &proto.CreateRequest{
+			Meta: []*proto.UserMeta{
+				{Key: "age", Value: newTestAny(t, "42")},
+			},
+		}, &proto.UserResponse{})
+		require.Error(t, err)
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "invalid meta: age: must be int, got string; email: is required", microErrors.Parse(err.Error()).Detail)
 	})
-	require.NoError(t, err)
-	require.NotNil(t, service)
 	t.Run("save to storage error", func(t *testing.T) {
 		st := new(storageMocks.User)
 		defer st.AssertExpectations(t)
-		service.storage = st
 		st.On("Add", mock.Anything, mock.Anything).Return(nil, errMock)
-		err = service.Create(context.Background(), &user.CreateRequest{}, &user.UserResponse{})
-		require.Error(t, err)
+		err := newService(st).Create(context.Background(), &proto.CreateRequest{}, &proto.UserResponse{})
 		require.EqualError(t, err, errMock.Error())
 	})
 	t.Run("all ok", func(t *testing.T) {
-		st := new(storageMocks.Storage)
+		st := new(storageMocks.User)
 		defer st.AssertExpectations(t)
-		service.storage = st
-		req := &user.CreateRequest{
+		req := &proto.CreateRequest{
 			Meta: []*proto.UserMeta{
-				{
-					Key:   "key1",
-					Value: newAny([]byte("value1")).Any,
-				},
-				{
-					Key:   "key2",
-					Value: newAny([]byte{1, 2, 3}).Any,
-				},
+				{Key: "key1", Value: newTestAny(t, "value1")},
+				{Key: "key2", Value: newTestAny(t, []byte{1, 2, 3})},
 			},
 		}
-		storageResponse := storage.User{
-			ID:     "1",
+		user := storageModel.User{
 			Status: proto.AccountStatus_name[int32(proto.AccountStatus_ACCOUNT_STATUS_ACTIVE)],
-			Meta:   newUserMeta(req.Meta),
+			Meta: map[string]interface{}{
+				"key1": "value1",
+				"key2": []byte{1, 2, 3},
+			},
+			MetaTypes: map[string]string{
+				"key1": "type.googleapis.com/google.protobuf.StringValue",
+			},
 		}
-		st.On("Add", mock.Anything, storage.User{
-			Status: proto.AccountStatus_name[int32(proto.AccountStatus_ACCOUNT_STATUS_ACTIVE)],
-			Meta:   newUserMeta(req.Meta),
-		}).Return(&storageResponse, nil)
-		var resp user.UserResponse
-		err = service.Create(context.Background(), req, &resp)
+		created := user
+		created.ID = "1"
+		st.On("Add", mock.Anything, user).Return(&created, nil)
+		var resp proto.UserResponse
+		err := newService(st).Create(context.Background(), req, &resp)
 		require.NoError(t, err)
-		require.NotNil(t, resp)
-		require.Equal(t, storageResponse.ID, resp.Id)
-		require.Equal(t, proto.AccountStatus(proto.AccountStatus_value[storageResponse.Status]), resp.Status)
-		require.NotNil(t, resp.Meta)
-		require.Equal(t, len(req.Meta), len(resp.Meta))
+		require.Equal(t, "1", resp.Id)
+		require.Equal(t, proto.AccountStatus_ACCOUNT_STATUS_ACTIVE, resp.Status)
+		require.Len(t, resp.Meta, 2)
 		for i := range req.Meta {
-			var found bool
-			for j := range resp.Meta {
-				if resp.Meta[j].Key == req.Meta[i].Key {
-					any, err := ptypes.MarshalAny(newAny(req.Meta[i].Value.GetValue()))
-					require.NoError(t, err)
-					require.True(t, bytes.Equal(resp.Meta[j].Value.GetValue(), any.Value))
-					found = true
-					break
-				}
-			}
-			require.True(t, found)
+			require.Equal(t, req.Meta[i].Key, resp.Meta[i].Key)
+			require.Equal(t, req.Meta[i].Value.TypeUrl, resp.Meta[i].Value.TypeUrl)
+			require.Equal(t, req.Meta[i].Value.Value, resp.Meta[i].Value.Value)
 		}
 	})
 }
@@ -4,19 +4,34 @@ import (
 	"context"
 
 	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/status"
 	storageModel "github.com/open-Q/user/storage/model"
 )
 
-// Delete deletes an existing user.
+// Delete marks the user deleted through the status machine,
+// deleted users are purged by the retention job.
 func (s Service) Delete(ctx context.Context, req *proto.DeleteRequest, resp *proto.UserResponse) error {
-	if err := s.userStorage.Delete(ctx, req.Id); err != nil {
+	user, err := s.findUser(ctx, "Delete", req.Id)
+	if err != nil {
+		return err
+	}
+
+	change := storageModel.StatusChange{
+		From:   user.Status,
+		To:     status.Deleted,
+		Reason: status.ReasonDeleted,
+		Actor:  status.ActorService,
+	}
+	if err := s.checkTransition(change.From, change.To, change.Reason, change.Actor); err != nil {
 		return err
 	}
-	users, err := s.userStorage.Find(ctx, storageModel.UserFindFilter{
-		IDs: []string{req.Id},
-	})
+
+	updatedUser, err := s.userStorage.ChangeStatus(ctx, req.Id, change)
+	s.invalidatePermissions(req.Id)
 	if err != nil {
+		s.requestLogger("Delete", req.Id).WithError(err).Error("could not delete user")
 		return err
 	}
-	return newUserResponse(resp, &users[0])
+	s.requestLogger("Delete", req.Id).WithField("from", change.From).Info("user deleted")
+	return newUserResponse(resp, updatedUser)
 }
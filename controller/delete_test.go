@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Delete(t *testing.T) {
+	newService := func(st *storageMocks.User) Service {
+		return New(Config{
+			UserStorage: st,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	req := &proto.DeleteRequest{Id: "1"}
+	t.Run("user not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{}, nil)
+		err := newService(st).Delete(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("already deleted", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Deleted}}, nil)
+		err := newService(st).Delete(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "user is already ACCOUNT_STATUS_DELETED", microErrors.Parse(err.Error()).Detail)
+	})
+	t.Run("change status error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		st.On("ChangeStatus", mock.Anything, "1", mock.Anything).Return(nil, errMock)
+		err := newService(st).Delete(context.Background(), req, &proto.UserResponse{})
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Suspended}}, nil)
+		st.On("ChangeStatus", mock.Anything, "1", storageModel.StatusChange{
+			From:   status.Suspended,
+			To:     status.Deleted,
+			Reason: status.ReasonDeleted,
+			Actor:  status.ActorService,
+		}).Return(&storageModel.User{ID: "1", Status: status.Deleted}, nil)
+		resp := &proto.UserResponse{}
+		err := newService(st).Delete(context.Background(), req, resp)
+		require.NoError(t, err)
+		require.Equal(t, "1", resp.Id)
+	})
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/export"
+	"github.com/pkg/errors"
+)
+
+// Export exports all data the service holds about the user.
+func (s Service) Export(ctx context.Context, req *proto.ExportRequest, resp *proto.ExportResponse) error {
+	archive, err := s.exporter.Export(ctx, req.Id)
+	if errors.Is(err, export.ErrUserNotFound) {
+		return microErrors.NotFound(errorID, "user %s not found", req.Id)
+	}
+	if err != nil {
+		s.requestLogger("Export", req.Id).WithError(err).Error("could not export user")
+		return err
+	}
+
+	var buf bytes.Buffer
+	contentType, err := export.Write(&buf, archive, req.Zip)
+	if err != nil {
+		return err
+	}
+
+	resp.ContentType = contentType
+	resp.Data = buf.Bytes()
+	return nil
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/export"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Export(t *testing.T) {
+	newService := func(st *storageMocks.User) Service {
+		return New(Config{
+			UserStorage: st,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return(nil, errMock)
+		err := newService(st).Export(context.Background(), &proto.ExportRequest{Id: "1"}, &proto.ExportResponse{})
+		require.Error(t, err)
+		require.Equal(t, errMock, err)
+	})
+	t.Run("user not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return(nil, nil)
+		err := newService(st).Export(context.Background(), &proto.ExportRequest{Id: "1"}, &proto.ExportResponse{})
+		require.Error(t, err)
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: "ACCOUNT_STATUS_ACTIVE"}}, nil)
+		resp := &proto.ExportResponse{}
+		require.NoError(t, newService(st).Export(context.Background(), &proto.ExportRequest{Id: "1"}, resp))
+		require.Equal(t, export.ContentTypeJSON, resp.ContentType)
+		var archive export.Archive
+		require.NoError(t, json.Unmarshal(resp.Data, &archive))
+		require.Equal(t, "1", archive.User.ID)
+		require.Equal(t, "ACCOUNT_STATUS_ACTIVE", archive.User.Status)
+	})
+}
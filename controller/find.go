@@ -4,10 +4,39 @@ import (
 	"context"
 
 	proto "github.com/open-Q/common/golang/proto/user"
+	storageModel "github.com/open-Q/user/storage/model"
 )
 
-// Find finds users using filter.
+// Find finds users using filter and streams them.
 func (s Service) Find(ctx context.Context, req *proto.FindFilter, resp proto.User_FindStream) error {
-	// TODO: implement
+	filter := storageModel.UserFindFilter{
+		IDs:          req.Ids,
+		MetaPatterns: req.MetaPatterns,
+		GroupIDs:     req.GroupIds,
+	}
+	for i := range req.Statuses {
+		filter.Statuses = append(filter.Statuses, req.Statuses[i].String())
+	}
+	if req.Limit > 0 {
+		filter.Limit = &req.Limit
+	}
+	if req.Offset > 0 {
+		filter.Offset = &req.Offset
+	}
+
+	users, err := s.userStorage.Find(ctx, filter)
+	if err != nil {
+		s.requestLogger("Find", "").WithError(err).Error("could not find users")
+		return err
+	}
+	for i := range users {
+		var user proto.UserResponse
+		if err := newUserResponse(&user, &users[i]); err != nil {
+			return err
+		}
+		if err := resp.Send(&user); err != nil {
+			return err
+		}
+	}
 	return nil
 }
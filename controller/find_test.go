@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testFindStream struct {
+	proto.User_FindStream
+	users []*proto.UserResponse
+}
+
+func (s *testFindStream) Send(user *proto.UserResponse) error {
+	s.users = append(s.users, user)
+	return nil
+}
+
+func TestService_Find(t *testing.T) {
+	newService := func(st *storageMocks.User) Service {
+		return New(Config{
+			UserStorage: st,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errMock)
+		err := newService(st).Find(context.Background(), &proto.FindFilter{}, &testFindStream{})
+		require.Equal(t, errMock, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		limit := int64(10)
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{
+			Statuses: []string{"ACCOUNT_STATUS_ACTIVE"},
+			GroupIDs: []string{"g1"},
+			Limit:    &limit,
+		}).Return([]storageModel.User{{ID: "1"}, {ID: "2"}}, nil)
+		stream := &testFindStream{}
+		err := newService(st).Find(context.Background(), &proto.FindFilter{
+			Statuses: []proto.AccountStatus{proto.AccountStatus_ACCOUNT_STATUS_ACTIVE},
+			GroupIds: []string{"g1"},
+			Limit:    10,
+		}, stream)
+		require.NoError(t, err)
+		require.Len(t, stream.users, 2)
+		require.Equal(t, "2", stream.users[1].Id)
+	})
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/storage"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// defaultPageLimit limits listed items if the request has no limit.
+const defaultPageLimit = 100
+
+// CreateGroup creates a new group, group names are unique.
+func (s Service) CreateGroup(ctx context.Context, req *proto.CreateGroupRequest, resp *proto.GroupResponse) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return microErrors.BadRequest(errorID, "group name is required")
+	}
+	group, err := s.groupStorage.AddGroup(ctx, storageModel.Group{
+		Name:        req.Name,
+		Description: req.Description,
+	})
+	if errors.Is(err, storage.ErrGroupExists) {
+		return microErrors.Conflict(errorID, "group %s already exists", req.Name)
+	}
+	if err != nil {
+		s.requestLogger("CreateGroup", "").WithField("group", req.Name).WithError(err).Error("could not create group")
+		return err
+	}
+	resp.Group = newGroupProto(*group)
+	return nil
+}
+
+// AddGroupMember adds the user to the group.
+func (s Service) AddGroupMember(ctx context.Context, req *proto.GroupMemberRequest, resp *proto.GroupMemberResponse) error {
+	if err := s.findGroup(ctx, "AddGroupMember", req.GroupId); err != nil {
+		return err
+	}
+	if _, err := s.findUser(ctx, "AddGroupMember", req.UserId); err != nil {
+		return err
+	}
+	if err := s.groupStorage.AddMember(ctx, req.GroupId, req.UserId); err != nil {
+		s.requestLogger("AddGroupMember", req.UserId).WithField("group_id", req.GroupId).WithError(err).Error("could not add group member")
+		return err
+	}
+	return nil
+}
+
+// RemoveGroupMember removes the user from the group.
+func (s Service) RemoveGroupMember(ctx context.Context, req *proto.GroupMemberRequest, resp *proto.GroupMemberResponse) error {
+	user, err := s.findUser(ctx, "RemoveGroupMember", req.UserId)
+	if err != nil {
+		return err
+	}
+	if !contains(user.GroupIDs, req.GroupId) {
+		return microErrors.NotFound(errorID, "user %s isn't a member of group %s", req.UserId, req.GroupId)
+	}
+	if err := s.groupStorage.RemoveMember(ctx, req.GroupId, req.UserId); err != nil {
+		s.requestLogger("RemoveGroupMember", req.UserId).WithField("group_id", req.GroupId).WithError(err).Error("could not remove group member")
+		return err
+	}
+	return nil
+}
+
+// ListGroupMembers returns a page of the group members.
+func (s Service) ListGroupMembers(ctx context.Context, req *proto.ListGroupMembersRequest, resp *proto.ListGroupMembersResponse) error {
+	if err := s.findGroup(ctx, "ListGroupMembers", req.GroupId); err != nil {
+		return err
+	}
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	users, err := s.userStorage.Find(ctx, storageModel.UserFindFilter{
+		GroupIDs: []string{req.GroupId},
+		Limit:    &limit,
+		Offset:   &offset,
+	})
+	if err != nil {
+		s.requestLogger("ListGroupMembers", "").WithField("group_id", req.GroupId).WithError(err).Error("could not find group members")
+		return err
+	}
+	resp.Users = make([]*proto.UserResponse, len(users))
+	for i := range users {
+		resp.Users[i] = &proto.UserResponse{}
+		if err := newUserResponse(resp.Users[i], &users[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListUserGroups returns groups the user is a member of.
+func (s Service) ListUserGroups(ctx context.Context, req *proto.ListUserGroupsRequest, resp *proto.ListGroupsResponse) error {
+	user, err := s.findUser(ctx, "ListUserGroups", req.Id)
+	if err != nil {
+		return err
+	}
+	if len(user.GroupIDs) == 0 {
+		return nil
+	}
+	groups, err := s.groupStorage.FindGroups(ctx, storageModel.GroupFindFilter{
+		IDs: user.GroupIDs,
+	})
+	if err != nil {
+		s.requestLogger("ListUserGroups", req.Id).WithError(err).Error("could not find user's groups")
+		return err
+	}
+	resp.Groups = make([]*proto.Group, len(groups))
+	for i := range groups {
+		resp.Groups[i] = newGroupProto(groups[i])
+	}
+	return nil
+}
+
+// findGroup returns not found error if the group doesn't exist.
+func (s Service) findGroup(ctx context.Context, operation, groupID string) error {
+	groups, err := s.groupStorage.FindGroups(ctx, storageModel.GroupFindFilter{
+		IDs: []string{groupID},
+	})
+	if err != nil {
+		s.requestLogger(operation, "").WithField("group_id", groupID).WithError(err).Error("could not find group")
+		return err
+	}
+	if len(groups) == 0 {
+		return microErrors.NotFound(errorID, "group %s not found", groupID)
+	}
+	return nil
+}
+
+func newGroupProto(group storageModel.Group) *proto.Group {
+	return &proto.Group{
+		Id:          group.ID,
+		Name:        group.Name,
+		Description: group.Description,
+		CreatedAt:   timestamppb.New(group.CreatedAt),
+	}
+}
+
+func contains(values []string, value string) bool {
+	for i := range values {
+		if values[i] == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Groups(t *testing.T) {
+	newService := func(st *storageMocks.User, gst *storageMocks.Group) Service {
+		return New(Config{
+			UserStorage:  st,
+			GroupStorage: gst,
+			Logger:       &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	group := storageModel.Group{ID: "g1", Name: "beta"}
+	groupFilter := storageModel.GroupFindFilter{IDs: []string{"g1"}}
+	userFilter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	t.Run("create without name", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer gst.AssertExpectations(t)
+		err := newService(st, gst).CreateGroup(context.Background(), &proto.CreateGroupRequest{Name: " "}, &proto.GroupResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("create existing group", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer gst.AssertExpectations(t)
+		gst.On("AddGroup", mock.Anything, storageModel.Group{Name: "beta"}).Return(nil, storage.ErrGroupExists)
+		err := newService(st, gst).CreateGroup(context.Background(), &proto.CreateGroupRequest{Name: "beta"}, &proto.GroupResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("create all ok", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer gst.AssertExpectations(t)
+		gst.On("AddGroup", mock.Anything, storageModel.Group{Name: "beta"}).Return(&group, nil)
+		var resp proto.GroupResponse
+		err := newService(st, gst).CreateGroup(context.Background(), &proto.CreateGroupRequest{Name: "beta"}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "g1", resp.Group.Id)
+	})
+	t.Run("add member to unknown group", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer gst.AssertExpectations(t)
+		gst.On("FindGroups", mock.Anything, groupFilter).Return(nil, nil)
+		err := newService(st, gst).AddGroupMember(context.Background(), &proto.GroupMemberRequest{GroupId: "g1", UserId: "1"}, &proto.GroupMemberResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("add member all ok", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer st.AssertExpectations(t)
+		defer gst.AssertExpectations(t)
+		gst.On("FindGroups", mock.Anything, groupFilter).Return([]storageModel.Group{group}, nil)
+		st.On("Find", mock.Anything, userFilter).Return([]storageModel.User{{ID: "1"}}, nil)
+		gst.On("AddMember", mock.Anything, "g1", "1").Return(nil)
+		err := newService(st, gst).AddGroupMember(context.Background(), &proto.GroupMemberRequest{GroupId: "g1", UserId: "1"}, &proto.GroupMemberResponse{})
+		require.NoError(t, err)
+	})
+	t.Run("remove not a member", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, userFilter).Return([]storageModel.User{{ID: "1"}}, nil)
+		err := newService(st, gst).RemoveGroupMember(context.Background(), &proto.GroupMemberRequest{GroupId: "g1", UserId: "1"}, &proto.GroupMemberResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("remove member all ok", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer st.AssertExpectations(t)
+		defer gst.AssertExpectations(t)
+		st.On("Find", mock.Anything, userFilter).Return([]storageModel.User{{ID: "1", GroupIDs: []string{"g1"}}}, nil)
+		gst.On("RemoveMember", mock.Anything, "g1", "1").Return(nil)
+		err := newService(st, gst).RemoveGroupMember(context.Background(), &proto.GroupMemberRequest{GroupId: "g1", UserId: "1"}, &proto.GroupMemberResponse{})
+		require.NoError(t, err)
+	})
+	t.Run("list members all ok", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer st.AssertExpectations(t)
+		defer gst.AssertExpectations(t)
+		limit, offset := int64(defaultPageLimit), int64(10)
+		gst.On("FindGroups", mock.Anything, groupFilter).Return([]storageModel.Group{group}, nil)
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{
+			GroupIDs: []string{"g1"},
+			Limit:    &limit,
+			Offset:   &offset,
+		}).Return([]storageModel.User{{ID: "1", GroupIDs: []string{"g1"}}}, nil)
+		var resp proto.ListGroupMembersResponse
+		err := newService(st, gst).ListGroupMembers(context.Background(), &proto.ListGroupMembersRequest{GroupId: "g1", Offset: 10}, &resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Users, 1)
+		require.Equal(t, []string{"g1"}, resp.Users[0].GroupIds)
+	})
+	t.Run("list user groups all ok", func(t *testing.T) {
+		st, gst := new(storageMocks.User), new(storageMocks.Group)
+		defer st.AssertExpectations(t)
+		defer gst.AssertExpectations(t)
+		st.On("Find", mock.Anything, userFilter).Return([]storageModel.User{{ID: "1", GroupIDs: []string{"g1"}}}, nil)
+		gst.On("FindGroups", mock.Anything, groupFilter).Return([]storageModel.Group{group}, nil)
+		var resp proto.ListGroupsResponse
+		err := newService(st, gst).ListUserGroups(context.Background(), &proto.ListUserGroupsRequest{Id: "1"}, &resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Groups, 1)
+		require.Equal(t, "beta", resp.Groups[0].Name)
+	})
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/storage"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// LinkIdentity links the external identity to the user, every identity is linked to one user.
+// Linking the identity already linked to the user does nothing.
+func (s Service) LinkIdentity(ctx context.Context, req *proto.IdentityRequest, resp *proto.IdentityResponse) error {
+	identity, err := newIdentity(req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.findUser(ctx, "LinkIdentity", req.UserId); err != nil {
+		return err
+	}
+	linked, err := s.identityStorage.LinkIdentity(ctx, identity)
+	if err != nil {
+		s.requestLogger("LinkIdentity", req.UserId).WithError(err).Error("could not link identity")
+		return err
+	}
+	if linked.UserID != req.UserId {
+		return microErrors.Conflict(errorID, "%s identity is linked to another user", req.Provider)
+	}
+	s.requestLogger("LinkIdentity", req.UserId).WithField("provider", req.Provider).Info("identity linked")
+	resp.Identity = newIdentityProto(*linked)
+	return nil
+}
+
+// UnlinkIdentity unlinks the user's external identity unless it's the user's last login method.
+func (s Service) UnlinkIdentity(ctx context.Context, req *proto.IdentityRequest, resp *proto.IdentityResponse) error {
+	identity, err := newIdentity(req)
+	if err != nil {
+		return err
+	}
+	if _, err := s.findUser(ctx, "UnlinkIdentity", req.UserId); err != nil {
+		return err
+	}
+	identities, err := s.identityStorage.ListIdentities(ctx, req.UserId)
+	if err != nil {
+		s.requestLogger("UnlinkIdentity", req.UserId).WithError(err).Error("could not list identities")
+		return err
+	}
+	if !findIdentity(identities, req.Provider, req.Subject) {
+		return microErrors.NotFound(errorID, "%s identity isn't linked", req.Provider)
+	}
+	// the storage checks it again in the transaction, this check gives a clear error.
+	if len(identities) == 1 {
+		hasPassword, err := s.credentials.HasPassword(ctx, req.UserId)
+		if err != nil {
+			s.requestLogger("UnlinkIdentity", req.UserId).WithError(err).Error("could not find password")
+			return err
+		}
+		if !hasPassword {
+			return microErrors.Conflict(errorID, "%s identity is the last login method of user %s", req.Provider, req.UserId)
+		}
+	}
+
+	err = s.identityStorage.UnlinkIdentity(ctx, identity)
+	if errors.Is(err, storage.ErrLastLoginMethod) {
+		return microErrors.Conflict(errorID, "%s identity is the last login method of user %s", req.Provider, req.UserId)
+	}
+	if err != nil {
+		s.requestLogger("UnlinkIdentity", req.UserId).WithError(err).Error("could not unlink identity")
+		return err
+	}
+	s.requestLogger("UnlinkIdentity", req.UserId).WithField("provider", req.Provider).Info("identity unlinked")
+	resp.Identity = newIdentityProto(identity)
+	return nil
+}
+
+// ListIdentities returns the user's external identities.
+func (s Service) ListIdentities(ctx context.Context, req *proto.ListIdentitiesRequest, resp *proto.ListIdentitiesResponse) error {
+	if _, err := s.findUser(ctx, "ListIdentities", req.UserId); err != nil {
+		return err
+	}
+	identities, err := s.identityStorage.ListIdentities(ctx, req.UserId)
+	if err != nil {
+		s.requestLogger("ListIdentities", req.UserId).WithError(err).Error("could not list identities")
+		return err
+	}
+	resp.Identities = make([]*proto.Identity, len(identities))
+	for i := range identities {
+		resp.Identities[i] = newIdentityProto(identities[i])
+	}
+	return nil
+}
+
+// FindByIdentity returns the user the external identity is linked to.
+func (s Service) FindByIdentity(ctx context.Context, req *proto.FindByIdentityRequest, resp *proto.UserResponse) error {
+	identity, err := s.identityStorage.FindIdentity(ctx, req.Provider, req.Subject)
+	if err != nil {
+		s.requestLogger("FindByIdentity", "").WithField("provider", req.Provider).WithError(err).Error("could not find identity")
+		return err
+	}
+	if identity == nil {
+		return microErrors.NotFound(errorID, "%s identity isn't linked", req.Provider)
+	}
+	user, err := s.findUser(ctx, "FindByIdentity", identity.UserID)
+	if err != nil {
+		return err
+	}
+	// identities are moved by merges, users merged before that are followed.
+	if user.MergedInto != "" {
+		user, err = s.findUser(ctx, "FindByIdentity", user.MergedInto)
+		if err != nil {
+			return err
+		}
+	}
+	return newUserResponse(resp, user)
+}
+
+func newIdentity(req *proto.IdentityRequest) (storageModel.Identity, error) {
+	if strings.TrimSpace(req.Provider) == "" || strings.TrimSpace(req.Subject) == "" {
+		return storageModel.Identity{}, microErrors.BadRequest(errorID, "identity provider and subject are required")
+	}
+	return storageModel.Identity{
+		Provider: req.Provider,
+		Subject:  req.Subject,
+		UserID:   req.UserId,
+	}, nil
+}
+
+func newIdentityProto(identity storageModel.Identity) *proto.Identity {
+	return &proto.Identity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		UserId:   identity.UserID,
+		LinkedAt: newTimestamp(identity.LinkedAt),
+	}
+}
+
+func findIdentity(identities []storageModel.Identity, provider, subject string) bool {
+	for i := range identities {
+		if identities[i].Provider == provider && identities[i].Subject == subject {
+			return true
+		}
+	}
+	return false
+}
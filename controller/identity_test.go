@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Identities(t *testing.T) {
+	newService := func(t *testing.T, st *storageMocks.User, ist *storageMocks.Identity, cst *storageMocks.Credential) Service {
+		credentials, err := credential.NewManager(credential.Config{
+			Storage: cst,
+			Params:  credential.Params{Algorithm: credential.Bcrypt, BcryptCost: 4},
+		})
+		require.NoError(t, err)
+		return New(Config{
+			UserStorage:     st,
+			IdentityStorage: ist,
+			Credentials:     credentials,
+			Logger:          &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	google := storageModel.Identity{Provider: "google", Subject: "123", UserID: "1"}
+	github := storageModel.Identity{Provider: "github", Subject: "123", UserID: "1"}
+	req := &proto.IdentityRequest{UserId: "1", Provider: "google", Subject: "123"}
+	t.Run("invalid identity", func(t *testing.T) {
+		err := newService(t, nil, nil, nil).LinkIdentity(context.Background(), &proto.IdentityRequest{UserId: "1", Provider: "google"}, &proto.IdentityResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("linked to another user", func(t *testing.T) {
+		st, ist := new(storageMocks.User), new(storageMocks.Identity)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		ist.On("LinkIdentity", mock.Anything, google).Return(&storageModel.Identity{Provider: "google", Subject: "123", UserID: "2"}, nil)
+		err := newService(t, st, ist, nil).LinkIdentity(context.Background(), req, &proto.IdentityResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("not linked", func(t *testing.T) {
+		st, ist := new(storageMocks.User), new(storageMocks.Identity)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		ist.On("ListIdentities", mock.Anything, "1").Return([]storageModel.Identity{github}, nil)
+		err := newService(t, st, ist, nil).UnlinkIdentity(context.Background(), req, &proto.IdentityResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("last login method", func(t *testing.T) {
+		st, ist, cst := new(storageMocks.User), new(storageMocks.Identity), new(storageMocks.Credential)
+		defer ist.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		ist.On("ListIdentities", mock.Anything, "1").Return([]storageModel.Identity{google}, nil)
+		cst.On("FindPassword", mock.Anything, "1").Return(nil, nil)
+		err := newService(t, st, ist, cst).UnlinkIdentity(context.Background(), req, &proto.IdentityResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("last login method (concurrent unlink)", func(t *testing.T) {
+		st, ist := new(storageMocks.User), new(storageMocks.Identity)
+		defer ist.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		ist.On("ListIdentities", mock.Anything, "1").Return([]storageModel.Identity{google, github}, nil)
+		ist.On("UnlinkIdentity", mock.Anything, google).Return(storage.ErrLastLoginMethod)
+		err := newService(t, st, ist, nil).UnlinkIdentity(context.Background(), req, &proto.IdentityResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok (merged user)", func(t *testing.T) {
+		st, ist := new(storageMocks.User), new(storageMocks.Identity)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", MergedInto: "2"}}, nil)
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{IDs: []string{"2"}}).Return([]storageModel.User{{ID: "2"}}, nil)
+		ist.On("FindIdentity", mock.Anything, "google", "123").Return(&google, nil)
+		resp := &proto.UserResponse{}
+		require.NoError(t, newService(t, st, ist, nil).FindByIdentity(context.Background(), &proto.FindByIdentityRequest{Provider: "google", Subject: "123"}, resp))
+		require.Equal(t, "2", resp.Id)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st, ist, cst := new(storageMocks.User), new(storageMocks.Identity), new(storageMocks.Credential)
+		defer ist.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		s := newService(t, st, ist, cst)
+
+		ist.On("LinkIdentity", mock.Anything, google).Return(&google, nil)
+		resp := &proto.IdentityResponse{}
+		require.NoError(t, s.LinkIdentity(context.Background(), req, resp))
+		require.Equal(t, "1", resp.Identity.UserId)
+
+		ist.On("FindIdentity", mock.Anything, "google", "123").Return(&google, nil)
+		userResp := &proto.UserResponse{}
+		require.NoError(t, s.FindByIdentity(context.Background(), &proto.FindByIdentityRequest{Provider: "google", Subject: "123"}, userResp))
+		require.Equal(t, "1", userResp.Id)
+
+		ist.On("ListIdentities", mock.Anything, "1").Return([]storageModel.Identity{google}, nil)
+		cst.On("FindPassword", mock.Anything, "1").Return(&storageModel.PasswordCredential{UserID: "1"}, nil)
+		ist.On("UnlinkIdentity", mock.Anything, google).Return(nil)
+		require.NoError(t, s.UnlinkIdentity(context.Background(), req, &proto.IdentityResponse{}))
+	})
+}
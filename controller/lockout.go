@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/lockout"
+)
+
+// RecordLoginAttempt records login attempt of the user, the login identifier or both
+// and returns the lockout state, so gateways share brute-force protection.
+func (s Service) RecordLoginAttempt(ctx context.Context, req *proto.LoginAttemptRequest, resp *proto.LockoutStateResponse) error {
+	state, err := s.lockouts.Record(ctx, req.UserId, req.Identifier, req.Success)
+	if err != nil {
+		return s.lockoutError("RecordLoginAttempt", req.UserId, err)
+	}
+	if state.Locked && !req.Success {
+		s.requestLogger("RecordLoginAttempt", req.UserId).WithField("locked_until", state.LockedUntil).Warn("login locked out")
+	}
+	if req.Success && req.UserId != "" {
+		s.touchActivity(ctx, "RecordLoginAttempt", req.UserId)
+	}
+	newLockoutStateResponse(resp, state)
+	return nil
+}
+
+// GetLockoutState returns lockout state of the user, the login identifier or both.
+func (s Service) GetLockoutState(ctx context.Context, req *proto.LockoutStateRequest, resp *proto.LockoutStateResponse) error {
+	state, err := s.lockouts.State(ctx, req.UserId, req.Identifier)
+	if err != nil {
+		return s.lockoutError("GetLockoutState", req.UserId, err)
+	}
+	newLockoutStateResponse(resp, state)
+	return nil
+}
+
+// UnlockLogin ends lockouts and resets failed logins of the user, the login identifier or both.
+func (s Service) UnlockLogin(ctx context.Context, req *proto.LockoutStateRequest, resp *proto.LockoutStateResponse) error {
+	state, err := s.lockouts.Unlock(ctx, req.UserId, req.Identifier)
+	if err != nil {
+		return s.lockoutError("UnlockLogin", req.UserId, err)
+	}
+	s.requestLogger("UnlockLogin", req.UserId).Info("login unlocked")
+	newLockoutStateResponse(resp, state)
+	return nil
+}
+
+// lockoutError converts lockout request errors to client errors.
+func (s Service) lockoutError(operation, userID string, err error) error {
+	if err == lockout.ErrNoSubject {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	s.requestLogger(operation, userID).WithError(err).Error("could not handle login lockout")
+	return err
+}
+
+func newLockoutStateResponse(resp *proto.LockoutStateResponse, state *lockout.State) {
+	resp.Locked = state.Locked
+	resp.LockedUntil = newTimestamp(state.LockedUntil)
+	resp.RemainingAttempts = int32(state.RemainingAttempts)
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/lockout"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Lockouts(t *testing.T) {
+	newService := func(t *testing.T, st *storageMocks.Lockout) Service {
+		lockouts, err := lockout.NewManager(lockout.Config{Storage: st, UserThreshold: 1})
+		require.NoError(t, err)
+		return New(Config{
+			Lockouts: lockouts,
+			Logger:   &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	key := lockout.UserKey("1")
+	t.Run("no subject", func(t *testing.T) {
+		err := newService(t, nil).GetLockoutState(context.Background(), &proto.LockoutStateRequest{}, &proto.LockoutStateResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("successful login", func(t *testing.T) {
+		st, ust := new(storageMocks.Lockout), new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		defer ust.AssertExpectations(t)
+		st.On("FindLockouts", mock.Anything, []string{key}).Return(nil, nil)
+		st.On("ResetLockout", mock.Anything, key, mock.Anything, mock.Anything).Return(nil)
+		ust.On("TouchActivity", mock.Anything, "1", mock.Anything).Return(nil).Once()
+		s := newService(t, st)
+		s.userStorage = ust
+		resp := &proto.LockoutStateResponse{}
+		require.NoError(t, s.RecordLoginAttempt(context.Background(), &proto.LoginAttemptRequest{UserId: "1", Success: true}, resp))
+		require.False(t, resp.Locked)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Lockout)
+		defer st.AssertExpectations(t)
+		st.On("FindLockouts", mock.Anything, []string{key}).Return(nil, nil).Once()
+		st.On("AddLoginFailure", mock.Anything, key, mock.Anything, mock.Anything).Return(nil)
+		st.On("CountLoginFailures", mock.Anything, key, mock.Anything).Return(int64(1), nil)
+		var locked storageModel.Lockout
+		st.On("Lock", mock.Anything, mock.Anything, 0).Return(func(_ context.Context, lockout storageModel.Lockout, _ int) bool {
+			locked = lockout
+			return true
+		}, nil)
+		st.On("FindLockouts", mock.Anything, []string{key}).Return(func(context.Context, []string) []storageModel.Lockout {
+			return []storageModel.Lockout{locked}
+		}, nil).Once()
+		s := newService(t, st)
+		resp := &proto.LockoutStateResponse{}
+		require.NoError(t, s.RecordLoginAttempt(context.Background(), &proto.LoginAttemptRequest{UserId: "1"}, resp))
+		require.True(t, resp.Locked)
+		require.Equal(t, locked.LockedUntil.Unix(), resp.LockedUntil.AsTime().Unix())
+		require.Zero(t, resp.RemainingAttempts)
+
+		st.On("ResetLockout", mock.Anything, key, mock.Anything, mock.Anything).Return(nil)
+		st.On("FindLockouts", mock.Anything, []string{key}).Return(nil, nil).Once()
+		resp = &proto.LockoutStateResponse{}
+		require.NoError(t, s.UnlockLogin(context.Background(), &proto.LockoutStateRequest{UserId: "1"}, resp))
+		require.False(t, resp.Locked)
+	})
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/status"
+	storageModel "github.com/open-Q/user/storage/model"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Merge merges the source user into the target one and publishes the merge event.
+// Merging the users again only publishes the event, so failed merges may be retried.
+func (s Service) Merge(ctx context.Context, req *proto.MergeRequest, resp *proto.UserResponse) error {
+	if req.SourceId == req.TargetId {
+		return microErrors.BadRequest(errorID, "user can't be merged into itself")
+	}
+	if strings.TrimSpace(req.Actor) == "" {
+		return microErrors.BadRequest(errorID, "actor is required")
+	}
+	logger := s.requestLogger("Merge", req.TargetId).WithField("source_id", req.SourceId)
+
+	users, err := s.userStorage.Find(ctx, storageModel.UserFindFilter{
+		IDs: []string{req.SourceId, req.TargetId},
+	})
+	if err != nil {
+		logger.WithError(err).Error("could not find users")
+		return err
+	}
+	var source, target *storageModel.User
+	for i := range users {
+		switch users[i].ID {
+		case req.SourceId:
+			source = &users[i]
+		case req.TargetId:
+			target = &users[i]
+		}
+	}
+	if source == nil {
+		return microErrors.NotFound(errorID, "user %s not found", req.SourceId)
+	}
+	if target == nil {
+		return microErrors.NotFound(errorID, "user %s not found", req.TargetId)
+	}
+	if target.MergedInto != "" {
+		return microErrors.Conflict(errorID, "user %s is merged into %s", target.ID, target.MergedInto)
+	}
+
+	if source.MergedInto == target.ID {
+		logger.Info("users are already merged")
+	} else {
+		if source.MergedInto != "" {
+			return microErrors.Conflict(errorID, "user %s is merged into %s", source.ID, source.MergedInto)
+		}
+		target, err = s.merge(ctx, req, source, target)
+		s.invalidatePermissions(req.SourceId, req.TargetId)
+		if err != nil {
+			return err
+		}
+		source.MergedAt = target.UpdatedAt
+		logger.WithField("actor", req.Actor).Info("users merged")
+	}
+
+	if err := s.publishMerge(ctx, req, source.MergedAt); err != nil {
+		logger.WithError(err).Error("could not publish merge event")
+		return microErrors.InternalServerError(errorID, "users are merged, but the merge event could not be published, retry the merge")
+	}
+	return newUserResponse(resp, target)
+}
+
+// merge stores the merge with meta resolved by the merge policies.
+func (s Service) merge(ctx context.Context, req *proto.MergeRequest, source, target *storageModel.User) (*storageModel.User, error) {
+	meta, metaTypes := s.mergePolicies.Merge(*target, *source)
+	if err := s.validateMeta("Merge", target.ID, meta); err != nil {
+		return nil, err
+	}
+
+	updatedUser, err := s.userStorage.Merge(ctx, storageModel.Merge{
+		SourceID:        source.ID,
+		TargetID:        target.ID,
+		Meta:            meta,
+		MetaTypes:       metaTypes,
+		GroupIDs:        source.GroupIDs,
+		Roles:           source.Roles,
+		SourceUpdatedAt: source.UpdatedAt,
+		TargetUpdatedAt: target.UpdatedAt,
+		SourceChange: storageModel.StatusChange{
+			From:   source.Status,
+			To:     status.Merged,
+			Reason: status.ReasonMerged,
+			Actor:  req.Actor,
+		},
+	})
+	if err != nil {
+		s.requestLogger("Merge", target.ID).WithField("source_id", source.ID).WithError(err).Error("could not merge users")
+		return nil, err
+	}
+	return updatedUser, nil
+}
+
+// publishMerge notifies other services the source user's data belongs to the target user now.
+func (s Service) publishMerge(ctx context.Context, req *proto.MergeRequest, mergedAt time.Time) error {
+	if s.publisher == nil {
+		return nil
+	}
+	return s.publisher.Publish(ctx, &proto.MergeEvent{
+		SourceId: req.SourceId,
+		TargetId: req.TargetId,
+		Actor:    req.Actor,
+		MergedAt: timestamppb.New(mergedAt),
+	})
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/micro/go-micro/v2/client"
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/meta"
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testPublisher struct {
+	msgs []interface{}
+	err  error
+}
+
+func (p *testPublisher) Publish(ctx context.Context, msg interface{}, opts ...client.PublishOption) error {
+	p.msgs = append(p.msgs, msg)
+	return p.err
+}
+
+func TestService_Merge(t *testing.T) {
+	newService := func(st *storageMocks.User, publisher *testPublisher) Service {
+		return New(Config{
+			UserStorage: st,
+			MergePolicies: meta.MergePolicies{
+				Keys: map[string]meta.MergePolicy{"tags": meta.MergeUnion},
+			},
+			MergePublisher: publisher,
+			Logger:         &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	updatedAt := time.Now().UTC()
+	source := storageModel.User{
+		ID:        "1",
+		Status:    status.Active,
+		Meta:      map[string]interface{}{"tags": []interface{}{"b"}, "phone": "+380000000000"},
+		Roles:     []storageModel.RoleAssignment{{Role: "editor"}},
+		UpdatedAt: updatedAt.Add(-time.Hour),
+	}
+	target := storageModel.User{
+		ID:        "2",
+		Status:    status.Active,
+		Meta:      map[string]interface{}{"tags": []interface{}{"a"}},
+		UpdatedAt: updatedAt.Add(-2 * time.Hour),
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1", "2"}}
+	req := &proto.MergeRequest{SourceId: "1", TargetId: "2", Actor: "support"}
+	t.Run("merge into itself", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		err := newService(st, &testPublisher{}).Merge(context.Background(), &proto.MergeRequest{SourceId: "1", TargetId: "1", Actor: "support"}, &proto.UserResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("source not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{target}, nil)
+		err := newService(st, &testPublisher{}).Merge(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("source merged into another user", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		merged := source
+		merged.MergedInto = "3"
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{merged, target}, nil)
+		err := newService(st, &testPublisher{}).Merge(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("merge error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		publisher := &testPublisher{}
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{source, target}, nil)
+		st.On("Merge", mock.Anything, mock.Anything).Return(nil, errMock)
+		err := newService(st, publisher).Merge(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, errMock, err)
+		require.Empty(t, publisher.msgs)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		publisher := &testPublisher{}
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{source, target}, nil)
+		st.On("Merge", mock.Anything, storageModel.Merge{
+			SourceID:        "1",
+			TargetID:        "2",
+			Meta:            map[string]interface{}{"tags": []interface{}{"a", "b"}, "phone": "+380000000000"},
+			MetaTypes:       map[string]string{},
+			Roles:           source.Roles,
+			SourceUpdatedAt: source.UpdatedAt,
+			TargetUpdatedAt: target.UpdatedAt,
+			SourceChange: storageModel.StatusChange{
+				From:   status.Active,
+				To:     status.Merged,
+				Reason: status.ReasonMerged,
+				Actor:  "support",
+			},
+		}).Return(&storageModel.User{ID: "2", Status: status.Active, UpdatedAt: updatedAt}, nil)
+		var resp proto.UserResponse
+		err := newService(st, publisher).Merge(context.Background(), req, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "2", resp.Id)
+		require.Len(t, publisher.msgs, 1)
+		event := publisher.msgs[0].(*proto.MergeEvent)
+		require.Equal(t, "1", event.SourceId)
+		require.Equal(t, "2", event.TargetId)
+		require.True(t, updatedAt.Equal(event.MergedAt.AsTime()))
+	})
+	t.Run("all ok (already merged)", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		publisher := &testPublisher{}
+		merged := source
+		merged.Status = status.Merged
+		merged.MergedInto = "2"
+		merged.MergedAt = updatedAt
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{merged, target}, nil)
+		err := newService(st, publisher).Merge(context.Background(), req, &proto.UserResponse{})
+		require.NoError(t, err)
+		require.Len(t, publisher.msgs, 1)
+	})
+	t.Run("publish error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		merged := source
+		merged.MergedInto = "2"
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{merged, target}, nil)
+		err := newService(st, &testPublisher{err: errMock}).Merge(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(500), microErrors.Parse(err.Error()).Code)
+	})
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/meta"
+	storageModel "github.com/open-Q/user/storage/model"
+)
+
+// ListMetaFields returns all meta schema fields.
+func (s Service) ListMetaFields(ctx context.Context, req *proto.ListMetaFieldsRequest, resp *proto.ListMetaFieldsResponse) error {
+	if s.metaSchema == nil {
+		return nil
+	}
+	fields := s.metaSchema.Fields()
+	resp.Fields = make([]*proto.MetaField, len(fields))
+	for i := range fields {
+		resp.Fields[i] = newMetaFieldProto(fields[i])
+	}
+	return nil
+}
+
+// PutMetaField creates or replaces meta schema field.
+func (s Service) PutMetaField(ctx context.Context, req *proto.PutMetaFieldRequest, resp *proto.MetaFieldResponse) error {
+	if s.metaSchema == nil {
+		return microErrors.NotFound(errorID, "meta schema is disabled")
+	}
+	field := newMetaField(req.Field)
+	if err := meta.ValidateField(field); err != nil {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	if err := s.metaSchema.Put(ctx, field); err != nil {
+		s.requestLogger("PutMetaField", "").WithError(err).Error("could not put meta field")
+		return err
+	}
+	resp.Field = newMetaFieldProto(field)
+	return nil
+}
+
+// DeleteMetaField removes meta schema field managed through RPCs.
+func (s Service) DeleteMetaField(ctx context.Context, req *proto.DeleteMetaFieldRequest, resp *proto.MetaFieldResponse) error {
+	if s.metaSchema == nil {
+		return microErrors.NotFound(errorID, "meta schema is disabled")
+	}
+	if err := s.metaSchema.Delete(ctx, req.Key); err != nil {
+		s.requestLogger("DeleteMetaField", "").WithError(err).Error("could not delete meta field")
+		return err
+	}
+	resp.Field = &proto.MetaField{
+		Key: req.Key,
+	}
+	return nil
+}
+
+func newMetaField(field *proto.MetaField) storageModel.MetaField {
+	return storageModel.MetaField{
+		Key:      field.GetKey(),
+		Type:     field.GetType(),
+		Format:   field.GetFormat(),
+		Required: field.GetRequired(),
+		MaxSize:  int(field.GetMaxSize()),
+	}
+}
+
+func newMetaFieldProto(field storageModel.MetaField) *proto.MetaField {
+	return &proto.MetaField{
+		Key:      field.Key,
+		Type:     field.Type,
+		Format:   field.Format,
+		Required: field.Required,
+		MaxSize:  int64(field.MaxSize),
+	}
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/meta"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_MetaSchema(t *testing.T) {
+	email := storageModel.MetaField{Key: "email", Type: meta.TypeString, Format: meta.FormatEmail, Required: true}
+	newService := func(t *testing.T, st *storageMocks.MetaSchema) Service {
+		registry, err := meta.NewRegistry(meta.RegistryConfig{
+			Fields:  []storageModel.MetaField{email},
+			Storage: st,
+		})
+		require.NoError(t, err)
+		return New(Config{
+			MetaSchema: registry,
+			Logger:     &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	disabled := New(Config{
+		Logger: &commonLog.Logger{Logger: logrus.New()},
+	})
+	t.Run("list disabled schema", func(t *testing.T) {
+		resp := &proto.ListMetaFieldsResponse{}
+		require.NoError(t, disabled.ListMetaFields(context.Background(), &proto.ListMetaFieldsRequest{}, resp))
+		require.Empty(t, resp.Fields)
+	})
+	t.Run("put to disabled schema", func(t *testing.T) {
+		err := disabled.PutMetaField(context.Background(), &proto.PutMetaFieldRequest{Field: &proto.MetaField{Key: "age", Type: meta.TypeInt}}, &proto.MetaFieldResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("put invalid field", func(t *testing.T) {
+		st := new(storageMocks.MetaSchema)
+		defer st.AssertExpectations(t)
+		err := newService(t, st).PutMetaField(context.Background(), &proto.PutMetaFieldRequest{Field: &proto.MetaField{Key: "age", Type: "integer"}}, &proto.MetaFieldResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("put field storage error", func(t *testing.T) {
+		st := new(storageMocks.MetaSchema)
+		defer st.AssertExpectations(t)
+		st.On("PutMetaField", mock.Anything, storageModel.MetaField{Key: "age", Type: meta.TypeInt}).Return(errMock)
+		err := newService(t, st).PutMetaField(context.Background(), &proto.PutMetaFieldRequest{Field: &proto.MetaField{Key: "age", Type: meta.TypeInt}}, &proto.MetaFieldResponse{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, errMock))
+	})
+	t.Run("delete from disabled schema", func(t *testing.T) {
+		err := disabled.DeleteMetaField(context.Background(), &proto.DeleteMetaFieldRequest{Key: "age"}, &proto.MetaFieldResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("delete field storage error", func(t *testing.T) {
+		st := new(storageMocks.MetaSchema)
+		defer st.AssertExpectations(t)
+		st.On("DeleteMetaField", mock.Anything, "age").Return(errMock)
+		err := newService(t, st).DeleteMetaField(context.Background(), &proto.DeleteMetaFieldRequest{Key: "age"}, &proto.MetaFieldResponse{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, errMock))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.MetaSchema)
+		defer st.AssertExpectations(t)
+		age := storageModel.MetaField{Key: "age", Type: meta.TypeInt, MaxSize: 3}
+		st.On("PutMetaField", mock.Anything, age).Return(nil)
+		st.On("DeleteMetaField", mock.Anything, "age").Return(nil)
+		service := newService(t, st)
+
+		putResp := &proto.MetaFieldResponse{}
+		require.NoError(t, service.PutMetaField(context.Background(), &proto.PutMetaFieldRequest{Field: &proto.MetaField{Key: "age", Type: meta.TypeInt, MaxSize: 3}}, putResp))
+		require.Equal(t, "age", putResp.Field.Key)
+		listResp := &proto.ListMetaFieldsResponse{}
+		require.NoError(t, service.ListMetaFields(context.Background(), &proto.ListMetaFieldsRequest{}, listResp))
+		require.Len(t, listResp.Fields, 2)
+		require.Equal(t, "age", listResp.Fields[0].Key)
+		require.Equal(t, "email", listResp.Fields[1].Key)
+		require.Equal(t, meta.FormatEmail, listResp.Fields[1].Format)
+
+		deleteResp := &proto.MetaFieldResponse{}
+		require.NoError(t, service.DeleteMetaField(context.Background(), &proto.DeleteMetaFieldRequest{Key: "age"}, deleteResp))
+		require.Equal(t, "age", deleteResp.Field.Key)
+		listResp = &proto.ListMetaFieldsResponse{}
+		require.NoError(t, service.ListMetaFields(context.Background(), &proto.ListMetaFieldsRequest{}, listResp))
+		require.Len(t, listResp.Fields, 1)
+	})
+}
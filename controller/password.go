@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"context"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/pkg/errors"
+)
+
+// SetPassword sets or replaces user's password.
+func (s Service) SetPassword(ctx context.Context, req *proto.SetPasswordRequest, resp *proto.SetPasswordResponse) error {
+	if _, err := s.findUser(ctx, "SetPassword", req.Id); err != nil {
+		return err
+	}
+	err := s.credentials.SetPassword(ctx, req.Id, req.Password)
+	if errors.Cause(err) == credential.ErrInvalidPassword {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	if err != nil {
+		s.requestLogger("SetPassword", req.Id).WithError(err).Error("could not set password")
+		return err
+	}
+	if err := s.revokeTokens(ctx, "SetPassword", req.Id); err != nil {
+		return err
+	}
+	s.requestLogger("SetPassword", req.Id).Info("password set")
+	return nil
+}
+
+// VerifyPassword checks user's password, users without password never match.
+func (s Service) VerifyPassword(ctx context.Context, req *proto.VerifyPasswordRequest, resp *proto.VerifyPasswordResponse) error {
+	if _, err := s.findUser(ctx, "VerifyPassword", req.Id); err != nil {
+		return err
+	}
+	valid, err := s.credentials.VerifyPassword(ctx, req.Id, req.Password)
+	if err != nil {
+		s.requestLogger("VerifyPassword", req.Id).WithError(err).Error("could not verify password")
+		return err
+	}
+	if valid {
+		s.touchActivity(ctx, "VerifyPassword", req.Id)
+	}
+	resp.Valid = valid
+	return nil
+}
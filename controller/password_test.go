@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Passwords(t *testing.T) {
+	params := credential.DefaultParams()
+	params.Argon2Memory = 64
+	params.Argon2Iterations = 1
+	newService := func(t *testing.T, st *storageMocks.User, cst *storageMocks.Credential) Service {
+		credentials, err := credential.NewManager(credential.Config{
+			Storage: cst,
+			Params:  params,
+		})
+		require.NoError(t, err)
+		return New(Config{
+			UserStorage: st,
+			Credentials: credentials,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	t.Run("user not found", func(t *testing.T) {
+		st, cst := new(storageMocks.User), new(storageMocks.Credential)
+		st.On("Find", mock.Anything, filter).Return(nil, nil)
+		err := newService(t, st, cst).SetPassword(context.Background(), &proto.SetPasswordRequest{Id: "1", Password: "password"}, &proto.SetPasswordResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("invalid password", func(t *testing.T) {
+		st, cst := new(storageMocks.User), new(storageMocks.Credential)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		err := newService(t, st, cst).SetPassword(context.Background(), &proto.SetPasswordRequest{Id: "1", Password: "short"}, &proto.SetPasswordResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st, cst := new(storageMocks.User), new(storageMocks.Credential)
+		defer cst.AssertExpectations(t)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		st.On("TouchActivity", mock.Anything, "1", mock.Anything).Return(errMock).Once()
+		var stored *storageModel.PasswordCredential
+		cst.On("PutPassword", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			c := args.Get(1).(storageModel.PasswordCredential)
+			stored = &c
+		}).Return(nil)
+		cst.On("FindPassword", mock.Anything, "1").Return(func(context.Context, string) *storageModel.PasswordCredential {
+			return stored
+		}, nil)
+		s := newService(t, st, cst)
+		require.NoError(t, s.SetPassword(context.Background(), &proto.SetPasswordRequest{Id: "1", Password: "password"}, &proto.SetPasswordResponse{}))
+
+		resp := &proto.VerifyPasswordResponse{}
+		require.NoError(t, s.VerifyPassword(context.Background(), &proto.VerifyPasswordRequest{Id: "1", Password: "password"}, resp))
+		require.True(t, resp.Valid)
+		resp = &proto.VerifyPasswordResponse{}
+		require.NoError(t, s.VerifyPassword(context.Background(), &proto.VerifyPasswordRequest{Id: "1", Password: "wrong"}, resp))
+		require.False(t, resp.Valid)
+	})
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/rbac"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// ListRoles returns all roles.
+func (s Service) ListRoles(ctx context.Context, req *proto.ListRolesRequest, resp *proto.ListRolesResponse) error {
+	roles := s.permissions.Roles()
+	resp.Roles = make([]*proto.Role, len(roles))
+	for i := range roles {
+		resp.Roles[i] = newRoleProto(roles[i])
+	}
+	return nil
+}
+
+// PutRole creates or replaces role.
+func (s Service) PutRole(ctx context.Context, req *proto.PutRoleRequest, resp *proto.RoleResponse) error {
+	role := newRole(req.Role)
+	if err := rbac.ValidateRole(role); err != nil {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	if err := s.permissions.PutRole(ctx, role); err != nil {
+		s.requestLogger("PutRole", "").WithField("role", role.Name).WithError(err).Error("could not put role")
+		return err
+	}
+	resp.Role = newRoleProto(role)
+	return nil
+}
+
+// DeleteRole removes role, its assignments grant nothing afterwards.
+func (s Service) DeleteRole(ctx context.Context, req *proto.DeleteRoleRequest, resp *proto.RoleResponse) error {
+	if err := s.permissions.DeleteRole(ctx, req.Name); err != nil {
+		s.requestLogger("DeleteRole", "").WithField("role", req.Name).WithError(err).Error("could not delete role")
+		return err
+	}
+	resp.Role = &proto.Role{Name: req.Name}
+	return nil
+}
+
+// AssignRole assigns the role to the user, optionally for the resource only.
+func (s Service) AssignRole(ctx context.Context, req *proto.RoleAssignmentRequest, resp *proto.RoleAssignmentResponse) error {
+	role, err := s.permissions.Role(ctx, req.Role)
+	if err != nil {
+		s.requestLogger("AssignRole", req.UserId).WithError(err).Error("could not find role")
+		return err
+	}
+	if role == nil {
+		return microErrors.NotFound(errorID, "role %s not found", req.Role)
+	}
+	user, err := s.findUser(ctx, "AssignRole", req.UserId)
+	if err != nil {
+		return err
+	}
+	if findAssignment(user.Roles, req.Role, req.Resource) {
+		return microErrors.Conflict(errorID, "role %s is already assigned", req.Role)
+	}
+
+	err = s.roleStorage.AssignRole(ctx, req.UserId, storageModel.RoleAssignment{
+		Role:     req.Role,
+		Resource: req.Resource,
+	})
+	s.invalidatePermissions(req.UserId)
+	if err != nil {
+		s.requestLogger("AssignRole", req.UserId).WithError(err).Error("could not assign role")
+		return err
+	}
+	s.requestLogger("AssignRole", req.UserId).WithField("role", req.Role).WithField("resource", req.Resource).Info("role assigned")
+	return nil
+}
+
+// UnassignRole removes user's role assignment for the resource.
+func (s Service) UnassignRole(ctx context.Context, req *proto.RoleAssignmentRequest, resp *proto.RoleAssignmentResponse) error {
+	user, err := s.findUser(ctx, "UnassignRole", req.UserId)
+	if err != nil {
+		return err
+	}
+	if !findAssignment(user.Roles, req.Role, req.Resource) {
+		return microErrors.NotFound(errorID, "role %s isn't assigned", req.Role)
+	}
+
+	err = s.roleStorage.UnassignRole(ctx, req.UserId, storageModel.RoleAssignment{
+		Role:     req.Role,
+		Resource: req.Resource,
+	})
+	s.invalidatePermissions(req.UserId)
+	if err != nil {
+		s.requestLogger("UnassignRole", req.UserId).WithError(err).Error("could not unassign role")
+		return err
+	}
+	s.requestLogger("UnassignRole", req.UserId).WithField("role", req.Role).WithField("resource", req.Resource).Info("role unassigned")
+	return nil
+}
+
+// CheckPermission checks if the user may do the action on the resource.
+func (s Service) CheckPermission(ctx context.Context, req *proto.CheckPermissionRequest, resp *proto.CheckPermissionResponse) error {
+	if strings.TrimSpace(req.Action) == "" {
+		return microErrors.BadRequest(errorID, "action is required")
+	}
+	allowed, err := s.permissions.Check(ctx, req.UserId, req.Action, req.Resource)
+	if errors.Is(err, rbac.ErrUserNotFound) {
+		return microErrors.NotFound(errorID, "user %s not found", req.UserId)
+	}
+	if err != nil {
+		s.requestLogger("CheckPermission", req.UserId).WithError(err).Error("could not check permission")
+		return err
+	}
+	resp.Allowed = allowed
+	return nil
+}
+
+// invalidatePermissions drops cached permission checks of the users.
+func (s Service) invalidatePermissions(userIDs ...string) {
+	if s.permissions == nil {
+		return
+	}
+	for i := range userIDs {
+		s.permissions.Invalidate(userIDs[i])
+	}
+}
+
+func findAssignment(assignments []storageModel.RoleAssignment, role, resource string) bool {
+	for i := range assignments {
+		if assignments[i].Role == role && assignments[i].Resource == resource {
+			return true
+		}
+	}
+	return false
+}
+
+func newRole(role *proto.Role) storageModel.Role {
+	if role == nil {
+		return storageModel.Role{}
+	}
+	return storageModel.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+	}
+}
+
+func newRoleProto(role storageModel.Role) *proto.Role {
+	return &proto.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/rbac"
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Roles(t *testing.T) {
+	editor := storageModel.Role{Name: "editor", Permissions: []string{"orders:*"}}
+	newService := func(t *testing.T, st *storageMocks.User, rst *storageMocks.Role) Service {
+		rst.On("ListRoles", mock.Anything).Return([]storageModel.Role{editor}, nil).Maybe()
+		permissions := rbac.NewChecker(rbac.Config{RoleStorage: rst, UserStorage: st})
+		require.NoError(t, permissions.Load(context.Background()))
+		return New(Config{
+			UserStorage: st,
+			RoleStorage: rst,
+			Permissions: permissions,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	t.Run("put invalid role", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		err := newService(t, st, rst).PutRole(context.Background(), &proto.PutRoleRequest{Role: &proto.Role{}}, &proto.RoleResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("put role all ok", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		defer rst.AssertExpectations(t)
+		viewer := storageModel.Role{Name: "viewer", Permissions: []string{"orders:read"}}
+		rst.On("PutRole", mock.Anything, viewer).Return(nil)
+		service := newService(t, st, rst)
+		err := service.PutRole(context.Background(), &proto.PutRoleRequest{Role: &proto.Role{Name: "viewer", Permissions: []string{"orders:read"}}}, &proto.RoleResponse{})
+		require.NoError(t, err)
+		var resp proto.ListRolesResponse
+		require.NoError(t, service.ListRoles(context.Background(), &proto.ListRolesRequest{}, &resp))
+		require.Len(t, resp.Roles, 2)
+	})
+	t.Run("assign unknown role", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		err := newService(t, st, rst).AssignRole(context.Background(), &proto.RoleAssignmentRequest{UserId: "1", Role: "admin"}, &proto.RoleAssignmentResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("assign already assigned role", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Roles: []storageModel.RoleAssignment{{Role: "editor"}}}}, nil)
+		err := newService(t, st, rst).AssignRole(context.Background(), &proto.RoleAssignmentRequest{UserId: "1", Role: "editor"}, &proto.RoleAssignmentResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("unassign not assigned role", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Roles: []storageModel.RoleAssignment{{Role: "editor"}}}}, nil)
+		err := newService(t, st, rst).UnassignRole(context.Background(), &proto.RoleAssignmentRequest{UserId: "1", Role: "editor", Resource: "shop/1"}, &proto.RoleAssignmentResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("check unknown user", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		st.On("Find", mock.Anything, filter).Return(nil, nil)
+		err := newService(t, st, rst).CheckPermission(context.Background(), &proto.CheckPermissionRequest{UserId: "1", Action: "orders:read"}, &proto.CheckPermissionResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok (assignment invalidates cached checks)", func(t *testing.T) {
+		st, rst := new(storageMocks.User), new(storageMocks.Role)
+		defer rst.AssertExpectations(t)
+		user := storageModel.User{ID: "1", Status: status.Active}
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil).Twice()
+		service := newService(t, st, rst)
+		check := func() bool {
+			var resp proto.CheckPermissionResponse
+			err := service.CheckPermission(context.Background(), &proto.CheckPermissionRequest{UserId: "1", Action: "orders:write", Resource: "shop/1"}, &resp)
+			require.NoError(t, err)
+			return resp.Allowed
+		}
+		require.False(t, check())
+
+		rst.On("AssignRole", mock.Anything, "1", storageModel.RoleAssignment{Role: "editor", Resource: "shop/1"}).Return(nil)
+		err := service.AssignRole(context.Background(), &proto.RoleAssignmentRequest{UserId: "1", Role: "editor", Resource: "shop/1"}, &proto.RoleAssignmentResponse{})
+		require.NoError(t, err)
+
+		user.Roles = []storageModel.RoleAssignment{{Role: "editor", Resource: "shop/1"}}
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil).Once()
+		require.True(t, check())
+		require.True(t, check())
+		st.AssertNumberOfCalls(t, "Find", 3)
+	})
+}
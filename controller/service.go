@@ -1,26 +1,156 @@
 package controller
 
 import (
+	"context"
+	"time"
+
+	"github.com/micro/go-micro/v2/client"
+	microErrors "github.com/micro/go-micro/v2/errors"
 	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/lockout"
+	"github.com/open-Q/user/logging"
+	"github.com/open-Q/user/meta"
+	"github.com/open-Q/user/rbac"
+	"github.com/open-Q/user/status"
 	"github.com/open-Q/user/storage"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/sirupsen/logrus"
 )
 
+// errorID identifies errors returned to the service clients.
+const errorID = "user"
+
 // Service represents service controller instance.
 type Service struct {
-	userStorage storage.User
-	logger      *commonLog.Logger
+	userStorage     storage.User
+	groupStorage    storage.Group
+	roleStorage     storage.Role
+	identityStorage storage.Identity
+	permissions     *rbac.Checker
+	credentials     *credential.Manager
+	apiKeys         *credential.KeyManager
+	twoFactor       *credential.TwoFactorManager
+	tokens          *credential.TokenManager
+	verifier        *verification.Manager
+	lockouts        *lockout.Manager
+	exporter        *export.Exporter
+	metaSchema      *meta.Registry
+	statusMachine   *status.Machine
+	mergePolicies   meta.MergePolicies
+	publisher       Publisher
+	logger          *commonLog.Logger
+}
+
+// Publisher publishes service events, e.g. micro.Event.
+type Publisher interface {
+	Publish(ctx context.Context, msg interface{}, opts ...client.PublishOption) error
 }
 
 // Config represents service configuration.
 type Config struct {
-	UserStorage storage.User
-	Logger      *commonLog.Logger
+	UserStorage  storage.User
+	GroupStorage storage.Group
+	RoleStorage  storage.Role
+	// IdentityStorage links users' external identities.
+	IdentityStorage storage.Identity
+	// HistorySources provide user history for data exports.
+	HistorySources []export.HistorySource
+	// MetaSchema validates written meta, any meta is accepted if empty.
+	MetaSchema *meta.Registry
+	// StatusMachine validates status transitions, the default one is used if empty.
+	StatusMachine *status.Machine
+	// Permissions checks permissions granted by the roles.
+	Permissions *rbac.Checker
+	// Credentials keeps users' passwords.
+	Credentials *credential.Manager
+	// APIKeys keeps users' API keys.
+	APIKeys *credential.KeyManager
+	// TwoFactor keeps users' TOTP two-factor, two-factor RPCs fail if empty.
+	TwoFactor *credential.TwoFactorManager
+	// Tokens issues password reset and magic link tokens, token RPCs fail if empty.
+	Tokens *credential.TokenManager
+	// Verifier verifies users' contacts, verification RPCs fail if empty.
+	Verifier *verification.Manager
+	// Lockouts tracks failed logins and locks users and login identifiers out.
+	Lockouts *lockout.Manager
+	// MergePolicies resolve meta conflicts of merged users.
+	MergePolicies meta.MergePolicies
+	// MergePublisher publishes merge events, no events are published if empty.
+	MergePublisher Publisher
+	Logger         *commonLog.Logger
 }
 
 // New creates new service instance.
 func New(cfg Config) Service {
+	statusMachine := cfg.StatusMachine
+	if statusMachine == nil {
+		statusMachine = status.DefaultMachine()
+	}
 	return Service{
-		logger:      cfg.Logger,
-		userStorage: cfg.UserStorage,
+		logger:          cfg.Logger,
+		userStorage:     cfg.UserStorage,
+		groupStorage:    cfg.GroupStorage,
+		roleStorage:     cfg.RoleStorage,
+		identityStorage: cfg.IdentityStorage,
+		permissions:     cfg.Permissions,
+		credentials:     cfg.Credentials,
+		apiKeys:         cfg.APIKeys,
+		twoFactor:       cfg.TwoFactor,
+		tokens:          cfg.Tokens,
+		verifier:        cfg.Verifier,
+		lockouts:        cfg.Lockouts,
+		exporter:        export.New(cfg.UserStorage, cfg.HistorySources...),
+		metaSchema:      cfg.MetaSchema,
+		statusMachine:   statusMachine,
+		mergePolicies:   cfg.MergePolicies,
+		publisher:       cfg.MergePublisher,
+	}
+}
+
+// requestLogger returns logger with request-scoped fields.
+func (s Service) requestLogger(operation, userID string) *logrus.Entry {
+	return logging.WithRequest(s.logger, operation, userID)
+}
+
+// findUser returns the user by ID or not found error.
+func (s Service) findUser(ctx context.Context, operation, userID string) (*storageModel.User, error) {
+	users, err := s.userStorage.Find(ctx, storageModel.UserFindFilter{
+		IDs: []string{userID},
+	})
+	if err != nil {
+		s.requestLogger(operation, userID).WithError(err).Error("could not find user")
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, microErrors.NotFound(errorID, "user %s not found", userID)
+	}
+	return &users[0], nil
+}
+
+// touchActivity records user's activity for retention, failures are only logged
+// since they must not fail the login.
+func (s Service) touchActivity(ctx context.Context, operation, userID string) {
+	if err := s.userStorage.TouchActivity(ctx, userID, time.Now()); err != nil {
+		s.requestLogger(operation, userID).WithError(err).Warn("could not record user activity")
+	}
+}
+
+// validateMeta validates user's meta against the meta schema.
+// Violations are only logged in the warn-only mode.
+func (s Service) validateMeta(operation, userID string, values map[string]interface{}) error {
+	if s.metaSchema == nil {
+		return nil
+	}
+	violations := s.metaSchema.Validate(values)
+	if len(violations) == 0 {
+		return nil
+	}
+	if s.metaSchema.Mode() == meta.ModeWarn {
+		s.requestLogger(operation, userID).WithField("violations", violations.Error()).Warn("meta doesn't match schema")
+		return nil
 	}
+	return microErrors.BadRequest(errorID, "invalid meta: %s", violations.Error())
 }
@@ -4,22 +4,33 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/micro/go-micro/v2"
 	commonLog "github.com/open-Q/common/golang/log"
-	"github.com/open-Q/user/mocks"
+	"github.com/open-Q/user/meta"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
 	"github.com/stretchr/testify/require"
 )
 
 var errMock = errors.New("error")
 
 func Test_New(t *testing.T) {
-	s, err := New(Config{
-		Storage: &mocks.Storage{},
-		Micro:   micro.NewService(),
-		Logger:  &commonLog.Logger{},
+	s := New(Config{
+		UserStorage: &storageMocks.User{},
+		Logger:      &commonLog.Logger{},
 	})
-	require.NoError(t, err)
-	require.NotNil(t, s)
 	require.NotNil(t, s.logger)
-	require.NotNil(t, s.storage)
+	require.NotNil(t, s.userStorage)
+	require.NotNil(t, s.exporter)
+}
+
+func newTestMetaSchema(t *testing.T, mode meta.Mode) *meta.Registry {
+	registry, err := meta.NewRegistry(meta.RegistryConfig{
+		Fields: []storageModel.MetaField{
+			{Key: "email", Type: meta.TypeString, Format: meta.FormatEmail, Required: true},
+			{Key: "age", Type: meta.TypeInt},
+		},
+		Mode: mode,
+	})
+	require.NoError(t, err)
+	return registry
 }
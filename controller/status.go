@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/status"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Suspend suspends the user, until the provided time if it's set.
+func (s Service) Suspend(ctx context.Context, req *proto.StatusChangeRequest, resp *proto.UserResponse) error {
+	return s.changeStatus(ctx, "Suspend", req, status.Suspended, resp)
+}
+
+// Reactivate makes the user active again.
+func (s Service) Reactivate(ctx context.Context, req *proto.StatusChangeRequest, resp *proto.UserResponse) error {
+	return s.changeStatus(ctx, "Reactivate", req, status.Active, resp)
+}
+
+// Ban bans the user, until the provided time if it's set.
+func (s Service) Ban(ctx context.Context, req *proto.StatusChangeRequest, resp *proto.UserResponse) error {
+	return s.changeStatus(ctx, "Ban", req, status.Banned, resp)
+}
+
+// ScheduleStatusChange schedules status change for the future.
+func (s Service) ScheduleStatusChange(ctx context.Context, req *proto.ScheduleStatusChangeRequest, resp *proto.StatusScheduleResponse) error {
+	if req.At == nil || !req.At.AsTime().After(time.Now()) {
+		return microErrors.BadRequest(errorID, "schedule time must be in the future")
+	}
+	user, err := s.findUser(ctx, "ScheduleStatusChange", req.Id)
+	if err != nil {
+		return err
+	}
+	to := req.Status.String()
+	if err := s.checkTransition(user.Status, to, req.Reason, req.Actor); err != nil {
+		return err
+	}
+
+	updatedUser, err := s.userStorage.AddStatusSchedule(ctx, req.Id, storageModel.StatusSchedule{
+		To:     to,
+		Reason: req.Reason,
+		Actor:  req.Actor,
+		At:     req.At.AsTime(),
+	})
+	if err != nil {
+		s.requestLogger("ScheduleStatusChange", req.Id).WithError(err).Error("could not schedule status change")
+		return err
+	}
+	schedule := updatedUser.StatusSchedules[len(updatedUser.StatusSchedules)-1]
+	s.requestLogger("ScheduleStatusChange", req.Id).WithFields(logrus.Fields{
+		"schedule_id": schedule.ID,
+		"to":          schedule.To,
+		"reason":      schedule.Reason,
+		"actor":       schedule.Actor,
+		"at":          schedule.At,
+	}).Info("user status change scheduled")
+	resp.Schedule = newStatusScheduleProto(schedule)
+	return nil
+}
+
+// ListStatusSchedules returns pending status changes of the user.
+func (s Service) ListStatusSchedules(ctx context.Context, req *proto.ListStatusSchedulesRequest, resp *proto.ListStatusSchedulesResponse) error {
+	user, err := s.findUser(ctx, "ListStatusSchedules", req.Id)
+	if err != nil {
+		return err
+	}
+	resp.Schedules = make([]*proto.StatusSchedule, len(user.StatusSchedules))
+	for i := range user.StatusSchedules {
+		resp.Schedules[i] = newStatusScheduleProto(user.StatusSchedules[i])
+	}
+	return nil
+}
+
+// CancelStatusSchedule cancels pending status change.
+func (s Service) CancelStatusSchedule(ctx context.Context, req *proto.CancelStatusScheduleRequest, resp *proto.StatusScheduleResponse) error {
+	user, err := s.findUser(ctx, "CancelStatusSchedule", req.Id)
+	if err != nil {
+		return err
+	}
+	var schedule *storageModel.StatusSchedule
+	for i := range user.StatusSchedules {
+		if user.StatusSchedules[i].ID == req.ScheduleId {
+			schedule = &user.StatusSchedules[i]
+			break
+		}
+	}
+	if schedule == nil {
+		return microErrors.NotFound(errorID, "status schedule %s not found", req.ScheduleId)
+	}
+
+	if _, err := s.userStorage.RemoveStatusSchedule(ctx, req.Id, req.ScheduleId); err != nil {
+		s.requestLogger("CancelStatusSchedule", req.Id).WithError(err).Error("could not cancel status schedule")
+		return err
+	}
+	s.requestLogger("CancelStatusSchedule", req.Id).WithField("schedule_id", req.ScheduleId).Info("user status schedule cancelled")
+	resp.Schedule = newStatusScheduleProto(*schedule)
+	return nil
+}
+
+// changeStatus moves the user to the status if the transition is allowed.
+// Temporary changes are reverted by the scheduler when they expire.
+func (s Service) changeStatus(ctx context.Context, operation string, req *proto.StatusChangeRequest, to string, resp *proto.UserResponse) error {
+	if req.Until != nil && !req.Until.AsTime().After(time.Now()) {
+		return microErrors.BadRequest(errorID, "until must be in the future")
+	}
+	user, err := s.findUser(ctx, operation, req.Id)
+	if err != nil {
+		return err
+	}
+
+	from := user.Status
+	if err := s.checkTransition(from, to, req.Reason, req.Actor); err != nil {
+		return err
+	}
+	change := storageModel.StatusChange{
+		From:   from,
+		To:     to,
+		Reason: req.Reason,
+		Actor:  req.Actor,
+	}
+	if req.Until != nil {
+		if err := s.statusMachine.Check(to, from, status.ReasonExpired, req.Actor); err != nil {
+			return microErrors.BadRequest(errorID, "status change can't be reverted: %s", err.Error())
+		}
+		change.Schedule = &storageModel.StatusSchedule{
+			From:   to,
+			To:     from,
+			Reason: status.ReasonExpired,
+			Actor:  req.Actor,
+			At:     req.Until.AsTime(),
+		}
+	}
+
+	updatedUser, err := s.userStorage.ChangeStatus(ctx, req.Id, change)
+	s.invalidatePermissions(req.Id)
+	if err != nil {
+		s.requestLogger(operation, req.Id).WithError(err).Error("could not change user status")
+		return err
+	}
+	logger := s.requestLogger(operation, req.Id).WithFields(logrus.Fields{
+		"from":   from,
+		"to":     to,
+		"reason": req.Reason,
+		"actor":  req.Actor,
+	})
+	if change.Schedule != nil {
+		logger = logger.WithField("until", change.Schedule.At)
+	}
+	logger.Info("user status changed")
+	return newUserResponse(resp, updatedUser)
+}
+
+// checkTransition returns conflict error for transitions which are not allowed
+// and bad request error for invalid reasons and actors.
+func (s Service) checkTransition(from, to, reason, actor string) error {
+	err := s.statusMachine.Check(from, to, reason, actor)
+	if err == nil {
+		return nil
+	}
+	var transitionErr *status.TransitionError
+	if errors.As(err, &transitionErr) {
+		return microErrors.Conflict(errorID, "%s", err.Error())
+	}
+	return microErrors.BadRequest(errorID, "%s", err.Error())
+}
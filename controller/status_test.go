@@ -0,0 +1,231 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestService_changeStatus(t *testing.T) {
+	newService := func(st *storageMocks.User) Service {
+		return New(Config{
+			UserStorage: st,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	req := &proto.StatusChangeRequest{
+		Id:     "1",
+		Reason: "abuse",
+		Actor:  "admin",
+	}
+	t.Run("find user error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return(nil, errMock)
+		err := newService(st).Suspend(context.Background(), req, &proto.UserResponse{})
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("user not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{}, nil)
+		err := newService(st).Suspend(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("reason is required", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		err := newService(st).Suspend(context.Background(), &proto.StatusChangeRequest{Id: "1", Actor: "admin"}, &proto.UserResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "reason is required", microErrors.Parse(err.Error()).Detail)
+	})
+	t.Run("transition is not allowed", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Banned}}, nil)
+		err := newService(st).Reactivate(context.Background(), req, &proto.UserResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "transition from ACCOUNT_STATUS_BANNED to ACCOUNT_STATUS_ACTIVE is not allowed", microErrors.Parse(err.Error()).Detail)
+	})
+	t.Run("change status error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		st.On("ChangeStatus", mock.Anything, "1", mock.Anything).Return(nil, errMock)
+		err := newService(st).Ban(context.Background(), req, &proto.UserResponse{})
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("until in the past", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		err := newService(st).Suspend(context.Background(), &proto.StatusChangeRequest{
+			Id:     "1",
+			Reason: "abuse",
+			Actor:  "admin",
+			Until:  timestamppb.New(time.Now().Add(-time.Hour)),
+		}, &proto.UserResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "until must be in the future", microErrors.Parse(err.Error()).Detail)
+	})
+	t.Run("irreversible temporary change", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		err := newService(st).Ban(context.Background(), &proto.StatusChangeRequest{
+			Id:     "1",
+			Reason: "abuse",
+			Actor:  "admin",
+			Until:  timestamppb.New(time.Now().Add(time.Hour)),
+		}, &proto.UserResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "status change can't be reverted: transition from ACCOUNT_STATUS_BANNED to ACCOUNT_STATUS_ACTIVE is not allowed", microErrors.Parse(err.Error()).Detail)
+	})
+	t.Run("all ok (temporary)", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		until := time.Now().Add(7 * 24 * time.Hour).UTC()
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		st.On("ChangeStatus", mock.Anything, "1", storageModel.StatusChange{
+			From:   status.Active,
+			To:     status.Suspended,
+			Reason: "abuse",
+			Actor:  "admin",
+			Schedule: &storageModel.StatusSchedule{
+				From:   status.Suspended,
+				To:     status.Active,
+				Reason: status.ReasonExpired,
+				Actor:  "admin",
+				At:     until,
+			},
+		}).Return(&storageModel.User{ID: "1", Status: status.Suspended}, nil)
+		err := newService(st).Suspend(context.Background(), &proto.StatusChangeRequest{
+			Id:     "1",
+			Reason: "abuse",
+			Actor:  "admin",
+			Until:  timestamppb.New(until),
+		}, &proto.UserResponse{})
+		require.NoError(t, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		st.On("ChangeStatus", mock.Anything, "1", storageModel.StatusChange{
+			From:   status.Active,
+			To:     status.Suspended,
+			Reason: "abuse",
+			Actor:  "admin",
+		}).Return(&storageModel.User{ID: "1", Status: status.Suspended}, nil)
+		var resp proto.UserResponse
+		err := newService(st).Suspend(context.Background(), req, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "1", resp.Id)
+		require.Equal(t, proto.AccountStatus_ACCOUNT_STATUS_SUSPENDED, resp.Status)
+	})
+}
+
+func TestService_StatusSchedules(t *testing.T) {
+	newService := func(st *storageMocks.User) Service {
+		return New(Config{
+			UserStorage: st,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	at := time.Now().Add(24 * time.Hour).UTC()
+	schedule := storageModel.StatusSchedule{
+		ID:     "s1",
+		To:     status.Deactivated,
+		Reason: "request",
+		Actor:  "user",
+		At:     at,
+	}
+	t.Run("schedule in the past", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		err := newService(st).ScheduleStatusChange(context.Background(), &proto.ScheduleStatusChangeRequest{
+			Id:     "1",
+			Status: proto.AccountStatus_ACCOUNT_STATUS_DEACTIVATED,
+			Reason: "request",
+			Actor:  "user",
+			At:     timestamppb.New(time.Now().Add(-time.Hour)),
+		}, &proto.StatusScheduleResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("schedule not allowed transition", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Banned}}, nil)
+		err := newService(st).ScheduleStatusChange(context.Background(), &proto.ScheduleStatusChangeRequest{
+			Id:     "1",
+			Status: proto.AccountStatus_ACCOUNT_STATUS_DEACTIVATED,
+			Reason: "request",
+			Actor:  "user",
+			At:     timestamppb.New(at),
+		}, &proto.StatusScheduleResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("schedule all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Active}}, nil)
+		st.On("AddStatusSchedule", mock.Anything, "1", storageModel.StatusSchedule{
+			To:     status.Deactivated,
+			Reason: "request",
+			Actor:  "user",
+			At:     at,
+		}).Return(&storageModel.User{ID: "1", StatusSchedules: []storageModel.StatusSchedule{schedule}}, nil)
+		var resp proto.StatusScheduleResponse
+		err := newService(st).ScheduleStatusChange(context.Background(), &proto.ScheduleStatusChangeRequest{
+			Id:     "1",
+			Status: proto.AccountStatus_ACCOUNT_STATUS_DEACTIVATED,
+			Reason: "request",
+			Actor:  "user",
+			At:     timestamppb.New(at),
+		}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "s1", resp.Schedule.Id)
+		require.Equal(t, proto.AccountStatus_ACCOUNT_STATUS_DEACTIVATED, resp.Schedule.To)
+	})
+	t.Run("list all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", StatusSchedules: []storageModel.StatusSchedule{schedule}}}, nil)
+		var resp proto.ListStatusSchedulesResponse
+		err := newService(st).ListStatusSchedules(context.Background(), &proto.ListStatusSchedulesRequest{Id: "1"}, &resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Schedules, 1)
+		require.Equal(t, "s1", resp.Schedules[0].Id)
+		require.True(t, at.Equal(resp.Schedules[0].At.AsTime()))
+	})
+	t.Run("cancel unknown schedule", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		err := newService(st).CancelStatusSchedule(context.Background(), &proto.CancelStatusScheduleRequest{Id: "1", ScheduleId: "s1"}, &proto.StatusScheduleResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("cancel all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", StatusSchedules: []storageModel.StatusSchedule{schedule}}}, nil)
+		st.On("RemoveStatusSchedule", mock.Anything, "1", "s1").Return(&storageModel.User{ID: "1"}, nil)
+		var resp proto.StatusScheduleResponse
+		err := newService(st).CancelStatusSchedule(context.Background(), &proto.CancelStatusScheduleRequest{Id: "1", ScheduleId: "s1"}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "s1", resp.Schedule.Id)
+	})
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/status"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// IssueToken sends a password reset or magic link token to the active user's contact.
+// The token replaces the user's previous token of the purpose.
+func (s Service) IssueToken(ctx context.Context, req *proto.IssueTokenRequest, resp *proto.IssueTokenResponse) error {
+	if s.tokens == nil {
+		return microErrors.New(errorID, "tokens require a notifier", http.StatusNotImplemented)
+	}
+	user, err := s.findUser(ctx, "IssueToken", req.Id)
+	if err != nil {
+		return err
+	}
+	if user.Status != status.Active {
+		return microErrors.Conflict(errorID, "user %s isn't active", req.Id)
+	}
+	token, err := s.tokens.Issue(ctx, *user, req.Purpose)
+	if err != nil {
+		return s.tokenError("IssueToken", req.Id, err)
+	}
+	s.requestLogger("IssueToken", req.Id).WithField("purpose", req.Purpose).Info("token issued")
+	resp.ExpiresAt = timestamppb.New(token.ExpiresAt)
+	return nil
+}
+
+// RedeemToken uses the token, password reset tokens set the requested password.
+// Invalid tokens and tokens of inactive users aren't valid.
+func (s Service) RedeemToken(ctx context.Context, req *proto.RedeemTokenRequest, resp *proto.RedeemTokenResponse) error {
+	if s.tokens == nil {
+		return microErrors.New(errorID, "tokens require a notifier", http.StatusNotImplemented)
+	}
+	// the password is validated first, so invalid passwords don't use the token.
+	if req.Purpose == storageModel.TokenPasswordReset {
+		if err := s.credentials.ValidatePassword(req.Password); err != nil {
+			return microErrors.BadRequest(errorID, "%s", err.Error())
+		}
+	}
+	token, err := s.tokens.Redeem(ctx, req.Purpose, req.Token)
+	if err == credential.ErrInvalidToken {
+		return nil
+	}
+	if err != nil {
+		return s.tokenError("RedeemToken", "", err)
+	}
+	users, err := s.userStorage.Find(ctx, storageModel.UserFindFilter{
+		IDs: []string{token.UserID},
+	})
+	if err != nil {
+		s.requestLogger("RedeemToken", token.UserID).WithError(err).Error("could not find user")
+		return err
+	}
+	if len(users) == 0 || users[0].Status != status.Active {
+		return nil
+	}
+	if token.Purpose == storageModel.TokenPasswordReset {
+		if err := s.credentials.SetPassword(ctx, token.UserID, req.Password); err != nil {
+			s.requestLogger("RedeemToken", token.UserID).WithError(err).Error("could not reset password")
+			return err
+		}
+		if err := s.revokeTokens(ctx, "RedeemToken", token.UserID); err != nil {
+			return err
+		}
+		s.requestLogger("RedeemToken", token.UserID).Info("password reset")
+	}
+	resp.Valid = true
+	resp.UserId = token.UserID
+	return nil
+}
+
+// revokeTokens invalidates the user's tokens after its password is changed.
+func (s Service) revokeTokens(ctx context.Context, operation, userID string) error {
+	if s.tokens == nil {
+		return nil
+	}
+	if err := s.tokens.Revoke(ctx, userID); err != nil {
+		s.requestLogger(operation, userID).WithError(err).Error("could not revoke tokens")
+		return err
+	}
+	return nil
+}
+
+// tokenError converts token request errors to client errors.
+func (s Service) tokenError(operation, userID string, err error) error {
+	switch errors.Cause(err) {
+	case credential.ErrUnknownPurpose, credential.ErrNoContact:
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	s.requestLogger(operation, userID).WithError(err).Error("could not handle token")
+	return err
+}
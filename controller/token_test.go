@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Tokens(t *testing.T) {
+	logger := &commonLog.Logger{Logger: logrus.New()}
+	params := credential.DefaultParams()
+	params.Argon2Memory = 64
+	params.Argon2Iterations = 1
+	newService := func(t *testing.T, st *storageMocks.User, cst *storageMocks.Credential, tst *storageMocks.Token) Service {
+		credentials, err := credential.NewManager(credential.Config{
+			Storage: cst,
+			Params:  params,
+		})
+		require.NoError(t, err)
+		return New(Config{
+			UserStorage: st,
+			Credentials: credentials,
+			Tokens: credential.NewTokenManager(credential.TokenConfig{
+				Storage:  tst,
+				Notifier: verification.NewLogNotifier(logger),
+			}),
+			Logger: logger,
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	user := storageModel.User{ID: "1", Status: status.Active, Meta: map[string]interface{}{"email": "john@example.com"}}
+	t.Run("not configured", func(t *testing.T) {
+		err := New(Config{Logger: logger}).IssueToken(context.Background(), &proto.IssueTokenRequest{Id: "1"}, &proto.IssueTokenResponse{})
+		require.Equal(t, int32(501), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("inactive user", func(t *testing.T) {
+		st := new(storageMocks.User)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1", Status: status.Suspended}}, nil)
+		err := newService(t, st, nil, nil).IssueToken(context.Background(), &proto.IssueTokenRequest{Id: "1", Purpose: storageModel.TokenMagicLink}, &proto.IssueTokenResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("unknown purpose", func(t *testing.T) {
+		st := new(storageMocks.User)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil)
+		err := newService(t, st, nil, nil).IssueToken(context.Background(), &proto.IssueTokenRequest{Id: "1", Purpose: "unknown"}, &proto.IssueTokenResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("invalid password", func(t *testing.T) {
+		tst := new(storageMocks.Token)
+		defer tst.AssertExpectations(t)
+		err := newService(t, nil, nil, tst).RedeemToken(context.Background(), &proto.RedeemTokenRequest{
+			Token:    "secret",
+			Purpose:  storageModel.TokenPasswordReset,
+			Password: "short",
+		}, &proto.RedeemTokenResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("invalid token", func(t *testing.T) {
+		tst := new(storageMocks.Token)
+		tst.On("UseToken", mock.Anything, storageModel.TokenMagicLink, mock.Anything, mock.Anything).Return(nil, nil)
+		resp := &proto.RedeemTokenResponse{}
+		require.NoError(t, newService(t, nil, nil, tst).RedeemToken(context.Background(), &proto.RedeemTokenRequest{
+			Token:   "secret",
+			Purpose: storageModel.TokenMagicLink,
+		}, resp))
+		require.False(t, resp.Valid)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st, cst, tst := new(storageMocks.User), new(storageMocks.Credential), new(storageMocks.Token)
+		defer cst.AssertExpectations(t)
+		defer tst.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil)
+		tst.On("PutToken", mock.Anything, mock.MatchedBy(func(token storageModel.Token) bool {
+			return token.UserID == "1" && token.Purpose == storageModel.TokenPasswordReset
+		})).Return(func(_ context.Context, token storageModel.Token) *storageModel.Token {
+			return &token
+		}, nil)
+		s := newService(t, st, cst, tst)
+		issueResp := &proto.IssueTokenResponse{}
+		require.NoError(t, s.IssueToken(context.Background(), &proto.IssueTokenRequest{Id: "1", Purpose: storageModel.TokenPasswordReset}, issueResp))
+		require.NotNil(t, issueResp.ExpiresAt)
+
+		tst.On("UseToken", mock.Anything, storageModel.TokenPasswordReset, mock.Anything, mock.Anything).Return(&storageModel.Token{
+			UserID:  "1",
+			Purpose: storageModel.TokenPasswordReset,
+		}, nil)
+		cst.On("PutPassword", mock.Anything, mock.MatchedBy(func(c storageModel.PasswordCredential) bool {
+			return c.UserID == "1"
+		})).Return(nil)
+		tst.On("DeleteTokens", mock.Anything, "1").Return(nil)
+		resp := &proto.RedeemTokenResponse{}
+		require.NoError(t, s.RedeemToken(context.Background(), &proto.RedeemTokenRequest{
+			Token:    "secret",
+			Purpose:  storageModel.TokenPasswordReset,
+			Password: "new password",
+		}, resp))
+		require.True(t, resp.Valid)
+		require.Equal(t, "1", resp.UserId)
+	})
+}
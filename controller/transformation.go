@@ -1,57 +1,100 @@
 package controller
 
 import (
-	"github.com/golang/protobuf/ptypes"
+	"sort"
+
 	proto "github.com/open-Q/common/golang/proto/user"
-	"github.com/open-Q/user/storage"
-	"google.golang.org/protobuf/types/known/anypb"
+	"github.com/open-Q/user/meta"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-type any struct {
-	*anypb.Any
-}
-
-func newAny(value []byte) any {
-	return any{
-		Any: &anypb.Any{
-			Value: value,
-		},
-	}
-}
-
-func newUserMeta(meta []*proto.UserMeta) map[string][]byte {
-	res := make(map[string][]byte)
-	for i := range meta {
-		res[meta[i].Key] = meta[i].Value.GetValue()
+// newUserMeta converts protobuf meta into storage meta values and their type URLs.
+func newUserMeta(protoMeta []*proto.UserMeta) (map[string]interface{}, map[string]string, error) {
+	values := make(map[string]interface{}, len(protoMeta))
+	types := make(map[string]string, len(protoMeta))
+	for i := range protoMeta {
+		key := protoMeta[i].Key
+		value, typeURL, err := meta.Decode(protoMeta[i].Value)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid %s meta value", key)
+		}
+		values[key] = value
+		if typeURL != "" {
+			types[key] = typeURL
+		}
 	}
-	return res
+	return values, types, nil
 }
 
-func newUserMetaProto(meta map[string][]byte) ([]*proto.UserMeta, error) {
-	res := make([]*proto.UserMeta, 0, len(meta))
-	for k, v := range meta {
+// newUserMetaProto converts storage meta values and their type URLs into protobuf meta.
+func newUserMetaProto(values map[string]interface{}, types map[string]string) ([]*proto.UserMeta, error) {
+	res := make([]*proto.UserMeta, 0, len(values))
+	for k, v := range values {
 		// skip nil values.
-		if len(v) == 0 {
+		if v == nil && types[k] == "" {
 			continue
 		}
-		any, err := ptypes.MarshalAny(newAny(v))
+		value, err := meta.Encode(v, types[k])
 		if err != nil {
-			// skip value.
-			continue
+			return nil, errors.Wrapf(err, "invalid %s meta value", k)
 		}
 		res = append(res, &proto.UserMeta{
-			Key: k,
-			Value: &anypb.Any{
-				Value: any.Value,
-			},
+			Key:   k,
+			Value: value,
 		})
 	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
 	return res, nil
 }
 
-func newUserResponse(resp *proto.UserResponse, user *storage.User) (err error) {
+func newUserResponse(resp *proto.UserResponse, user *storageModel.User) (err error) {
 	resp.Id = user.ID
 	resp.Status = proto.AccountStatus(proto.AccountStatus_value[user.Status])
-	resp.Meta, err = newUserMetaProto(user.Meta)
+	resp.MergedInto = user.MergedInto
+	resp.GroupIds = user.GroupIDs
+	resp.TwoFactorEnabled = user.TwoFactorEnabled
+	resp.VerifiedContacts = newContactVerificationsProto(user)
+	resp.Meta, err = newUserMetaProto(user.Meta, user.MetaTypes)
 	return
 }
+
+// mergeMeta merges meta update into the user's meta, keys with nil values are removed.
+func mergeMeta(values map[string]interface{}, types map[string]string, update map[string]interface{}, updateTypes map[string]string) (map[string]interface{}, map[string]string) {
+	resValues := make(map[string]interface{}, len(values)+len(update))
+	resTypes := make(map[string]string, len(types)+len(updateTypes))
+	for k, v := range values {
+		resValues[k] = v
+		if t, ok := types[k]; ok {
+			resTypes[k] = t
+		}
+	}
+	for k, v := range update {
+		delete(resValues, k)
+		delete(resTypes, k)
+		if v == nil {
+			continue
+		}
+		resValues[k] = v
+		if t, ok := updateTypes[k]; ok {
+			resTypes[k] = t
+		}
+	}
+	return resValues, resTypes
+}
+
+// newStatusScheduleProto converts status schedule, From is unspecified if any status is expected.
+func newStatusScheduleProto(schedule storageModel.StatusSchedule) *proto.StatusSchedule {
+	return &proto.StatusSchedule{
+		Id:        schedule.ID,
+		From:      proto.AccountStatus(proto.AccountStatus_value[schedule.From]),
+		To:        proto.AccountStatus(proto.AccountStatus_value[schedule.To]),
+		Reason:    schedule.Reason,
+		Actor:     schedule.Actor,
+		At:        timestamppb.New(schedule.At),
+		CreatedAt: timestamppb.New(schedule.CreatedAt),
+	}
+}
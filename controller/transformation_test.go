@@ -1,100 +1,138 @@
 package controller
 
 import (
-	"bytes"
 	"testing"
 
-	"github.com/golang/protobuf/ptypes"
 	proto "github.com/open-Q/common/golang/proto/user"
-	"github.com/open-Q/user/storage"
+	storageModel "github.com/open-Q/user/storage/model"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
-func Test_newAny(t *testing.T) {
-	v := []byte("hello")
-	any := newAny(v)
-	require.NotNil(t, any)
-	require.Equal(t, v, any.Value)
+func newTestAny(t *testing.T, m interface{}) *anypb.Any {
+	switch v := m.(type) {
+	case []byte:
+		return &anypb.Any{Value: v}
+	case string:
+		res, err := anypb.New(wrapperspb.String(v))
+		require.NoError(t, err)
+		return res
+	case int64:
+		res, err := anypb.New(wrapperspb.Int64(v))
+		require.NoError(t, err)
+		return res
+	}
+	t.Fatalf("unsupported test value %T", m)
+	return nil
 }
 
 func Test_newUserMeta(t *testing.T) {
-	meta := []*proto.UserMeta{
-		{
-			Key:   "key1",
-			Value: newAny([]byte("hello")).Any,
-		},
-		{
-			Key:   "key2",
-			Value: newAny([]byte("world")).Any,
-		},
-	}
-	userMeta := newUserMeta(meta)
-	require.NotNil(t, userMeta)
-	for i := range meta {
-		v, ok := userMeta[meta[i].Key]
-		require.True(t, ok)
-		require.True(t, bytes.Equal(v, meta[i].Value.GetValue()))
-	}
+	t.Run("invalid value", func(t *testing.T) {
+		_, _, err := newUserMeta([]*proto.UserMeta{
+			{
+				Key: "key1",
+				Value: &anypb.Any{
+					TypeUrl: "type.googleapis.com/google.protobuf.StringValue",
+					Value:   []byte{0xff},
+				},
+			},
+		})
+		require.Error(t, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		values, types, err := newUserMeta([]*proto.UserMeta{
+			{Key: "key1", Value: newTestAny(t, "hello")},
+			{Key: "key2", Value: newTestAny(t, int64(42))},
+			{Key: "key3", Value: newTestAny(t, []byte("world"))},
+		})
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"key1": "hello",
+			"key2": int64(42),
+			"key3": []byte("world"),
+		}, values)
+		require.Equal(t, map[string]string{
+			"key1": "type.googleapis.com/google.protobuf.StringValue",
+			"key2": "type.googleapis.com/google.protobuf.Int64Value",
+		}, types)
+	})
 }
 
 func Test_newUserMetaProto(t *testing.T) {
-	meta := map[string][]byte{
-		"hello": nil,
-		"key1":  []byte("value"),
-		"key2":  {1, 2, 3},
-	}
-	res, err := newUserMetaProto(meta)
-	require.NoError(t, err)
-	require.NotNil(t, res)
-	require.Equal(t, len(meta)-1, len(res))
-	for k, v := range meta {
-		if len(v) == 0 {
-			continue
-		}
-		var found bool
-		for i := range res {
-			if res[i].Key == k {
-				any, err := ptypes.MarshalAny(newAny(v))
-				require.NoError(t, err)
-				require.NotNil(t, any)
-				require.True(t, bytes.Equal(res[i].Value.GetValue(), any.Value))
-				found = true
-				break
-			}
-		}
-		require.True(t, found)
-	}
+	t.Run("type mismatch", func(t *testing.T) {
+		_, err := newUserMetaProto(map[string]interface{}{
+			"key1": true,
+		}, map[string]string{
+			"key1": "type.googleapis.com/google.protobuf.Int64Value",
+		})
+		require.Error(t, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		res, err := newUserMetaProto(map[string]interface{}{
+			"hello": nil,
+			"key1":  "value",
+			"key2":  int64(42),
+			"key3":  []byte{1, 2, 3},
+		}, map[string]string{
+			"key1": "type.googleapis.com/google.protobuf.StringValue",
+			"key2": "type.googleapis.com/google.protobuf.Int64Value",
+		})
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		require.Equal(t, "key1", res[0].Key)
+		require.Equal(t, newTestAny(t, "value").Value, res[0].Value.Value)
+		require.Equal(t, "type.googleapis.com/google.protobuf.StringValue", res[0].Value.TypeUrl)
+		require.Equal(t, "key2", res[1].Key)
+		require.Equal(t, newTestAny(t, int64(42)).Value, res[1].Value.Value)
+		require.Equal(t, "key3", res[2].Key)
+		require.Equal(t, []byte{1, 2, 3}, res[2].Value.Value)
+		require.Empty(t, res[2].Value.TypeUrl)
+	})
 }
 
 func Test_newUserResponse(t *testing.T) {
-	user := storage.User{
+	user := storageModel.User{
 		ID:     "1",
 		Status: "ACCOUNT_STATUS_ACTIVE",
-		Meta: map[string][]byte{
-			"key1": []byte("value1"),
-			"key2": []byte("value2"),
+		Meta: map[string]interface{}{
+			"key1": "value1",
+		},
+		MetaTypes: map[string]string{
+			"key1": "type.googleapis.com/google.protobuf.StringValue",
 		},
 	}
 	var resp proto.UserResponse
 	err := newUserResponse(&resp, &user)
 	require.NoError(t, err)
-	require.NotNil(t, resp)
 	require.Equal(t, "1", resp.Id)
 	require.Equal(t, proto.AccountStatus_ACCOUNT_STATUS_ACTIVE, resp.Status)
-	require.NotNil(t, resp.Meta)
-	require.Equal(t, len(user.Meta), len(resp.Meta))
-	for k, v := range user.Meta {
-		var found bool
-		for i := range resp.Meta {
-			if resp.Meta[i].Key == k {
-				any, err := ptypes.MarshalAny(newAny(v))
-				require.NoError(t, err)
-				require.NotNil(t, any)
-				require.True(t, bytes.Equal(any.Value, resp.Meta[i].Value.GetValue()))
-				found = true
-				break
-			}
-		}
-		require.True(t, found)
-	}
+	require.Len(t, resp.Meta, 1)
+	require.Equal(t, "key1", resp.Meta[0].Key)
+	require.Equal(t, newTestAny(t, "value1").Value, resp.Meta[0].Value.Value)
+}
+
+func Test_mergeMeta(t *testing.T) {
+	values, types := mergeMeta(map[string]interface{}{
+		"key1": "value1",
+		"key2": int64(2),
+		"key3": []byte{3},
+	}, map[string]string{
+		"key1": "type.googleapis.com/google.protobuf.StringValue",
+		"key2": "type.googleapis.com/google.protobuf.Int64Value",
+	}, map[string]interface{}{
+		"key1": nil,
+		"key2": []byte{2},
+		"key4": "value4",
+	}, map[string]string{
+		"key4": "type.googleapis.com/google.protobuf.StringValue",
+	})
+	require.Equal(t, map[string]interface{}{
+		"key2": []byte{2},
+		"key3": []byte{3},
+		"key4": "value4",
+	}, values)
+	require.Equal(t, map[string]string{
+		"key4": "type.googleapis.com/google.protobuf.StringValue",
+	}, types)
 }
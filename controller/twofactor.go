@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/pkg/errors"
+)
+
+// EnrollTwoFactor starts TOTP enrollment, it's enabled once confirmed by ConfirmTwoFactor.
+func (s Service) EnrollTwoFactor(ctx context.Context, req *proto.EnrollTwoFactorRequest, resp *proto.EnrollTwoFactorResponse) error {
+	if err := s.checkTwoFactor(ctx, "EnrollTwoFactor", req.Id); err != nil {
+		return err
+	}
+	enrollment, err := s.twoFactor.Enroll(ctx, req.Id, req.AccountName)
+	if err != nil {
+		return s.twoFactorError("EnrollTwoFactor", req.Id, err)
+	}
+	resp.Secret = enrollment.Secret
+	resp.Uri = enrollment.URI
+	return nil
+}
+
+// ConfirmTwoFactor enables two-factor with the first code and returns recovery codes.
+func (s Service) ConfirmTwoFactor(ctx context.Context, req *proto.TwoFactorCodeRequest, resp *proto.RecoveryCodesResponse) error {
+	if err := s.checkTwoFactor(ctx, "ConfirmTwoFactor", req.Id); err != nil {
+		return err
+	}
+	codes, ok, err := s.twoFactor.Confirm(ctx, req.Id, req.Code)
+	if err != nil {
+		return s.twoFactorError("ConfirmTwoFactor", req.Id, err)
+	}
+	if !ok {
+		return microErrors.BadRequest(errorID, "invalid two-factor code")
+	}
+	s.requestLogger("ConfirmTwoFactor", req.Id).Info("two-factor enabled")
+	resp.RecoveryCodes = codes
+	return nil
+}
+
+// VerifyTwoFactor checks TOTP or recovery code, every code is accepted once.
+func (s Service) VerifyTwoFactor(ctx context.Context, req *proto.TwoFactorCodeRequest, resp *proto.VerifyTwoFactorResponse) error {
+	if err := s.checkTwoFactor(ctx, "VerifyTwoFactor", req.Id); err != nil {
+		return err
+	}
+	valid, err := s.twoFactor.Verify(ctx, req.Id, req.Code)
+	if err != nil {
+		return s.twoFactorError("VerifyTwoFactor", req.Id, err)
+	}
+	resp.Valid = valid
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces user's recovery codes, the old ones can't be used afterwards.
+func (s Service) RegenerateRecoveryCodes(ctx context.Context, req *proto.RegenerateRecoveryCodesRequest, resp *proto.RecoveryCodesResponse) error {
+	if err := s.checkTwoFactor(ctx, "RegenerateRecoveryCodes", req.Id); err != nil {
+		return err
+	}
+	codes, err := s.twoFactor.RegenerateRecoveryCodes(ctx, req.Id)
+	if err != nil {
+		return s.twoFactorError("RegenerateRecoveryCodes", req.Id, err)
+	}
+	s.requestLogger("RegenerateRecoveryCodes", req.Id).Info("recovery codes regenerated")
+	resp.RecoveryCodes = codes
+	return nil
+}
+
+// DisableTwoFactor removes user's two-factor enrollment.
+func (s Service) DisableTwoFactor(ctx context.Context, req *proto.DisableTwoFactorRequest, resp *proto.DisableTwoFactorResponse) error {
+	if err := s.checkTwoFactor(ctx, "DisableTwoFactor", req.Id); err != nil {
+		return err
+	}
+	if err := s.twoFactor.Disable(ctx, req.Id); err != nil {
+		return s.twoFactorError("DisableTwoFactor", req.Id, err)
+	}
+	s.requestLogger("DisableTwoFactor", req.Id).Info("two-factor disabled")
+	return nil
+}
+
+// checkTwoFactor checks that two-factor is configured and the user exists.
+func (s Service) checkTwoFactor(ctx context.Context, operation, userID string) error {
+	if s.twoFactor == nil {
+		return microErrors.New(errorID, "two-factor requires the encryption keyring", http.StatusNotImplemented)
+	}
+	_, err := s.findUser(ctx, operation, userID)
+	return err
+}
+
+// twoFactorError converts two-factor state errors to client errors.
+func (s Service) twoFactorError(operation, userID string, err error) error {
+	switch errors.Cause(err) {
+	case credential.ErrTwoFactorEnabled:
+		return microErrors.Conflict(errorID, "%s", err.Error())
+	case credential.ErrTwoFactorNotEnabled, credential.ErrTwoFactorNotPending:
+		return microErrors.NotFound(errorID, "%s", err.Error())
+	}
+	s.requestLogger(operation, userID).WithError(err).Error("could not handle two-factor")
+	return err
+}
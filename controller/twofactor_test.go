@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/storage/encryption"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeys struct{}
+
+func (testKeys) ActiveKey() (encryption.Key, error) {
+	return encryption.Key{ID: "k1", Secret: make([]byte, 32)}, nil
+}
+
+func (k testKeys) Key(id string) (encryption.Key, error) {
+	return k.ActiveKey()
+}
+
+func TestService_TwoFactor(t *testing.T) {
+	newService := func(t *testing.T, st *storageMocks.User, tst *storageMocks.TwoFactor) Service {
+		twoFactor, err := credential.NewTwoFactorManager(credential.TwoFactorConfig{
+			Storage: tst,
+			Cipher:  encryption.NewCipher(testKeys{}),
+		})
+		require.NoError(t, err)
+		return New(Config{
+			UserStorage: st,
+			TwoFactor:   twoFactor,
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	t.Run("not configured", func(t *testing.T) {
+		s := New(Config{Logger: &commonLog.Logger{Logger: logrus.New()}})
+		err := s.EnrollTwoFactor(context.Background(), &proto.EnrollTwoFactorRequest{Id: "1"}, &proto.EnrollTwoFactorResponse{})
+		require.Equal(t, int32(501), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("already enabled", func(t *testing.T) {
+		st, tst := new(storageMocks.User), new(storageMocks.TwoFactor)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		tst.On("FindTwoFactor", mock.Anything, "1").Return(&storageModel.TwoFactor{UserID: "1", ConfirmedAt: time.Now()}, nil)
+		err := newService(t, st, tst).EnrollTwoFactor(context.Background(), &proto.EnrollTwoFactorRequest{Id: "1"}, &proto.EnrollTwoFactorResponse{})
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("not enrolled", func(t *testing.T) {
+		st, tst := new(storageMocks.User), new(storageMocks.TwoFactor)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		tst.On("FindTwoFactor", mock.Anything, "1").Return(nil, nil)
+		err := newService(t, st, tst).VerifyTwoFactor(context.Background(), &proto.TwoFactorCodeRequest{Id: "1", Code: "123456"}, &proto.VerifyTwoFactorResponse{})
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok (invalid confirmation code)", func(t *testing.T) {
+		st, tst := new(storageMocks.User), new(storageMocks.TwoFactor)
+		defer tst.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{{ID: "1"}}, nil)
+		var stored *storageModel.TwoFactor
+		tst.On("FindTwoFactor", mock.Anything, "1").Return(func(context.Context, string) *storageModel.TwoFactor {
+			return stored
+		}, nil)
+		tst.On("PutTwoFactor", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			twoFactor := args.Get(1).(storageModel.TwoFactor)
+			stored = &twoFactor
+		}).Return(nil)
+		s := newService(t, st, tst)
+		resp := &proto.EnrollTwoFactorResponse{}
+		require.NoError(t, s.EnrollTwoFactor(context.Background(), &proto.EnrollTwoFactorRequest{Id: "1"}, resp))
+		require.NotEmpty(t, resp.Secret)
+		require.NotEmpty(t, resp.Uri)
+
+		err := s.ConfirmTwoFactor(context.Background(), &proto.TwoFactorCodeRequest{Id: "1", Code: "abcdef"}, &proto.RecoveryCodesResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+}
@@ -3,11 +3,42 @@ package controller
 import (
 	"context"
 
+	microErrors "github.com/micro/go-micro/v2/errors"
 	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/storage"
+	"github.com/pkg/errors"
 )
 
 // Update updates existing user data.
+// Request meta is merged into the user's meta, keys without values are removed.
+// Concurrent updates of the same user are rejected with Conflict instead of overwriting each other.
 func (s Service) Update(ctx context.Context, req *proto.UpdateRequest, resp *proto.UserResponse) error {
-	// TODO: implement
-	return nil
+	// status is changed by the dedicated RPCs only.
+	if req.Status != 0 {
+		return microErrors.BadRequest(errorID, "status can't be updated, use Suspend, Reactivate, Ban or ScheduleStatusChange instead")
+	}
+	meta, metaTypes, err := newUserMeta(req.Meta)
+	if err != nil {
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+
+	user, err := s.findUser(ctx, "Update", req.Id)
+	if err != nil {
+		return err
+	}
+
+	user.Meta, user.MetaTypes = mergeMeta(user.Meta, user.MetaTypes, meta, metaTypes)
+	if err := s.validateMeta("Update", req.Id, user.Meta); err != nil {
+		return err
+	}
+
+	updatedUser, err := s.userStorage.Update(ctx, *user)
+	if errors.Is(err, storage.ErrUserChanged) {
+		return microErrors.Conflict(errorID, "user %s was changed meanwhile, retry the update", req.Id)
+	}
+	if err != nil {
+		s.requestLogger("Update", req.Id).WithError(err).Error("could not update user")
+		return err
+	}
+	return newUserResponse(resp, updatedUser)
 }
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/meta"
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Update(t *testing.T) {
+	newService := func(st *storageMocks.User, mode meta.Mode) Service {
+		return New(Config{
+			UserStorage: st,
+			MetaSchema:  newTestMetaSchema(t, mode),
+			Logger:      &commonLog.Logger{Logger: logrus.New()},
+		})
+	}
+	user := storageModel.User{
+		ID:     "1",
+		Status: "ACCOUNT_STATUS_ACTIVE",
+		Meta: map[string]interface{}{
+			"email": "john@example.com",
+		},
+		MetaTypes: map[string]string{
+			"email": "type.googleapis.com/google.protobuf.StringValue",
+		},
+	}
+	t.Run("find user error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errMock)
+		err := newService(st, meta.ModeEnforce).Update(context.Background(), &proto.UpdateRequest{Id: "1"}, &proto.UserResponse{})
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("user not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return([]storageModel.User{}, nil)
+		err := newService(st, meta.ModeEnforce).Update(context.Background(), &proto.UpdateRequest{Id: "1"}, &proto.UserResponse{})
+		require.Error(t, err)
+		require.Equal(t, int32(404), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("status update error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		err := newService(st, meta.ModeEnforce).Update(context.Background(), &proto.UpdateRequest{
+			Id:     "1",
+			Status: proto.AccountStatus_ACCOUNT_STATUS_SUSPENDED,
+		}, &proto.UserResponse{})
+		require.Error(t, err)
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("meta schema violation", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{IDs: []string{"1"}}).Return([]storageModel.User{user}, nil)
+		err := newService(st, meta.ModeEnforce).Update(context.Background(), &proto.UpdateRequest{
+			Id: "1",
+			Meta: []*proto.UserMeta{
+				{Key: "email"},
+			},
+		}, &proto.UserResponse{})
+		require.Error(t, err)
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+		require.Equal(t, "invalid meta: email: is required", microErrors.Parse(err.Error()).Detail)
+	})
+	t.Run("warn-only meta schema violation", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		updatedUser := user
+		updatedUser.Meta = map[string]interface{}{
+			"email": "john@example.com",
+			"age":   "42",
+		}
+		updatedUser.MetaTypes = map[string]string{
+			"email": "type.googleapis.com/google.protobuf.StringValue",
+			"age":   "type.googleapis.com/google.protobuf.StringValue",
+		}
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{IDs: []string{"1"}}).Return([]storageModel.User{user}, nil)
+		st.On("Update", mock.Anything, updatedUser).Return(&updatedUser, nil)
+		var resp proto.UserResponse
+		err := newService(st, meta.ModeWarn).Update(context.Background(), &proto.UpdateRequest{
+			Id: "1",
+			Meta: []*proto.UserMeta{
+				{Key: "age", Value: newTestAny(t, "42")},
+			},
+		}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "1", resp.Id)
+		require.Len(t, resp.Meta, 2)
+	})
+	t.Run("user was changed error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{IDs: []string{"1"}}).Return([]storageModel.User{user}, nil)
+		st.On("Update", mock.Anything, mock.Anything).Return(nil, storage.ErrUserChanged)
+		err := newService(st, meta.ModeEnforce).Update(context.Background(), &proto.UpdateRequest{
+			Id: "1",
+			Meta: []*proto.UserMeta{
+				{Key: "age", Value: newTestAny(t, int64(42))},
+			},
+		}, &proto.UserResponse{})
+		require.Error(t, err)
+		require.Equal(t, int32(409), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		updatedUser := user
+		updatedUser.Meta = map[string]interface{}{
+			"email": "john@example.com",
+			"age":   int64(42),
+		}
+		updatedUser.MetaTypes = map[string]string{
+			"email": "type.googleapis.com/google.protobuf.StringValue",
+			"age":   "type.googleapis.com/google.protobuf.Int64Value",
+		}
+		st.On("Find", mock.Anything, storageModel.UserFindFilter{IDs: []string{"1"}}).Return([]storageModel.User{user}, nil)
+		st.On("Update", mock.Anything, updatedUser).Return(&updatedUser, nil)
+		var resp proto.UserResponse
+		err := newService(st, meta.ModeEnforce).Update(context.Background(), &proto.UpdateRequest{
+			Id: "1",
+			Meta: []*proto.UserMeta{
+				{Key: "age", Value: newTestAny(t, int64(42))},
+			},
+		}, &resp)
+		require.NoError(t, err)
+		require.Equal(t, "1", resp.Id)
+		require.Equal(t, proto.AccountStatus_ACCOUNT_STATUS_ACTIVE, resp.Status)
+		require.Len(t, resp.Meta, 2)
+	})
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	proto "github.com/open-Q/common/golang/proto/user"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// IssueVerification sends a single-use code or token to the user's contact.
+func (s Service) IssueVerification(ctx context.Context, req *proto.IssueVerificationRequest, resp *proto.IssueVerificationResponse) error {
+	if s.verifier == nil {
+		return microErrors.New(errorID, "verification requires a notifier", http.StatusNotImplemented)
+	}
+	user, err := s.findUser(ctx, "IssueVerification", req.Id)
+	if err != nil {
+		return err
+	}
+	kind := req.Kind
+	if kind == "" {
+		kind = storageModel.VerificationCode
+	}
+	challenge, err := s.verifier.Issue(ctx, *user, req.ContactKey, kind)
+	if err != nil {
+		return s.verificationError("IssueVerification", req.Id, err)
+	}
+	s.requestLogger("IssueVerification", req.Id).WithField("contact_key", req.ContactKey).WithField("challenge_id", challenge.ID).Info("verification issued")
+	resp.ChallengeId = challenge.ID
+	resp.ExpiresAt = timestamppb.New(challenge.ExpiresAt)
+	return nil
+}
+
+// ConfirmVerification verifies the user's contact if the code or token matches.
+func (s Service) ConfirmVerification(ctx context.Context, req *proto.ConfirmVerificationRequest, resp *proto.ConfirmVerificationResponse) error {
+	if s.verifier == nil {
+		return microErrors.New(errorID, "verification requires a notifier", http.StatusNotImplemented)
+	}
+	user, err := s.findUser(ctx, "ConfirmVerification", req.Id)
+	if err != nil {
+		return err
+	}
+	verified, err := s.verifier.Confirm(ctx, *user, req.ContactKey, req.Secret)
+	if err != nil {
+		return s.verificationError("ConfirmVerification", req.Id, err)
+	}
+	if verified {
+		s.requestLogger("ConfirmVerification", req.Id).WithField("contact_key", req.ContactKey).Info("contact verified")
+	}
+	resp.Verified = verified
+	return nil
+}
+
+// verificationError converts verification request errors to client errors.
+func (s Service) verificationError(operation, userID string, err error) error {
+	switch errors.Cause(err) {
+	case verification.ErrRateLimited:
+		return microErrors.New(errorID, err.Error(), http.StatusTooManyRequests)
+	case verification.ErrUnknownContact, verification.ErrNoContact, verification.ErrUnknownKind:
+		return microErrors.BadRequest(errorID, "%s", err.Error())
+	}
+	s.requestLogger(operation, userID).WithError(err).Error("could not handle verification")
+	return err
+}
+
+// newContactVerificationsProto converts verified contacts sorted by contact key.
+func newContactVerificationsProto(user *storageModel.User) []*proto.ContactVerification {
+	verified := verification.Verified(*user)
+	if len(verified) == 0 {
+		return nil
+	}
+	res := make([]*proto.ContactVerification, 0, len(verified))
+	for key, at := range verified {
+		res = append(res, &proto.ContactVerification{
+			Key:        key,
+			VerifiedAt: timestamppb.New(at),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	commonLog "github.com/open-Q/common/golang/log"
+	proto "github.com/open-Q/common/golang/proto/user"
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Verification(t *testing.T) {
+	logger := &commonLog.Logger{Logger: logrus.New()}
+	newService := func(t *testing.T, st *storageMocks.User, vst *storageMocks.Verification) Service {
+		verifier, err := verification.NewManager(verification.Config{
+			Storage:     vst,
+			Notifier:    verification.NewLogNotifier(logger),
+			ContactKeys: []string{"email"},
+		})
+		require.NoError(t, err)
+		return New(Config{
+			UserStorage: st,
+			Verifier:    verifier,
+			Logger:      logger,
+		})
+	}
+	filter := storageModel.UserFindFilter{IDs: []string{"1"}}
+	user := storageModel.User{ID: "1", Meta: map[string]interface{}{"email": "john@example.com"}}
+	t.Run("not configured", func(t *testing.T) {
+		err := New(Config{Logger: logger}).IssueVerification(context.Background(), &proto.IssueVerificationRequest{Id: "1"}, &proto.IssueVerificationResponse{})
+		require.Equal(t, int32(501), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("unknown contact", func(t *testing.T) {
+		st, vst := new(storageMocks.User), new(storageMocks.Verification)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil)
+		err := newService(t, st, vst).IssueVerification(context.Background(), &proto.IssueVerificationRequest{Id: "1", ContactKey: "phone"}, &proto.IssueVerificationResponse{})
+		require.Equal(t, int32(400), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("rate limited", func(t *testing.T) {
+		st, vst := new(storageMocks.User), new(storageMocks.Verification)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil)
+		vst.On("AddChallenge", mock.Anything, mock.Anything, verification.DefaultRateLimit, mock.Anything).Return(nil, storage.ErrChallengeLimit)
+		err := newService(t, st, vst).IssueVerification(context.Background(), &proto.IssueVerificationRequest{Id: "1", ContactKey: "email"}, &proto.IssueVerificationResponse{})
+		require.Equal(t, int32(429), microErrors.Parse(err.Error()).Code)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st, vst := new(storageMocks.User), new(storageMocks.Verification)
+		defer vst.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]storageModel.User{user}, nil)
+		vst.On("AddChallenge", mock.Anything, mock.MatchedBy(func(c storageModel.VerificationChallenge) bool {
+			return c.Kind == storageModel.VerificationCode
+		}), verification.DefaultRateLimit, mock.Anything).Return(&storageModel.VerificationChallenge{ID: "c1"}, nil)
+		s := newService(t, st, vst)
+		resp := &proto.IssueVerificationResponse{}
+		require.NoError(t, s.IssueVerification(context.Background(), &proto.IssueVerificationRequest{Id: "1", ContactKey: "email"}, resp))
+		require.Equal(t, "c1", resp.ChallengeId)
+
+		vst.On("FindChallenges", mock.Anything, "1", "email", mock.Anything).Return(nil, nil)
+		confirmResp := &proto.ConfirmVerificationResponse{}
+		require.NoError(t, s.ConfirmVerification(context.Background(), &proto.ConfirmVerificationRequest{Id: "1", ContactKey: "email", Secret: "123456"}, confirmResp))
+		require.False(t, confirmResp.Verified)
+	})
+}
@@ -0,0 +1,230 @@
+package credential
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// DefaultTouchInterval is a default period between last usage writes.
+const DefaultTouchInterval = time.Minute
+
+// There are API key format settings, keys look like "uk_<prefix>_<secret>".
+const (
+	apiKeyType         = "uk"
+	apiKeyPrefixLength = 5
+	apiKeySecretLength = 32
+	// flushTimeout limits the last usage write on shutdown.
+	flushTimeout = 5 * time.Second
+)
+
+// ErrInvalidAPIKey is returned when the API key is unknown, malformed, revoked or expired.
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+var prefixEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// KeyConfig represents API key manager configuration.
+type KeyConfig struct {
+	Storage storage.APIKey
+	// TouchInterval is a period between last usage writes, DefaultTouchInterval is used if empty.
+	// Keys used within the interval are written once.
+	TouchInterval time.Duration
+	Logger        *commonLog.Logger
+}
+
+// KeyManager creates and verifies users' API keys.
+// Only SHA-256 hashes of the keys are kept, keys have enough entropy to not need slow hashes.
+type KeyManager struct {
+	storage       storage.APIKey
+	touchInterval time.Duration
+	logger        *commonLog.Logger
+	now           func() time.Time
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time
+}
+
+// NewKeyManager creates new KeyManager instance.
+func NewKeyManager(cfg KeyConfig) *KeyManager {
+	touchInterval := cfg.TouchInterval
+	if touchInterval <= 0 {
+		touchInterval = DefaultTouchInterval
+	}
+	return &KeyManager{
+		storage:       cfg.Storage,
+		touchInterval: touchInterval,
+		logger:        cfg.Logger,
+		now:           time.Now,
+		lastUsed:      map[string]time.Time{},
+	}
+}
+
+// CreateKey creates user's API key and returns it with the secret.
+// The secret isn't kept, so it can't be returned again.
+func (m *KeyManager) CreateKey(ctx context.Context, key model.APIKey) (*model.APIKey, string, error) {
+	if err := ValidateKey(key, m.now()); err != nil {
+		return nil, "", err
+	}
+	prefix, err := randomString(apiKeyPrefixLength, prefixEncoding)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(apiKeySecretLength, base64.RawURLEncoding)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix = strings.ToLower(prefix)
+	secret = strings.Join([]string{apiKeyType, prefix, secret}, "_")
+
+	key.Prefix = prefix
+	key.Hash = hashToken(secret)
+	created, err := m.storage.AddAPIKey(ctx, key)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "could not add API key")
+	}
+	return created, secret, nil
+}
+
+// ValidateKey validates API key definition.
+func ValidateKey(key model.APIKey, now time.Time) error {
+	if strings.TrimSpace(key.Name) == "" {
+		return errors.New("API key name is required")
+	}
+	if !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(now) {
+		return errors.New("API key expiry must be in the future")
+	}
+	return nil
+}
+
+// ListKeys returns user's API keys, revoked ones included.
+func (m *KeyManager) ListKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	keys, err := m.storage.FindAPIKeys(ctx, model.APIKeyFindFilter{
+		UserIDs: []string{userID},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find API keys")
+	}
+	return keys, nil
+}
+
+// RevokeKey revokes user's API key, it can't be used afterwards.
+func (m *KeyManager) RevokeKey(ctx context.Context, userID, keyID string) (*model.APIKey, error) {
+	key, err := m.storage.RevokeAPIKey(ctx, userID, keyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not revoke API key")
+	}
+	return key, nil
+}
+
+// VerifyKey returns the API key matching the secret, ErrInvalidAPIKey is returned
+// if there is no usable one. Usage is recorded by the next flush.
+func (m *KeyManager) VerifyKey(ctx context.Context, secret string) (*model.APIKey, error) {
+	parts := strings.SplitN(secret, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyType {
+		return nil, ErrInvalidAPIKey
+	}
+	keys, err := m.storage.FindAPIKeys(ctx, model.APIKeyFindFilter{
+		Prefixes: []string{parts[1]},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find API key")
+	}
+	if len(keys) == 0 {
+		return nil, ErrInvalidAPIKey
+	}
+	key := keys[0]
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	now := m.now()
+	if !key.RevokedAt.IsZero() || (!key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+	m.touch(key, now)
+	return &key, nil
+}
+
+// Start writes recorded usage on every touch interval until the context is canceled,
+// the usage recorded meanwhile is written before it returns.
+func (m *KeyManager) Start(ctx context.Context) {
+	ticker := time.NewTicker(m.touchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+			m.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			m.flush(ctx)
+		}
+	}
+}
+
+// HasScope checks if the API key grants the scope, keys without scopes grant nothing.
+func HasScope(key model.APIKey, scope string) bool {
+	for i := range key.Scopes {
+		if key.Scopes[i] == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// touch records key usage unless the stored one is recent enough.
+func (m *KeyManager) touch(key model.APIKey, at time.Time) {
+	if at.Sub(key.LastUsedAt) < m.touchInterval {
+		return
+	}
+	m.mu.Lock()
+	m.lastUsed[key.ID] = at
+	m.mu.Unlock()
+}
+
+// flush writes recorded usage, failed writes are retried by the next flush.
+func (m *KeyManager) flush(ctx context.Context) {
+	m.mu.Lock()
+	lastUsed := m.lastUsed
+	m.lastUsed = map[string]time.Time{}
+	m.mu.Unlock()
+	if len(lastUsed) == 0 {
+		return
+	}
+	if err := m.storage.TouchAPIKeys(ctx, lastUsed); err != nil {
+		m.logger.WithError(err).WithField("keys", len(lastUsed)).Warn("could not record API key usage")
+		m.mu.Lock()
+		for id, at := range lastUsed {
+			if at.After(m.lastUsed[id]) {
+				m.lastUsed[id] = at
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// hashToken hashes high-entropy secrets, e.g. API keys and recovery codes.
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int, encoding interface{ EncodeToString([]byte) string }) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "could not read random bytes")
+	}
+	return encoding.EncodeToString(b), nil
+}
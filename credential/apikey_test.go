@@ -0,0 +1,119 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyManager(st *storageMocks.APIKey) *KeyManager {
+	return NewKeyManager(KeyConfig{
+		Storage: st,
+		Logger:  &commonLog.Logger{Logger: logrus.New()},
+	})
+}
+
+func TestKeyManager_CreateKey(t *testing.T) {
+	t.Run("name is required", func(t *testing.T) {
+		_, _, err := newTestKeyManager(new(storageMocks.APIKey)).CreateKey(context.Background(), model.APIKey{UserID: "1"})
+		require.EqualError(t, err, "API key name is required")
+	})
+	t.Run("expired", func(t *testing.T) {
+		_, _, err := newTestKeyManager(new(storageMocks.APIKey)).CreateKey(context.Background(), model.APIKey{
+			UserID:    "1",
+			Name:      "ci",
+			ExpiresAt: time.Now().Add(-time.Hour),
+		})
+		require.EqualError(t, err, "API key expiry must be in the future")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.APIKey)
+		defer st.AssertExpectations(t)
+		st.On("AddAPIKey", mock.Anything, mock.Anything).Return(func(_ context.Context, key model.APIKey) *model.APIKey {
+			key.ID = "k1"
+			return &key
+		}, nil)
+		key, secret, err := newTestKeyManager(st).CreateKey(context.Background(), model.APIKey{UserID: "1", Name: "ci"})
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(secret, "uk_"+key.Prefix+"_"))
+		require.Len(t, key.Prefix, 8)
+		require.Equal(t, hashToken(secret), key.Hash)
+		require.NotContains(t, key.Hash, secret)
+	})
+}
+
+func TestKeyManager_VerifyKey(t *testing.T) {
+	secret := "uk_abcdefgh_secret"
+	key := model.APIKey{ID: "k1", UserID: "1", Prefix: "abcdefgh", Hash: hashToken(secret)}
+	filter := model.APIKeyFindFilter{Prefixes: []string{"abcdefgh"}}
+	t.Run("malformed", func(t *testing.T) {
+		_, err := newTestKeyManager(new(storageMocks.APIKey)).VerifyKey(context.Background(), "secret")
+		require.Equal(t, ErrInvalidAPIKey, err)
+	})
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.APIKey)
+		st.On("FindAPIKeys", mock.Anything, filter).Return(nil, errors.New("find error"))
+		_, err := newTestKeyManager(st).VerifyKey(context.Background(), secret)
+		require.EqualError(t, err, "could not find API key: find error")
+	})
+	for name, k := range map[string]model.APIKey{
+		"wrong secret": {ID: "k1", Prefix: "abcdefgh", Hash: hashToken("uk_abcdefgh_other")},
+		"revoked":      {ID: "k1", Prefix: "abcdefgh", Hash: key.Hash, RevokedAt: time.Now()},
+		"expired":      {ID: "k1", Prefix: "abcdefgh", Hash: key.Hash, ExpiresAt: time.Now().Add(-time.Second)},
+	} {
+		k := k
+		t.Run(name, func(t *testing.T) {
+			st := new(storageMocks.APIKey)
+			st.On("FindAPIKeys", mock.Anything, filter).Return([]model.APIKey{k}, nil)
+			_, err := newTestKeyManager(st).VerifyKey(context.Background(), secret)
+			require.Equal(t, ErrInvalidAPIKey, err)
+		})
+	}
+	t.Run("all ok (usage coalesced)", func(t *testing.T) {
+		st := new(storageMocks.APIKey)
+		defer st.AssertExpectations(t)
+		st.On("FindAPIKeys", mock.Anything, filter).Return([]model.APIKey{key}, nil)
+		m := newTestKeyManager(st)
+		usedAt := time.Now()
+		m.now = func() time.Time {
+			return usedAt
+		}
+		for i := 0; i < 3; i++ {
+			verified, err := m.VerifyKey(context.Background(), secret)
+			require.NoError(t, err)
+			require.Equal(t, "1", verified.UserID)
+		}
+
+		st.On("TouchAPIKeys", mock.Anything, map[string]time.Time{"k1": usedAt}).Return(errors.New("touch error")).Once()
+		m.flush(context.Background())
+		st.On("TouchAPIKeys", mock.Anything, map[string]time.Time{"k1": usedAt}).Return(nil).Once()
+		m.flush(context.Background())
+		m.flush(context.Background())
+	})
+	t.Run("all ok (recently used)", func(t *testing.T) {
+		st := new(storageMocks.APIKey)
+		recent := key
+		recent.LastUsedAt = time.Now()
+		st.On("FindAPIKeys", mock.Anything, filter).Return([]model.APIKey{recent}, nil)
+		m := newTestKeyManager(st)
+		_, err := m.VerifyKey(context.Background(), secret)
+		require.NoError(t, err)
+		m.flush(context.Background())
+		st.AssertNotCalled(t, "TouchAPIKeys", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHasScope(t *testing.T) {
+	key := model.APIKey{Scopes: []string{"orders:read"}}
+	require.True(t, HasScope(key, "orders:read"))
+	require.False(t, HasScope(key, "orders:write"))
+}
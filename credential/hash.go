@@ -0,0 +1,164 @@
+package credential
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// There are supported password hashing algorithms.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// bcryptMaxLength is the longest password bcrypt hashes without truncation.
+const bcryptMaxLength = 72
+
+// Params represents password hashing parameters.
+type Params struct {
+	Algorithm string
+	// Argon2Memory is argon2id memory cost in KiB.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+	Argon2SaltLength  uint32
+	Argon2KeyLength   uint32
+	BcryptCost        int
+}
+
+// DefaultParams returns argon2id parameters recommended by OWASP.
+func DefaultParams() Params {
+	return Params{
+		Algorithm:         Argon2id,
+		Argon2Memory:      19 * 1024,
+		Argon2Iterations:  2,
+		Argon2Parallelism: 1,
+		Argon2SaltLength:  16,
+		Argon2KeyLength:   32,
+		BcryptCost:        12,
+	}
+}
+
+// Validate validates hashing parameters.
+func (p Params) Validate() error {
+	switch p.Algorithm {
+	case Argon2id:
+		if p.Argon2Memory < 8*uint32(p.Argon2Parallelism) || p.Argon2Iterations < 1 || p.Argon2Parallelism < 1 {
+			return errors.New("invalid argon2id cost parameters")
+		}
+		if p.Argon2SaltLength < 8 || p.Argon2KeyLength < 16 {
+			return errors.New("argon2id salt must be at least 8 bytes and key at least 16 bytes long")
+		}
+	case Bcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return errors.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return errors.Errorf("unknown password hashing algorithm %q", p.Algorithm)
+	}
+	return nil
+}
+
+// Hash hashes the password, the result is encoded with the algorithm and its parameters.
+// Argon2id hashes use the PHC string format, bcrypt ones use the modular crypt format.
+func Hash(password string, p Params) (string, error) {
+	switch p.Algorithm {
+	case Argon2id:
+		salt := make([]byte, p.Argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", errors.Wrap(err, "could not generate salt")
+		}
+		key := argon2.IDKey([]byte(password), salt, p.Argon2Iterations, p.Argon2Memory, p.Argon2Parallelism, p.Argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, p.Argon2Memory, p.Argon2Iterations, p.Argon2Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case Bcrypt:
+		if len(password) > bcryptMaxLength {
+			return "", errors.Errorf("bcrypt password must not be longer than %d bytes", bcryptMaxLength)
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		if err != nil {
+			return "", errors.Wrap(err, "could not hash password")
+		}
+		return string(hash), nil
+	default:
+		return "", errors.Errorf("unknown password hashing algorithm %q", p.Algorithm)
+	}
+}
+
+// Verify checks the password against the encoded hash in constant time.
+func Verify(password, encoded string) (bool, error) {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		h, err := parseArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		key := argon2.IDKey([]byte(password), h.salt, h.params.Argon2Iterations, h.params.Argon2Memory, h.params.Argon2Parallelism, uint32(len(h.key)))
+		return subtle.ConstantTimeCompare(key, h.key) == 1, nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "could not verify password")
+	}
+	return true, nil
+}
+
+// NeedsRehash checks if the hash was made by another algorithm or with weaker parameters.
+func NeedsRehash(encoded string, p Params) bool {
+	switch p.Algorithm {
+	case Argon2id:
+		h, err := parseArgon2id(encoded)
+		if err != nil {
+			return true
+		}
+		return h.params.Argon2Memory < p.Argon2Memory ||
+			h.params.Argon2Iterations < p.Argon2Iterations ||
+			h.params.Argon2Parallelism < p.Argon2Parallelism ||
+			uint32(len(h.salt)) < p.Argon2SaltLength ||
+			uint32(len(h.key)) < p.Argon2KeyLength
+	case Bcrypt:
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost < p.BcryptCost
+	}
+	return false
+}
+
+type argon2idHash struct {
+	params Params
+	salt   []byte
+	key    []byte
+}
+
+// parseArgon2id parses argon2id hash in the PHC string format.
+func parseArgon2id(encoded string) (*argon2idHash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return nil, errors.New("invalid argon2id hash format")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2id version")
+	}
+	h := argon2idHash{params: Params{Algorithm: Argon2id}}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.params.Argon2Memory, &h.params.Argon2Iterations, &h.params.Argon2Parallelism); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id parameters")
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id salt")
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, errors.Wrap(err, "invalid argon2id key")
+	}
+	return &h, nil
+}
@@ -0,0 +1,84 @@
+package credential
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testParams are cheap argon2id parameters for tests.
+func testParams() Params {
+	p := DefaultParams()
+	p.Argon2Memory = 64
+	p.Argon2Iterations = 1
+	p.BcryptCost = 4
+	return p
+}
+
+func TestParams_Validate(t *testing.T) {
+	require.NoError(t, DefaultParams().Validate())
+	require.EqualError(t, Params{Algorithm: "md5"}.Validate(), `unknown password hashing algorithm "md5"`)
+	require.EqualError(t, Params{Algorithm: Bcrypt, BcryptCost: 2}.Validate(), "bcrypt cost must be between 4 and 31")
+	p := DefaultParams()
+	p.Argon2Iterations = 0
+	require.EqualError(t, p.Validate(), "invalid argon2id cost parameters")
+}
+
+func TestHash(t *testing.T) {
+	t.Run("argon2id", func(t *testing.T) {
+		hash, err := Hash("secret", testParams())
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+		other, err := Hash("secret", testParams())
+		require.NoError(t, err)
+		require.NotEqual(t, hash, other)
+
+		valid, err := Verify("secret", hash)
+		require.NoError(t, err)
+		require.True(t, valid)
+		valid, err = Verify("wrong", hash)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+	t.Run("bcrypt", func(t *testing.T) {
+		p := testParams()
+		p.Algorithm = Bcrypt
+		hash, err := Hash("secret", p)
+		require.NoError(t, err)
+		valid, err := Verify("secret", hash)
+		require.NoError(t, err)
+		require.True(t, valid)
+		valid, err = Verify("wrong", hash)
+		require.NoError(t, err)
+		require.False(t, valid)
+
+		_, err = Hash(strings.Repeat("a", 73), p)
+		require.EqualError(t, err, "bcrypt password must not be longer than 72 bytes")
+	})
+	t.Run("invalid hash", func(t *testing.T) {
+		_, err := Verify("secret", "$argon2id$v=19$m=x$salt$key")
+		require.Error(t, err)
+		_, err = Verify("secret", "plain")
+		require.Error(t, err)
+	})
+}
+
+func TestNeedsRehash(t *testing.T) {
+	p := testParams()
+	hash, err := Hash("secret", p)
+	require.NoError(t, err)
+	require.False(t, NeedsRehash(hash, p))
+
+	stronger := p
+	stronger.Argon2Iterations = 2
+	require.True(t, NeedsRehash(hash, stronger))
+
+	bcryptParams := p
+	bcryptParams.Algorithm = Bcrypt
+	require.True(t, NeedsRehash(hash, bcryptParams))
+	bcryptHash, err := Hash("secret", bcryptParams)
+	require.NoError(t, err)
+	require.False(t, NeedsRehash(bcryptHash, bcryptParams))
+	require.True(t, NeedsRehash(bcryptHash, p))
+}
@@ -0,0 +1,153 @@
+package credential
+
+import (
+	"context"
+	"unicode/utf8"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are default password policy settings.
+const (
+	DefaultMinLength = 8
+	DefaultMaxLength = 1024
+)
+
+// ErrInvalidPassword is returned when the password doesn't satisfy the policy.
+var ErrInvalidPassword = errors.New("invalid password")
+
+// Config represents password manager configuration.
+type Config struct {
+	Storage storage.Credential
+	// Params are used for new hashes, DefaultParams are used if empty.
+	// Hashes made with other algorithm or weaker parameters are upgraded on verification.
+	Params Params
+	// MinLength is a minimal password length in characters, DefaultMinLength is used if empty.
+	MinLength int
+	Logger    *commonLog.Logger
+}
+
+// Manager sets and verifies users' passwords.
+type Manager struct {
+	storage   storage.Credential
+	params    Params
+	minLength int
+	logger    *commonLog.Logger
+	// dummyHash is verified when the user has no password,
+	// so the response time doesn't reveal it.
+	dummyHash string
+}
+
+// NewManager creates new Manager instance.
+func NewManager(cfg Config) (*Manager, error) {
+	params := cfg.Params
+	if params.Algorithm == "" {
+		params = DefaultParams()
+	}
+	if err := params.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid hashing parameters")
+	}
+	minLength := cfg.MinLength
+	if minLength <= 0 {
+		minLength = DefaultMinLength
+	}
+	dummyHash, err := Hash("", params)
+	if err != nil {
+		return nil, err
+	}
+	return &Manager{
+		storage:   cfg.Storage,
+		params:    params,
+		minLength: minLength,
+		logger:    cfg.Logger,
+		dummyHash: dummyHash,
+	}, nil
+}
+
+// SetPassword validates the password and replaces user's password hash.
+func (m *Manager) SetPassword(ctx context.Context, userID, password string) error {
+	if err := m.ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := Hash(password, m.params)
+	if err != nil {
+		return errors.Wrap(ErrInvalidPassword, err.Error())
+	}
+	if err := m.storage.PutPassword(ctx, model.PasswordCredential{
+		UserID: userID,
+		Hash:   hash,
+	}); err != nil {
+		return errors.Wrap(err, "could not put password")
+	}
+	return nil
+}
+
+// VerifyPassword checks the password in constant time, users without password never match.
+// The hash is transparently upgraded if it's outdated, failed upgrade doesn't fail verification.
+func (m *Manager) VerifyPassword(ctx context.Context, userID, password string) (bool, error) {
+	credential, err := m.storage.FindPassword(ctx, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not find password")
+	}
+	if credential == nil {
+		_, _ = Verify(password, m.dummyHash)
+		return false, nil
+	}
+	valid, err := Verify(password, credential.Hash)
+	if err != nil {
+		return false, err
+	}
+	if valid && NeedsRehash(credential.Hash, m.params) {
+		m.rehash(ctx, *credential, password)
+	}
+	return valid, nil
+}
+
+// DeletePassword removes user's password hash.
+func (m *Manager) DeletePassword(ctx context.Context, userID string) error {
+	if err := m.storage.DeletePassword(ctx, userID); err != nil {
+		return errors.Wrap(err, "could not delete password")
+	}
+	return nil
+}
+
+// HasPassword checks if the user has a password.
+func (m *Manager) HasPassword(ctx context.Context, userID string) (bool, error) {
+	credential, err := m.storage.FindPassword(ctx, userID)
+	if err != nil {
+		return false, errors.Wrap(err, "could not find password")
+	}
+	return credential != nil, nil
+}
+
+// rehash replaces the outdated hash unless the password was changed meanwhile.
+func (m *Manager) rehash(ctx context.Context, credential model.PasswordCredential, password string) {
+	logger := m.logger.WithField("user_id", credential.UserID)
+	hash, err := Hash(password, m.params)
+	if err != nil {
+		logger.WithError(err).Warn("could not rehash password")
+		return
+	}
+	previousHash := credential.Hash
+	credential.Hash = hash
+	if err := m.storage.ReplacePassword(ctx, credential, previousHash); err != nil {
+		logger.WithError(err).Warn("could not replace outdated password hash")
+		return
+	}
+	logger.WithField("algorithm", m.params.Algorithm).Info("password rehashed")
+}
+
+// ValidatePassword checks if the password satisfies the policy.
+func (m *Manager) ValidatePassword(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < m.minLength {
+		return errors.Wrapf(ErrInvalidPassword, "password must be at least %d characters long", m.minLength)
+	}
+	if length > DefaultMaxLength {
+		return errors.Wrapf(ErrInvalidPassword, "password must not be longer than %d characters", DefaultMaxLength)
+	}
+	return nil
+}
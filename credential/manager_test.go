@@ -0,0 +1,99 @@
+package credential
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	pkgErrors "github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T, st *storageMocks.Credential, p Params) *Manager {
+	m, err := NewManager(Config{
+		Storage: st,
+		Params:  p,
+		Logger:  &commonLog.Logger{Logger: logrus.New()},
+	})
+	require.NoError(t, err)
+	return m
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(Config{Params: Params{Algorithm: "md5"}})
+	require.EqualError(t, err, `invalid hashing parameters: unknown password hashing algorithm "md5"`)
+}
+
+func TestManager_SetPassword(t *testing.T) {
+	t.Run("too short", func(t *testing.T) {
+		st := new(storageMocks.Credential)
+		err := newTestManager(t, st, testParams()).SetPassword(context.Background(), "1", "short")
+		require.EqualError(t, err, "password must be at least 8 characters long: invalid password")
+		require.Equal(t, ErrInvalidPassword, pkgErrors.Cause(err))
+	})
+	t.Run("storage error", func(t *testing.T) {
+		st := new(storageMocks.Credential)
+		st.On("PutPassword", mock.Anything, mock.Anything).Return(errors.New("put error"))
+		err := newTestManager(t, st, testParams()).SetPassword(context.Background(), "1", "password")
+		require.EqualError(t, err, "could not put password: put error")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Credential)
+		defer st.AssertExpectations(t)
+		st.On("PutPassword", mock.Anything, mock.MatchedBy(func(c model.PasswordCredential) bool {
+			valid, err := Verify("password", c.Hash)
+			return c.UserID == "1" && valid && err == nil
+		})).Return(nil)
+		require.NoError(t, newTestManager(t, st, testParams()).SetPassword(context.Background(), "1", "password"))
+	})
+}
+
+func TestManager_VerifyPassword(t *testing.T) {
+	p := testParams()
+	hash, err := Hash("password", p)
+	require.NoError(t, err)
+	credential := &model.PasswordCredential{UserID: "1", Hash: hash}
+	t.Run("no password", func(t *testing.T) {
+		st := new(storageMocks.Credential)
+		st.On("FindPassword", mock.Anything, "1").Return(nil, nil)
+		valid, err := newTestManager(t, st, p).VerifyPassword(context.Background(), "1", "password")
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.Credential)
+		st.On("FindPassword", mock.Anything, "1").Return(nil, errors.New("find error"))
+		_, err := newTestManager(t, st, p).VerifyPassword(context.Background(), "1", "password")
+		require.EqualError(t, err, "could not find password: find error")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Credential)
+		defer st.AssertExpectations(t)
+		st.On("FindPassword", mock.Anything, "1").Return(credential, nil)
+		m := newTestManager(t, st, p)
+		valid, err := m.VerifyPassword(context.Background(), "1", "password")
+		require.NoError(t, err)
+		require.True(t, valid)
+		valid, err = m.VerifyPassword(context.Background(), "1", "wrong")
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+	t.Run("all ok (rehashed)", func(t *testing.T) {
+		upgraded := p
+		upgraded.Argon2Iterations = 2
+		st := new(storageMocks.Credential)
+		defer st.AssertExpectations(t)
+		st.On("FindPassword", mock.Anything, "1").Return(credential, nil)
+		st.On("ReplacePassword", mock.Anything, mock.MatchedBy(func(c model.PasswordCredential) bool {
+			return !NeedsRehash(c.Hash, upgraded)
+		}), hash).Return(errors.New("password was changed"))
+		valid, err := newTestManager(t, st, upgraded).VerifyPassword(context.Background(), "1", "password")
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+}
@@ -0,0 +1,138 @@
+package credential
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/pkg/errors"
+)
+
+// There are default token settings.
+const (
+	DefaultTokenContactKey = "email"
+	DefaultResetTokenTTL   = 30 * time.Minute
+	DefaultMagicLinkTTL    = 15 * time.Minute
+)
+
+// tokenSecretLength is a number of random bytes in a token.
+const tokenSecretLength = 32
+
+// There are token errors.
+var (
+	ErrInvalidToken   = errors.New("invalid or expired token")
+	ErrUnknownPurpose = errors.New("unknown token purpose")
+	ErrNoContact      = errors.New("user has no contact to send the token to")
+)
+
+// TokenConfig represents token manager configuration.
+type TokenConfig struct {
+	Storage  storage.Token
+	Notifier verification.Notifier
+	// ContactKey is the meta key of the contact tokens are sent to, DefaultTokenContactKey is used if empty.
+	ContactKey string
+	// ResetTTL and MagicLinkTTL limit token lifetime,
+	// DefaultResetTokenTTL and DefaultMagicLinkTTL are used if empty.
+	ResetTTL     time.Duration
+	MagicLinkTTL time.Duration
+}
+
+// TokenManager issues and redeems password reset and magic link tokens.
+// Only SHA-256 hashes of the tokens are kept, tokens have enough entropy to not need slow hashes.
+type TokenManager struct {
+	storage    storage.Token
+	notifier   verification.Notifier
+	contactKey string
+	ttls       map[string]time.Duration
+	now        func() time.Time
+}
+
+// NewTokenManager creates new TokenManager instance.
+func NewTokenManager(cfg TokenConfig) *TokenManager {
+	contactKey := cfg.ContactKey
+	if contactKey == "" {
+		contactKey = DefaultTokenContactKey
+	}
+	resetTTL := cfg.ResetTTL
+	if resetTTL <= 0 {
+		resetTTL = DefaultResetTokenTTL
+	}
+	magicLinkTTL := cfg.MagicLinkTTL
+	if magicLinkTTL <= 0 {
+		magicLinkTTL = DefaultMagicLinkTTL
+	}
+	return &TokenManager{
+		storage:    cfg.Storage,
+		notifier:   cfg.Notifier,
+		contactKey: contactKey,
+		ttls: map[string]time.Duration{
+			model.TokenPasswordReset: resetTTL,
+			model.TokenMagicLink:     magicLinkTTL,
+		},
+		now: time.Now,
+	}
+}
+
+// Issue sends new token of the purpose to the user's contact and returns it.
+// The token replaces the user's previous token of the purpose.
+func (m *TokenManager) Issue(ctx context.Context, user model.User, purpose string) (*model.Token, error) {
+	ttl, ok := m.ttls[purpose]
+	if !ok {
+		return nil, ErrUnknownPurpose
+	}
+	contact, _ := user.Meta[m.contactKey].(string)
+	if contact == "" {
+		return nil, ErrNoContact
+	}
+	secret, err := randomString(tokenSecretLength, base64.RawURLEncoding)
+	if err != nil {
+		return nil, err
+	}
+	token, err := m.storage.PutToken(ctx, model.Token{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Hash:      hashToken(secret),
+		ExpiresAt: m.now().Add(ttl),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not put token")
+	}
+	if err := m.notifier.Notify(ctx, verification.Message{
+		UserID:     user.ID,
+		ContactKey: m.contactKey,
+		Contact:    contact,
+		Kind:       purpose,
+		Secret:     secret,
+		ExpiresAt:  token.ExpiresAt,
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not send token")
+	}
+	return token, nil
+}
+
+// Redeem uses the token of the purpose and returns it, ErrInvalidToken is returned
+// if the token is unknown, expired, already used or replaced by a newer one.
+func (m *TokenManager) Redeem(ctx context.Context, purpose, secret string) (*model.Token, error) {
+	if _, ok := m.ttls[purpose]; !ok {
+		return nil, ErrUnknownPurpose
+	}
+	token, err := m.storage.UseToken(ctx, purpose, hashToken(secret), m.now())
+	if err != nil {
+		return nil, errors.Wrap(err, "could not use token")
+	}
+	if token == nil {
+		return nil, ErrInvalidToken
+	}
+	return token, nil
+}
+
+// Revoke invalidates all tokens of the user, e.g. after its password is changed.
+func (m *TokenManager) Revoke(ctx context.Context, userID string) error {
+	if err := m.storage.DeleteTokens(ctx, userID); err != nil {
+		return errors.Wrap(err, "could not delete tokens")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package credential
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testNotifier struct {
+	messages []verification.Message
+}
+
+func (n *testNotifier) Notify(_ context.Context, message verification.Message) error {
+	n.messages = append(n.messages, message)
+	return nil
+}
+
+func TestTokenManager(t *testing.T) {
+	user := model.User{ID: "1", Meta: map[string]interface{}{"email": "john@example.com"}}
+	t.Run("unknown purpose", func(t *testing.T) {
+		m := NewTokenManager(TokenConfig{Storage: new(storageMocks.Token)})
+		_, err := m.Issue(context.Background(), user, "unknown")
+		require.Equal(t, ErrUnknownPurpose, err)
+		_, err = m.Redeem(context.Background(), "unknown", "secret")
+		require.Equal(t, ErrUnknownPurpose, err)
+	})
+	t.Run("no contact", func(t *testing.T) {
+		m := NewTokenManager(TokenConfig{Storage: new(storageMocks.Token), ContactKey: "phone"})
+		_, err := m.Issue(context.Background(), user, model.TokenMagicLink)
+		require.Equal(t, ErrNoContact, err)
+	})
+	t.Run("invalid token", func(t *testing.T) {
+		st := new(storageMocks.Token)
+		st.On("UseToken", mock.Anything, model.TokenMagicLink, hashToken("secret"), mock.Anything).Return(nil, nil)
+		_, err := NewTokenManager(TokenConfig{Storage: st}).Redeem(context.Background(), model.TokenMagicLink, "secret")
+		require.Equal(t, ErrInvalidToken, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Token)
+		defer st.AssertExpectations(t)
+		notifier := &testNotifier{}
+		m := NewTokenManager(TokenConfig{Storage: st, Notifier: notifier})
+		now := time.Now()
+		m.now = func() time.Time {
+			return now
+		}
+		var stored model.Token
+		st.On("PutToken", mock.Anything, mock.Anything).Return(func(_ context.Context, token model.Token) *model.Token {
+			stored = token
+			return &token
+		}, nil)
+		token, err := m.Issue(context.Background(), user, model.TokenPasswordReset)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(DefaultResetTokenTTL), token.ExpiresAt)
+		require.Len(t, notifier.messages, 1)
+		message := notifier.messages[0]
+		require.Equal(t, "john@example.com", message.Contact)
+		require.Equal(t, model.TokenPasswordReset, message.Kind)
+		require.Equal(t, hashToken(message.Secret), stored.Hash)
+		require.NotContains(t, stored.Hash, message.Secret)
+
+		st.On("UseToken", mock.Anything, model.TokenPasswordReset, stored.Hash, now).Return(&stored, nil)
+		redeemed, err := m.Redeem(context.Background(), model.TokenPasswordReset, message.Secret)
+		require.NoError(t, err)
+		require.Equal(t, "1", redeemed.UserID)
+
+		st.On("DeleteTokens", mock.Anything, "1").Return(nil)
+		require.NoError(t, m.Revoke(context.Background(), "1"))
+	})
+}
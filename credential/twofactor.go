@@ -0,0 +1,263 @@
+package credential
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/encryption"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are default two-factor settings.
+const (
+	DefaultIssuer            = "open-Q"
+	DefaultSkew              = 1
+	DefaultRecoveryCodeCount = 10
+)
+
+// recoveryCodeLength is a length of recovery codes in bytes, they are long enough to be hashed with SHA-256.
+const recoveryCodeLength = 10
+
+// There are two-factor errors.
+var (
+	ErrTwoFactorEnabled    = errors.New("two-factor is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor isn't enabled")
+	ErrTwoFactorNotPending = errors.New("two-factor enrollment not found")
+)
+
+// TwoFactorConfig represents two-factor manager configuration.
+type TwoFactorConfig struct {
+	Storage storage.TwoFactor
+	// Cipher encrypts TOTP secrets at rest.
+	Cipher *encryption.Cipher
+	// Issuer is shown by authenticator apps, DefaultIssuer is used if empty.
+	Issuer string
+	// Skew is a number of time steps codes are accepted before and after the current one,
+	// DefaultSkew is used if empty, negative skew accepts the current time step only.
+	Skew int
+	// RecoveryCodeCount is a number of generated recovery codes, DefaultRecoveryCodeCount is used if empty.
+	RecoveryCodeCount int
+}
+
+// TwoFactorManager enrolls and verifies users' TOTP two-factor authentication.
+type TwoFactorManager struct {
+	storage           storage.TwoFactor
+	cipher            *encryption.Cipher
+	issuer            string
+	skew              int
+	recoveryCodeCount int
+	now               func() time.Time
+}
+
+// Enrollment represents started two-factor enrollment.
+type Enrollment struct {
+	// Secret is base32 encoded TOTP secret for manual entry.
+	Secret string
+	// URI is otpauth URI for QR codes.
+	URI string
+}
+
+// NewTwoFactorManager creates new TwoFactorManager instance.
+func NewTwoFactorManager(cfg TwoFactorConfig) (*TwoFactorManager, error) {
+	if cfg.Cipher == nil {
+		return nil, errors.New("two-factor secrets require a cipher")
+	}
+	issuer := cfg.Issuer
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
+	skew := cfg.Skew
+	if skew == 0 {
+		skew = DefaultSkew
+	}
+	if skew < 0 {
+		skew = 0
+	}
+	recoveryCodeCount := cfg.RecoveryCodeCount
+	if recoveryCodeCount <= 0 {
+		recoveryCodeCount = DefaultRecoveryCodeCount
+	}
+	return &TwoFactorManager{
+		storage:           cfg.Storage,
+		cipher:            cfg.Cipher,
+		issuer:            issuer,
+		skew:              skew,
+		recoveryCodeCount: recoveryCodeCount,
+		now:               time.Now,
+	}, nil
+}
+
+// Enroll generates new TOTP secret, two-factor is enabled once the enrollment is confirmed.
+// Unconfirmed enrollment is replaced.
+func (m *TwoFactorManager) Enroll(ctx context.Context, userID, account string) (*Enrollment, error) {
+	twoFactor, err := m.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor != nil && !twoFactor.ConfirmedAt.IsZero() {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret := make([]byte, totpSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errors.Wrap(err, "could not generate TOTP secret")
+	}
+	envelope, err := m.cipher.Encrypt(secret, []byte(userID))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encrypt TOTP secret")
+	}
+	if err := m.storage.PutTwoFactor(ctx, model.TwoFactor{
+		UserID:      userID,
+		Secret:      envelope.Data,
+		SecretKeyID: envelope.KeyID,
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not put two-factor enrollment")
+	}
+
+	if account == "" {
+		account = userID
+	}
+	return &Enrollment{
+		Secret: totpEncoding.EncodeToString(secret),
+		URI:    totpURI(m.issuer, account, secret),
+	}, nil
+}
+
+// Confirm enables two-factor if the code matches the enrolled secret and returns recovery codes.
+// Recovery codes are returned only once.
+func (m *TwoFactorManager) Confirm(ctx context.Context, userID, code string) ([]string, bool, error) {
+	twoFactor, err := m.find(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if twoFactor == nil {
+		return nil, false, ErrTwoFactorNotPending
+	}
+	if !twoFactor.ConfirmedAt.IsZero() {
+		return nil, false, ErrTwoFactorEnabled
+	}
+	counter, ok, err := m.matchTOTP(*twoFactor, code)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	codes, hashes, err := m.newRecoveryCodes()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := m.storage.ConfirmTwoFactor(ctx, userID, counter, hashes); err != nil {
+		return nil, false, errors.Wrap(err, "could not confirm two-factor enrollment")
+	}
+	return codes, true, nil
+}
+
+// Verify checks TOTP or recovery code. Every code is accepted once.
+func (m *TwoFactorManager) Verify(ctx context.Context, userID, code string) (bool, error) {
+	twoFactor, err := m.find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if twoFactor == nil || twoFactor.ConfirmedAt.IsZero() {
+		return false, ErrTwoFactorNotEnabled
+	}
+
+	if len(code) != totpDigits {
+		ok, err := m.storage.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+		if err != nil {
+			return false, errors.Wrap(err, "could not use recovery code")
+		}
+		return ok, nil
+	}
+	counter, ok, err := m.matchTOTP(*twoFactor, code)
+	if err != nil || !ok {
+		return false, err
+	}
+	ok, err = m.storage.UseTOTPCounter(ctx, userID, counter)
+	if err != nil {
+		return false, errors.Wrap(err, "could not use TOTP code")
+	}
+	return ok, nil
+}
+
+// RegenerateRecoveryCodes replaces user's recovery codes with new ones.
+func (m *TwoFactorManager) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	twoFactor, err := m.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil || twoFactor.ConfirmedAt.IsZero() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	codes, hashes, err := m.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.storage.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, errors.Wrap(err, "could not replace recovery codes")
+	}
+	return codes, nil
+}
+
+// Disable removes user's two-factor enrollment.
+func (m *TwoFactorManager) Disable(ctx context.Context, userID string) error {
+	if err := m.storage.DeleteTwoFactor(ctx, userID); err != nil {
+		return errors.Wrap(err, "could not delete two-factor enrollment")
+	}
+	return nil
+}
+
+func (m *TwoFactorManager) find(ctx context.Context, userID string) (*model.TwoFactor, error) {
+	twoFactor, err := m.storage.FindTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find two-factor enrollment")
+	}
+	return twoFactor, nil
+}
+
+// matchTOTP returns the time step of the code within the allowed skew.
+// Replays are rejected by the storage when the time step is used.
+func (m *TwoFactorManager) matchTOTP(twoFactor model.TwoFactor, code string) (int64, bool, error) {
+	secret, err := m.cipher.Decrypt(encryption.Envelope{
+		KeyID: twoFactor.SecretKeyID,
+		Data:  twoFactor.Secret,
+	}, []byte(twoFactor.UserID))
+	if err != nil {
+		return 0, false, errors.Wrap(err, "could not decrypt TOTP secret")
+	}
+	current := totpCounter(m.now())
+	for counter := current - int64(m.skew); counter <= current+int64(m.skew); counter++ {
+		if counter <= twoFactor.LastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, counter)), []byte(code)) == 1 {
+			return counter, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// newRecoveryCodes returns recovery codes formatted like "abcd-efgh-ijkl-mnop" and their hashes.
+func (m *TwoFactorManager) newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, m.recoveryCodeCount)
+	hashes := make([]string, m.recoveryCodeCount)
+	for i := range codes {
+		code, err := randomString(recoveryCodeLength, totpEncoding)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "could not generate recovery code")
+		}
+		code = strings.ToLower(code)
+		codes[i] = strings.Join([]string{code[:4], code[4:8], code[8:12], code[12:]}, "-")
+		hashes[i] = hashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes the recovery code ignoring its case and separators.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashToken(code)
+}
@@ -0,0 +1,110 @@
+package credential
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/storage/encryption"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeys struct{}
+
+func (testKeys) ActiveKey() (encryption.Key, error) {
+	return encryption.Key{ID: "k1", Secret: make([]byte, 32)}, nil
+}
+
+func (k testKeys) Key(id string) (encryption.Key, error) {
+	return k.ActiveKey()
+}
+
+func newTestTwoFactorManager(t *testing.T, st *storageMocks.TwoFactor) *TwoFactorManager {
+	m, err := NewTwoFactorManager(TwoFactorConfig{
+		Storage: st,
+		Cipher:  encryption.NewCipher(testKeys{}),
+	})
+	require.NoError(t, err)
+	return m
+}
+
+func TestNewTwoFactorManager(t *testing.T) {
+	_, err := NewTwoFactorManager(TwoFactorConfig{})
+	require.EqualError(t, err, "two-factor secrets require a cipher")
+}
+
+func TestTwoFactorManager(t *testing.T) {
+	t.Run("already enabled", func(t *testing.T) {
+		st := new(storageMocks.TwoFactor)
+		st.On("FindTwoFactor", mock.Anything, "1").Return(&model.TwoFactor{UserID: "1", ConfirmedAt: time.Now()}, nil)
+		_, err := newTestTwoFactorManager(t, st).Enroll(context.Background(), "1", "")
+		require.Equal(t, ErrTwoFactorEnabled, err)
+	})
+	t.Run("not enabled", func(t *testing.T) {
+		st := new(storageMocks.TwoFactor)
+		st.On("FindTwoFactor", mock.Anything, "1").Return(&model.TwoFactor{UserID: "1"}, nil)
+		_, err := newTestTwoFactorManager(t, st).Verify(context.Background(), "1", "123456")
+		require.Equal(t, ErrTwoFactorNotEnabled, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.TwoFactor)
+		defer st.AssertExpectations(t)
+		m := newTestTwoFactorManager(t, st)
+		now := time.Now()
+		m.now = func() time.Time {
+			return now
+		}
+
+		var stored *model.TwoFactor
+		st.On("FindTwoFactor", mock.Anything, "1").Return(func(context.Context, string) *model.TwoFactor {
+			return stored
+		}, nil)
+		st.On("PutTwoFactor", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			twoFactor := args.Get(1).(model.TwoFactor)
+			stored = &twoFactor
+		}).Return(nil)
+		enrollment, err := m.Enroll(context.Background(), "1", "john")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/open-Q:john?"))
+		require.NotContains(t, string(stored.Secret), enrollment.Secret)
+		secret, err := totpEncoding.DecodeString(enrollment.Secret)
+		require.NoError(t, err)
+
+		_, ok, err := m.Confirm(context.Background(), "1", "abcdef")
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		// codes of the previous time step are accepted.
+		counter := totpCounter(now) - 1
+		var hashes []string
+		st.On("ConfirmTwoFactor", mock.Anything, "1", counter, mock.Anything).Run(func(args mock.Arguments) {
+			hashes = args.Get(3).([]string)
+			stored.ConfirmedAt = now
+			stored.LastCounter = counter
+		}).Return(nil)
+		codes, ok, err := m.Confirm(context.Background(), "1", totpCode(secret, counter))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Len(t, codes, DefaultRecoveryCodeCount)
+		require.Equal(t, hashRecoveryCode(codes[0]), hashes[0])
+
+		// the confirmation code can't be replayed.
+		ok, err = m.Verify(context.Background(), "1", totpCode(secret, counter))
+		require.NoError(t, err)
+		require.False(t, ok)
+
+		st.On("UseTOTPCounter", mock.Anything, "1", counter+1).Return(true, nil)
+		ok, err = m.Verify(context.Background(), "1", totpCode(secret, counter+1))
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		st.On("UseRecoveryCode", mock.Anything, "1", hashes[1]).Return(true, nil)
+		ok, err = m.Verify(context.Background(), "1", strings.ToUpper(codes[1]))
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+}
@@ -0,0 +1,119 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// There are archive content types.
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeZip  = "application/zip"
+)
+
+// Archive represents everything the service holds about a single user.
+// Pending verification challenges and single-use tokens aren't exported,
+// they hold hashes only and expire shortly.
+type Archive struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	User        User           `json:"user"`
+	History     []HistoryEntry `json:"history"`
+}
+
+// User represents exported user record.
+type User struct {
+	ID               string                 `json:"id"`
+	Status           string                 `json:"status"`
+	Meta             map[string]interface{} `json:"meta,omitempty"`
+	MetaTypes        map[string]string      `json:"meta_types,omitempty"`
+	GroupIDs         []string               `json:"group_ids,omitempty"`
+	Roles            []Role                 `json:"roles,omitempty"`
+	TwoFactorEnabled bool                   `json:"two_factor_enabled"`
+	// VerifiedContacts holds verification times of the contacts verified with their current values.
+	VerifiedContacts map[string]time.Time `json:"verified_contacts,omitempty"`
+	// ContactVerifications holds all stored verifications, including ones of the changed contacts.
+	ContactVerifications []ContactVerification `json:"contact_verifications,omitempty"`
+	// StatusSchedules holds pending status changes.
+	StatusSchedules []StatusSchedule `json:"status_schedules,omitempty"`
+	MergedInto      string           `json:"merged_into,omitempty"`
+	MergedAt        *time.Time       `json:"merged_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// ContactVerification represents exported contact verification, contact value hashes aren't exported.
+type ContactVerification struct {
+	Contact    string    `json:"contact"`
+	VerifiedAt time.Time `json:"verified_at"`
+	// Current is set if the contact still has the verified value.
+	Current bool `json:"current"`
+}
+
+// StatusSchedule represents exported pending status change.
+type StatusSchedule struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Reason    string    `json:"reason,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	At        time.Time `json:"at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Role represents exported role assignment.
+type Role struct {
+	Role       string    `json:"role"`
+	Resource   string    `json:"resource,omitempty"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// HistoryEntry represents a single history or audit entry related to the user.
+type HistoryEntry struct {
+	At      time.Time              `json:"at"`
+	Source  string                 `json:"source"`
+	Type    string                 `json:"type"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// HistorySource represents source of user history entries.
+type HistorySource interface {
+	// Name returns the name of the source used in the exported entries.
+	Name() string
+	History(ctx context.Context, userID string) ([]HistoryEntry, error)
+}
+
+// Write writes the archive as JSON, optionally wrapped into a zip file.
+// Returns the content type of the written data.
+func Write(w io.Writer, archive *Archive, zipped bool) (string, error) {
+	if !zipped {
+		return ContentTypeJSON, writeJSON(w, archive)
+	}
+
+	zw := zip.NewWriter(w)
+	f, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     fmt.Sprintf("user_%s.json", archive.User.ID),
+		Method:   zip.Deflate,
+		Modified: archive.GeneratedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := writeJSON(f, archive); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	return ContentTypeZip, nil
+}
+
+func writeJSON(w io.Writer, archive *Archive) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(archive)
+}
@@ -0,0 +1,166 @@
+package export
+
+import (
+	"context"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUserNotFound is returned when there is no user to export.
+var ErrUserNotFound = errors.New("user not found")
+
+// Exporter represents user data exporter.
+type Exporter struct {
+	userStorage storage.User
+	sources     []HistorySource
+	now         func() time.Time
+}
+
+// New creates new Exporter instance.
+func New(userStorage storage.User, sources ...HistorySource) *Exporter {
+	return &Exporter{
+		userStorage: userStorage,
+		sources:     sources,
+		now:         time.Now,
+	}
+}
+
+// Export collects all user data into the archive.
+func (e *Exporter) Export(ctx context.Context, userID string) (*Archive, error) {
+	users, err := e.userStorage.Find(ctx, model.UserFindFilter{
+		IDs: []string{userID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, ErrUserNotFound
+	}
+
+	archive := Archive{
+		GeneratedAt: e.now().UTC(),
+		User:        NewUser(users[0]),
+		History:     make([]HistoryEntry, 0),
+	}
+
+	for i := range e.sources {
+		entries, err := e.sources[i].History(ctx, userID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get %s history", e.sources[i].Name())
+		}
+		for j := range entries {
+			entries[j].Source = e.sources[i].Name()
+		}
+		archive.History = append(archive.History, entries...)
+	}
+	sort.SliceStable(archive.History, func(i, j int) bool {
+		return archive.History[i].At.Before(archive.History[j].At)
+	})
+
+	return &archive, nil
+}
+
+// NewUser converts storage user into exported user with decoded meta.
+func NewUser(u model.User) User {
+	user := User{
+		ID:               u.ID,
+		Status:           u.Status,
+		MetaTypes:        u.MetaTypes,
+		GroupIDs:         u.GroupIDs,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		MergedInto:       u.MergedInto,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+	}
+	for i := range u.StatusSchedules {
+		schedule := u.StatusSchedules[i]
+		user.StatusSchedules = append(user.StatusSchedules, StatusSchedule{
+			ID:        schedule.ID,
+			From:      schedule.From,
+			To:        schedule.To,
+			Reason:    schedule.Reason,
+			Actor:     schedule.Actor,
+			At:        schedule.At,
+			CreatedAt: schedule.CreatedAt,
+		})
+	}
+	for i := range u.Roles {
+		user.Roles = append(user.Roles, Role{
+			Role:       u.Roles[i].Role,
+			Resource:   u.Roles[i].Resource,
+			AssignedAt: u.Roles[i].AssignedAt,
+		})
+	}
+	if !u.MergedAt.IsZero() {
+		mergedAt := u.MergedAt
+		user.MergedAt = &mergedAt
+	}
+	if len(u.Meta) != 0 {
+		user.Meta = make(map[string]interface{}, len(u.Meta))
+		for k, v := range u.Meta {
+			user.Meta[k] = decodeValue(v)
+		}
+	}
+	// contacts are matched against decoded meta, binary stored contacts are verified as well.
+	u.Meta = user.Meta
+	verified := verification.Verified(u)
+	if len(verified) != 0 {
+		user.VerifiedContacts = verified
+	}
+	for contact, v := range u.VerifiedContacts {
+		_, current := verified[contact]
+		user.ContactVerifications = append(user.ContactVerifications, ContactVerification{
+			Contact:    contact,
+			VerifiedAt: v.VerifiedAt,
+			Current:    current,
+		})
+	}
+	sort.Slice(user.ContactVerifications, func(i, j int) bool {
+		return user.ContactVerifications[i].Contact < user.ContactVerifications[j].Contact
+	})
+	return user
+}
+
+// decodeValue converts stored meta value into JSON friendly form.
+// Binary values holding text are exported as strings.
+func decodeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.Binary:
+		return decodeValue(v.Data)
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return v
+	case primitive.A:
+		return decodeValue([]interface{}(v))
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = decodeValue(v[i])
+		}
+		return res
+	case primitive.D:
+		return decodeValue(v.Map())
+	case primitive.M:
+		return decodeValue(map[string]interface{}(v))
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = decodeValue(v[k])
+		}
+		return res
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case primitive.ObjectID:
+		return v.Hex()
+	}
+	return value
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/verification"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errMock = errors.New("error")
+
+type testSource struct {
+	entries []HistoryEntry
+	err     error
+}
+
+func (s testSource) Name() string {
+	return "test"
+}
+
+func (s testSource) History(context.Context, string) ([]HistoryEntry, error) {
+	return s.entries, s.err
+}
+
+func TestExporter_Export(t *testing.T) {
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, model.UserFindFilter{IDs: []string{"1"}}).Return(nil, errMock)
+		_, err := New(st).Export(context.Background(), "1")
+		require.Error(t, err)
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("user not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{}, nil)
+		_, err := New(st).Export(context.Background(), "1")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrUserNotFound))
+	})
+	t.Run("history error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{{ID: "1"}}, nil)
+		_, err := New(st, testSource{err: errMock}).Export(context.Background(), "1")
+		require.Error(t, err)
+		require.EqualError(t, err, "could not get test history: "+errMock.Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		createdAt := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{
+			{
+				ID:               "1",
+				Status:           "ACCOUNT_STATUS_ACTIVE",
+				CreatedAt:        createdAt,
+				UpdatedAt:        createdAt,
+				GroupIDs:         []string{"g1"},
+				TwoFactorEnabled: true,
+				MergedInto:       "2",
+				MergedAt:         createdAt,
+				Roles: []model.RoleAssignment{
+					{Role: "admin", Resource: "r1", AssignedAt: createdAt},
+				},
+				StatusSchedules: []model.StatusSchedule{
+					{ID: "s1", To: "ACCOUNT_STATUS_SUSPENDED", Reason: "fraud_check", At: createdAt.Add(time.Hour), CreatedAt: createdAt},
+				},
+				VerifiedContacts: map[string]model.ContactVerification{
+					"email": {ValueHash: verification.ValueHash("john@example.com"), VerifiedAt: createdAt},
+					"phone": {ValueHash: verification.ValueHash("+100"), VerifiedAt: createdAt},
+				},
+				Meta: map[string]interface{}{
+					"email":  primitive.Binary{Data: []byte("john@example.com")},
+					"phone":  "+200",
+					"name":   primitive.Binary{Data: []byte("john")},
+					"avatar": primitive.Binary{Data: []byte{0xff, 0xfe}},
+					"tags":   primitive.A{"a", primitive.Binary{Data: []byte("b")}},
+					"nested": primitive.D{{Key: "key", Value: int32(1)}},
+				},
+			},
+		}, nil)
+		source := testSource{
+			entries: []HistoryEntry{
+				{At: createdAt.Add(time.Hour), Type: "second"},
+				{At: createdAt, Type: "first"},
+			},
+		}
+		archive, err := New(st, source).Export(context.Background(), "1")
+		require.NoError(t, err)
+		require.Equal(t, "1", archive.User.ID)
+		require.Equal(t, "ACCOUNT_STATUS_ACTIVE", archive.User.Status)
+		require.Equal(t, createdAt, archive.User.CreatedAt)
+		require.Equal(t, []string{"g1"}, archive.User.GroupIDs)
+		require.True(t, archive.User.TwoFactorEnabled)
+		require.Equal(t, "2", archive.User.MergedInto)
+		require.Equal(t, &createdAt, archive.User.MergedAt)
+		require.Equal(t, []Role{{Role: "admin", Resource: "r1", AssignedAt: createdAt}}, archive.User.Roles)
+		require.Equal(t, map[string]time.Time{"email": createdAt}, archive.User.VerifiedContacts)
+		require.Equal(t, []ContactVerification{
+			{Contact: "email", VerifiedAt: createdAt, Current: true},
+			{Contact: "phone", VerifiedAt: createdAt},
+		}, archive.User.ContactVerifications)
+		require.Equal(t, []StatusSchedule{
+			{ID: "s1", To: "ACCOUNT_STATUS_SUSPENDED", Reason: "fraud_check", At: createdAt.Add(time.Hour), CreatedAt: createdAt},
+		}, archive.User.StatusSchedules)
+		require.Equal(t, map[string]interface{}{
+			"email":  "john@example.com",
+			"phone":  "+200",
+			"name":   "john",
+			"avatar": []byte{0xff, 0xfe},
+			"tags":   []interface{}{"a", "b"},
+			"nested": map[string]interface{}{"key": int32(1)},
+		}, archive.User.Meta)
+		require.Len(t, archive.History, 2)
+		require.Equal(t, "first", archive.History[0].Type)
+		require.Equal(t, "test", archive.History[0].Source)
+	})
+}
+
+func Test_Write(t *testing.T) {
+	archive := &Archive{
+		GeneratedAt: time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC),
+		User: User{
+			ID:     "1",
+			Status: "ACCOUNT_STATUS_ACTIVE",
+		},
+	}
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		contentType, err := Write(&buf, archive, false)
+		require.NoError(t, err)
+		require.Equal(t, ContentTypeJSON, contentType)
+		var res Archive
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+		require.Equal(t, *archive, res)
+	})
+	t.Run("zip", func(t *testing.T) {
+		var buf bytes.Buffer
+		contentType, err := Write(&buf, archive, true)
+		require.NoError(t, err)
+		require.Equal(t, ContentTypeZip, contentType)
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		require.Len(t, zr.File, 1)
+		require.Equal(t, "user_1.json", zr.File[0].Name)
+		f, err := zr.File[0].Open()
+		require.NoError(t, err)
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		var res Archive
+		require.NoError(t, json.Unmarshal(data, &res))
+		require.Equal(t, *archive, res)
+	})
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"context"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+)
+
+// IdentitySource provides user's linked external identities for data exports.
+type IdentitySource struct {
+	identityStorage storage.Identity
+}
+
+// NewIdentitySource creates new IdentitySource instance.
+func NewIdentitySource(identityStorage storage.Identity) *IdentitySource {
+	return &IdentitySource{
+		identityStorage: identityStorage,
+	}
+}
+
+// Name returns the name of the source used in the exported entries.
+func (s *IdentitySource) Name() string {
+	return "identity"
+}
+
+// History returns user's linked identities.
+func (s *IdentitySource) History(ctx context.Context, userID string) ([]HistoryEntry, error) {
+	identities, err := s.identityStorage.ListIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, len(identities))
+	for i, identity := range identities {
+		entries[i] = HistoryEntry{
+			At:   identity.LinkedAt,
+			Type: "identity_linked",
+			Details: map[string]interface{}{
+				"provider": identity.Provider,
+				"subject":  identity.Subject,
+			},
+		}
+	}
+	return entries, nil
+}
+
+// APIKeySource provides metadata of user's API keys for data exports,
+// key hashes are never exported.
+type APIKeySource struct {
+	apiKeyStorage storage.APIKey
+}
+
+// NewAPIKeySource creates new APIKeySource instance.
+func NewAPIKeySource(apiKeyStorage storage.APIKey) *APIKeySource {
+	return &APIKeySource{
+		apiKeyStorage: apiKeyStorage,
+	}
+}
+
+// Name returns the name of the source used in the exported entries.
+func (s *APIKeySource) Name() string {
+	return "api_key"
+}
+
+// History returns creation and revocation of user's API keys.
+func (s *APIKeySource) History(ctx context.Context, userID string) ([]HistoryEntry, error) {
+	keys, err := s.apiKeyStorage.FindAPIKeys(ctx, model.APIKeyFindFilter{
+		UserIDs: []string{userID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]HistoryEntry, 0, len(keys))
+	for _, key := range keys {
+		details := map[string]interface{}{
+			"id":     key.ID,
+			"name":   key.Name,
+			"prefix": key.Prefix,
+			"scopes": key.Scopes,
+		}
+		if !key.ExpiresAt.IsZero() {
+			details["expires_at"] = key.ExpiresAt
+		}
+		if !key.LastUsedAt.IsZero() {
+			details["last_used_at"] = key.LastUsedAt
+		}
+		entries = append(entries, HistoryEntry{
+			At:      key.CreatedAt,
+			Type:    "api_key_created",
+			Details: details,
+		})
+		if !key.RevokedAt.IsZero() {
+			entries = append(entries, HistoryEntry{
+				At:   key.RevokedAt,
+				Type: "api_key_revoked",
+				Details: map[string]interface{}{
+					"id": key.ID,
+				},
+			})
+		}
+	}
+	return entries, nil
+}
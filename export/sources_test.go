@@ -0,0 +1,98 @@
+package export
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentitySource_History(t *testing.T) {
+	t.Run("list error", func(t *testing.T) {
+		st := new(storageMocks.Identity)
+		defer st.AssertExpectations(t)
+		st.On("ListIdentities", mock.Anything, "1").Return(nil, errMock)
+		_, err := NewIdentitySource(st).History(context.Background(), "1")
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Identity)
+		defer st.AssertExpectations(t)
+		at := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+		st.On("ListIdentities", mock.Anything, "1").Return([]model.Identity{
+			{Provider: "google", Subject: "s1", UserID: "1", LinkedAt: at},
+		}, nil)
+		source := NewIdentitySource(st)
+		require.Equal(t, "identity", source.Name())
+		entries, err := source.History(context.Background(), "1")
+		require.NoError(t, err)
+		require.Equal(t, []HistoryEntry{
+			{
+				At:   at,
+				Type: "identity_linked",
+				Details: map[string]interface{}{
+					"provider": "google",
+					"subject":  "s1",
+				},
+			},
+		}, entries)
+	})
+}
+
+func TestAPIKeySource_History(t *testing.T) {
+	filter := model.APIKeyFindFilter{UserIDs: []string{"1"}}
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.APIKey)
+		defer st.AssertExpectations(t)
+		st.On("FindAPIKeys", mock.Anything, filter).Return(nil, errMock)
+		_, err := NewAPIKeySource(st).History(context.Background(), "1")
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.APIKey)
+		defer st.AssertExpectations(t)
+		at := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+		st.On("FindAPIKeys", mock.Anything, filter).Return([]model.APIKey{
+			{ID: "k1", UserID: "1", Name: "ci", Prefix: "p1", Hash: "secret", Scopes: []string{"read"}, CreatedAt: at},
+			{ID: "k2", UserID: "1", Name: "old", Prefix: "p2", Hash: "secret", CreatedAt: at, LastUsedAt: at.Add(time.Hour), RevokedAt: at.Add(2 * time.Hour)},
+		}, nil)
+		source := NewAPIKeySource(st)
+		require.Equal(t, "api_key", source.Name())
+		entries, err := source.History(context.Background(), "1")
+		require.NoError(t, err)
+		require.Equal(t, []HistoryEntry{
+			{
+				At:   at,
+				Type: "api_key_created",
+				Details: map[string]interface{}{
+					"id":     "k1",
+					"name":   "ci",
+					"prefix": "p1",
+					"scopes": []string{"read"},
+				},
+			},
+			{
+				At:   at,
+				Type: "api_key_created",
+				Details: map[string]interface{}{
+					"id":           "k2",
+					"name":         "old",
+					"prefix":       "p2",
+					"scopes":       []string(nil),
+					"last_used_at": at.Add(time.Hour),
+				},
+			},
+			{
+				At:   at.Add(2 * time.Hour),
+				Type: "api_key_revoked",
+				Details: map[string]interface{}{
+					"id": "k2",
+				},
+			},
+		}, entries)
+	})
+}
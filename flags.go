@@ -0,0 +1,122 @@
+package main
+
+import (
+	"time"
+
+	commonService "github.com/open-Q/common/golang/service"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/resilience"
+	"github.com/open-Q/user/tracing"
+)
+
+// flags represents parsed contract flags.
+// Flags missing in the contract return zero values.
+type flags map[string]commonService.GenericFlag
+
+func (f flags) stringValue(name string) string {
+	flag := f[name]
+	v, _ := flag.Value().(string)
+	return v
+}
+
+func (f flags) stringsValue(name string) []string {
+	flag := f[name]
+	v, _ := flag.Value().([]string)
+	return v
+}
+
+func (f flags) boolValue(name string) bool {
+	flag := f[name]
+	v, _ := flag.Value().(bool)
+	return v
+}
+
+func (f flags) intValue(name string) int {
+	flag := f[name]
+	v, _ := flag.Value().(int)
+	return v
+}
+
+func (f flags) uint64Value(name string) uint64 {
+	flag := f[name]
+	v, _ := flag.Value().(uint64)
+	return v
+}
+
+func (f flags) float64Value(name string) float64 {
+	flag := f[name]
+	v, _ := flag.Value().(float64)
+	return v
+}
+
+func (f flags) durationValue(name string) time.Duration {
+	flag := f[name]
+	v, _ := flag.Value().(time.Duration)
+	return v
+}
+
+func (f flags) mongoConfig() storage.MongoConfig {
+	return storage.MongoConfig{
+		ConnString:                    f.stringValue(envMongoConn),
+		DBName:                        f.stringValue(envMongoDB),
+		ReadPreference:                f.stringValue(envMongoReadPreference),
+		ReadConcern:                   f.stringValue(envMongoReadConcern),
+		WriteConcern:                  f.stringValue(envMongoWriteConcern),
+		MinPoolSize:                   f.uint64Value(envMongoMinPoolSize),
+		MaxPoolSize:                   f.uint64Value(envMongoMaxPoolSize),
+		ConnectTimeout:                f.durationValue(envMongoConnectTimeout),
+		ServerSelectionTimeout:        f.durationValue(envMongoServerSelectionTimeout),
+		SocketTimeout:                 f.durationValue(envMongoSocketTimeout),
+		TLS:                           f.boolValue(envMongoTLS),
+		TLSCAFile:                     f.stringValue(envMongoTLSCAFile),
+		TLSCertificateKeyFile:         f.stringValue(envMongoTLSCertKeyFile),
+		TLSCertificateKeyFilePassword: f.stringValue(envMongoTLSCertKeyPassword),
+		TLSInsecure:                   f.boolValue(envMongoTLSInsecure),
+		AuthMechanism:                 f.stringValue(envMongoAuthMechanism),
+		AuthSource:                    f.stringValue(envMongoAuthSource),
+	}
+}
+
+func (f flags) resilienceConfig() resilience.Config {
+	return resilience.Config{
+		MaxAttempts:      f.intValue(envStorageRetryMaxAttempts),
+		BaseDelay:        f.durationValue(envStorageRetryBaseDelay),
+		MaxDelay:         f.durationValue(envStorageRetryMaxDelay),
+		BreakerThreshold: f.intValue(envStorageBreakerThreshold),
+		BreakerCooldown:  f.durationValue(envStorageBreakerCooldown),
+	}
+}
+
+func (f flags) tracingConfig() tracing.Config {
+	return tracing.Config{
+		Exporter:       f.stringValue(envTracingExporter),
+		Endpoint:       f.stringValue(envTracingEndpoint),
+		Insecure:       f.boolValue(envTracingInsecure),
+		File:           f.stringValue(envTracingFile),
+		SampleRatio:    f.float64Value(envTracingSampleRatio),
+		ServiceName:    serviceName,
+		ServiceVersion: version,
+	}
+}
+
+// passwordParams returns password hashing parameters, unset flags keep the defaults.
+func (f flags) passwordParams() credential.Params {
+	p := credential.DefaultParams()
+	if v := f.stringValue(envPasswordAlgorithm); v != "" {
+		p.Algorithm = v
+	}
+	if v := f.intValue(envPasswordArgon2Memory); v > 0 {
+		p.Argon2Memory = uint32(v)
+	}
+	if v := f.intValue(envPasswordArgon2Iterations); v > 0 {
+		p.Argon2Iterations = uint32(v)
+	}
+	if v := f.intValue(envPasswordArgon2Parallelism); v > 0 {
+		p.Argon2Parallelism = uint8(v)
+	}
+	if v := f.intValue(envPasswordBcryptCost); v > 0 {
+		p.BcryptCost = v
+	}
+	return p
+}
@@ -3,11 +3,20 @@ module github.com/open-Q/user
 go 1.15
 
 require (
-	github.com/golang/protobuf v1.4.3
+	github.com/golang/protobuf v1.5.2
 	github.com/micro/go-micro/v2 v2.9.1
 	github.com/open-Q/common/golang v0.0.0-20201102144218-67472f7b6da5
 	github.com/pkg/errors v0.9.1
-	github.com/stretchr/testify v1.6.1
+	github.com/prometheus/client_golang v1.8.0
+	github.com/sirupsen/logrus v1.6.0
+	github.com/stretchr/testify v1.7.0
 	go.mongodb.org/mongo-driver v1.4.2
-	google.golang.org/protobuf v1.25.0
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc v1.0.1
+	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	google.golang.org/grpc v1.41.0
+	google.golang.org/protobuf v1.27.1
 )
@@ -0,0 +1,138 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// There are health statuses.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultTimeout is the default timeout of dependency checks.
+const DefaultTimeout = 2 * time.Second
+
+// Check checks a dependency of the service.
+type Check func(ctx context.Context) error
+
+// Report represents health check report.
+type Report struct {
+	Status string `json:"status"`
+	// Reasons describe why the service isn't ready regardless of its dependencies.
+	Reasons []string               `json:"reasons,omitempty"`
+	Checks  map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult represents result of a dependency check.
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Checker represents service liveness and readiness checker.
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+	blocks map[*string]struct{}
+}
+
+// NewChecker creates new Checker instance.
+// Zero timeout is replaced with DefaultTimeout.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{
+		timeout: timeout,
+		checks:  make(map[string]Check),
+		blocks:  make(map[*string]struct{}),
+	}
+}
+
+// AddCheck adds dependency check performed on readiness requests.
+func (c *Checker) AddCheck(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// Block makes the service not ready until the returned function is called,
+// e.g. during startup, shutdown or migrations.
+func (c *Checker) Block(reason string) (unblock func()) {
+	key := &reason
+	c.mu.Lock()
+	c.blocks[key] = struct{}{}
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.blocks, key)
+			c.mu.Unlock()
+		})
+	}
+}
+
+// Live reports whether the service is alive.
+func (c *Checker) Live() Report {
+	return Report{Status: StatusUp}
+}
+
+// Ready reports whether the service and its dependencies are ready to serve requests.
+func (c *Checker) Ready(ctx context.Context) Report {
+	c.mu.RLock()
+	reasons := make([]string, 0, len(c.blocks))
+	for reason := range c.blocks {
+		reasons = append(reasons, *reason)
+	}
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: make(map[string]CheckResult, len(checks)),
+	}
+	if len(reasons) != 0 {
+		sort.Strings(reasons)
+		report.Status = StatusDown
+		report.Reasons = reasons
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	wg.Add(len(checks))
+	for name, check := range checks {
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := CheckResult{Status: StatusUp}
+			if err := check(ctx); err != nil {
+				result = CheckResult{Status: StatusDown, Error: strings.TrimSpace(err.Error())}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report
+}
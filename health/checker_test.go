@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_Ready(t *testing.T) {
+	t.Run("dependency is down", func(t *testing.T) {
+		c := NewChecker(0)
+		c.AddCheck("mongo", func(context.Context) error {
+			return errors.New("could not ping connection")
+		})
+		c.AddCheck("cache", func(context.Context) error {
+			return nil
+		})
+		require.Equal(t, Report{
+			Status: StatusDown,
+			Checks: map[string]CheckResult{
+				"mongo": {Status: StatusDown, Error: "could not ping connection"},
+				"cache": {Status: StatusUp},
+			},
+		}, c.Ready(context.Background()))
+	})
+	t.Run("check times out", func(t *testing.T) {
+		c := NewChecker(10 * time.Millisecond)
+		c.AddCheck("mongo", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		report := c.Ready(context.Background())
+		require.Equal(t, StatusDown, report.Status)
+		require.Equal(t, context.DeadlineExceeded.Error(), report.Checks["mongo"].Error)
+	})
+	t.Run("blocked", func(t *testing.T) {
+		c := NewChecker(0)
+		unblockStartup := c.Block("starting")
+		unblockMigration := c.Block("migration")
+		require.Equal(t, Report{
+			Status:  StatusDown,
+			Reasons: []string{"migration", "starting"},
+			Checks:  map[string]CheckResult{},
+		}, c.Ready(context.Background()))
+
+		unblockStartup()
+		unblockStartup()
+		require.Equal(t, []string{"migration"}, c.Ready(context.Background()).Reasons)
+		unblockMigration()
+		require.Equal(t, StatusUp, c.Ready(context.Background()).Status)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		c := NewChecker(0)
+		c.AddCheck("mongo", func(context.Context) error {
+			return nil
+		})
+		require.Equal(t, Report{
+			Status: StatusUp,
+			Checks: map[string]CheckResult{"mongo": {Status: StatusUp}},
+		}, c.Ready(context.Background()))
+		require.Equal(t, Report{Status: StatusUp}, c.Live())
+	})
+}
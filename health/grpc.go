@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultWatchInterval is the default interval of readiness checks of watch requests.
+const DefaultWatchInterval = 5 * time.Second
+
+// GRPCServer represents the standard gRPC health server reporting the service readiness.
+type GRPCServer struct {
+	checker       *Checker
+	services      map[string]struct{}
+	watchInterval time.Duration
+}
+
+// NewGRPCServer creates new GRPCServer instance.
+// The overall health is reported for the empty service name and the provided services.
+func NewGRPCServer(checker *Checker, services ...string) *GRPCServer {
+	s := GRPCServer{
+		checker:       checker,
+		services:      map[string]struct{}{"": {}},
+		watchInterval: DefaultWatchInterval,
+	}
+	for _, service := range services {
+		s.services[service] = struct{}{}
+	}
+	return &s
+}
+
+// Check returns the service serving status.
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if _, ok := s.services[req.Service]; !ok {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+	return &healthpb.HealthCheckResponse{
+		Status: s.servingStatus(ctx),
+	}, nil
+}
+
+// Watch streams the service serving status whenever it changes.
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		current := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if _, ok := s.services[req.Service]; ok {
+			current = s.servingStatus(ctx)
+		}
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *GRPCServer) servingStatus(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	if s.checker.Ready(ctx).Status != StatusUp {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
@@ -0,0 +1,75 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testWatchServer struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses chan *healthpb.HealthCheckResponse
+}
+
+func (s testWatchServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s testWatchServer) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (s testWatchServer) Send(resp *healthpb.HealthCheckResponse) error {
+	s.responses <- resp
+	return nil
+}
+
+func TestGRPCServer_Check(t *testing.T) {
+	c := NewChecker(0)
+	s := NewGRPCServer(c, "user")
+
+	_, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "group"})
+	require.Error(t, err)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	unblock := c.Block("starting")
+	resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "user"})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+
+	unblock()
+	resp, err = s.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func TestGRPCServer_Watch(t *testing.T) {
+	c := NewChecker(0)
+	s := NewGRPCServer(c)
+	s.watchInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := testWatchServer{
+		ctx:       ctx,
+		responses: make(chan *healthpb.HealthCheckResponse, 1),
+	}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Watch(&healthpb.HealthCheckRequest{}, stream)
+	}()
+
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, (<-stream.responses).Status)
+	c.Block("shutting down")
+	require.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, (<-stream.responses).Status)
+
+	cancel()
+	require.Equal(t, codes.Canceled, status.Code(<-errs))
+}
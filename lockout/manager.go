@@ -0,0 +1,295 @@
+package lockout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are default lockout settings.
+const (
+	DefaultUserThreshold       = 5
+	DefaultIdentifierThreshold = 10
+	DefaultWindow              = 15 * time.Minute
+	DefaultBaseDuration        = time.Minute
+	DefaultMaxDuration         = 24 * time.Hour
+	DefaultResetAfter          = 24 * time.Hour
+)
+
+// There are lockout key prefixes.
+const (
+	userKeyPrefix       = "user:"
+	identifierKeyPrefix = "identifier:"
+)
+
+// ErrNoSubject is returned when neither the user nor the login identifier is given.
+var ErrNoSubject = errors.New("user ID or login identifier is required")
+
+// Config represents lockout manager configuration.
+type Config struct {
+	Storage storage.Lockout
+	// UserThreshold and IdentifierThreshold are numbers of failed logins within Window
+	// locking the user or the login identifier, DefaultUserThreshold, DefaultIdentifierThreshold
+	// and DefaultWindow are used if empty.
+	UserThreshold       int
+	IdentifierThreshold int
+	Window              time.Duration
+	// BaseDuration is the first lockout duration, every next lockout lasts twice longer
+	// up to MaxDuration. DefaultBaseDuration and DefaultMaxDuration are used if empty.
+	BaseDuration time.Duration
+	MaxDuration  time.Duration
+	// ResetAfter is a period after the lockout ends its count is forgotten, DefaultResetAfter is used if empty.
+	ResetAfter time.Duration
+}
+
+// State represents login lockout state of the user and the login identifier.
+type State struct {
+	Locked      bool
+	LockedUntil time.Time
+	// RemainingAttempts is a number of failed logins left before the lockout.
+	RemainingAttempts int
+}
+
+// Manager tracks failed logins and locks users and login identifiers out.
+type Manager struct {
+	storage             storage.Lockout
+	userThreshold       int
+	identifierThreshold int
+	window              time.Duration
+	baseDuration        time.Duration
+	maxDuration         time.Duration
+	resetAfter          time.Duration
+	now                 func() time.Time
+}
+
+// NewManager creates new Manager instance.
+func NewManager(cfg Config) (*Manager, error) {
+	m := Manager{
+		storage:             cfg.Storage,
+		userThreshold:       cfg.UserThreshold,
+		identifierThreshold: cfg.IdentifierThreshold,
+		window:              cfg.Window,
+		baseDuration:        cfg.BaseDuration,
+		maxDuration:         cfg.MaxDuration,
+		resetAfter:          cfg.ResetAfter,
+		now:                 time.Now,
+	}
+	if m.userThreshold <= 0 {
+		m.userThreshold = DefaultUserThreshold
+	}
+	if m.identifierThreshold <= 0 {
+		m.identifierThreshold = DefaultIdentifierThreshold
+	}
+	if m.window <= 0 {
+		m.window = DefaultWindow
+	}
+	if m.baseDuration <= 0 {
+		m.baseDuration = DefaultBaseDuration
+	}
+	if m.maxDuration <= 0 {
+		m.maxDuration = DefaultMaxDuration
+	}
+	if m.resetAfter <= 0 {
+		m.resetAfter = DefaultResetAfter
+	}
+	if m.baseDuration > m.maxDuration {
+		return nil, errors.New("base lockout duration must not be longer than the max one")
+	}
+	return &m, nil
+}
+
+// UserKey returns lockout key of the user.
+func UserKey(userID string) string {
+	return userKeyPrefix + userID
+}
+
+// IdentifierKey returns lockout key of the login identifier, e.g. the login name.
+// Identifiers are hashed, so they aren't kept in plaintext.
+func IdentifierKey(identifier string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(identifier))))
+	return identifierKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// Record records login attempt of the user, the login identifier or both and returns the new state.
+// Failures lock the key out once its threshold is reached within the window, successes
+// reset failures and lockout counts of unlocked keys. Attempts of locked keys change nothing.
+func (m *Manager) Record(ctx context.Context, userID, identifier string, success bool) (*State, error) {
+	thresholds, err := m.thresholds(userID, identifier)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	lockouts, err := m.lockouts(ctx, thresholds)
+	if err != nil {
+		return nil, err
+	}
+	for key, threshold := range thresholds {
+		lockout := lockouts[key]
+		if now.Before(lockout.LockedUntil) {
+			continue
+		}
+		if success {
+			err = m.reset(ctx, key, now)
+		} else {
+			err = m.fail(ctx, lockout, key, threshold, now)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return m.state(ctx, thresholds, now)
+}
+
+// State returns lockout state of the user, the login identifier or both.
+func (m *Manager) State(ctx context.Context, userID, identifier string) (*State, error) {
+	thresholds, err := m.thresholds(userID, identifier)
+	if err != nil {
+		return nil, err
+	}
+	return m.state(ctx, thresholds, m.now())
+}
+
+// Unlock ends lockouts and resets failures of the user, the login identifier or both.
+func (m *Manager) Unlock(ctx context.Context, userID, identifier string) (*State, error) {
+	thresholds, err := m.thresholds(userID, identifier)
+	if err != nil {
+		return nil, err
+	}
+	now := m.now()
+	for key := range thresholds {
+		if err := m.reset(ctx, key, now); err != nil {
+			return nil, err
+		}
+	}
+	return m.state(ctx, thresholds, now)
+}
+
+// Duration returns duration of the lockout following the count of previous ones.
+func (m *Manager) Duration(count int) time.Duration {
+	duration := m.baseDuration
+	for i := 0; i < count && duration < m.maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > m.maxDuration {
+		return m.maxDuration
+	}
+	return duration
+}
+
+// fail records failed login and locks the key out once its threshold is reached.
+// Concurrent failures lock the key once.
+func (m *Manager) fail(ctx context.Context, lockout model.Lockout, key string, threshold int, now time.Time) error {
+	if err := m.storage.AddLoginFailure(ctx, key, now, now.Add(m.window)); err != nil {
+		return errors.Wrap(err, "could not add login failure")
+	}
+	failures, err := m.failures(ctx, lockout, key, now)
+	if err != nil {
+		return err
+	}
+	if failures < threshold {
+		return nil
+	}
+	lockedUntil := now.Add(m.Duration(lockout.Count))
+	_, err = m.storage.Lock(ctx, model.Lockout{
+		Key:         key,
+		Count:       lockout.Count + 1,
+		LockedUntil: lockedUntil,
+		ResetAt:     now,
+		ExpiresAt:   lockedUntil.Add(m.resetAfter),
+	}, lockout.Count)
+	if err != nil {
+		return errors.Wrap(err, "could not lock login out")
+	}
+	return nil
+}
+
+func (m *Manager) reset(ctx context.Context, key string, now time.Time) error {
+	if err := m.storage.ResetLockout(ctx, key, now, now.Add(m.window)); err != nil {
+		return errors.Wrap(err, "could not reset lockout")
+	}
+	return nil
+}
+
+// state combines states of the keys, the most restrictive one wins.
+func (m *Manager) state(ctx context.Context, thresholds map[string]int, now time.Time) (*State, error) {
+	lockouts, err := m.lockouts(ctx, thresholds)
+	if err != nil {
+		return nil, err
+	}
+	state := State{RemainingAttempts: -1}
+	for key, threshold := range thresholds {
+		lockout := lockouts[key]
+		remaining := 0
+		if now.Before(lockout.LockedUntil) {
+			state.Locked = true
+			if lockout.LockedUntil.After(state.LockedUntil) {
+				state.LockedUntil = lockout.LockedUntil
+			}
+		} else {
+			failures, err := m.failures(ctx, lockout, key, now)
+			if err != nil {
+				return nil, err
+			}
+			if failures < threshold {
+				remaining = threshold - failures
+			}
+		}
+		if state.RemainingAttempts < 0 || remaining < state.RemainingAttempts {
+			state.RemainingAttempts = remaining
+		}
+	}
+	return &state, nil
+}
+
+// failures counts failures within the window since the key was last reset or locked.
+func (m *Manager) failures(ctx context.Context, lockout model.Lockout, key string, now time.Time) (int, error) {
+	since := now.Add(-m.window)
+	if lockout.ResetAt.After(since) {
+		since = lockout.ResetAt
+	}
+	count, err := m.storage.CountLoginFailures(ctx, key, since)
+	if err != nil {
+		return 0, errors.Wrap(err, "could not count login failures")
+	}
+	return int(count), nil
+}
+
+// lockouts returns lockout states by the keys, keys without state have zero one.
+func (m *Manager) lockouts(ctx context.Context, thresholds map[string]int) (map[string]model.Lockout, error) {
+	keys := make([]string, 0, len(thresholds))
+	for key := range thresholds {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	found, err := m.storage.FindLockouts(ctx, keys)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find lockouts")
+	}
+	lockouts := make(map[string]model.Lockout, len(found))
+	for i := range found {
+		lockouts[found[i].Key] = found[i]
+	}
+	return lockouts, nil
+}
+
+// thresholds returns failure thresholds by keys of the user and the login identifier.
+func (m *Manager) thresholds(userID, identifier string) (map[string]int, error) {
+	thresholds := map[string]int{}
+	if userID != "" {
+		thresholds[UserKey(userID)] = m.userThreshold
+	}
+	if strings.TrimSpace(identifier) != "" {
+		thresholds[IdentifierKey(identifier)] = m.identifierThreshold
+	}
+	if len(thresholds) == 0 {
+		return nil, ErrNoSubject
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,101 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(Config{BaseDuration: time.Hour, MaxDuration: time.Minute})
+	require.EqualError(t, err, "base lockout duration must not be longer than the max one")
+}
+
+func TestManager_Duration(t *testing.T) {
+	m, err := NewManager(Config{BaseDuration: time.Minute, MaxDuration: 10 * time.Minute})
+	require.NoError(t, err)
+	for count, duration := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute} {
+		require.Equal(t, duration, m.Duration(count))
+	}
+	require.Equal(t, 10*time.Minute, m.Duration(1000))
+}
+
+func TestIdentifierKey(t *testing.T) {
+	require.Equal(t, IdentifierKey("John@Example.com "), IdentifierKey("john@example.com"))
+	require.NotContains(t, IdentifierKey("john@example.com"), "john")
+}
+
+func TestManager_Record(t *testing.T) {
+	now := time.Now()
+	newManager := func(t *testing.T, st *storageMocks.Lockout) *Manager {
+		m, err := NewManager(Config{Storage: st, UserThreshold: 3})
+		require.NoError(t, err)
+		m.now = func() time.Time {
+			return now
+		}
+		return m
+	}
+	key := UserKey("1")
+	t.Run("no subject", func(t *testing.T) {
+		_, err := newManager(t, nil).Record(context.Background(), "", " ", false)
+		require.Equal(t, ErrNoSubject, err)
+	})
+	t.Run("failure below threshold", func(t *testing.T) {
+		st := new(storageMocks.Lockout)
+		defer st.AssertExpectations(t)
+		st.On("FindLockouts", mock.Anything, []string{key}).Return(nil, nil)
+		st.On("AddLoginFailure", mock.Anything, key, now, now.Add(DefaultWindow)).Return(nil)
+		st.On("CountLoginFailures", mock.Anything, key, now.Add(-DefaultWindow)).Return(int64(2), nil)
+		state, err := newManager(t, st).Record(context.Background(), "1", "", false)
+		require.NoError(t, err)
+		require.Equal(t, State{RemainingAttempts: 1}, *state)
+	})
+	t.Run("failure locks out", func(t *testing.T) {
+		st := new(storageMocks.Lockout)
+		defer st.AssertExpectations(t)
+		previous := model.Lockout{Key: key, Count: 2, ResetAt: now.Add(-time.Minute)}
+		locked := model.Lockout{
+			Key:         key,
+			Count:       3,
+			LockedUntil: now.Add(4 * DefaultBaseDuration),
+			ResetAt:     now,
+			ExpiresAt:   now.Add(4 * DefaultBaseDuration).Add(DefaultResetAfter),
+		}
+		st.On("FindLockouts", mock.Anything, []string{key}).Return([]model.Lockout{previous}, nil).Once()
+		st.On("AddLoginFailure", mock.Anything, key, now, now.Add(DefaultWindow)).Return(nil)
+		st.On("CountLoginFailures", mock.Anything, key, previous.ResetAt).Return(int64(3), nil)
+		st.On("Lock", mock.Anything, locked, 2).Return(true, nil)
+		st.On("FindLockouts", mock.Anything, []string{key}).Return([]model.Lockout{locked}, nil).Once()
+		state, err := newManager(t, st).Record(context.Background(), "1", "", false)
+		require.NoError(t, err)
+		require.Equal(t, State{Locked: true, LockedUntil: locked.LockedUntil}, *state)
+	})
+	t.Run("locked attempt", func(t *testing.T) {
+		st := new(storageMocks.Lockout)
+		defer st.AssertExpectations(t)
+		locked := model.Lockout{Key: key, Count: 1, LockedUntil: now.Add(time.Minute)}
+		st.On("FindLockouts", mock.Anything, []string{key}).Return([]model.Lockout{locked}, nil)
+		state, err := newManager(t, st).Record(context.Background(), "1", "", true)
+		require.NoError(t, err)
+		require.True(t, state.Locked)
+	})
+	t.Run("success resets", func(t *testing.T) {
+		st := new(storageMocks.Lockout)
+		defer st.AssertExpectations(t)
+		identifierKey := IdentifierKey("john")
+		keys := []string{identifierKey, key}
+		st.On("FindLockouts", mock.Anything, keys).Return(nil, nil).Once()
+		st.On("ResetLockout", mock.Anything, key, now, now.Add(DefaultWindow)).Return(nil)
+		st.On("ResetLockout", mock.Anything, identifierKey, now, now.Add(DefaultWindow)).Return(nil)
+		st.On("FindLockouts", mock.Anything, keys).Return([]model.Lockout{{Key: key, ResetAt: now}, {Key: identifierKey, ResetAt: now}}, nil).Once()
+		st.On("CountLoginFailures", mock.Anything, mock.Anything, now).Return(int64(0), nil)
+		state, err := newManager(t, st).Record(context.Background(), "1", "john", true)
+		require.NoError(t, err)
+		require.Equal(t, State{RemainingAttempts: 3}, *state)
+	})
+}
@@ -0,0 +1,13 @@
+package logging
+
+import (
+	commonLog "github.com/open-Q/common/golang/log"
+)
+
+// Flush commits written log records to the logger output, e.g. the log file.
+func Flush(logger *commonLog.Logger) error {
+	if syncer, ok := logger.Out.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlush(t *testing.T) {
+	t.Run("output without sync", func(t *testing.T) {
+		logger := &commonLog.Logger{Logger: logrus.New()}
+		logger.SetOutput(new(bytes.Buffer))
+		require.NoError(t, Flush(logger))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		logger, err := commonLog.NewFileLogger(t.TempDir(), "log.json", os.ModePerm)
+		require.NoError(t, err)
+		logger.Info("service stopped")
+		require.NoError(t, Flush(logger))
+
+		data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(logger.Out.(*os.File).Name()), "log.json"))
+		require.NoError(t, err)
+		require.Contains(t, string(data), "service stopped")
+	})
+}
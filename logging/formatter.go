@@ -0,0 +1,59 @@
+package logging
+
+import (
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/sirupsen/logrus"
+)
+
+// There are request-scoped log field names.
+const (
+	FieldOperation = "operation"
+	FieldUserID    = "user_id"
+)
+
+// Formatter represents logrus formatter which redacts PII before passing
+// the entry to the underlying formatter.
+type Formatter struct {
+	next     logrus.Formatter
+	redactor *Redactor
+}
+
+// NewFormatter creates new Formatter instance.
+func NewFormatter(next logrus.Formatter, redactor *Redactor) *Formatter {
+	return &Formatter{
+		next:     next,
+		redactor: redactor,
+	}
+}
+
+// Format redacts entry message and fields and formats the entry.
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	// the entry is shared with hooks, so it's modified on a copy.
+	redacted := *entry
+	redacted.Message = f.redactor.String(entry.Message)
+	redacted.Data = make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		if k == FieldUserID || k == FieldOperation {
+			redacted.Data[k] = v
+			continue
+		}
+		redacted.Data[k] = f.redactor.Value(k, v)
+	}
+	return f.next.Format(&redacted)
+}
+
+// Redact makes the logger mask PII in all subsequent entries.
+func Redact(logger *commonLog.Logger, redactor *Redactor) {
+	logger.SetFormatter(NewFormatter(logger.Formatter, redactor))
+}
+
+// WithRequest returns log entry with request-scoped fields.
+func WithRequest(logger *commonLog.Logger, operation, userID string) *logrus.Entry {
+	fields := logrus.Fields{
+		FieldOperation: operation,
+	}
+	if userID != "" {
+		fields[FieldUserID] = userID
+	}
+	return logger.WithFields(fields)
+}
@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(buf *bytes.Buffer) *commonLog.Logger {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(buf)
+	return &commonLog.Logger{Logger: logger}
+}
+
+func TestFormatter_Format(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf)
+	Redact(logger, NewRedactor([]string{"passport"}))
+
+	WithRequest(logger, "Create", "5fa0bcf6a2a4e5b2c1d3e4f5").
+		WithField("passport", "AB123").
+		WithError(errors.New("could not insert john@example.com")).
+		Error("could not create user john@example.com")
+
+	var res map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &res))
+	require.Equal(t, "could not create user "+Mask, res["msg"])
+	require.Equal(t, "could not insert "+Mask, res["error"])
+	require.Equal(t, Mask, res["passport"])
+	require.Equal(t, "Create", res[FieldOperation])
+	require.Equal(t, "5fa0bcf6a2a4e5b2c1d3e4f5", res[FieldUserID])
+}
+
+func Test_WithRequest(t *testing.T) {
+	logger := newTestLogger(new(bytes.Buffer))
+	entry := WithRequest(logger, "Find", "")
+	require.Equal(t, logrus.Fields{FieldOperation: "Find"}, entry.Data)
+	entry = WithRequest(logger, "Delete", "1")
+	require.Equal(t, logrus.Fields{FieldOperation: "Delete", FieldUserID: "1"}, entry.Data)
+}
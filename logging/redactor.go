@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Mask replaces redacted values.
+const Mask = "[REDACTED]"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phonePattern matches international numbers only, bare digits may be timestamps or counters.
+	phonePattern = regexp.MustCompile(`\+\d[\d ().\-]{6,}\d`)
+	// phoneFieldPattern matches numbers without a country code in phone-like fields.
+	phoneFieldPattern = regexp.MustCompile(`((?i:phone|mobile|msisdn)[\w.]*"?\s*[:=]\s*)("?\d[\d ().\-]{6,}\d"?)`)
+)
+
+// Redactor represents PII redactor.
+// It masks values of the configured meta keys and known PII patterns (emails, phone numbers).
+type Redactor struct {
+	metaKeys    map[string]struct{}
+	patterns    []*regexp.Regexp
+	keyPatterns []*regexp.Regexp
+}
+
+// NewRedactor creates new Redactor instance.
+func NewRedactor(metaKeys []string) *Redactor {
+	r := Redactor{
+		metaKeys:    make(map[string]struct{}, len(metaKeys)),
+		patterns:    []*regexp.Regexp{emailPattern, phonePattern},
+		keyPatterns: []*regexp.Regexp{phoneFieldPattern},
+	}
+	for i := range metaKeys {
+		r.metaKeys[metaKeys[i]] = struct{}{}
+		// matches `key: value`, `meta.key: "value"` and `"key":"value"` forms,
+		// the key must start a word, so `id` doesn't match `user_id` or `request.id`.
+		r.keyPatterns = append(r.keyPatterns, regexp.MustCompile(
+			fmt.Sprintf(`((?:^|[^\w.])(?:meta\.)?%s"?\s*[:=]\s*)("[^"]*"|[^\s,}]+)`, regexp.QuoteMeta(metaKeys[i])),
+		))
+	}
+	return &r
+}
+
+// String masks PII in the provided string.
+func (r *Redactor) String(s string) string {
+	for i := range r.keyPatterns {
+		s = r.keyPatterns[i].ReplaceAllString(s, "${1}"+Mask)
+	}
+	for i := range r.patterns {
+		s = r.patterns[i].ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// IsSensitive checks whether the provided field or meta key must be masked entirely.
+func (r *Redactor) IsSensitive(key string) bool {
+	_, ok := r.metaKeys[key]
+	return ok
+}
+
+// Value masks PII in the value of the field with the provided key.
+func (r *Redactor) Value(key string, value interface{}) interface{} {
+	if r.IsSensitive(key) {
+		return Mask
+	}
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.String(v)
+	case error:
+		return r.String(v.Error())
+	case fmt.Stringer:
+		return r.String(v.String())
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = r.Value(k, v[k])
+		}
+		return res
+	case bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	}
+
+	return r.String(fmt.Sprintf("%v", value))
+}
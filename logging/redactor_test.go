@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_String(t *testing.T) {
+	r := NewRedactor([]string{"passport"})
+	tt := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{
+			name:     "no pii",
+			value:    "user 5fa0bcf6a2a4e5b2c1d3e4f5 not found",
+			expected: "user 5fa0bcf6a2a4e5b2c1d3e4f5 not found",
+		},
+		{
+			name:     "email",
+			value:    "duplicate email john.doe@example.com",
+			expected: "duplicate email " + Mask,
+		},
+		{
+			name:     "phone",
+			value:    "phones +1 (555) 010-9999 and mobile=380501234567",
+			expected: "phones " + Mask + " and mobile=" + Mask,
+		},
+		{
+			name:     "json phone field",
+			value:    `{"phone":"0501234567"}`,
+			expected: `{"phone":` + Mask + `}`,
+		},
+		{
+			name:     "timestamps",
+			value:    "challenge 1604188800 expires at 1604189700123",
+			expected: "challenge 1604188800 expires at 1604189700123",
+		},
+		{
+			name:     "meta key",
+			value:    `E11000 duplicate key error dup key: { meta.passport: "AB123" }`,
+			expected: `E11000 duplicate key error dup key: { meta.passport: ` + Mask + ` }`,
+		},
+		{
+			name:     "json meta key",
+			value:    `{"passport":"AB123","name":"john"}`,
+			expected: `{"passport":` + Mask + `,"name":"john"}`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, r.String(tc.value))
+		})
+	}
+	t.Run("key boundary", func(t *testing.T) {
+		r := NewRedactor([]string{"id", "name"})
+		require.Equal(t,
+			"user_id=1 request_id=2 username=bob user.id=3 id="+Mask+" meta.name: "+Mask,
+			r.String("user_id=1 request_id=2 username=bob user.id=3 id=4 meta.name: john"),
+		)
+		require.Equal(t, `{"user_id":"1","id":`+Mask+`}`, r.String(`{"user_id":"1","id":"4"}`))
+		require.Equal(t, "name="+Mask, r.String("name=john"))
+	})
+}
+
+func TestRedactor_Value(t *testing.T) {
+	r := NewRedactor([]string{"passport"})
+	require.Equal(t, Mask, r.Value("passport", 123))
+	require.Equal(t, 123, r.Value("count", 123))
+	require.Nil(t, r.Value("value", nil))
+	require.Equal(t, "mail to "+Mask, r.Value("error", errors.New("mail to john@example.com")))
+	require.Equal(t, map[string]interface{}{
+		"passport": Mask,
+		"email":    Mask,
+		"age":      30,
+	}, r.Value("meta", map[string]interface{}{
+		"passport": "AB123",
+		"email":    "john@example.com",
+		"age":      30,
+	}))
+}
@@ -4,71 +4,540 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 
-	common "github.com/open-Q/common/golang"
+	"github.com/micro/go-micro/v2"
+	"github.com/micro/go-micro/v2/server"
 	commonLog "github.com/open-Q/common/golang/log"
 	proto "github.com/open-Q/common/golang/proto/user"
 	commonService "github.com/open-Q/common/golang/service"
+	"github.com/open-Q/user/command"
 	"github.com/open-Q/user/controller"
+	"github.com/open-Q/user/credential"
+	"github.com/open-Q/user/health"
+	"github.com/open-Q/user/lockout"
+	"github.com/open-Q/user/logging"
+	"github.com/open-Q/user/meta"
+	"github.com/open-Q/user/metrics"
+	"github.com/open-Q/user/rbac"
+	"github.com/open-Q/user/retention"
+	"github.com/open-Q/user/shutdown"
+	"github.com/open-Q/user/status"
 	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/encryption"
+	storageModel "github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/storage/resilience"
+	"github.com/open-Q/user/tracing"
+	"github.com/open-Q/user/verification"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 const (
-	envMongoConn = "mongo:conn"
-	envMongoDB   = "mongo:db"
+	envMongoConn                   = "mongo:conn"
+	envMongoDB                     = "mongo:db"
+	envMongoReadPreference         = "mongo:read-preference"
+	envMongoReadConcern            = "mongo:read-concern"
+	envMongoWriteConcern           = "mongo:write-concern"
+	envMongoMinPoolSize            = "mongo:min-pool-size"
+	envMongoMaxPoolSize            = "mongo:max-pool-size"
+	envMongoConnectTimeout         = "mongo:connect-timeout"
+	envMongoServerSelectionTimeout = "mongo:server-selection-timeout"
+	envMongoSocketTimeout          = "mongo:socket-timeout"
+	envMongoTLS                    = "mongo:tls"
+	envMongoTLSCAFile              = "mongo:tls-ca-file"
+	envMongoTLSCertKeyFile         = "mongo:tls-cert-key-file"
+	envMongoTLSCertKeyPassword     = "mongo:tls-cert-key-password"
+	envMongoTLSInsecure            = "mongo:tls-insecure"
+	envMongoAuthMechanism          = "mongo:auth-mechanism"
+	envMongoAuthSource             = "mongo:auth-source"
+
+	envStorageRetryMaxAttempts = "storage:retry-max-attempts"
+	envStorageRetryBaseDelay   = "storage:retry-base-delay"
+	envStorageRetryMaxDelay    = "storage:retry-max-delay"
+	envStorageBreakerThreshold = "storage:breaker-threshold"
+	envStorageBreakerCooldown  = "storage:breaker-cooldown"
+
+	envEncryptionKeyring  = "encryption:keyring"
+	envEncryptionMetaKeys = "encryption:meta-keys"
+
+	envLogRedactMetaKeys = "log:redact-meta-keys"
+
+	envRetentionPolicies = "retention:policies"
+	envRetentionInterval = "retention:interval"
+	envRetentionDryRun   = "retention:dry-run"
+
+	envMetricsAddr = "metrics:addr"
+
+	envTracingExporter    = "tracing:exporter"
+	envTracingEndpoint    = "tracing:endpoint"
+	envTracingInsecure    = "tracing:insecure"
+	envTracingFile        = "tracing:file"
+	envTracingSampleRatio = "tracing:sample-ratio"
+
+	envHealthAddr         = "health:addr"
+	envHealthGRPCAddr     = "health:grpc-addr"
+	envHealthCheckTimeout = "health:check-timeout"
+
+	envShutdownTimeout = "shutdown:timeout"
+
+	envMetaSchemaFile            = "meta-schema:file"
+	envMetaSchemaMode            = "meta-schema:mode"
+	envMetaSchemaAllowUnknown    = "meta-schema:allow-unknown"
+	envMetaSchemaRefreshInterval = "meta-schema:refresh-interval"
+
+	envStatusTransitions       = "status:transitions"
+	envStatusSchedulerInterval = "status:scheduler-interval"
+
+	envMergePolicies = "merge:policies"
+	envMergeTopic    = "merge:topic"
+
+	envRBACCacheTTL        = "rbac:cache-ttl"
+	envRBACCacheSize       = "rbac:cache-size"
+	envRBACRefreshInterval = "rbac:refresh-interval"
+
+	envPasswordAlgorithm         = "password:algorithm"
+	envPasswordArgon2Memory      = "password:argon2-memory"
+	envPasswordArgon2Iterations  = "password:argon2-iterations"
+	envPasswordArgon2Parallelism = "password:argon2-parallelism"
+	envPasswordBcryptCost        = "password:bcrypt-cost"
+	envPasswordMinLength         = "password:min-length"
+
+	envAPIKeyTouchInterval = "api-key:touch-interval"
+
+	envTwoFactorIssuer            = "two-factor:issuer"
+	envTwoFactorSkew              = "two-factor:skew"
+	envTwoFactorRecoveryCodeCount = "two-factor:recovery-code-count"
+
+	envVerificationNotifier     = "verification:notifier"
+	envVerificationNotifierFile = "verification:notifier-file"
+	envVerificationContactKeys  = "verification:contact-keys"
+	envVerificationCodeTTL      = "verification:code-ttl"
+	envVerificationTokenTTL     = "verification:token-ttl"
+	envVerificationRateLimit    = "verification:rate-limit"
+	envVerificationRateWindow   = "verification:rate-window"
+	envVerificationMaxAttempts  = "verification:max-attempts"
+
+	envTokenContactKey   = "token:contact-key"
+	envTokenResetTTL     = "token:reset-ttl"
+	envTokenMagicLinkTTL = "token:magic-link-ttl"
+
+	envLockoutUserThreshold       = "lockout:user-threshold"
+	envLockoutIdentifierThreshold = "lockout:identifier-threshold"
+	envLockoutWindow              = "lockout:window"
+	envLockoutBaseDuration        = "lockout:base-duration"
+	envLockoutMaxDuration         = "lockout:max-duration"
+	envLockoutResetAfter          = "lockout:reset-after"
 )
 
+const serviceName = "user"
+
+// defaultMergeTopic is a topic of the merge events if it's not configured.
+const defaultMergeTopic = "user.merged"
+
 // This variable is assigned during build time using build flags.
 var version string
 
 func main() {
 	ctx := context.Background()
 
+	// run subcommand instead of the service if requested.
+	if len(os.Args) > 1 {
+		if cmd, ok := command.Lookup(os.Args[1]); ok {
+			if err := cmd.Run(ctx, os.Args[2:]); err != nil {
+				log.Fatalf("%s: %v", cmd.Name, err)
+			}
+			return
+		}
+	}
+
 	// initialize logger.
 	logger, err := commonLog.NewFileLogger("./log", fmt.Sprintf("log_%s.json", version), os.ModePerm)
 	if err != nil {
 		log.Fatalf("could not initialize logger: %v", err)
 	}
 
-	// setup interrupt hook.
-	go common.InterruptHook(func() {
-		logger.Error("interrupted")
-		os.Exit(1)
-	})
-
 	// create service instance.
 	microService, flagsMap, err := commonService.New("./.contract/contract.json")
 	if err != nil {
 		logger.Fatalf("could not create service: %v", err)
 	}
+	serviceFlags := flags(flagsMap)
+
+	// mask PII in logs.
+	logging.Redact(logger, logging.NewRedactor(serviceFlags.stringsValue(envLogRedactMetaKeys)))
+
+	// expose health checks, the service isn't ready until it's started.
+	// On SIGINT or SIGTERM it becomes not ready and rejects new requests
+	// before the server deregisters and stops listening.
+	healthChecker := health.NewChecker(serviceFlags.durationValue(envHealthCheckTimeout))
+	drainer := shutdown.NewDrainer()
+	unblockStartup := healthChecker.Block("starting")
+	microService.Init(
+		micro.AfterStart(func() error {
+			unblockStartup()
+			return nil
+		}),
+		micro.BeforeStop(func() error {
+			healthChecker.Block("shutting down")
+			drainer.Close()
+			return nil
+		}),
+	)
+	if healthAddr := serviceFlags.stringValue(envHealthAddr); healthAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(healthAddr, healthChecker.Handler()); err != nil {
+				logger.Errorf("could not serve health checks: %v", err)
+			}
+		}()
+	}
+	var healthServer *grpc.Server
+	if healthGRPCAddr := serviceFlags.stringValue(envHealthGRPCAddr); healthGRPCAddr != "" {
+		listener, err := net.Listen("tcp", healthGRPCAddr)
+		if err != nil {
+			logger.Fatalf("could not listen for gRPC health checks: %v", err)
+		}
+		healthServer = grpc.NewServer()
+		healthpb.RegisterHealthServer(healthServer, health.NewGRPCServer(healthChecker, microService.Name()))
+		go func() {
+			if err := healthServer.Serve(listener); err != nil {
+				logger.Errorf("could not serve gRPC health checks: %v", err)
+			}
+		}()
+	}
+
+	// expose metrics.
+	var serviceMetrics *metrics.Metrics
+	if metricsAddr := serviceFlags.stringValue(envMetricsAddr); metricsAddr != "" {
+		serviceMetrics = metrics.New()
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", serviceMetrics.Handler())
+		go func() {
+			if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+				logger.Errorf("could not serve metrics: %v", err)
+			}
+		}()
+		if err := microService.Server().Init(server.WrapHandler(serviceMetrics.HandlerWrapper())); err != nil {
+			logger.Fatalf("could not instrument service handlers: %v", err)
+		}
+	}
+
+	// setup tracing.
+	var tracerProvider *sdktrace.TracerProvider
+	if tracingConfig := serviceFlags.tracingConfig(); tracingConfig.Exporter != "" {
+		tracerProvider, err = tracing.NewProvider(tracingConfig)
+		if err != nil {
+			logger.Fatalf("could not setup tracing: %v", err)
+		}
+		if err := microService.Server().Init(server.WrapHandler(tracing.HandlerWrapper(tracerProvider))); err != nil {
+			logger.Fatalf("could not trace service handlers: %v", err)
+		}
+	}
 
 	// initialize storage.
-	mongoConnString := flagsMap[envMongoConn]
-	mongoDBName := flagsMap[envMongoDB]
-	userStorage, err := storage.NewMongoStorage(ctx, mongoConnString.Value().(string), mongoDBName.Value().(string))
+	mongoConfig := serviceFlags.mongoConfig()
+	if serviceMetrics != nil {
+		mongoConfig.PoolMonitor = serviceMetrics.PoolMonitor()
+	}
+	if tracerProvider != nil {
+		mongoConfig.CommandMonitor = tracing.CommandMonitor(tracerProvider)
+	}
+	logger.Infof("connecting to storage: %s", mongoConfig)
+	userStorage, err := storage.NewMongoStorage(ctx, mongoConfig)
 	if err != nil {
 		logger.Fatalf("could not create connection to storage: %v", err)
 	}
-	defer func() {
-		if err := userStorage.Disconnect(ctx); err != nil {
-			logger.Errorf("could not close storage connection: %v", err)
+	healthChecker.AddCheck("mongo", userStorage.Ping)
+
+	// retry transient storage failures.
+	var userStore storage.User = resilience.NewStorage(userStorage, serviceFlags.resilienceConfig())
+
+	// setup meta encryption.
+	var cipher *encryption.Cipher
+	if keyringPath := serviceFlags.stringValue(envEncryptionKeyring); keyringPath != "" {
+		keyring, err := encryption.NewFileKeyring(keyringPath)
+		if err != nil {
+			logger.Fatalf("could not load encryption keyring: %v", err)
+		}
+		cipher = encryption.NewCipher(keyring)
+		metaKeys := serviceFlags.stringsValue(envEncryptionMetaKeys)
+		userStore = encryption.NewStorage(userStore, cipher, metaKeys)
+	}
+
+	// instrument storage calls.
+	if tracerProvider != nil {
+		userStore = tracing.NewStorage(userStore, tracerProvider)
+	}
+	if serviceMetrics != nil {
+		userStore = metrics.NewStorage(userStore, serviceMetrics)
+	}
+
+	// start retention job.
+	if interval := serviceFlags.durationValue(envRetentionInterval); interval > 0 {
+		policies := retention.DefaultPolicies()
+		if policiesPath := serviceFlags.stringValue(envRetentionPolicies); policiesPath != "" {
+			if policies, err = retention.LoadPolicies(policiesPath); err != nil {
+				logger.Fatalf("could not load retention policies: %v", err)
+			}
+		}
+		retentionJob, err := retention.NewJob(retention.Config{
+			UserStorage: userStore,
+			Recorder:    retention.NewLogRecorder(logger),
+			Policies:    policies,
+			Interval:    interval,
+			DryRun:      serviceFlags.boolValue(envRetentionDryRun),
+		})
+		if err != nil {
+			logger.Fatalf("could not create retention job: %v", err)
+		}
+		drainer.Go(retentionJob.Start)
+	}
+
+	// load meta schema, fields managed through RPCs are reloaded in background.
+	var metaFields []storageModel.MetaField
+	if metaSchemaPath := serviceFlags.stringValue(envMetaSchemaFile); metaSchemaPath != "" {
+		if metaFields, err = meta.LoadFields(metaSchemaPath); err != nil {
+			logger.Fatalf("could not load meta schema: %v", err)
 		}
-	}()
+	}
+	metaSchema, err := meta.NewRegistry(meta.RegistryConfig{
+		Fields:          metaFields,
+		Storage:         storage.NewMongoMetaSchemaStorage(userStorage),
+		Logger:          logger,
+		Mode:            meta.Mode(serviceFlags.stringValue(envMetaSchemaMode)),
+		AllowUnknown:    serviceFlags.boolValue(envMetaSchemaAllowUnknown),
+		RefreshInterval: serviceFlags.durationValue(envMetaSchemaRefreshInterval),
+	})
+	if err != nil {
+		logger.Fatalf("could not create meta schema: %v", err)
+	}
+	if err := metaSchema.Load(ctx); err != nil {
+		logger.Fatalf("could not load meta schema: %v", err)
+	}
+	drainer.Go(metaSchema.Start)
+
+	// load status lifecycle.
+	statusMachine := status.DefaultMachine()
+	if transitionsPath := serviceFlags.stringValue(envStatusTransitions); transitionsPath != "" {
+		if statusMachine, err = status.LoadMachine(transitionsPath); err != nil {
+			logger.Fatalf("could not load status transitions: %v", err)
+		}
+	}
+
+	groupStore, err := storage.NewMongoGroupStorage(ctx, userStorage)
+	if err != nil {
+		logger.Fatalf("could not create group storage: %v", err)
+	}
+
+	identityStore, err := storage.NewMongoIdentityStorage(ctx, userStorage)
+	if err != nil {
+		logger.Fatalf("could not create identity storage: %v", err)
+	}
+
+	// load roles, roles changed by other replicas are reloaded in background.
+	roleStore := storage.NewMongoRoleStorage(userStorage)
+	permissions := rbac.NewChecker(rbac.Config{
+		RoleStorage:     roleStore,
+		UserStorage:     userStore,
+		Logger:          logger,
+		CacheTTL:        serviceFlags.durationValue(envRBACCacheTTL),
+		CacheSize:       serviceFlags.intValue(envRBACCacheSize),
+		RefreshInterval: serviceFlags.durationValue(envRBACRefreshInterval),
+	})
+	if err := permissions.Load(ctx); err != nil {
+		logger.Fatalf("could not load roles: %v", err)
+	}
+	drainer.Go(permissions.Start)
+
+	// start status scheduler, it's safe to run on every replica.
+	statusScheduler := status.NewScheduler(status.SchedulerConfig{
+		UserStorage: userStore,
+		Machine:     statusMachine,
+		Logger:      logger,
+		OnChange:    permissions.Invalidate,
+		Interval:    serviceFlags.durationValue(envStatusSchedulerInterval),
+	})
+	drainer.Go(statusScheduler.Start)
+
+	// passwords hashed with outdated parameters are rehashed on verification.
+	credentials, err := credential.NewManager(credential.Config{
+		Storage:   storage.NewMongoCredentialStorage(userStorage),
+		Params:    serviceFlags.passwordParams(),
+		MinLength: serviceFlags.intValue(envPasswordMinLength),
+		Logger:    logger,
+	})
+	if err != nil {
+		logger.Fatalf("could not create password manager: %v", err)
+	}
+
+	// API key usage is written in batches, pending usage is written on shutdown.
+	apiKeyStore, err := storage.NewMongoAPIKeyStorage(ctx, userStorage)
+	if err != nil {
+		logger.Fatalf("could not create API key storage: %v", err)
+	}
+	apiKeys := credential.NewKeyManager(credential.KeyConfig{
+		Storage:       apiKeyStore,
+		TouchInterval: serviceFlags.durationValue(envAPIKeyTouchInterval),
+		Logger:        logger,
+	})
+	drainer.Go(apiKeys.Start)
+
+	// TOTP secrets are encrypted, so two-factor is only available with the keyring.
+	var twoFactor *credential.TwoFactorManager
+	if cipher != nil {
+		twoFactor, err = credential.NewTwoFactorManager(credential.TwoFactorConfig{
+			Storage:           storage.NewMongoTwoFactorStorage(userStorage),
+			Cipher:            cipher,
+			Issuer:            serviceFlags.stringValue(envTwoFactorIssuer),
+			Skew:              serviceFlags.intValue(envTwoFactorSkew),
+			RecoveryCodeCount: serviceFlags.intValue(envTwoFactorRecoveryCodeCount),
+		})
+		if err != nil {
+			logger.Fatalf("could not create two-factor manager: %v", err)
+		}
+	}
+
+	// contacts are verified and tokens are issued only if a notifier delivering them is configured.
+	var verifier *verification.Manager
+	var tokens *credential.TokenManager
+	var notifier verification.Notifier
+	switch name := serviceFlags.stringValue(envVerificationNotifier); name {
+	case "":
+	case "log":
+		notifier = verification.NewLogNotifier(logger)
+	case "file":
+		notifier = verification.NewFileNotifier(serviceFlags.stringValue(envVerificationNotifierFile))
+	default:
+		logger.Fatalf("unknown verification notifier %q", name)
+	}
+	if notifier != nil {
+		verificationStore, err := storage.NewMongoVerificationStorage(ctx, userStorage)
+		if err != nil {
+			logger.Fatalf("could not create verification storage: %v", err)
+		}
+		verifier, err = verification.NewManager(verification.Config{
+			Storage:     verificationStore,
+			Notifier:    notifier,
+			ContactKeys: serviceFlags.stringsValue(envVerificationContactKeys),
+			CodeTTL:     serviceFlags.durationValue(envVerificationCodeTTL),
+			TokenTTL:    serviceFlags.durationValue(envVerificationTokenTTL),
+			RateLimit:   serviceFlags.intValue(envVerificationRateLimit),
+			RateWindow:  serviceFlags.durationValue(envVerificationRateWindow),
+			MaxAttempts: serviceFlags.intValue(envVerificationMaxAttempts),
+		})
+		if err != nil {
+			logger.Fatalf("could not create verification manager: %v", err)
+		}
+
+		tokenStore, err := storage.NewMongoTokenStorage(ctx, userStorage)
+		if err != nil {
+			logger.Fatalf("could not create token storage: %v", err)
+		}
+		tokens = credential.NewTokenManager(credential.TokenConfig{
+			Storage:      tokenStore,
+			Notifier:     notifier,
+			ContactKey:   serviceFlags.stringValue(envTokenContactKey),
+			ResetTTL:     serviceFlags.durationValue(envTokenResetTTL),
+			MagicLinkTTL: serviceFlags.durationValue(envTokenMagicLinkTTL),
+		})
+	}
+
+	// failed logins are tracked in the storage, so gateway replicas share lockouts.
+	lockoutStore, err := storage.NewMongoLockoutStorage(ctx, userStorage)
+	if err != nil {
+		logger.Fatalf("could not create lockout storage: %v", err)
+	}
+	lockouts, err := lockout.NewManager(lockout.Config{
+		Storage:             lockoutStore,
+		UserThreshold:       serviceFlags.intValue(envLockoutUserThreshold),
+		IdentifierThreshold: serviceFlags.intValue(envLockoutIdentifierThreshold),
+		Window:              serviceFlags.durationValue(envLockoutWindow),
+		BaseDuration:        serviceFlags.durationValue(envLockoutBaseDuration),
+		MaxDuration:         serviceFlags.durationValue(envLockoutMaxDuration),
+		ResetAfter:          serviceFlags.durationValue(envLockoutResetAfter),
+	})
+	if err != nil {
+		logger.Fatalf("could not create lockout manager: %v", err)
+	}
+
+	// load meta conflict policies of user merges.
+	var mergePolicies meta.MergePolicies
+	if mergePoliciesPath := serviceFlags.stringValue(envMergePolicies); mergePoliciesPath != "" {
+		if mergePolicies, err = meta.LoadMergePolicies(mergePoliciesPath); err != nil {
+			logger.Fatalf("could not load merge policies: %v", err)
+		}
+	}
+	mergeTopic := serviceFlags.stringValue(envMergeTopic)
+	if mergeTopic == "" {
+		mergeTopic = defaultMergeTopic
+	}
+
+	// track in-flight requests to drain them on shutdown.
+	if err := microService.Server().Init(server.WrapHandler(drainer.HandlerWrapper())); err != nil {
+		logger.Fatalf("could not track service handlers: %v", err)
+	}
 
 	// register service controller.
 	service := controller.New(controller.Config{
-		Logger:      logger,
-		UserStorage: userStorage,
+		Logger:          logger,
+		UserStorage:     userStore,
+		GroupStorage:    groupStore,
+		RoleStorage:     roleStore,
+		IdentityStorage: identityStore,
+		Permissions:     permissions,
+		Credentials:     credentials,
+		APIKeys:         apiKeys,
+		TwoFactor:       twoFactor,
+		Verifier:        verifier,
+		Tokens:          tokens,
+		Lockouts:        lockouts,
+		HistorySources:  command.HistorySources(userStore, identityStore, apiKeyStore),
+		MetaSchema:      metaSchema,
+		StatusMachine:   statusMachine,
+		MergePolicies:   mergePolicies,
+		MergePublisher:  micro.NewEvent(mergeTopic, microService.Client()),
 	})
 	if err := proto.RegisterUserHandler(microService.Server(), service); err != nil {
 		logger.Fatalf("could not register service controller: %v", err)
 	}
 
-	// run service.
+	// run service until SIGINT or SIGTERM.
 	logger.Infof("service started, version: %s", version)
 	if err := microService.Run(); err != nil {
 		logger.Fatalf("could not run service: %v", err)
 	}
+
+	// drain in-flight requests and background workers.
+	shutdownTimeout := serviceFlags.durationValue(envShutdownTimeout)
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = shutdown.DefaultTimeout
+	}
+	logger.Infof("shutting down, timeout: %s", shutdownTimeout)
+	drainCtx, cancelDrain := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancelDrain()
+	if err := drainer.Drain(drainCtx); err != nil {
+		logger.Errorf("could not shut down gracefully: %v", err)
+	}
+	if healthServer != nil {
+		healthServer.Stop()
+	}
+
+	// flush spans, close the storage connection and flush logs.
+	closeCtx, cancelClose := context.WithTimeout(ctx, shutdownTimeout)
+	defer cancelClose()
+	if tracerProvider != nil {
+		if err := tracerProvider.Shutdown(closeCtx); err != nil {
+			logger.Errorf("could not flush spans: %v", err)
+		}
+	}
+	if err := userStorage.Disconnect(closeCtx); err != nil {
+		logger.Errorf("could not close storage connection: %v", err)
+	}
 	logger.Info("service stopped")
+	if err := logging.Flush(logger); err != nil {
+		log.Printf("could not flush logs: %v", err)
+	}
 }
@@ -0,0 +1,46 @@
+package meta
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+type fieldFile struct {
+	Key      string `json:"key"`
+	Type     string `json:"type"`
+	Format   string `json:"format"`
+	Required bool   `json:"required"`
+	MaxSize  int    `json:"max_size"`
+}
+
+// LoadFields loads meta schema fields from the JSON file.
+func LoadFields(path string) ([]model.MetaField, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s file data", path)
+	}
+
+	var files []fieldFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, errors.Wrap(err, "could not parse meta schema file")
+	}
+
+	fields := make([]model.MetaField, len(files))
+	for i := range files {
+		fields[i] = model.MetaField{
+			Key:      files[i].Key,
+			Type:     files[i].Type,
+			Format:   files[i].Format,
+			Required: files[i].Required,
+			MaxSize:  files[i].MaxSize,
+		}
+		if err := ValidateField(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return fields, nil
+}
@@ -0,0 +1,48 @@
+package meta
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFieldsFile(t *testing.T, data string) string {
+	dir, err := ioutil.TempDir("", "meta")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	fPath := path.Join(dir, "schema.json")
+	require.NoError(t, ioutil.WriteFile(fPath, []byte(data), os.ModePerm))
+	return fPath
+}
+
+func TestLoadFields(t *testing.T) {
+	t.Run("read file error", func(t *testing.T) {
+		_, err := LoadFields("/not/existing/schema.json")
+		require.Error(t, err)
+	})
+	t.Run("parse error", func(t *testing.T) {
+		_, err := LoadFields(writeFieldsFile(t, "{"))
+		require.Error(t, err)
+	})
+	t.Run("invalid field", func(t *testing.T) {
+		_, err := LoadFields(writeFieldsFile(t, `[{"key": "age", "type": "number"}]`))
+		require.EqualError(t, err, `unknown age meta field type "number"`)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		fields, err := LoadFields(writeFieldsFile(t, `[
+			{"key": "email", "type": "string", "format": "email", "required": true, "max_size": 254},
+			{"key": "age", "type": "int"}
+		]`))
+		require.NoError(t, err)
+		require.Equal(t, []model.MetaField{
+			{Key: "email", Type: TypeString, Format: FormatEmail, Required: true, MaxSize: 254},
+			{Key: "age", Type: TypeInt},
+		}, fields)
+	})
+}
@@ -0,0 +1,144 @@
+package meta
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// MergePolicy represents resolution policy of meta keys both merged users have.
+type MergePolicy string
+
+// There are available merge policies.
+const (
+	// MergePreferTarget keeps the target user's value.
+	MergePreferTarget MergePolicy = "prefer_target"
+	// MergePreferNewest keeps the value of the user updated last.
+	MergePreferNewest MergePolicy = "prefer_newest"
+	// MergeUnion combines list values keeping the target's order,
+	// other values are resolved as MergePreferTarget.
+	MergeUnion MergePolicy = "union"
+)
+
+// MergePolicies represents meta conflict policies of user merges.
+type MergePolicies struct {
+	// Default is used for keys without own policy, MergePreferTarget if empty.
+	Default MergePolicy
+	Keys    map[string]MergePolicy
+}
+
+type mergePoliciesFile struct {
+	Default MergePolicy            `json:"default"`
+	Keys    map[string]MergePolicy `json:"keys"`
+}
+
+// LoadMergePolicies loads merge policies from the JSON file.
+func LoadMergePolicies(path string) (MergePolicies, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return MergePolicies{}, errors.Wrapf(err, "could not read %s file data", path)
+	}
+
+	var file mergePoliciesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return MergePolicies{}, errors.Wrap(err, "could not parse merge policies file")
+	}
+
+	policies := MergePolicies{
+		Default: file.Default,
+		Keys:    file.Keys,
+	}
+	if err := policies.Validate(); err != nil {
+		return MergePolicies{}, err
+	}
+	return policies, nil
+}
+
+// Validate validates merge policies.
+func (p MergePolicies) Validate() error {
+	if p.Default != "" && !validMergePolicy(p.Default) {
+		return errors.Errorf("unknown default merge policy %q", p.Default)
+	}
+	for k, v := range p.Keys {
+		if !validMergePolicy(v) {
+			return errors.Errorf("unknown %s meta merge policy %q", k, v)
+		}
+	}
+	return nil
+}
+
+// Merge returns meta of the target user merged with the source user's one.
+// Keys only one of the users has are always kept.
+func (p MergePolicies) Merge(target, source model.User) (map[string]interface{}, map[string]string) {
+	values := make(map[string]interface{}, len(target.Meta)+len(source.Meta))
+	types := make(map[string]string, len(target.MetaTypes)+len(source.MetaTypes))
+	for k, v := range source.Meta {
+		values[k] = v
+		if t, ok := source.MetaTypes[k]; ok {
+			types[k] = t
+		}
+	}
+	for k, v := range target.Meta {
+		if sourceValue, ok := source.Meta[k]; ok {
+			switch p.policy(k) {
+			case MergePreferNewest:
+				if source.UpdatedAt.After(target.UpdatedAt) {
+					continue
+				}
+			case MergeUnion:
+				v = union(v, sourceValue)
+			}
+		}
+		values[k] = v
+		delete(types, k)
+		if t, ok := target.MetaTypes[k]; ok {
+			types[k] = t
+		}
+	}
+	return values, types
+}
+
+// policy returns policy of the meta key.
+func (p MergePolicies) policy(key string) MergePolicy {
+	if policy, ok := p.Keys[key]; ok {
+		return policy
+	}
+	if p.Default != "" {
+		return p.Default
+	}
+	return MergePreferTarget
+}
+
+// union returns target list extended with the source list's values it doesn't have.
+// The target value is returned if any of the values isn't a list.
+func union(target, source interface{}) interface{} {
+	targetList, ok := target.([]interface{})
+	if !ok {
+		return target
+	}
+	sourceList, ok := source.([]interface{})
+	if !ok {
+		return target
+	}
+	res := append([]interface{}(nil), targetList...)
+	for _, v := range sourceList {
+		var found bool
+		for i := range res {
+			if reflect.DeepEqual(res[i], v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func validMergePolicy(policy MergePolicy) bool {
+	return policy == MergePreferTarget || policy == MergePreferNewest || policy == MergeUnion
+}
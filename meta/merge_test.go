@@ -0,0 +1,106 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMergePolicies(t *testing.T) {
+	t.Run("read file error", func(t *testing.T) {
+		_, err := LoadMergePolicies("/not/existing/merge.json")
+		require.Error(t, err)
+	})
+	t.Run("parse error", func(t *testing.T) {
+		_, err := LoadMergePolicies(writeFieldsFile(t, "{"))
+		require.Error(t, err)
+	})
+	t.Run("unknown default policy", func(t *testing.T) {
+		_, err := LoadMergePolicies(writeFieldsFile(t, `{"default": "prefer_source"}`))
+		require.EqualError(t, err, `unknown default merge policy "prefer_source"`)
+	})
+	t.Run("unknown key policy", func(t *testing.T) {
+		_, err := LoadMergePolicies(writeFieldsFile(t, `{"keys": {"tags": "concat"}}`))
+		require.EqualError(t, err, `unknown tags meta merge policy "concat"`)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		policies, err := LoadMergePolicies(writeFieldsFile(t, `{"default": "prefer_newest", "keys": {"tags": "union"}}`))
+		require.NoError(t, err)
+		require.Equal(t, MergePolicies{
+			Default: MergePreferNewest,
+			Keys:    map[string]MergePolicy{"tags": MergeUnion},
+		}, policies)
+	})
+}
+
+func TestMergePolicies_Merge(t *testing.T) {
+	now := time.Now()
+	target := model.User{
+		Meta: map[string]interface{}{
+			"name":  "John",
+			"city":  "Kyiv",
+			"tags":  []interface{}{"a", "b"},
+			"email": "john@example.com",
+		},
+		MetaTypes: map[string]string{
+			"name": "type.googleapis.com/google.protobuf.StringValue",
+		},
+		UpdatedAt: now.Add(-time.Hour),
+	}
+	source := model.User{
+		Meta: map[string]interface{}{
+			"name":  "Johnny",
+			"city":  "Lviv",
+			"tags":  []interface{}{"b", "c"},
+			"phone": "+380000000000",
+		},
+		MetaTypes: map[string]string{
+			"name":  "type.googleapis.com/google.protobuf.Value",
+			"phone": "type.googleapis.com/google.protobuf.StringValue",
+		},
+		UpdatedAt: now,
+	}
+	t.Run("all ok (prefer target by default)", func(t *testing.T) {
+		values, types := MergePolicies{}.Merge(target, source)
+		require.Equal(t, map[string]interface{}{
+			"name":  "John",
+			"city":  "Kyiv",
+			"tags":  []interface{}{"a", "b"},
+			"email": "john@example.com",
+			"phone": "+380000000000",
+		}, values)
+		require.Equal(t, map[string]string{
+			"name":  "type.googleapis.com/google.protobuf.StringValue",
+			"phone": "type.googleapis.com/google.protobuf.StringValue",
+		}, types)
+	})
+	t.Run("all ok (key policies)", func(t *testing.T) {
+		values, types := MergePolicies{
+			Keys: map[string]MergePolicy{
+				"name": MergePreferNewest,
+				"tags": MergeUnion,
+				"city": MergeUnion,
+			},
+		}.Merge(target, source)
+		require.Equal(t, map[string]interface{}{
+			"name":  "Johnny",
+			"city":  "Kyiv",
+			"tags":  []interface{}{"a", "b", "c"},
+			"email": "john@example.com",
+			"phone": "+380000000000",
+		}, values)
+		require.Equal(t, map[string]string{
+			"name":  "type.googleapis.com/google.protobuf.Value",
+			"phone": "type.googleapis.com/google.protobuf.StringValue",
+		}, types)
+	})
+	t.Run("all ok (prefer newest target)", func(t *testing.T) {
+		newest := target
+		newest.UpdatedAt = now.Add(time.Hour)
+		values, _ := MergePolicies{Default: MergePreferNewest}.Merge(newest, source)
+		require.Equal(t, "John", values["name"])
+		require.Equal(t, "Kyiv", values["city"])
+	})
+}
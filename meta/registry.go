@@ -0,0 +1,201 @@
+package meta
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// Mode represents meta schema enforcement mode.
+type Mode string
+
+// There are available meta schema enforcement modes.
+const (
+	// ModeEnforce rejects writes which violate the schema.
+	ModeEnforce Mode = "enforce"
+	// ModeWarn only reports schema violations, e.g. while the schema is rolled out.
+	ModeWarn Mode = "warn"
+)
+
+// DefaultRefreshInterval is a default period between stored schema reloads.
+const DefaultRefreshInterval = time.Minute
+
+// RegistryConfig represents meta schema registry configuration.
+type RegistryConfig struct {
+	// Fields are defined in the service configuration.
+	// Fields managed through RPCs take precedence over them.
+	Fields []model.MetaField
+	// Storage keeps fields managed through RPCs, the schema is read-only if empty.
+	Storage storage.MetaSchema
+	// Logger reports failed reloads of the stored fields.
+	Logger *commonLog.Logger
+	Mode   Mode
+	// AllowUnknown makes keys missing in the schema valid.
+	AllowUnknown bool
+	// RefreshInterval is a period between stored fields reloads,
+	// so changes made by other replicas are picked up.
+	RefreshInterval time.Duration
+}
+
+// Registry represents meta schema registry.
+// The schema is empty until it has fields, and empty schema accepts any meta.
+type Registry struct {
+	storage         storage.MetaSchema
+	logger          *commonLog.Logger
+	mode            Mode
+	allowUnknown    bool
+	refreshInterval time.Duration
+
+	mu     sync.RWMutex
+	config map[string]model.MetaField
+	stored map[string]model.MetaField
+}
+
+// NewRegistry creates new Registry instance.
+func NewRegistry(cfg RegistryConfig) (*Registry, error) {
+	mode := cfg.Mode
+	if mode == "" {
+		mode = ModeEnforce
+	}
+	if mode != ModeEnforce && mode != ModeWarn {
+		return nil, errors.Errorf("unknown meta schema mode %q", mode)
+	}
+	refreshInterval := cfg.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+	config := make(map[string]model.MetaField, len(cfg.Fields))
+	for i := range cfg.Fields {
+		if err := ValidateField(cfg.Fields[i]); err != nil {
+			return nil, err
+		}
+		if _, ok := config[cfg.Fields[i].Key]; ok {
+			return nil, errors.Errorf("duplicate %s meta field", cfg.Fields[i].Key)
+		}
+		config[cfg.Fields[i].Key] = cfg.Fields[i]
+	}
+	return &Registry{
+		storage:         cfg.Storage,
+		logger:          cfg.Logger,
+		mode:            mode,
+		allowUnknown:    cfg.AllowUnknown,
+		refreshInterval: refreshInterval,
+		config:          config,
+		stored:          map[string]model.MetaField{},
+	}, nil
+}
+
+// Mode returns schema enforcement mode.
+func (r *Registry) Mode() Mode {
+	return r.mode
+}
+
+// Load reloads fields managed through RPCs from the storage.
+func (r *Registry) Load(ctx context.Context) error {
+	if r.storage == nil {
+		return nil
+	}
+	fields, err := r.storage.ListMetaFields(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not load meta schema")
+	}
+	stored := make(map[string]model.MetaField, len(fields))
+	for i := range fields {
+		stored[fields[i].Key] = fields[i]
+	}
+	r.mu.Lock()
+	r.stored = stored
+	r.mu.Unlock()
+	return nil
+}
+
+// Start reloads stored fields on every refresh interval until the context is canceled.
+// Failed reloads keep the previously loaded fields.
+func (r *Registry) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Load(ctx); err != nil && ctx.Err() == nil {
+				r.logger.WithError(err).Error("could not reload meta schema")
+			}
+		}
+	}
+}
+
+// Fields returns all schema fields sorted by key.
+func (r *Registry) Fields() []model.MetaField {
+	fields := r.fields()
+	res := make([]model.MetaField, 0, len(fields))
+	for _, field := range fields {
+		res = append(res, field)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
+
+// Put creates or replaces schema field.
+func (r *Registry) Put(ctx context.Context, field model.MetaField) error {
+	if err := ValidateField(field); err != nil {
+		return err
+	}
+	if r.storage == nil {
+		return errors.New("meta schema is read-only")
+	}
+	if err := r.storage.PutMetaField(ctx, field); err != nil {
+		return errors.Wrapf(err, "could not put %s meta field", field.Key)
+	}
+	r.mu.Lock()
+	r.stored[field.Key] = field
+	r.mu.Unlock()
+	return nil
+}
+
+// Delete removes schema field managed through RPCs.
+// Field defined in the configuration is restored then.
+func (r *Registry) Delete(ctx context.Context, key string) error {
+	if r.storage == nil {
+		return errors.New("meta schema is read-only")
+	}
+	if err := r.storage.DeleteMetaField(ctx, key); err != nil {
+		return errors.Wrapf(err, "could not delete %s meta field", key)
+	}
+	r.mu.Lock()
+	delete(r.stored, key)
+	r.mu.Unlock()
+	return nil
+}
+
+// Validate validates meta values against the schema.
+func (r *Registry) Validate(values map[string]interface{}) Violations {
+	fields := r.fields()
+	if len(fields) == 0 {
+		return nil
+	}
+	return Validate(fields, values, r.allowUnknown)
+}
+
+// fields returns configured fields overridden by the stored ones.
+func (r *Registry) fields() map[string]model.MetaField {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fields := make(map[string]model.MetaField, len(r.config)+len(r.stored))
+	for k, v := range r.config {
+		fields[k] = v
+	}
+	for k, v := range r.stored {
+		fields[k] = v
+	}
+	return fields
+}
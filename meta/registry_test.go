@@ -0,0 +1,108 @@
+package meta
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errMock = errors.New("error")
+
+func TestNewRegistry(t *testing.T) {
+	t.Run("unknown mode", func(t *testing.T) {
+		_, err := NewRegistry(RegistryConfig{Mode: "strict"})
+		require.EqualError(t, err, `unknown meta schema mode "strict"`)
+	})
+	t.Run("invalid field", func(t *testing.T) {
+		_, err := NewRegistry(RegistryConfig{
+			Fields: []model.MetaField{{Key: "age"}},
+		})
+		require.EqualError(t, err, `unknown age meta field type ""`)
+	})
+	t.Run("duplicate field", func(t *testing.T) {
+		_, err := NewRegistry(RegistryConfig{
+			Fields: []model.MetaField{
+				{Key: "age", Type: TypeInt},
+				{Key: "age", Type: TypeFloat},
+			},
+		})
+		require.EqualError(t, err, "duplicate age meta field")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		r, err := NewRegistry(RegistryConfig{})
+		require.NoError(t, err)
+		require.Equal(t, ModeEnforce, r.Mode())
+		require.Equal(t, DefaultRefreshInterval, r.refreshInterval)
+		require.Empty(t, r.Fields())
+		require.Empty(t, r.Validate(map[string]interface{}{"any": 1}))
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	age := model.MetaField{Key: "age", Type: TypeInt}
+	t.Run("read-only", func(t *testing.T) {
+		r, err := NewRegistry(RegistryConfig{})
+		require.NoError(t, err)
+		require.NoError(t, r.Load(context.Background()))
+		require.EqualError(t, r.Put(context.Background(), age), "meta schema is read-only")
+		require.EqualError(t, r.Delete(context.Background(), age.Key), "meta schema is read-only")
+	})
+	t.Run("storage errors", func(t *testing.T) {
+		st := new(storageMocks.MetaSchema)
+		defer st.AssertExpectations(t)
+		st.On("ListMetaFields", mock.Anything).Return(nil, errMock)
+		st.On("PutMetaField", mock.Anything, age).Return(errMock)
+		st.On("DeleteMetaField", mock.Anything, age.Key).Return(errMock)
+		r, err := NewRegistry(RegistryConfig{Storage: st})
+		require.NoError(t, err)
+		require.EqualError(t, r.Load(context.Background()), "could not load meta schema: error")
+		require.EqualError(t, r.Put(context.Background(), age), "could not put age meta field: error")
+		require.EqualError(t, r.Delete(context.Background(), age.Key), "could not delete age meta field: error")
+		require.Empty(t, r.Fields())
+	})
+	t.Run("invalid field", func(t *testing.T) {
+		r, err := NewRegistry(RegistryConfig{Storage: new(storageMocks.MetaSchema)})
+		require.NoError(t, err)
+		require.EqualError(t, r.Put(context.Background(), model.MetaField{Type: TypeInt}), "meta field key is required")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		email := model.MetaField{Key: "email", Type: TypeString, Required: true}
+		storedAge := model.MetaField{Key: "age", Type: TypeFloat}
+		name := model.MetaField{Key: "name", Type: TypeString}
+		st := new(storageMocks.MetaSchema)
+		defer st.AssertExpectations(t)
+		st.On("ListMetaFields", mock.Anything).Return([]model.MetaField{storedAge}, nil)
+		st.On("PutMetaField", mock.Anything, name).Return(nil)
+		st.On("DeleteMetaField", mock.Anything, storedAge.Key).Return(nil)
+		r, err := NewRegistry(RegistryConfig{
+			Fields:  []model.MetaField{email, age},
+			Storage: st,
+			Mode:    ModeWarn,
+		})
+		require.NoError(t, err)
+		require.Equal(t, ModeWarn, r.Mode())
+
+		require.NoError(t, r.Load(context.Background()))
+		require.Equal(t, []model.MetaField{storedAge, email}, r.Fields())
+
+		require.NoError(t, r.Put(context.Background(), name))
+		require.Equal(t, []model.MetaField{storedAge, email, name}, r.Fields())
+
+		require.NoError(t, r.Delete(context.Background(), storedAge.Key))
+		require.Equal(t, []model.MetaField{age, email, name}, r.Fields())
+
+		require.Equal(t, Violations{
+			{Key: "age", Reason: "must be int, got float"},
+			{Key: "email", Reason: "is required"},
+			{Key: "other", Reason: "is not allowed"},
+		}, r.Validate(map[string]interface{}{
+			"age":   1.5,
+			"other": "value",
+		}))
+	})
+}
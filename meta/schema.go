@@ -0,0 +1,208 @@
+package meta
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are meta value types declared by the schema.
+const (
+	TypeString    = "string"
+	TypeBool      = "bool"
+	TypeInt       = "int"
+	TypeFloat     = "float"
+	TypeBytes     = "bytes"
+	TypeTimestamp = "timestamp"
+	TypeObject    = "object"
+	TypeList      = "list"
+)
+
+// There are string meta value formats declared by the schema.
+const (
+	FormatEmail = "email"
+	FormatURI   = "uri"
+	FormatUUID  = "uuid"
+	// FormatPhone is an E.164 phone number.
+	FormatPhone = "phone"
+	// FormatDate is a date in the YYYY-MM-DD form.
+	FormatDate = "date"
+)
+
+var (
+	uuidRegexp  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	phoneRegexp = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+)
+
+// formats contains string meta value format validators.
+var formats = map[string]func(s string) bool{
+	FormatEmail: func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	FormatURI: func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+	},
+	FormatUUID:  uuidRegexp.MatchString,
+	FormatPhone: phoneRegexp.MatchString,
+	FormatDate: func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	},
+}
+
+// types contains meta value types declared by the schema.
+var types = map[string]struct{}{
+	TypeString:    {},
+	TypeBool:      {},
+	TypeInt:       {},
+	TypeFloat:     {},
+	TypeBytes:     {},
+	TypeTimestamp: {},
+	TypeObject:    {},
+	TypeList:      {},
+}
+
+// Violation represents meta value which doesn't match the schema.
+type Violation struct {
+	Key    string
+	Reason string
+}
+
+// Violations represents all schema violations of the user's meta.
+type Violations []Violation
+
+// Error returns violations as a string value.
+func (v Violations) Error() string {
+	reasons := make([]string, len(v))
+	for i := range v {
+		reasons[i] = v[i].Key + ": " + v[i].Reason
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// Keys returns keys of the invalid meta values.
+func (v Violations) Keys() []string {
+	keys := make([]string, len(v))
+	for i := range v {
+		keys[i] = v[i].Key
+	}
+	return keys
+}
+
+// ValidateField validates meta schema field definition.
+func ValidateField(field model.MetaField) error {
+	if strings.TrimSpace(field.Key) == "" {
+		return errors.New("meta field key is required")
+	}
+	if _, ok := types[field.Type]; !ok {
+		return errors.Errorf("unknown %s meta field type %q", field.Key, field.Type)
+	}
+	if field.Format != "" {
+		if field.Type != TypeString {
+			return errors.Errorf("%s meta field of %s type can't have format", field.Key, field.Type)
+		}
+		if _, ok := formats[field.Format]; !ok {
+			return errors.Errorf("unknown %s meta field format %q", field.Key, field.Format)
+		}
+	}
+	if field.MaxSize < 0 {
+		return errors.Errorf("%s meta field max size must not be negative", field.Key)
+	}
+	return nil
+}
+
+// Validate validates meta values against the schema fields.
+// Values of unknown keys are reported unless allowUnknown is set.
+// Violations are sorted by key and never contain the values themselves.
+func Validate(fields map[string]model.MetaField, values map[string]interface{}, allowUnknown bool) Violations {
+	var violations Violations
+	for key, field := range fields {
+		if v, ok := values[key]; field.Required && (!ok || v == nil) {
+			violations = append(violations, Violation{Key: key, Reason: "is required"})
+		}
+	}
+	for key, value := range values {
+		if value == nil {
+			continue
+		}
+		field, ok := fields[key]
+		if !ok {
+			if !allowUnknown {
+				violations = append(violations, Violation{Key: key, Reason: "is not allowed"})
+			}
+			continue
+		}
+		if reason := validateValue(field, Native(value)); reason != "" {
+			violations = append(violations, Violation{Key: key, Reason: reason})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].Key < violations[j].Key
+	})
+	return violations
+}
+
+// validateValue returns the reason the value doesn't match the field, empty if it does.
+func validateValue(field model.MetaField, value interface{}) string {
+	valueType := typeOf(value)
+	// integers are valid floats.
+	if valueType != field.Type && !(field.Type == TypeFloat && valueType == TypeInt) {
+		return fmt.Sprintf("must be %s, got %s", field.Type, valueType)
+	}
+	if field.Format != "" && !formats[field.Format](value.(string)) {
+		return fmt.Sprintf("must be %s formatted", field.Format)
+	}
+	if size, ok := sizeOf(value); ok && field.MaxSize > 0 && size > field.MaxSize {
+		return fmt.Sprintf("must not be longer than %d", field.MaxSize)
+	}
+	return ""
+}
+
+// typeOf returns schema type of the native meta value.
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return TypeString
+	case bool:
+		return TypeBool
+	case int, int32, int64:
+		return TypeInt
+	case float32, float64:
+		return TypeFloat
+	case []byte:
+		return TypeBytes
+	case time.Time:
+		return TypeTimestamp
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeList
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// sizeOf returns size of the native meta value, false if the value has no size.
+func sizeOf(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case string:
+		return utf8.RuneCountInString(v), true
+	case []byte:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	case []interface{}:
+		return len(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidateField(t *testing.T) {
+	tt := []struct {
+		name  string
+		field model.MetaField
+		err   string
+	}{
+		{
+			name:  "empty key",
+			field: model.MetaField{Type: TypeString},
+			err:   "meta field key is required",
+		},
+		{
+			name:  "unknown type",
+			field: model.MetaField{Key: "age", Type: "number"},
+			err:   `unknown age meta field type "number"`,
+		},
+		{
+			name:  "format of non-string type",
+			field: model.MetaField{Key: "age", Type: TypeInt, Format: FormatEmail},
+			err:   "age meta field of int type can't have format",
+		},
+		{
+			name:  "unknown format",
+			field: model.MetaField{Key: "email", Type: TypeString, Format: "mail"},
+			err:   `unknown email meta field format "mail"`,
+		},
+		{
+			name:  "negative max size",
+			field: model.MetaField{Key: "name", Type: TypeString, MaxSize: -1},
+			err:   "name meta field max size must not be negative",
+		},
+		{
+			name:  "all ok",
+			field: model.MetaField{Key: "email", Type: TypeString, Format: FormatEmail, Required: true, MaxSize: 254},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateField(tc.field)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fields := map[string]model.MetaField{
+		"email":    {Key: "email", Type: TypeString, Format: FormatEmail, Required: true},
+		"name":     {Key: "name", Type: TypeString, MaxSize: 3},
+		"age":      {Key: "age", Type: TypeInt},
+		"score":    {Key: "score", Type: TypeFloat},
+		"born":     {Key: "born", Type: TypeTimestamp},
+		"tags":     {Key: "tags", Type: TypeList, MaxSize: 2},
+		"avatar":   {Key: "avatar", Type: TypeBytes},
+		"site":     {Key: "site", Type: TypeString, Format: FormatURI},
+		"phone":    {Key: "phone", Type: TypeString, Format: FormatPhone},
+		"uuid":     {Key: "uuid", Type: TypeString, Format: FormatUUID},
+		"birthday": {Key: "birthday", Type: TypeString, Format: FormatDate},
+	}
+	t.Run("violations", func(t *testing.T) {
+		violations := Validate(fields, map[string]interface{}{
+			"name":     "Jöhnny",
+			"age":      "42",
+			"tags":     primitive.A{"a", "b", "c"},
+			"site":     "example.com",
+			"phone":    "0123",
+			"uuid":     "123",
+			"birthday": "2020-13-01",
+			"unknown":  true,
+		}, false)
+		require.Equal(t, Violations{
+			{Key: "age", Reason: "must be int, got string"},
+			{Key: "birthday", Reason: "must be date formatted"},
+			{Key: "email", Reason: "is required"},
+			{Key: "name", Reason: "must not be longer than 3"},
+			{Key: "phone", Reason: "must be phone formatted"},
+			{Key: "site", Reason: "must be uri formatted"},
+			{Key: "tags", Reason: "must not be longer than 2"},
+			{Key: "unknown", Reason: "is not allowed"},
+			{Key: "uuid", Reason: "must be uuid formatted"},
+		}, violations)
+		require.Equal(t, "age: must be int, got string; birthday: must be date formatted", violations[:2].Error())
+		require.Equal(t, []string{"age", "birthday"}, violations[:2].Keys())
+	})
+	t.Run("invalid email", func(t *testing.T) {
+		violations := Validate(fields, map[string]interface{}{
+			"email": "John <john@example.com>",
+		}, false)
+		require.Equal(t, Violations{{Key: "email", Reason: "must be email formatted"}}, violations)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		violations := Validate(fields, map[string]interface{}{
+			"email":    "john@example.com",
+			"name":     "Jön",
+			"age":      int64(42),
+			"score":    int64(1),
+			"born":     primitive.NewDateTimeFromTime(time.Now()),
+			"tags":     []interface{}{"a"},
+			"avatar":   primitive.Binary{Data: []byte{1}},
+			"site":     "https://example.com",
+			"phone":    "+12025550123",
+			"uuid":     "123e4567-e89b-12d3-a456-426614174000",
+			"birthday": "2020-02-29",
+			"unknown":  true,
+			"empty":    nil,
+		}, true)
+		require.Empty(t, violations)
+	})
+}
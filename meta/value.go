@@ -0,0 +1,334 @@
+package meta
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// codec converts well-known type into native storage value and back.
+type codec struct {
+	// message returns an empty message of the type.
+	message func() proto.Message
+	// decode returns native value of the message, false if the conversion is lossy.
+	decode func(m proto.Message) (interface{}, bool)
+	// encode returns message of the native value, false if the value has another type.
+	encode func(v interface{}) (proto.Message, bool)
+}
+
+// codecs contains well-known types stored as native values, so they can be queried.
+var codecs = map[protoreflect.FullName]codec{
+	"google.protobuf.StringValue": {
+		message: func() proto.Message { return new(wrapperspb.StringValue) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*wrapperspb.StringValue).Value, true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			s, ok := v.(string)
+			return wrapperspb.String(s), ok
+		},
+	},
+	"google.protobuf.BoolValue": {
+		message: func() proto.Message { return new(wrapperspb.BoolValue) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*wrapperspb.BoolValue).Value, true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			b, ok := v.(bool)
+			return wrapperspb.Bool(b), ok
+		},
+	},
+	"google.protobuf.BytesValue": {
+		message: func() proto.Message { return new(wrapperspb.BytesValue) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*wrapperspb.BytesValue).Value, true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			b, ok := payload(v)
+			return wrapperspb.Bytes(b), ok
+		},
+	},
+	"google.protobuf.Int32Value": {
+		message: func() proto.Message { return new(wrapperspb.Int32Value) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*wrapperspb.Int32Value).Value, true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			i, ok := int64Value(v)
+			return wrapperspb.Int32(int32(i)), ok && i >= math.MinInt32 && i <= math.MaxInt32
+		},
+	},
+	"google.protobuf.Int64Value": {
+		message: func() proto.Message { return new(wrapperspb.Int64Value) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*wrapperspb.Int64Value).Value, true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			i, ok := int64Value(v)
+			return wrapperspb.Int64(i), ok
+		},
+	},
+	"google.protobuf.UInt32Value": {
+		message: func() proto.Message { return new(wrapperspb.UInt32Value) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return int64(m.(*wrapperspb.UInt32Value).Value), true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			i, ok := int64Value(v)
+			return wrapperspb.UInt32(uint32(i)), ok && i >= 0 && i <= math.MaxUint32
+		},
+	},
+	"google.protobuf.UInt64Value": {
+		message: func() proto.Message { return new(wrapperspb.UInt64Value) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			// mongo has no unsigned integers.
+			u := m.(*wrapperspb.UInt64Value).Value
+			return int64(u), u <= math.MaxInt64
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			i, ok := int64Value(v)
+			return wrapperspb.UInt64(uint64(i)), ok && i >= 0
+		},
+	},
+	"google.protobuf.FloatValue": {
+		message: func() proto.Message { return new(wrapperspb.FloatValue) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return float64(m.(*wrapperspb.FloatValue).Value), true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			f, ok := float64Value(v)
+			return wrapperspb.Float(float32(f)), ok
+		},
+	},
+	"google.protobuf.DoubleValue": {
+		message: func() proto.Message { return new(wrapperspb.DoubleValue) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*wrapperspb.DoubleValue).Value, true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			f, ok := float64Value(v)
+			return wrapperspb.Double(f), ok
+		},
+	},
+	"google.protobuf.Timestamp": {
+		message: func() proto.Message { return new(timestamppb.Timestamp) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			// mongo keeps milliseconds only.
+			ts := m.(*timestamppb.Timestamp)
+			return ts.AsTime(), ts.IsValid() && ts.Nanos%int32(time.Millisecond) == 0
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			t, ok := timeValue(v)
+			return timestamppb.New(t), ok
+		},
+	},
+	"google.protobuf.Struct": {
+		message: func() proto.Message { return new(structpb.Struct) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*structpb.Struct).AsMap(), true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			m, ok := Native(v).(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			s, err := structpb.NewStruct(m)
+			return s, err == nil
+		},
+	},
+	"google.protobuf.ListValue": {
+		message: func() proto.Message { return new(structpb.ListValue) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*structpb.ListValue).AsSlice(), true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			l, ok := Native(v).([]interface{})
+			if !ok {
+				return nil, false
+			}
+			list, err := structpb.NewList(l)
+			return list, err == nil
+		},
+	},
+	"google.protobuf.Value": {
+		message: func() proto.Message { return new(structpb.Value) },
+		decode: func(m proto.Message) (interface{}, bool) {
+			return m.(*structpb.Value).AsInterface(), true
+		},
+		encode: func(v interface{}) (proto.Message, bool) {
+			value, err := structpb.NewValue(Native(v))
+			return value, err == nil
+		},
+	},
+}
+
+// typeURLPrefix is the default prefix of the type URLs of inferred types.
+const typeURLPrefix = "type.googleapis.com/"
+
+// Decode converts protobuf meta value into the storage value and its type URL.
+// Well-known types are decoded into native values when it's lossless,
+// other messages are kept serialized.
+func Decode(value *anypb.Any) (interface{}, string, error) {
+	if value == nil {
+		return nil, "", nil
+	}
+	c, ok := codecs[value.MessageName()]
+	if !ok {
+		return value.GetValue(), value.GetTypeUrl(), nil
+	}
+
+	m := c.message()
+	if err := value.UnmarshalTo(m); err != nil {
+		return nil, "", errors.Wrapf(err, "could not decode %s value", value.MessageName())
+	}
+	if native, ok := c.decode(m); ok {
+		return native, value.GetTypeUrl(), nil
+	}
+	return value.GetValue(), value.GetTypeUrl(), nil
+}
+
+// Encode converts the storage value and its type URL back into protobuf meta value.
+// Values stored without a type URL are encoded as serialized bytes if they
+// are binary, otherwise the type is inferred from the value.
+func Encode(value interface{}, typeURL string) (*anypb.Any, error) {
+	if typeURL == "" {
+		if b, ok := bytesValue(value); ok {
+			return &anypb.Any{Value: b}, nil
+		}
+		typeURL = inferTypeURL(value)
+		if typeURL == "" {
+			return nil, errors.Errorf("unsupported meta value type %T", value)
+		}
+	}
+
+	res := anypb.Any{TypeUrl: typeURL}
+	if c, ok := codecs[res.MessageName()]; ok {
+		if m, ok := c.encode(value); ok {
+			b, err := proto.Marshal(m)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not encode %s value", res.MessageName())
+			}
+			res.Value = b
+			return &res, nil
+		}
+	}
+
+	// messages are kept serialized.
+	b, ok := payload(value)
+	if !ok {
+		return nil, errors.Errorf("meta value of type %T doesn't match %s", value, typeURL)
+	}
+	res.Value = b
+	return &res, nil
+}
+
+// inferTypeURL returns type URL of the well-known type matching the value,
+// e.g. for values imported without a type.
+func inferTypeURL(value interface{}) string {
+	var name string
+	switch Native(value).(type) {
+	case string:
+		name = "google.protobuf.StringValue"
+	case bool:
+		name = "google.protobuf.BoolValue"
+	case int, int32, int64:
+		name = "google.protobuf.Int64Value"
+	case float32, float64:
+		name = "google.protobuf.DoubleValue"
+	case time.Time:
+		name = "google.protobuf.Timestamp"
+	case map[string]interface{}:
+		name = "google.protobuf.Struct"
+	case []interface{}:
+		name = "google.protobuf.ListValue"
+	case nil:
+		name = "google.protobuf.Value"
+	default:
+		return ""
+	}
+	return typeURLPrefix + name
+}
+
+// Native converts mongo primitive values into the native ones.
+func Native(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.Binary:
+		return v.Data
+	case primitive.DateTime:
+		return v.Time().UTC()
+	case primitive.A:
+		return Native([]interface{}(v))
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i := range v {
+			res[i] = Native(v[i])
+		}
+		return res
+	case primitive.D:
+		return Native(v.Map())
+	case primitive.M:
+		return Native(map[string]interface{}(v))
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k := range v {
+			res[k] = Native(v[k])
+		}
+		return res
+	}
+	return value
+}
+
+func bytesValue(value interface{}) ([]byte, bool) {
+	b, ok := Native(value).([]byte)
+	return b, ok
+}
+
+// payload returns binary value of the typed meta value.
+// Binary values holding text are exported as strings, so strings are accepted too.
+func payload(value interface{}) ([]byte, bool) {
+	if s, ok := value.(string); ok {
+		return []byte(s), true
+	}
+	return bytesValue(value)
+}
+
+func int64Value(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		// JSON numbers, e.g. of imported users.
+		return int64(v), v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64
+	}
+	return 0, false
+}
+
+func float64Value(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case int, int32, int64:
+		i, _ := int64Value(v)
+		return float64(i), true
+	}
+	return 0, false
+}
+
+func timeValue(value interface{}) (time.Time, bool) {
+	t, ok := Native(value).(time.Time)
+	return t, ok
+}
@@ -0,0 +1,145 @@
+package meta
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newAny(t *testing.T, m proto.Message) *anypb.Any {
+	res, err := anypb.New(m)
+	require.NoError(t, err)
+	return res
+}
+
+func TestDecode(t *testing.T) {
+	ts := time.Date(2020, 11, 2, 14, 42, 18, int(123*time.Millisecond), time.UTC)
+	s, err := structpb.NewStruct(map[string]interface{}{"city": "Kyiv", "floor": 2.0})
+	require.NoError(t, err)
+	tt := []struct {
+		name     string
+		value    *anypb.Any
+		expected interface{}
+	}{
+		{"string", newAny(t, wrapperspb.String("hello")), "hello"},
+		{"bool", newAny(t, wrapperspb.Bool(true)), true},
+		{"bytes", newAny(t, wrapperspb.Bytes([]byte{1, 2})), []byte{1, 2}},
+		{"int32", newAny(t, wrapperspb.Int32(-5)), int32(-5)},
+		{"int64", newAny(t, wrapperspb.Int64(1<<40)), int64(1 << 40)},
+		{"uint32", newAny(t, wrapperspb.UInt32(5)), int64(5)},
+		{"uint64", newAny(t, wrapperspb.UInt64(5)), int64(5)},
+		{"float", newAny(t, wrapperspb.Float(1.5)), 1.5},
+		{"double", newAny(t, wrapperspb.Double(1.5)), 1.5},
+		{"timestamp", newAny(t, timestamppb.New(ts)), ts},
+		{"struct", newAny(t, s), map[string]interface{}{"city": "Kyiv", "floor": 2.0}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			v, typeURL, err := Decode(tc.value)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+			require.Equal(t, tc.value.TypeUrl, typeURL)
+		})
+	}
+	t.Run("lossy values are kept serialized", func(t *testing.T) {
+		for _, m := range []proto.Message{
+			wrapperspb.UInt64(1 << 63),
+			timestamppb.New(ts.Add(time.Nanosecond)),
+		} {
+			value := newAny(t, m)
+			v, typeURL, err := Decode(value)
+			require.NoError(t, err)
+			require.Equal(t, value.Value, v)
+			require.Equal(t, value.TypeUrl, typeURL)
+		}
+	})
+	t.Run("custom message", func(t *testing.T) {
+		value := newAny(t, durationpb.New(time.Minute))
+		value.TypeUrl = "example.com/acme.Address"
+		v, typeURL, err := Decode(value)
+		require.NoError(t, err)
+		require.Equal(t, value.Value, v)
+		require.Equal(t, "example.com/acme.Address", typeURL)
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		_, _, err := Decode(&anypb.Any{
+			TypeUrl: "type.googleapis.com/google.protobuf.StringValue",
+			Value:   []byte{0xff},
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		ts := time.Date(2020, 11, 2, 14, 42, 18, 0, time.UTC)
+		for _, m := range []proto.Message{
+			wrapperspb.String("hello"),
+			wrapperspb.Bool(true),
+			wrapperspb.Bytes([]byte{1, 2}),
+			wrapperspb.Int32(-5),
+			wrapperspb.Int64(1 << 40),
+			wrapperspb.UInt32(5),
+			wrapperspb.UInt64(1 << 63),
+			wrapperspb.Float(1.25),
+			wrapperspb.Double(1.25),
+			timestamppb.New(ts),
+			timestamppb.New(ts.Add(time.Nanosecond)),
+			structpb.NewStringValue("hello"),
+			durationpb.New(time.Minute),
+		} {
+			value := newAny(t, m)
+			v, typeURL, err := Decode(value)
+			require.NoError(t, err)
+			res, err := Encode(v, typeURL)
+			require.NoError(t, err)
+			require.True(t, proto.Equal(value, res), value.TypeUrl)
+		}
+	})
+	t.Run("mongo values", func(t *testing.T) {
+		ts := time.Date(2020, 11, 2, 14, 42, 18, 0, time.UTC)
+		res, err := Encode(primitive.NewDateTimeFromTime(ts), "type.googleapis.com/google.protobuf.Timestamp")
+		require.NoError(t, err)
+		require.True(t, proto.Equal(newAny(t, timestamppb.New(ts)), res))
+
+		res, err = Encode(primitive.D{{Key: "city", Value: "Kyiv"}}, "type.googleapis.com/google.protobuf.Struct")
+		require.NoError(t, err)
+		s, err := structpb.NewStruct(map[string]interface{}{"city": "Kyiv"})
+		require.NoError(t, err)
+		require.True(t, proto.Equal(newAny(t, s), res))
+
+		res, err = Encode(float64(42), "type.googleapis.com/google.protobuf.Int64Value")
+		require.NoError(t, err)
+		require.True(t, proto.Equal(newAny(t, wrapperspb.Int64(42)), res))
+	})
+	t.Run("untyped values", func(t *testing.T) {
+		res, err := Encode(primitive.Binary{Data: []byte("hello")}, "")
+		require.NoError(t, err)
+		require.True(t, proto.Equal(&anypb.Any{Value: []byte("hello")}, res))
+
+		res, err = Encode("hello", "")
+		require.NoError(t, err)
+		require.True(t, proto.Equal(newAny(t, wrapperspb.String("hello")), res))
+
+		res, err = Encode(int32(5), "")
+		require.NoError(t, err)
+		require.True(t, proto.Equal(newAny(t, wrapperspb.Int64(5)), res))
+
+		_, err = Encode(struct{}{}, "")
+		require.Error(t, err)
+		require.EqualError(t, err, "unsupported meta value type struct {}")
+	})
+	t.Run("value doesn't match type", func(t *testing.T) {
+		_, err := Encode(1.5, "type.googleapis.com/google.protobuf.Int64Value")
+		require.Error(t, err)
+		require.EqualError(t, err, "meta value of type float64 doesn't match type.googleapis.com/google.protobuf.Int64Value")
+	})
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/server"
+)
+
+// HandlerWrapper returns go-micro handler wrapper which instruments every handled request.
+func (m *Metrics) HandlerWrapper() server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			method := req.Endpoint()
+			inFlight := m.rpcInFlight.WithLabelValues(method)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			err := next(ctx, req, rsp)
+			m.rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+			m.rpcRequests.WithLabelValues(method, responseCode(err)).Inc()
+
+			return err
+		}
+	}
+}
+
+// responseCode returns status code of the handler response.
+// Errors without a code are reported as internal ones.
+func responseCode(err error) string {
+	if err == nil {
+		return "200"
+	}
+	code := microErrors.FromError(err).Code
+	if code == 0 {
+		code = 500
+	}
+	return strconv.Itoa(int(code))
+}
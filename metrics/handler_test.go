@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	server.Request
+	endpoint string
+}
+
+func (r testRequest) Endpoint() string {
+	return r.endpoint
+}
+
+func TestMetrics_HandlerWrapper(t *testing.T) {
+	m := New()
+	req := testRequest{endpoint: "User.Create"}
+	handle := func(err error) server.HandlerFunc {
+		return m.HandlerWrapper()(func(context.Context, server.Request, interface{}) error {
+			require.Equal(t, float64(1), testutil.ToFloat64(m.rpcInFlight.WithLabelValues("User.Create")))
+			return err
+		})
+	}
+
+	require.NoError(t, handle(nil)(context.Background(), req, nil))
+	require.Error(t, handle(errors.New("error"))(context.Background(), req, nil))
+	require.Error(t, handle(microErrors.NotFound("id", "not found"))(context.Background(), req, nil))
+
+	require.Equal(t, float64(1), testutil.ToFloat64(m.rpcRequests.WithLabelValues("User.Create", "200")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.rpcRequests.WithLabelValues("User.Create", "500")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.rpcRequests.WithLabelValues("User.Create", "404")))
+	require.Equal(t, float64(0), testutil.ToFloat64(m.rpcInFlight.WithLabelValues("User.Create")))
+	require.Equal(t, 1, testutil.CollectAndCount(m.rpcDuration))
+}
@@ -0,0 +1,117 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "user"
+
+// Metrics represents service metrics registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	rpcRequests        *prometheus.CounterVec
+	rpcDuration        *prometheus.HistogramVec
+	rpcInFlight        *prometheus.GaugeVec
+	storageDuration    *prometheus.HistogramVec
+	storageErrors      *prometheus.CounterVec
+	storageInFlight    *prometheus.GaugeVec
+	poolConnections    *prometheus.GaugeVec
+	poolInUse          *prometheus.GaugeVec
+	poolMaxConnections *prometheus.GaugeVec
+	poolCheckOutErrors *prometheus.CounterVec
+}
+
+// New creates new Metrics instance with all service collectors registered.
+func New() *Metrics {
+	m := Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "requests_total",
+			Help:      "Number of handled RPC requests by method and response code.",
+		}, []string{"method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "request_duration_seconds",
+			Help:      "RPC request handling latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		rpcInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "rpc",
+			Name:      "requests_in_flight",
+			Help:      "Number of RPC requests being handled.",
+		}, []string{"method"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Storage operation latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed storage operations by error type.",
+		}, []string{"operation", "type"}),
+		storageInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operations_in_flight",
+			Help:      "Number of storage operations being executed.",
+		}, []string{"operation"}),
+		poolConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "mongo_pool",
+			Name:      "connections",
+			Help:      "Number of open mongo connections.",
+		}, []string{"address"}),
+		poolInUse: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "mongo_pool",
+			Name:      "connections_in_use",
+			Help:      "Number of mongo connections checked out of the pool.",
+		}, []string{"address"}),
+		poolMaxConnections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "mongo_pool",
+			Name:      "max_connections",
+			Help:      "Maximal size of the mongo connection pool.",
+		}, []string{"address"}),
+		poolCheckOutErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mongo_pool",
+			Name:      "checkout_failures_total",
+			Help:      "Number of failed mongo connection checkouts by reason.",
+		}, []string{"address", "reason"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.rpcRequests,
+		m.rpcDuration,
+		m.rpcInFlight,
+		m.storageDuration,
+		m.storageErrors,
+		m.storageInFlight,
+		m.poolConnections,
+		m.poolInUse,
+		m.poolMaxConnections,
+		m.poolCheckOutErrors,
+	)
+
+	return &m
+}
+
+// Handler returns HTTP handler of the Prometheus scrape endpoint.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
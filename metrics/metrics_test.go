@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.rpcRequests.WithLabelValues("User.Create", "200").Inc()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `user_rpc_requests_total{code="200",method="User.Create"} 1`)
+	require.Contains(t, rec.Body.String(), "go_goroutines")
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolMonitor returns mongo pool monitor which exports connection pool statistics.
+func (m *Metrics) PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: m.poolEvent,
+	}
+}
+
+func (m *Metrics) poolEvent(e *event.PoolEvent) {
+	switch e.Type {
+	case event.PoolCreated:
+		if e.PoolOptions != nil {
+			m.poolMaxConnections.WithLabelValues(e.Address).Set(float64(e.PoolOptions.MaxPoolSize))
+		}
+	case event.ConnectionCreated:
+		m.poolConnections.WithLabelValues(e.Address).Inc()
+	case event.ConnectionClosed:
+		m.poolConnections.WithLabelValues(e.Address).Dec()
+	case event.GetSucceeded:
+		m.poolInUse.WithLabelValues(e.Address).Inc()
+	case event.ConnectionReturned:
+		m.poolInUse.WithLabelValues(e.Address).Dec()
+	case event.GetFailed:
+		m.poolCheckOutErrors.WithLabelValues(e.Address, e.Reason).Inc()
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestMetrics_PoolMonitor(t *testing.T) {
+	m := New()
+	monitor := m.PoolMonitor()
+	const address = "127.0.0.1:27017"
+	for _, e := range []event.PoolEvent{
+		{Type: event.PoolCreated, PoolOptions: &event.MonitorPoolOptions{MaxPoolSize: 100}},
+		{Type: event.ConnectionCreated},
+		{Type: event.ConnectionCreated},
+		{Type: event.GetSucceeded},
+		{Type: event.GetSucceeded},
+		{Type: event.ConnectionReturned},
+		{Type: event.ConnectionClosed},
+		{Type: event.GetFailed, Reason: "timeout"},
+	} {
+		e.Address = address
+		monitor.Event(&e)
+	}
+
+	require.Equal(t, float64(100), testutil.ToFloat64(m.poolMaxConnections.WithLabelValues(address)))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.poolConnections.WithLabelValues(address)))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.poolInUse.WithLabelValues(address)))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.poolCheckOutErrors.WithLabelValues(address, "timeout")))
+}
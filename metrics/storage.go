@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/storage/resilience"
+)
+
+// There are storage operation names.
+const (
+	operationAdd    = "add"
+	operationDelete = "delete"
+	operationUpdate = "update"
+	operationFind   = "find"
+
+	operationChangeStatus         = "change_status"
+	operationAddStatusSchedule    = "add_status_schedule"
+	operationRemoveStatusSchedule = "remove_status_schedule"
+	operationMerge                = "merge"
+	operationAnonymise            = "anonymise"
+	operationTouchActivity        = "touch_activity"
+)
+
+// storageErrorTypes maps common storage errors to error types.
+var storageErrorTypes = []struct {
+	err  error
+	name string
+}{
+	{commonErrors.ErrStorageConvert, "convert"},
+	{commonErrors.ErrStorageInsert, "insert"},
+	{commonErrors.ErrStorageDelete, "delete"},
+	{commonErrors.ErrStorageUpdate, "update"},
+	{commonErrors.ErrStorageFind, "find"},
+}
+
+// Storage represents user storage decorator which instruments every storage call.
+type Storage struct {
+	next    storage.User
+	metrics *Metrics
+}
+
+// NewStorage creates new Storage instance.
+func NewStorage(next storage.User, m *Metrics) *Storage {
+	return &Storage{
+		next:    next,
+		metrics: m,
+	}
+}
+
+// Disconnect breaks storage connection.
+func (s *Storage) Disconnect(ctx context.Context) error {
+	return s.next.Disconnect(ctx)
+}
+
+// Add adds a new user.
+func (s *Storage) Add(ctx context.Context, user model.User) (res *model.User, err error) {
+	defer s.observe(operationAdd)(&err)
+	return s.next.Add(ctx, user)
+}
+
+// Delete removes an existing user by ID.
+func (s *Storage) Delete(ctx context.Context, userID string) (err error) {
+	defer s.observe(operationDelete)(&err)
+	return s.next.Delete(ctx, userID)
+}
+
+// Anonymise removes user's personal data.
+func (s *Storage) Anonymise(ctx context.Context, user model.User) (res *model.User, err error) {
+	defer s.observe(operationAnonymise)(&err)
+	return s.next.Anonymise(ctx, user)
+}
+
+// TouchActivity moves user's last activity time forward.
+func (s *Storage) TouchActivity(ctx context.Context, userID string, at time.Time) (err error) {
+	defer s.observe(operationTouchActivity)(&err)
+	return s.next.TouchActivity(ctx, userID, at)
+}
+
+// Update updates an existing user.
+func (s *Storage) Update(ctx context.Context, user model.User) (res *model.User, err error) {
+	defer s.observe(operationUpdate)(&err)
+	return s.next.Update(ctx, user)
+}
+
+// ChangeStatus changes user's status.
+func (s *Storage) ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (res *model.User, err error) {
+	defer s.observe(operationChangeStatus)(&err)
+	return s.next.ChangeStatus(ctx, userID, change)
+}
+
+// AddStatusSchedule adds status change scheduled for the future.
+func (s *Storage) AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (res *model.User, err error) {
+	defer s.observe(operationAddStatusSchedule)(&err)
+	return s.next.AddStatusSchedule(ctx, userID, schedule)
+}
+
+// RemoveStatusSchedule removes pending status change.
+func (s *Storage) RemoveStatusSchedule(ctx context.Context, userID, scheduleID string) (res *model.User, err error) {
+	defer s.observe(operationRemoveStatusSchedule)(&err)
+	return s.next.RemoveStatusSchedule(ctx, userID, scheduleID)
+}
+
+// Merge merges the source user into the target one.
+func (s *Storage) Merge(ctx context.Context, merge model.Merge) (res *model.User, err error) {
+	defer s.observe(operationMerge)(&err)
+	return s.next.Merge(ctx, merge)
+}
+
+// Find finds users by filter.
+func (s *Storage) Find(ctx context.Context, filter model.UserFindFilter) (res []model.User, err error) {
+	defer s.observe(operationFind)(&err)
+	return s.next.Find(ctx, filter)
+}
+
+// observe starts operation measurement, the returned function records its result.
+func (s *Storage) observe(operation string) func(err *error) {
+	inFlight := s.metrics.storageInFlight.WithLabelValues(operation)
+	inFlight.Inc()
+	start := time.Now()
+
+	return func(err *error) {
+		inFlight.Dec()
+		s.metrics.storageDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		if *err != nil {
+			s.metrics.storageErrors.WithLabelValues(operation, errorType(*err)).Inc()
+		}
+	}
+}
+
+// errorType returns low cardinality type of the storage error.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, resilience.ErrUnavailable):
+		return "unavailable"
+	case storage.IsTransient(err):
+		return "transient"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	}
+	for _, t := range storageErrorTypes {
+		if errors.Is(err, t.err) {
+			return t.name
+		}
+	}
+	return "unknown"
+}
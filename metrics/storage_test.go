@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/open-Q/user/storage/resilience"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage_Find(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	m := New()
+	s := NewStorage(st, m)
+
+	st.On("Find", mock.Anything, mock.Anything).Return([]model.User{{ID: "1"}}, nil).Once()
+	res, err := s.Find(context.Background(), model.UserFindFilter{})
+	require.NoError(t, err)
+	require.Equal(t, []model.User{{ID: "1"}}, res)
+
+	st.On("Find", mock.Anything, mock.Anything).Return(nil, commonErrors.NewStorageFindError("error")).Once()
+	_, err = s.Find(context.Background(), model.UserFindFilter{})
+	require.Error(t, err)
+
+	require.Equal(t, float64(1), testutil.ToFloat64(m.storageErrors.WithLabelValues(operationFind, "find")))
+	require.Equal(t, float64(0), testutil.ToFloat64(m.storageInFlight.WithLabelValues(operationFind)))
+	require.Equal(t, 1, testutil.CollectAndCount(m.storageDuration))
+}
+
+func TestStorage_Delete(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	m := New()
+	s := NewStorage(st, m)
+
+	st.On("Delete", mock.Anything, "1").Return(resilience.ErrUnavailable).Once()
+	err := s.Delete(context.Background(), "1")
+	require.Error(t, err)
+	require.True(t, errors.Is(err, resilience.ErrUnavailable))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.storageErrors.WithLabelValues(operationDelete, "unavailable")))
+}
+
+func Test_errorType(t *testing.T) {
+	tt := []struct {
+		err      error
+		expected string
+	}{
+		{resilience.ErrUnavailable, "unavailable"},
+		{storage.NewTransientError(commonErrors.NewStorageFindError("error")), "transient"},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), "deadline_exceeded"},
+		{context.Canceled, "canceled"},
+		{commonErrors.NewStorageConvertError("error"), "convert"},
+		{commonErrors.NewStorageInsertError("error"), "insert"},
+		{commonErrors.NewStorageUpdateError("error"), "update"},
+		{errors.New("error"), "unknown"},
+	}
+	for _, tc := range tt {
+		require.Equal(t, tc.expected, errorType(tc.err), tc.err.Error())
+	}
+}
@@ -0,0 +1,303 @@
+package rbac
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/open-Q/user/status"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are default checker settings.
+const (
+	DefaultCacheTTL        = time.Minute
+	DefaultCacheSize       = 10000
+	DefaultRefreshInterval = time.Minute
+)
+
+// wildcard matches any action or, at the end of a resource, any resource with the prefix.
+const wildcard = "*"
+
+// ErrUserNotFound is returned when permissions of unknown user are checked.
+var ErrUserNotFound = errors.New("user not found")
+
+// Config represents permission checker configuration.
+type Config struct {
+	RoleStorage storage.Role
+	UserStorage storage.User
+	Logger      *commonLog.Logger
+	// CacheTTL limits how long results are cached, DefaultCacheTTL is used if empty.
+	// Changes made by other replicas are visible once cached results expire.
+	CacheTTL time.Duration
+	// CacheSize limits number of cached results, DefaultCacheSize is used if empty.
+	CacheSize int
+	// RefreshInterval is a period between role reloads, DefaultRefreshInterval is used if empty.
+	RefreshInterval time.Duration
+}
+
+// Checker checks users' permissions granted by the assigned roles.
+// Only active users have permissions.
+type Checker struct {
+	roleStorage     storage.Role
+	userStorage     storage.User
+	logger          *commonLog.Logger
+	cacheTTL        time.Duration
+	cacheSize       int
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	mu    sync.RWMutex
+	roles map[string]model.Role
+	cache map[cacheKey]cacheEntry
+	// generation is changed on every invalidation, so results computed
+	// before it aren't cached.
+	generation uint64
+}
+
+type cacheKey struct {
+	userID   string
+	action   string
+	resource string
+}
+
+type cacheEntry struct {
+	allowed   bool
+	expiresAt time.Time
+}
+
+// NewChecker creates new Checker instance.
+func NewChecker(cfg Config) *Checker {
+	cacheTTL := cfg.CacheTTL
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+	cacheSize := cfg.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	refreshInterval := cfg.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+	return &Checker{
+		roleStorage:     cfg.RoleStorage,
+		userStorage:     cfg.UserStorage,
+		logger:          cfg.Logger,
+		cacheTTL:        cacheTTL,
+		cacheSize:       cacheSize,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+		roles:           map[string]model.Role{},
+		cache:           map[cacheKey]cacheEntry{},
+	}
+}
+
+// ValidateRole validates role definition.
+func ValidateRole(role model.Role) error {
+	if strings.TrimSpace(role.Name) == "" {
+		return errors.New("role name is required")
+	}
+	for i := range role.Permissions {
+		if strings.TrimSpace(role.Permissions[i]) == "" {
+			return errors.Errorf("%s role has empty permission", role.Name)
+		}
+	}
+	return nil
+}
+
+// Load reloads roles from the storage and drops cached results.
+func (c *Checker) Load(ctx context.Context) error {
+	roles, err := c.roleStorage.ListRoles(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not load roles")
+	}
+	loaded := make(map[string]model.Role, len(roles))
+	for i := range roles {
+		loaded[roles[i].Name] = roles[i]
+	}
+	c.mu.Lock()
+	c.roles = loaded
+	c.resetCache()
+	c.mu.Unlock()
+	return nil
+}
+
+// Start reloads roles on every refresh interval until the context is canceled.
+// Failed reloads keep the previously loaded roles.
+func (c *Checker) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Load(ctx); err != nil && ctx.Err() == nil {
+				c.logger.WithError(err).Error("could not reload roles")
+			}
+		}
+	}
+}
+
+// Roles returns all roles sorted by name.
+func (c *Checker) Roles() []model.Role {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	roles := make([]model.Role, 0, len(c.roles))
+	for _, role := range c.roles {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].Name < roles[j].Name
+	})
+	return roles
+}
+
+// Role returns the role by name, roles are reloaded if it's unknown,
+// e.g. because it was created by another replica.
+func (c *Checker) Role(ctx context.Context, name string) (*model.Role, error) {
+	if role, ok := c.role(name); ok {
+		return &role, nil
+	}
+	if err := c.Load(ctx); err != nil {
+		return nil, err
+	}
+	if role, ok := c.role(name); ok {
+		return &role, nil
+	}
+	return nil, nil
+}
+
+// PutRole creates or replaces role.
+func (c *Checker) PutRole(ctx context.Context, role model.Role) error {
+	if err := ValidateRole(role); err != nil {
+		return err
+	}
+	if err := c.roleStorage.PutRole(ctx, role); err != nil {
+		return errors.Wrapf(err, "could not put %s role", role.Name)
+	}
+	c.mu.Lock()
+	c.roles[role.Name] = role
+	c.resetCache()
+	c.mu.Unlock()
+	return nil
+}
+
+// DeleteRole removes role, its assignments grant nothing afterwards.
+func (c *Checker) DeleteRole(ctx context.Context, name string) error {
+	if err := c.roleStorage.DeleteRole(ctx, name); err != nil {
+		return errors.Wrapf(err, "could not delete %s role", name)
+	}
+	c.mu.Lock()
+	delete(c.roles, name)
+	c.resetCache()
+	c.mu.Unlock()
+	return nil
+}
+
+// Invalidate drops cached results of the user, e.g. after its roles or status are changed.
+func (c *Checker) Invalidate(userID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key := range c.cache {
+		if key.userID == userID {
+			delete(c.cache, key)
+		}
+	}
+}
+
+// Check checks if the user may do the action on the resource.
+// Results are cached until they expire or the user's results are invalidated.
+func (c *Checker) Check(ctx context.Context, userID, action, resource string) (bool, error) {
+	key := cacheKey{userID: userID, action: action, resource: resource}
+	now := c.now()
+	c.mu.RLock()
+	entry, ok := c.cache[key]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.allowed, nil
+	}
+
+	users, err := c.userStorage.Find(ctx, model.UserFindFilter{
+		IDs: []string{userID},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "could not find user")
+	}
+	if len(users) == 0 {
+		return false, ErrUserNotFound
+	}
+
+	allowed := c.allowed(users[0], action, resource)
+	c.mu.Lock()
+	if generation == c.generation {
+		if len(c.cache) >= c.cacheSize {
+			c.cache = map[cacheKey]cacheEntry{}
+		}
+		c.cache[key] = cacheEntry{allowed: allowed, expiresAt: now.Add(c.cacheTTL)}
+	}
+	c.mu.Unlock()
+	return allowed, nil
+}
+
+// allowed checks if any of the user's roles for the resource grants the action.
+func (c *Checker) allowed(user model.User, action, resource string) bool {
+	if user.Status != status.Active {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, assignment := range user.Roles {
+		if !matchResource(assignment.Resource, resource) {
+			continue
+		}
+		for _, permission := range c.roles[assignment.Role].Permissions {
+			if matchAction(permission, action) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resetCache drops all cached results, the lock must be held.
+func (c *Checker) resetCache() {
+	c.cache = map[cacheKey]cacheEntry{}
+	c.generation++
+}
+
+func (c *Checker) role(name string) (model.Role, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	role, ok := c.roles[name]
+	return role, ok
+}
+
+// matchAction checks if the permission grants the action.
+// Permission "*" grants any action, "orders:*" grants any action starting with "orders:".
+func matchAction(permission, action string) bool {
+	if strings.HasSuffix(permission, wildcard) {
+		return strings.HasPrefix(action, strings.TrimSuffix(permission, wildcard))
+	}
+	return permission == action
+}
+
+// matchResource checks if the role assigned for the scope applies to the resource.
+// Empty scope applies to any resource, "shop/*" applies to any resource starting with "shop/".
+func matchResource(scope, resource string) bool {
+	if scope == "" {
+		return true
+	}
+	if strings.HasSuffix(scope, wildcard) {
+		return strings.HasPrefix(resource, strings.TrimSuffix(scope, wildcard))
+	}
+	return scope == resource
+}
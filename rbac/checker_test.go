@@ -0,0 +1,141 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/status"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateRole(t *testing.T) {
+	require.EqualError(t, ValidateRole(model.Role{Name: " "}), "role name is required")
+	require.EqualError(t, ValidateRole(model.Role{Name: "admin", Permissions: []string{""}}), "admin role has empty permission")
+	require.NoError(t, ValidateRole(model.Role{Name: "admin", Permissions: []string{"*"}}))
+}
+
+func TestChecker_Check(t *testing.T) {
+	roles := []model.Role{
+		{Name: "admin", Permissions: []string{"*"}},
+		{Name: "editor", Permissions: []string{"orders:read", "orders:write"}},
+		{Name: "viewer", Permissions: []string{"orders:*"}},
+	}
+	filter := model.UserFindFilter{IDs: []string{"1"}}
+	newChecker := func(t *testing.T, user *model.User) (*Checker, *storageMocks.User) {
+		rst := new(storageMocks.Role)
+		rst.On("ListRoles", mock.Anything).Return(roles, nil)
+		st := new(storageMocks.User)
+		if user != nil {
+			st.On("Find", mock.Anything, filter).Return([]model.User{*user}, nil)
+		} else {
+			st.On("Find", mock.Anything, filter).Return(nil, nil)
+		}
+		c := NewChecker(Config{RoleStorage: rst, UserStorage: st})
+		require.NoError(t, c.Load(context.Background()))
+		return c, st
+	}
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		st.On("Find", mock.Anything, filter).Return(nil, errors.New("find error"))
+		c := NewChecker(Config{UserStorage: st})
+		_, err := c.Check(context.Background(), "1", "orders:read", "")
+		require.EqualError(t, err, "could not find user: find error")
+	})
+	t.Run("user not found", func(t *testing.T) {
+		c, _ := newChecker(t, nil)
+		_, err := c.Check(context.Background(), "1", "orders:read", "")
+		require.Equal(t, ErrUserNotFound, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		c, _ := newChecker(t, &model.User{
+			ID:     "1",
+			Status: status.Active,
+			Roles: []model.RoleAssignment{
+				{Role: "editor", Resource: "shop/1"},
+				{Role: "viewer", Resource: "region/eu/*"},
+				{Role: "unknown"},
+			},
+		})
+		for _, tc := range []struct {
+			action   string
+			resource string
+			allowed  bool
+		}{
+			{"orders:write", "shop/1", true},
+			{"orders:write", "shop/2", false},
+			{"orders:delete", "shop/1", false},
+			{"orders:delete", "region/eu/shop/3", true},
+			{"orders:read", "region/us/shop/4", false},
+			{"users:read", "", false},
+		} {
+			allowed, err := c.Check(context.Background(), "1", tc.action, tc.resource)
+			require.NoError(t, err)
+			require.Equal(t, tc.allowed, allowed, "%s on %s", tc.action, tc.resource)
+		}
+	})
+	t.Run("inactive user", func(t *testing.T) {
+		c, _ := newChecker(t, &model.User{
+			ID:     "1",
+			Status: status.Suspended,
+			Roles:  []model.RoleAssignment{{Role: "admin"}},
+		})
+		allowed, err := c.Check(context.Background(), "1", "orders:read", "")
+		require.NoError(t, err)
+		require.False(t, allowed)
+	})
+	t.Run("all ok (cached until invalidated)", func(t *testing.T) {
+		c, st := newChecker(t, &model.User{
+			ID:     "1",
+			Status: status.Active,
+			Roles:  []model.RoleAssignment{{Role: "admin"}},
+		})
+		for i := 0; i < 2; i++ {
+			allowed, err := c.Check(context.Background(), "1", "orders:read", "")
+			require.NoError(t, err)
+			require.True(t, allowed)
+		}
+		st.AssertNumberOfCalls(t, "Find", 1)
+
+		c.Invalidate("1")
+		_, err := c.Check(context.Background(), "1", "orders:read", "")
+		require.NoError(t, err)
+		st.AssertNumberOfCalls(t, "Find", 2)
+
+		c.now = func() time.Time {
+			return time.Now().Add(DefaultCacheTTL)
+		}
+		_, err = c.Check(context.Background(), "1", "orders:read", "")
+		require.NoError(t, err)
+		st.AssertNumberOfCalls(t, "Find", 3)
+	})
+}
+
+func TestChecker_Roles(t *testing.T) {
+	rst := new(storageMocks.Role)
+	defer rst.AssertExpectations(t)
+	c := NewChecker(Config{RoleStorage: rst})
+	editor := model.Role{Name: "editor", Permissions: []string{"orders:write"}}
+	t.Run("invalid role", func(t *testing.T) {
+		require.Error(t, c.PutRole(context.Background(), model.Role{}))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		rst.On("PutRole", mock.Anything, editor).Return(nil)
+		require.NoError(t, c.PutRole(context.Background(), editor))
+		role, err := c.Role(context.Background(), "editor")
+		require.NoError(t, err)
+		require.Equal(t, editor, *role)
+		require.Equal(t, []model.Role{editor}, c.Roles())
+
+		rst.On("DeleteRole", mock.Anything, "editor").Return(nil)
+		require.NoError(t, c.DeleteRole(context.Background(), "editor"))
+		rst.On("ListRoles", mock.Anything).Return(nil, nil)
+		role, err = c.Role(context.Background(), "editor")
+		require.NoError(t, err)
+		require.Nil(t, role)
+	})
+}
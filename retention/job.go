@@ -0,0 +1,163 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+const defaultBatchSize = 100
+
+// Report represents retention run report.
+type Report struct {
+	StartedAt time.Time
+	DryRun    bool
+	Records   []Record
+}
+
+// Failed returns the number of failed actions.
+func (r *Report) Failed() int {
+	var failed int
+	for i := range r.Records {
+		if r.Records[i].Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Config represents retention job configuration.
+type Config struct {
+	UserStorage storage.User
+	Recorder    Recorder
+	Policies    []Policy
+	// Interval is a period between scheduled runs.
+	Interval time.Duration
+	// DryRun makes scheduled runs only report matching users.
+	DryRun    bool
+	BatchSize int64
+}
+
+// Job represents retention background job.
+type Job struct {
+	userStorage storage.User
+	recorder    Recorder
+	policies    []Policy
+	interval    time.Duration
+	dryRun      bool
+	batchSize   int64
+	now         func() time.Time
+}
+
+// NewJob creates new Job instance.
+func NewJob(cfg Config) (*Job, error) {
+	for i := range cfg.Policies {
+		if err := cfg.Policies[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Interval <= 0 {
+		return nil, errors.New("retention interval must be positive")
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Job{
+		userStorage: cfg.UserStorage,
+		recorder:    cfg.Recorder,
+		policies:    cfg.Policies,
+		interval:    cfg.Interval,
+		dryRun:      cfg.DryRun,
+		batchSize:   batchSize,
+		now:         time.Now,
+	}, nil
+}
+
+// Start runs retention on every interval until the context is canceled.
+func (j *Job) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		// errors are reported per record, so the report itself is not needed here.
+		_, _ = j.Run(ctx, j.dryRun)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run applies all retention policies once.
+// In dry-run mode matching users are only reported.
+func (j *Job) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	report := Report{
+		StartedAt: j.now().UTC(),
+		DryRun:    dryRun,
+	}
+	for i := range j.policies {
+		if err := j.applyPolicy(ctx, j.policies[i], &report); err != nil {
+			return &report, errors.Wrapf(err, "could not apply policy for %s status", j.policies[i].Status)
+		}
+	}
+	return &report, nil
+}
+
+func (j *Job) applyPolicy(ctx context.Context, policy Policy, report *Report) error {
+	filter := policy.filter(report.StartedAt)
+	limit := j.batchSize
+	var offset int64
+	filter.Limit = &limit
+	filter.Offset = &offset
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		users, err := j.userStorage.Find(ctx, filter)
+		if err != nil {
+			return err
+		}
+
+		for i := range users {
+			record := Record{
+				UserID:    users[i].ID,
+				Status:    users[i].Status,
+				Action:    policy.Action,
+				UpdatedAt: users[i].UpdatedAt,
+				At:        j.now().UTC(),
+				DryRun:    report.DryRun,
+			}
+			if !report.DryRun {
+				record.Err = j.apply(ctx, policy.Action, users[i])
+			}
+			// applied users don't match the filter anymore, the rest must be skipped.
+			if report.DryRun || record.Err != nil {
+				offset++
+			}
+			report.Records = append(report.Records, record)
+			if j.recorder != nil {
+				j.recorder.Record(ctx, record)
+			}
+		}
+
+		if int64(len(users)) < limit {
+			return nil
+		}
+	}
+}
+
+func (j *Job) apply(ctx context.Context, action Action, user model.User) error {
+	switch action {
+	case ActionPurge:
+		return j.userStorage.Delete(ctx, user.ID)
+	case ActionAnonymise:
+		_, err := j.userStorage.Anonymise(ctx, user)
+		return err
+	}
+	return errors.Errorf("unknown action %q", action)
+}
@@ -0,0 +1,143 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errMock = errors.New("error")
+
+type testRecorder struct {
+	records []Record
+}
+
+func (r *testRecorder) Record(_ context.Context, record Record) {
+	r.records = append(r.records, record)
+}
+
+func newTestJob(t *testing.T, st *storageMocks.User, rec Recorder, policies ...Policy) *Job {
+	j, err := NewJob(Config{
+		UserStorage: st,
+		Recorder:    rec,
+		Policies:    policies,
+		Interval:    time.Hour,
+		BatchSize:   2,
+	})
+	require.NoError(t, err)
+	j.now = func() time.Time {
+		return time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return j
+}
+
+func Test_NewJob(t *testing.T) {
+	t.Run("invalid policy", func(t *testing.T) {
+		_, err := NewJob(Config{
+			Policies: []Policy{{}},
+			Interval: time.Hour,
+		})
+		require.Error(t, err)
+	})
+	t.Run("invalid interval", func(t *testing.T) {
+		_, err := NewJob(Config{})
+		require.Error(t, err)
+		require.EqualError(t, err, "retention interval must be positive")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		j, err := NewJob(Config{
+			Policies: DefaultPolicies(),
+			Interval: time.Hour,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, j)
+		require.Equal(t, int64(defaultBatchSize), j.batchSize)
+	})
+}
+
+func TestJob_Run(t *testing.T) {
+	purge := Policy{
+		Status: "ACCOUNT_STATUS_DELETED",
+		After:  24 * time.Hour,
+		Action: ActionPurge,
+	}
+	anonymise := Policy{
+		Status: "ACCOUNT_STATUS_ACTIVE",
+		After:  24 * time.Hour,
+		Action: ActionAnonymise,
+	}
+	before := time.Date(2020, 10, 31, 0, 0, 0, 0, time.UTC)
+	filterMatcher := func(status string, offset int64) interface{} {
+		return mock.MatchedBy(func(f model.UserFindFilter) bool {
+			timeBefore := f.InactiveBefore
+			if status == model.StatusDeleted {
+				timeBefore = f.DeletedBefore
+			}
+			return f.Statuses[0] == status && timeBefore.Equal(before) && f.UpdatedBefore == nil &&
+				*f.Offset == offset && *f.Limit == 2
+		})
+	}
+	t.Run("find error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errMock)
+		_, err := newTestJob(t, st, nil, purge).Run(context.Background(), false)
+		require.Error(t, err)
+		require.EqualError(t, err, "could not apply policy for ACCOUNT_STATUS_DELETED status: "+errMock.Error())
+	})
+	t.Run("dry run", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		rec := new(testRecorder)
+		st.On("Find", mock.Anything, filterMatcher(purge.Status, 0)).Return([]model.User{
+			{ID: "1", Status: purge.Status},
+			{ID: "2", Status: purge.Status},
+		}, nil).Once()
+		st.On("Find", mock.Anything, filterMatcher(purge.Status, 2)).Return([]model.User{
+			{ID: "3", Status: purge.Status},
+		}, nil).Once()
+		report, err := newTestJob(t, st, rec, purge).Run(context.Background(), true)
+		require.NoError(t, err)
+		require.True(t, report.DryRun)
+		require.Len(t, report.Records, 3)
+		require.Equal(t, report.Records, rec.records)
+		for i := range report.Records {
+			require.True(t, report.Records[i].DryRun)
+			require.Equal(t, ActionPurge, report.Records[i].Action)
+		}
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		rec := new(testRecorder)
+		activeUser := model.User{
+			ID:           "3",
+			Status:       anonymise.Status,
+			Meta:         map[string]interface{}{"email": "john@example.com"},
+			LastActiveAt: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+			UpdatedAt:    time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		st.On("Find", mock.Anything, filterMatcher(purge.Status, 0)).Return([]model.User{
+			{ID: "1", Status: purge.Status},
+			{ID: "2", Status: purge.Status},
+		}, nil).Once()
+		st.On("Delete", mock.Anything, "1").Return(nil).Once()
+		st.On("Delete", mock.Anything, "2").Return(errMock).Once()
+		st.On("Find", mock.Anything, filterMatcher(purge.Status, 1)).Return([]model.User{}, nil).Once()
+		st.On("Find", mock.Anything, filterMatcher(anonymise.Status, 0)).Return([]model.User{activeUser}, nil).Once()
+		st.On("Anonymise", mock.Anything, activeUser).Return(&model.User{}, nil).Once()
+		report, err := newTestJob(t, st, rec, purge, anonymise).Run(context.Background(), false)
+		require.NoError(t, err)
+		require.Len(t, report.Records, 3)
+		require.Equal(t, 1, report.Failed())
+		require.Equal(t, errMock, report.Records[1].Err)
+		require.Equal(t, ActionAnonymise, report.Records[2].Action)
+		require.Equal(t, report.Records, rec.records)
+	})
+}
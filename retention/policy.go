@@ -0,0 +1,125 @@
+package retention
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// Action represents retention action.
+type Action string
+
+// There are available retention actions.
+const (
+	// ActionPurge removes user entirely along with the data kept apart from it.
+	ActionPurge Action = "purge"
+	// ActionAnonymise removes user's personal data keeping the record itself.
+	ActionAnonymise Action = "anonymise"
+)
+
+// There are default retention periods.
+const (
+	DefaultDeletedRetention  = 30 * 24 * time.Hour
+	DefaultInactiveRetention = 3 * 365 * 24 * time.Hour
+)
+
+// Policy represents retention policy for users with the specific status.
+type Policy struct {
+	Status string
+	// After is a period after which the action is applied. It's measured since the deletion
+	// for deleted users and since the last activity for the rest, users without the time aren't matched.
+	After  time.Duration
+	Action Action
+}
+
+// filter returns the filter which matches users the policy applies to at the provided time.
+func (p *Policy) filter(at time.Time) model.UserFindFilter {
+	before := at.Add(-p.After)
+	filter := model.UserFindFilter{
+		Statuses: []string{p.Status},
+	}
+	if p.Status == model.StatusDeleted {
+		filter.DeletedBefore = &before
+	} else {
+		filter.InactiveBefore = &before
+	}
+	return filter
+}
+
+type policyFile struct {
+	Status string `json:"status"`
+	After  string `json:"after"`
+	Action Action `json:"action"`
+}
+
+// DefaultPolicies returns policies which purge deleted users after 30 days
+// and anonymise users which were inactive for 3 years.
+func DefaultPolicies() []Policy {
+	return []Policy{
+		{
+			Status: model.StatusDeleted,
+			After:  DefaultDeletedRetention,
+			Action: ActionPurge,
+		},
+		{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			After:  DefaultInactiveRetention,
+			Action: ActionAnonymise,
+		},
+	}
+}
+
+// Validate validates retention policy.
+func (p *Policy) Validate() error {
+	if strings.TrimSpace(p.Status) == "" {
+		return errors.New("policy status is required")
+	}
+	if p.After <= 0 {
+		return errors.Errorf("policy for %s status must have positive retention period", p.Status)
+	}
+	if p.Action != ActionPurge && p.Action != ActionAnonymise {
+		return errors.Errorf("unknown policy action %q", p.Action)
+	}
+	return nil
+}
+
+// LoadPolicies loads retention policies from the JSON file.
+// Retention periods use time.ParseDuration format.
+func LoadPolicies(path string) ([]Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s file data", path)
+	}
+
+	var files []policyFile
+	if err := json.Unmarshal(data, &files); err != nil {
+		return nil, errors.Wrap(err, "could not parse policies file")
+	}
+
+	policies := make([]Policy, len(files))
+	statuses := make(map[string]struct{}, len(files))
+	for i := range files {
+		after, err := time.ParseDuration(files[i].After)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse retention period for %s status", files[i].Status)
+		}
+		policies[i] = Policy{
+			Status: files[i].Status,
+			After:  after,
+			Action: files[i].Action,
+		}
+		if err := policies[i].Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := statuses[policies[i].Status]; ok {
+			return nil, errors.Errorf("duplicate policy for %s status", policies[i].Status)
+		}
+		statuses[policies[i].Status] = struct{}{}
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,119 @@
+package retention
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func writePoliciesFile(t *testing.T, data string) string {
+	dir, err := ioutil.TempDir("", "retention")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	fPath := path.Join(dir, "policies.json")
+	require.NoError(t, ioutil.WriteFile(fPath, []byte(data), os.ModePerm))
+	return fPath
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		policy Policy
+		err    string
+	}{
+		{
+			name:   "empty status",
+			policy: Policy{After: time.Hour, Action: ActionPurge},
+			err:    "policy status is required",
+		},
+		{
+			name:   "invalid period",
+			policy: Policy{Status: "ACCOUNT_STATUS_DELETED", Action: ActionPurge},
+			err:    "policy for ACCOUNT_STATUS_DELETED status must have positive retention period",
+		},
+		{
+			name:   "unknown action",
+			policy: Policy{Status: "ACCOUNT_STATUS_DELETED", After: time.Hour, Action: "archive"},
+			err:    `unknown policy action "archive"`,
+		},
+		{
+			name:   "all ok",
+			policy: Policy{Status: "ACCOUNT_STATUS_DELETED", After: time.Hour, Action: ActionPurge},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestPolicy_filter(t *testing.T) {
+	at := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	before := at.Add(-time.Hour)
+	t.Run("deleted users", func(t *testing.T) {
+		policy := Policy{Status: model.StatusDeleted, After: time.Hour, Action: ActionPurge}
+		require.Equal(t, model.UserFindFilter{
+			Statuses:      []string{model.StatusDeleted},
+			DeletedBefore: &before,
+		}, policy.filter(at))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		policy := Policy{Status: "ACCOUNT_STATUS_ACTIVE", After: time.Hour, Action: ActionAnonymise}
+		require.Equal(t, model.UserFindFilter{
+			Statuses:       []string{"ACCOUNT_STATUS_ACTIVE"},
+			InactiveBefore: &before,
+		}, policy.filter(at))
+	})
+}
+
+func Test_DefaultPolicies(t *testing.T) {
+	policies := DefaultPolicies()
+	require.Len(t, policies, 2)
+	for i := range policies {
+		require.NoError(t, policies[i].Validate())
+	}
+}
+
+func Test_LoadPolicies(t *testing.T) {
+	t.Run("read file error", func(t *testing.T) {
+		_, err := LoadPolicies("invalid/path.json")
+		require.Error(t, err)
+	})
+	t.Run("invalid period", func(t *testing.T) {
+		_, err := LoadPolicies(writePoliciesFile(t, `[{"status":"ACCOUNT_STATUS_DELETED","after":"30 days","action":"purge"}]`))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "could not parse retention period for ACCOUNT_STATUS_DELETED status")
+	})
+	t.Run("duplicate status", func(t *testing.T) {
+		_, err := LoadPolicies(writePoliciesFile(t, `[
+			{"status":"ACCOUNT_STATUS_DELETED","after":"720h","action":"purge"},
+			{"status":"ACCOUNT_STATUS_DELETED","after":"24h","action":"anonymise"}
+		]`))
+		require.Error(t, err)
+		require.EqualError(t, err, "duplicate policy for ACCOUNT_STATUS_DELETED status")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		policies, err := LoadPolicies(writePoliciesFile(t, `[{"status":"ACCOUNT_STATUS_DELETED","after":"720h","action":"purge"}]`))
+		require.NoError(t, err)
+		require.Equal(t, []Policy{
+			{
+				Status: "ACCOUNT_STATUS_DELETED",
+				After:  DefaultDeletedRetention,
+				Action: ActionPurge,
+			},
+		}, policies)
+	})
+}
@@ -0,0 +1,54 @@
+package retention
+
+import (
+	"context"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/sirupsen/logrus"
+)
+
+// Record represents a single retention action result.
+type Record struct {
+	UserID string
+	Status string
+	Action Action
+	// UpdatedAt is the last user update time the policy was applied against.
+	UpdatedAt time.Time
+	At        time.Time
+	DryRun    bool
+	Err       error
+}
+
+// Recorder represents retention records sink.
+type Recorder interface {
+	Record(ctx context.Context, record Record)
+}
+
+// LogRecorder writes retention records into the service log.
+type LogRecorder struct {
+	logger *commonLog.Logger
+}
+
+// NewLogRecorder creates new LogRecorder instance.
+func NewLogRecorder(logger *commonLog.Logger) *LogRecorder {
+	return &LogRecorder{
+		logger: logger,
+	}
+}
+
+// Record writes retention record into the log.
+func (r *LogRecorder) Record(_ context.Context, record Record) {
+	entry := r.logger.WithFields(logrus.Fields{
+		"user_id":    record.UserID,
+		"status":     record.Status,
+		"action":     record.Action,
+		"updated_at": record.UpdatedAt,
+		"dry_run":    record.DryRun,
+	})
+	if record.Err != nil {
+		entry.WithError(record.Err).Error("retention action failed")
+		return
+	}
+	entry.Info("retention action applied")
+}
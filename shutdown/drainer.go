@@ -0,0 +1,98 @@
+package shutdown
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout is the default period in-flight requests and background
+// workers are given to finish on shutdown.
+const DefaultTimeout = 30 * time.Second
+
+// Drainer tracks in-flight requests and background workers so they can
+// finish before the storage is disconnected.
+type Drainer struct {
+	mu       sync.Mutex
+	closed   bool
+	requests sync.WaitGroup
+	workers  sync.WaitGroup
+
+	workersCtx    context.Context
+	cancelWorkers context.CancelFunc
+}
+
+// NewDrainer creates new Drainer instance.
+func NewDrainer() *Drainer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Drainer{
+		workersCtx:    ctx,
+		cancelWorkers: cancel,
+	}
+}
+
+// Go runs background worker, its context is canceled on drain.
+func (d *Drainer) Go(worker func(ctx context.Context)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return
+	}
+	d.workers.Add(1)
+	go func() {
+		defer d.workers.Done()
+		worker(d.workersCtx)
+	}()
+}
+
+// HandlerWrapper returns go-micro handler wrapper which tracks in-flight
+// requests and rejects new ones once the drainer is closed.
+func (d *Drainer) HandlerWrapper() server.HandlerWrapper {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			d.mu.Lock()
+			if d.closed {
+				d.mu.Unlock()
+				return microErrors.New(req.Service(), "service is shutting down", http.StatusServiceUnavailable)
+			}
+			d.requests.Add(1)
+			d.mu.Unlock()
+			defer d.requests.Done()
+
+			return next(ctx, req, rsp)
+		}
+	}
+}
+
+// Close stops accepting new requests and workers.
+func (d *Drainer) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+}
+
+// Drain closes the drainer, cancels background workers and waits for them
+// and in-flight requests to finish until the context is done.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.Close()
+	d.cancelWorkers()
+
+	done := make(chan struct{})
+	go func() {
+		d.requests.Wait()
+		d.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "could not drain requests and workers")
+	}
+}
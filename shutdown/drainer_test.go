@@ -0,0 +1,68 @@
+package shutdown
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	microErrors "github.com/micro/go-micro/v2/errors"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func (testRequest) Service() string { return "user" }
+
+func TestDrainer_Drain(t *testing.T) {
+	t.Run("in-flight request exceeds deadline", func(t *testing.T) {
+		d := NewDrainer()
+		started, release := make(chan struct{}), make(chan struct{})
+		handler := d.HandlerWrapper()(func(context.Context, server.Request, interface{}) error {
+			close(started)
+			<-release
+			return nil
+		})
+		go func() {
+			_ = handler(context.Background(), testRequest{}, nil)
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := d.Drain(ctx)
+		require.Error(t, err)
+		require.EqualError(t, err, "could not drain requests and workers: context deadline exceeded")
+		close(release)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		d := NewDrainer()
+		started, finished := make(chan struct{}), make(chan struct{})
+		handler := d.HandlerWrapper()(func(context.Context, server.Request, interface{}) error {
+			close(started)
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+		go func() {
+			require.NoError(t, handler(context.Background(), testRequest{}, nil))
+		}()
+		d.Go(func(ctx context.Context) {
+			<-ctx.Done()
+			close(finished)
+		})
+		<-started
+
+		require.NoError(t, d.Drain(context.Background()))
+		<-finished
+
+		err := handler(context.Background(), testRequest{}, nil)
+		require.Error(t, err)
+		require.Equal(t, int32(503), microErrors.FromError(err).Code)
+
+		d.Go(func(context.Context) {
+			t.Error("worker must not run after drain")
+		})
+	})
+}
@@ -0,0 +1,51 @@
+package status
+
+import (
+	"context"
+
+	"github.com/open-Q/user/export"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+)
+
+// HistorySource provides user's status transitions for data exports.
+type HistorySource struct {
+	userStorage storage.User
+}
+
+// NewHistorySource creates new HistorySource instance.
+func NewHistorySource(userStorage storage.User) *HistorySource {
+	return &HistorySource{
+		userStorage: userStorage,
+	}
+}
+
+// Name returns the name of the source used in the exported entries.
+func (h *HistorySource) Name() string {
+	return "status"
+}
+
+// History returns user's status transitions.
+func (h *HistorySource) History(ctx context.Context, userID string) ([]export.HistoryEntry, error) {
+	users, err := h.userStorage.Find(ctx, model.UserFindFilter{
+		IDs: []string{userID},
+	})
+	if err != nil || len(users) == 0 {
+		return nil, err
+	}
+
+	entries := make([]export.HistoryEntry, len(users[0].StatusHistory))
+	for i, change := range users[0].StatusHistory {
+		entries[i] = export.HistoryEntry{
+			At:   change.At,
+			Type: "status_change",
+			Details: map[string]interface{}{
+				"from":   change.From,
+				"to":     change.To,
+				"reason": change.Reason,
+				"actor":  change.Actor,
+			},
+		}
+	}
+	return entries, nil
+}
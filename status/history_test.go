@@ -0,0 +1,62 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/export"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistorySource_History(t *testing.T) {
+	filter := model.UserFindFilter{IDs: []string{"1"}}
+	t.Run("find user error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return(nil, errors.New("error"))
+		_, err := NewHistorySource(st).History(context.Background(), "1")
+		require.EqualError(t, err, "error")
+	})
+	t.Run("user not found", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, filter).Return([]model.User{}, nil)
+		entries, err := NewHistorySource(st).History(context.Background(), "1")
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		at := time.Date(2020, 11, 1, 10, 0, 0, 0, time.UTC)
+		st.On("Find", mock.Anything, filter).Return([]model.User{
+			{
+				ID: "1",
+				StatusHistory: []model.StatusChange{
+					{From: Active, To: Suspended, Reason: "abuse", Actor: "admin", At: at},
+				},
+			},
+		}, nil)
+		source := NewHistorySource(st)
+		require.Equal(t, "status", source.Name())
+		entries, err := source.History(context.Background(), "1")
+		require.NoError(t, err)
+		require.Equal(t, []export.HistoryEntry{
+			{
+				At:   at,
+				Type: "status_change",
+				Details: map[string]interface{}{
+					"from":   Active,
+					"to":     Suspended,
+					"reason": "abuse",
+					"actor":  "admin",
+				},
+			},
+		}, entries)
+	})
+}
@@ -0,0 +1,205 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are account statuses.
+const (
+	Active      = "ACCOUNT_STATUS_ACTIVE"
+	Suspended   = "ACCOUNT_STATUS_SUSPENDED"
+	Banned      = "ACCOUNT_STATUS_BANNED"
+	Deactivated = "ACCOUNT_STATUS_DEACTIVATED"
+	Deleted     = model.StatusDeleted
+	// Merged is set by merging the user into another one only and has no transitions.
+	Merged = "ACCOUNT_STATUS_MERGED"
+)
+
+// There are reason codes of the status changes made by the service.
+const (
+	// ReasonExpired is a reason code of reverting temporary status changes.
+	ReasonExpired = "expired"
+	// ReasonMerged is a reason code of merging the user into another one.
+	ReasonMerged = "merged"
+	// ReasonDeleted is a reason code of deleting the user.
+	ReasonDeleted = "deleted"
+)
+
+// ActorService is an actor of the status changes requested without one, e.g. deletions.
+const ActorService = "user-service"
+
+// statuses contains known account statuses.
+var statuses = map[string]struct{}{
+	Active:      {},
+	Suspended:   {},
+	Banned:      {},
+	Deactivated: {},
+	Deleted:     {},
+}
+
+// Known checks if the status is a known account status.
+// Merged status is set by merging only, so it isn't a known one.
+func Known(status string) bool {
+	_, ok := statuses[status]
+	return ok
+}
+
+// Transition represents allowed status transition.
+type Transition struct {
+	From string
+	To   string
+	// Reasons limits reason codes of the transition, any code is accepted if empty.
+	Reasons []string
+}
+
+// TransitionError represents rejected status transition.
+type TransitionError struct {
+	From string
+	To   string
+}
+
+// Error returns error as a string value.
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transition from %s to %s is not allowed", e.From, e.To)
+}
+
+// Machine represents account status lifecycle.
+type Machine struct {
+	initial     string
+	transitions map[string]map[string]Transition
+}
+
+type machineFile struct {
+	Initial     string `json:"initial"`
+	Transitions []struct {
+		From    string   `json:"from"`
+		To      string   `json:"to"`
+		Reasons []string `json:"reasons"`
+	} `json:"transitions"`
+}
+
+// DefaultTransitions returns transitions which allow suspending, banning and
+// deactivating active or suspended users, reactivating suspended or deactivated ones,
+// and deleting any of them.
+func DefaultTransitions() []Transition {
+	return []Transition{
+		{From: Active, To: Suspended},
+		{From: Suspended, To: Active},
+		{From: Active, To: Banned},
+		{From: Suspended, To: Banned},
+		{From: Active, To: Deactivated},
+		{From: Suspended, To: Deactivated},
+		{From: Deactivated, To: Active},
+		{From: Active, To: Deleted},
+		{From: Suspended, To: Deleted},
+		{From: Banned, To: Deleted},
+		{From: Deactivated, To: Deleted},
+	}
+}
+
+// DefaultMachine returns machine which creates active users and uses default transitions.
+func DefaultMachine() *Machine {
+	// default transitions are always valid.
+	m, _ := NewMachine(Active, DefaultTransitions())
+	return m
+}
+
+// NewMachine creates new Machine instance.
+// Users are created with the initial status.
+func NewMachine(initial string, transitions []Transition) (*Machine, error) {
+	if _, ok := statuses[initial]; !ok {
+		return nil, errors.Errorf("unknown initial status %q", initial)
+	}
+	m := Machine{
+		initial:     initial,
+		transitions: make(map[string]map[string]Transition, len(transitions)),
+	}
+	for _, t := range transitions {
+		if _, ok := statuses[t.From]; !ok {
+			return nil, errors.Errorf("unknown transition status %q", t.From)
+		}
+		if _, ok := statuses[t.To]; !ok {
+			return nil, errors.Errorf("unknown transition status %q", t.To)
+		}
+		if t.From == t.To {
+			return nil, errors.Errorf("transition from %s to itself", t.From)
+		}
+		if _, ok := m.transitions[t.From][t.To]; ok {
+			return nil, errors.Errorf("duplicate transition from %s to %s", t.From, t.To)
+		}
+		for i := range t.Reasons {
+			if strings.TrimSpace(t.Reasons[i]) == "" {
+				return nil, errors.Errorf("empty reason of transition from %s to %s", t.From, t.To)
+			}
+		}
+		if m.transitions[t.From] == nil {
+			m.transitions[t.From] = make(map[string]Transition)
+		}
+		m.transitions[t.From][t.To] = t
+	}
+	return &m, nil
+}
+
+// LoadMachine loads initial status and transitions from the JSON file.
+func LoadMachine(path string) (*Machine, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s file data", path)
+	}
+
+	var file machineFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrap(err, "could not parse status transitions file")
+	}
+
+	transitions := make([]Transition, len(file.Transitions))
+	for i, t := range file.Transitions {
+		transitions[i] = Transition{
+			From:    t.From,
+			To:      t.To,
+			Reasons: t.Reasons,
+		}
+	}
+	return NewMachine(file.Initial, transitions)
+}
+
+// Initial returns status of the created users.
+func (m *Machine) Initial() string {
+	return m.initial
+}
+
+// Check checks if the user may be moved from one status to another.
+// Reason code and actor are required for every transition.
+func (m *Machine) Check(from, to, reason, actor string) error {
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("reason is required")
+	}
+	if strings.TrimSpace(actor) == "" {
+		return errors.New("actor is required")
+	}
+	if from == to {
+		return errors.Errorf("user is already %s", to)
+	}
+	t, ok := m.transitions[from][to]
+	if !ok {
+		return &TransitionError{From: from, To: to}
+	}
+	if len(t.Reasons) == 0 {
+		return nil
+	}
+	for i := range t.Reasons {
+		if t.Reasons[i] == reason {
+			return nil
+		}
+	}
+	reasons := append([]string(nil), t.Reasons...)
+	sort.Strings(reasons)
+	return errors.Errorf("unknown reason %q of transition from %s to %s, expected one of: %s", reason, from, to, strings.Join(reasons, ", "))
+}
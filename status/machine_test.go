@@ -0,0 +1,199 @@
+package status
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeMachineFile(t *testing.T, data string) string {
+	dir, err := ioutil.TempDir("", "status")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	fPath := path.Join(dir, "transitions.json")
+	require.NoError(t, ioutil.WriteFile(fPath, []byte(data), os.ModePerm))
+	return fPath
+}
+
+func TestNewMachine(t *testing.T) {
+	tt := []struct {
+		name        string
+		initial     string
+		transitions []Transition
+		err         string
+	}{
+		{
+			name:    "unknown initial status",
+			initial: "ACCOUNT_STATUS_NEW",
+			err:     `unknown initial status "ACCOUNT_STATUS_NEW"`,
+		},
+		{
+			name:        "unknown status",
+			initial:     Active,
+			transitions: []Transition{{From: Active, To: "ACCOUNT_STATUS_LOCKED"}},
+			err:         `unknown transition status "ACCOUNT_STATUS_LOCKED"`,
+		},
+		{
+			name:        "transition to itself",
+			initial:     Active,
+			transitions: []Transition{{From: Active, To: Active}},
+			err:         "transition from ACCOUNT_STATUS_ACTIVE to itself",
+		},
+		{
+			name:        "duplicate transition",
+			initial:     Active,
+			transitions: []Transition{{From: Active, To: Banned}, {From: Active, To: Banned}},
+			err:         "duplicate transition from ACCOUNT_STATUS_ACTIVE to ACCOUNT_STATUS_BANNED",
+		},
+		{
+			name:        "empty reason",
+			initial:     Active,
+			transitions: []Transition{{From: Active, To: Banned, Reasons: []string{" "}}},
+			err:         "empty reason of transition from ACCOUNT_STATUS_ACTIVE to ACCOUNT_STATUS_BANNED",
+		},
+		{
+			name:        "all ok",
+			initial:     Active,
+			transitions: DefaultTransitions(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewMachine(tc.initial, tc.transitions)
+			if tc.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.initial, m.Initial())
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestDefaultMachine(t *testing.T) {
+	m := DefaultMachine()
+	require.NotNil(t, m)
+	require.Equal(t, Active, m.Initial())
+	require.NoError(t, m.Check(Active, Suspended, "abuse", "admin"))
+}
+
+func TestLoadMachine(t *testing.T) {
+	t.Run("read file error", func(t *testing.T) {
+		_, err := LoadMachine("/not/existing/transitions.json")
+		require.Error(t, err)
+	})
+	t.Run("parse error", func(t *testing.T) {
+		_, err := LoadMachine(writeMachineFile(t, "{"))
+		require.Error(t, err)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		m, err := LoadMachine(writeMachineFile(t, `{
+			"initial": "ACCOUNT_STATUS_SUSPENDED",
+			"transitions": [
+				{"from": "ACCOUNT_STATUS_SUSPENDED", "to": "ACCOUNT_STATUS_ACTIVE", "reasons": ["email_verified"]}
+			]
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, Suspended, m.Initial())
+		require.NoError(t, m.Check(Suspended, Active, "email_verified", "system"))
+		require.Error(t, m.Check(Active, Suspended, "abuse", "admin"))
+	})
+}
+
+func TestMachine_Check(t *testing.T) {
+	m, err := NewMachine(Active, []Transition{
+		{From: Active, To: Suspended},
+		{From: Active, To: Banned, Reasons: []string{"spam", "fraud"}},
+	})
+	require.NoError(t, err)
+	tt := []struct {
+		name   string
+		from   string
+		to     string
+		reason string
+		actor  string
+		err    string
+	}{
+		{
+			name:  "empty reason",
+			from:  Active,
+			to:    Suspended,
+			actor: "admin",
+			err:   "reason is required",
+		},
+		{
+			name:   "empty actor",
+			from:   Active,
+			to:     Suspended,
+			reason: "abuse",
+			err:    "actor is required",
+		},
+		{
+			name:   "same status",
+			from:   Active,
+			to:     Active,
+			reason: "abuse",
+			actor:  "admin",
+			err:    "user is already ACCOUNT_STATUS_ACTIVE",
+		},
+		{
+			name:   "not allowed transition",
+			from:   Suspended,
+			to:     Active,
+			reason: "cleared",
+			actor:  "admin",
+			err:    "transition from ACCOUNT_STATUS_SUSPENDED to ACCOUNT_STATUS_ACTIVE is not allowed",
+		},
+		{
+			name:   "unknown reason",
+			from:   Active,
+			to:     Banned,
+			reason: "abuse",
+			actor:  "admin",
+			err:    `unknown reason "abuse" of transition from ACCOUNT_STATUS_ACTIVE to ACCOUNT_STATUS_BANNED, expected one of: fraud, spam`,
+		},
+		{
+			name:   "all ok (any reason)",
+			from:   Active,
+			to:     Suspended,
+			reason: "abuse",
+			actor:  "admin",
+		},
+		{
+			name:   "all ok",
+			from:   Active,
+			to:     Banned,
+			reason: "spam",
+			actor:  "admin",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := m.Check(tc.from, tc.to, tc.reason, tc.actor)
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.err)
+		})
+	}
+	t.Run("transition error", func(t *testing.T) {
+		err := m.Check(Banned, Active, "cleared", "admin")
+		var transitionErr *TransitionError
+		require.ErrorAs(t, err, &transitionErr)
+		require.Equal(t, Banned, transitionErr.From)
+		require.Equal(t, Active, transitionErr.To)
+	})
+}
+
+func TestKnown(t *testing.T) {
+	require.True(t, Known(Active))
+	require.True(t, Known(Deleted))
+	require.False(t, Known(Merged))
+	require.False(t, Known("ACCOUNT_STATUS_BLOCKED"))
+}
@@ -0,0 +1,190 @@
+package status
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSchedulerInterval is a default period between scheduler runs.
+const DefaultSchedulerInterval = time.Minute
+
+const defaultBatchSize = 100
+
+// SchedulerConfig represents status scheduler configuration.
+type SchedulerConfig struct {
+	UserStorage storage.User
+	Machine     *Machine
+	Logger      *commonLog.Logger
+	// OnChange is called after the user's status is changed, e.g. to drop cached permissions.
+	OnChange func(userID string)
+	// Interval is a period between runs, DefaultSchedulerInterval is used if empty.
+	Interval  time.Duration
+	BatchSize int64
+}
+
+// Scheduler executes due status schedules.
+// Every schedule is removed atomically with the status change it makes,
+// so replicas running the scheduler concurrently execute it once.
+type Scheduler struct {
+	userStorage storage.User
+	machine     *Machine
+	logger      *commonLog.Logger
+	onChange    func(userID string)
+	interval    time.Duration
+	batchSize   int64
+	now         func() time.Time
+}
+
+// NewScheduler creates new Scheduler instance.
+func NewScheduler(cfg SchedulerConfig) *Scheduler {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultSchedulerInterval
+	}
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Scheduler{
+		userStorage: cfg.UserStorage,
+		machine:     cfg.Machine,
+		logger:      cfg.Logger,
+		onChange:    cfg.OnChange,
+		interval:    interval,
+		batchSize:   batchSize,
+		now:         time.Now,
+	}
+}
+
+// Start executes due schedules on every interval until the context is canceled.
+func (s *Scheduler) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.Run(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).Error("could not execute status schedules")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run executes all due schedules once and returns the number of executed ones.
+// Schedules which can't be applied anymore, e.g. because the status was changed
+// since they were created, are removed without changing the status.
+func (s *Scheduler) Run(ctx context.Context) (int, error) {
+	now := s.now().UTC()
+	limit := s.batchSize
+	var offset int64
+	var executed int
+	for {
+		if err := ctx.Err(); err != nil {
+			return executed, err
+		}
+
+		users, err := s.userStorage.Find(ctx, model.UserFindFilter{
+			ScheduledBefore: &now,
+			Limit:           &limit,
+			Offset:          &offset,
+		})
+		if err != nil {
+			return executed, errors.Wrap(err, "could not find users with due schedules")
+		}
+
+		for i := range users {
+			schedules := dueSchedules(users[i].StatusSchedules, now)
+			var failed bool
+			for j := range schedules {
+				ok, err := s.execute(ctx, &users[i], schedules[j])
+				if err != nil {
+					failed = true
+					break
+				}
+				if ok {
+					executed++
+				}
+			}
+			// users with failed schedules are still matched by the filter.
+			if failed {
+				offset++
+			}
+		}
+
+		if int64(len(users)) < limit {
+			return executed, nil
+		}
+	}
+}
+
+// execute applies the schedule to the user and updates the user.
+// Returns false if the schedule was dropped instead.
+func (s *Scheduler) execute(ctx context.Context, user *model.User, schedule model.StatusSchedule) (bool, error) {
+	logger := s.logger.WithFields(logrus.Fields{
+		"user_id":     user.ID,
+		"schedule_id": schedule.ID,
+		"from":        user.Status,
+		"to":          schedule.To,
+		"reason":      schedule.Reason,
+		"actor":       schedule.Actor,
+	})
+
+	dropReason := ""
+	if schedule.From != "" && schedule.From != user.Status {
+		dropReason = "status was changed"
+	} else if err := s.machine.Check(user.Status, schedule.To, schedule.Reason, schedule.Actor); err != nil {
+		dropReason = err.Error()
+	}
+	if dropReason != "" {
+		updatedUser, err := s.userStorage.RemoveStatusSchedule(ctx, user.ID, schedule.ID)
+		if err != nil {
+			logger.WithError(err).Warn("could not drop status schedule")
+			return false, err
+		}
+		logger.WithField("drop_reason", dropReason).Info("status schedule dropped")
+		*user = *updatedUser
+		return false, nil
+	}
+
+	updatedUser, err := s.userStorage.ChangeStatus(ctx, user.ID, model.StatusChange{
+		From:       user.Status,
+		To:         schedule.To,
+		Reason:     schedule.Reason,
+		Actor:      schedule.Actor,
+		ScheduleID: schedule.ID,
+	})
+	if err != nil {
+		// another replica might have executed it already.
+		logger.WithError(err).Warn("could not execute status schedule")
+		return false, err
+	}
+	logger.Info("status schedule executed")
+	if s.onChange != nil {
+		s.onChange(user.ID)
+	}
+	*user = *updatedUser
+	return true, nil
+}
+
+// dueSchedules returns schedules due at the time in the order they are due.
+func dueSchedules(schedules []model.StatusSchedule, now time.Time) []model.StatusSchedule {
+	var due []model.StatusSchedule
+	for i := range schedules {
+		if !schedules[i].At.After(now) {
+			due = append(due, schedules[i])
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].At.Before(due[j].At)
+	})
+	return due
+}
@@ -0,0 +1,105 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var errMock = errors.New("error")
+
+func newTestScheduler(st *storageMocks.User) *Scheduler {
+	s := NewScheduler(SchedulerConfig{
+		UserStorage: st,
+		Machine:     DefaultMachine(),
+		Logger:      &commonLog.Logger{Logger: logrus.New()},
+		BatchSize:   2,
+	})
+	s.now = func() time.Time {
+		return time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return s
+}
+
+func withOffset(offset int64) interface{} {
+	return mock.MatchedBy(func(filter model.UserFindFilter) bool {
+		return filter.ScheduledBefore != nil && filter.Offset != nil && *filter.Offset == offset
+	})
+}
+
+func TestNewScheduler(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{})
+	require.Equal(t, DefaultSchedulerInterval, s.interval)
+	require.Equal(t, int64(defaultBatchSize), s.batchSize)
+}
+
+func TestScheduler_Run(t *testing.T) {
+	t.Run("find users error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		st.On("Find", mock.Anything, withOffset(0)).Return(nil, errMock)
+		_, err := newTestScheduler(st).Run(context.Background())
+		require.EqualError(t, err, "could not find users with due schedules: error")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		past := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+		future := time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)
+		reversal := model.StatusSchedule{ID: "s1", From: Suspended, To: Active, Reason: ReasonExpired, Actor: "admin", At: past}
+		pending := model.StatusSchedule{ID: "s2", To: Banned, Reason: "abuse", Actor: "admin", At: future}
+		obsolete := model.StatusSchedule{ID: "s3", From: Suspended, To: Active, Reason: ReasonExpired, Actor: "admin", At: past}
+		failing := model.StatusSchedule{ID: "s4", To: Deactivated, Reason: "request", Actor: "user", At: past}
+		invalid := model.StatusSchedule{ID: "s5", To: Suspended, Reason: "abuse", Actor: "admin", At: past}
+
+		st.On("Find", mock.Anything, withOffset(0)).Return([]model.User{
+			{ID: "1", Status: Suspended, StatusSchedules: []model.StatusSchedule{pending, reversal}},
+			{ID: "2", Status: Active, StatusSchedules: []model.StatusSchedule{obsolete}},
+		}, nil).Once()
+		st.On("ChangeStatus", mock.Anything, "1", model.StatusChange{
+			From:       Suspended,
+			To:         Active,
+			Reason:     ReasonExpired,
+			Actor:      "admin",
+			ScheduleID: "s1",
+		}).Return(&model.User{ID: "1", Status: Active, StatusSchedules: []model.StatusSchedule{pending}}, nil)
+		st.On("RemoveStatusSchedule", mock.Anything, "2", "s3").Return(&model.User{ID: "2", Status: Active}, nil)
+
+		st.On("Find", mock.Anything, withOffset(0)).Return([]model.User{
+			{ID: "3", Status: Active, StatusSchedules: []model.StatusSchedule{failing}},
+			{ID: "4", Status: Banned, StatusSchedules: []model.StatusSchedule{invalid}},
+		}, nil).Once()
+		st.On("ChangeStatus", mock.Anything, "3", mock.Anything).Return(nil, errMock)
+		st.On("RemoveStatusSchedule", mock.Anything, "4", "s5").Return(&model.User{ID: "4", Status: Banned}, nil)
+
+		st.On("Find", mock.Anything, withOffset(1)).Return([]model.User{}, nil).Once()
+
+		s := newTestScheduler(st)
+		var changed []string
+		s.onChange = func(userID string) {
+			changed = append(changed, userID)
+		}
+		executed, err := s.Run(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, 1, executed)
+		require.Equal(t, []string{"1"}, changed)
+	})
+}
+
+func Test_dueSchedules(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	schedules := []model.StatusSchedule{
+		{ID: "1", At: now.Add(time.Hour)},
+		{ID: "2", At: now},
+		{ID: "3", At: now.Add(-time.Hour)},
+	}
+	require.Equal(t, []model.StatusSchedule{schedules[2], schedules[1]}, dueSchedules(schedules, now))
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	apiKeyCollection = "api_key"
+)
+
+// MongoAPIKeyStorage represents mongo API key storage model.
+type MongoAPIKeyStorage struct {
+	collection *commonStorage.MongoCollection
+}
+
+// MongoAPIKey represents API key mongo storage model.
+type MongoAPIKey struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	Name       string             `bson:"name"`
+	Prefix     string             `bson:"prefix"`
+	Hash       string             `bson:"hash"`
+	Scopes     []string           `bson:"scopes,omitempty"`
+	ExpiresAt  time.Time          `bson:"expires_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at,omitempty"`
+	LastUsedAt time.Time          `bson:"last_used_at,omitempty"`
+	RevokedAt  time.Time          `bson:"revoked_at,omitempty"`
+}
+
+// NewMongoAPIKeyStorage returns new MongoAPIKeyStorage instance
+// which shares the connection with the user storage.
+// Key prefixes are made unique by the index created here.
+func NewMongoAPIKeyStorage(ctx context.Context, s *MongoStorage) (*MongoAPIKeyStorage, error) {
+	collection := s.database.Collection(apiKeyCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"prefix": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"user_id": 1},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create API key indexes")
+	}
+	return &MongoAPIKeyStorage{
+		collection: &commonStorage.MongoCollection{
+			Collection: collection,
+		},
+	}, nil
+}
+
+// AddAPIKey adds a new API key, key prefixes are unique.
+// Creation time is set by the storage.
+func (s *MongoAPIKeyStorage) AddAPIKey(ctx context.Context, key model.APIKey) (*model.APIKey, error) {
+	mKey, err := NewMongoAPIKey(key)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	mKey.CreatedAt = now()
+
+	res, err := s.collection.InsertOne(ctx, mKey)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageInsertError(err.Error()), err)
+	}
+
+	mKey.ID = res.InsertedID.(primitive.ObjectID)
+
+	return mKey.ToAPIKey(), nil
+}
+
+// FindAPIKeys finds API keys by filter, keys are sorted by creation time.
+func (s *MongoAPIKeyStorage) FindAPIKeys(ctx context.Context, filter model.APIKeyFindFilter) ([]model.APIKey, error) {
+	mongoFilter := bson.M{}
+	if len(filter.IDs) != 0 {
+		ids, err := newObjectIDs(filter.IDs)
+		if err != nil {
+			return nil, commonErrors.NewStorageConvertError(err.Error())
+		}
+		mongoFilter["_id"] = bson.M{
+			"$in": ids,
+		}
+	}
+	if len(filter.UserIDs) != 0 {
+		ids, err := newObjectIDs(filter.UserIDs)
+		if err != nil {
+			return nil, commonErrors.NewStorageConvertError(err.Error())
+		}
+		mongoFilter["user_id"] = bson.M{
+			"$in": ids,
+		}
+	}
+	if len(filter.Prefixes) != 0 {
+		mongoFilter["prefix"] = bson.M{
+			"$in": filter.Prefixes,
+		}
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cursor, err := s.collection.Find(ctx, mongoFilter, opts)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mKeys []MongoAPIKey
+	if err := cursor.All(ctx, &mKeys); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	keys := make([]model.APIKey, len(mKeys))
+	for i := range mKeys {
+		keys[i] = *mKeys[i].ToAPIKey()
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes user's API key and returns it.
+// Revocation time is set by the storage.
+func (s *MongoAPIKeyStorage) RevokeAPIKey(ctx context.Context, userID, keyID string) (*model.APIKey, error) {
+	ids, err := newObjectIDs([]string{userID, keyID})
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id":     ids[1],
+		"user_id": ids[0],
+		"revoked_at": bson.M{
+			"$exists": false,
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"revoked_at": now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var mKey MongoAPIKey
+	err = s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&mKey)
+	if err == mongo.ErrNoDocuments {
+		err = errors.New("API key not found or is already revoked")
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return mKey.ToAPIKey(), nil
+}
+
+// TouchAPIKeys records last usage times of API keys in a single batch.
+func (s *MongoAPIKeyStorage) TouchAPIKeys(ctx context.Context, lastUsed map[string]time.Time) error {
+	if len(lastUsed) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(lastUsed))
+	for keyID, at := range lastUsed {
+		id, err := primitive.ObjectIDFromHex(keyID)
+		if err != nil {
+			return commonErrors.NewStorageConvertError(err.Error())
+		}
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$max": bson.M{"last_used_at": at.UTC().Truncate(time.Millisecond)}}))
+	}
+
+	opts := options.BulkWrite().SetOrdered(false)
+	if _, err := s.collection.BulkWrite(ctx, models, opts); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// ToAPIKey converts MongoAPIKey model to APIKey model.
+func (m MongoAPIKey) ToAPIKey() *model.APIKey {
+	key := model.APIKey{
+		UserID:     m.UserID.Hex(),
+		Name:       m.Name,
+		Prefix:     m.Prefix,
+		Hash:       m.Hash,
+		Scopes:     m.Scopes,
+		ExpiresAt:  m.ExpiresAt,
+		CreatedAt:  m.CreatedAt,
+		LastUsedAt: m.LastUsedAt,
+		RevokedAt:  m.RevokedAt,
+	}
+	if !m.ID.IsZero() {
+		key.ID = m.ID.Hex()
+	}
+	return &key
+}
+
+// NewMongoAPIKey converts APIKey model to MongoAPIKey model.
+func NewMongoAPIKey(k model.APIKey) (*MongoAPIKey, error) {
+	userID, err := primitive.ObjectIDFromHex(k.UserID)
+	if err != nil {
+		return nil, err
+	}
+	key := MongoAPIKey{
+		UserID:     userID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Hash:       k.Hash,
+		Scopes:     k.Scopes,
+		ExpiresAt:  k.ExpiresAt,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+	if k.ID != "" {
+		id, err := primitive.ObjectIDFromHex(k.ID)
+		if err != nil {
+			return nil, err
+		}
+		key.ID = id
+	}
+	return &key, nil
+}
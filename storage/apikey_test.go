@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoAPIKeyStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	apiKeyStorage, err := NewMongoAPIKeyStorage(ctx, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, apiKeyStorage.collection.Drop(ctx))
+	}()
+	userID := primitive.NewObjectID().Hex()
+
+	t.Run("duplicate prefix error", func(t *testing.T) {
+		_, err := apiKeyStorage.AddAPIKey(ctx, model.APIKey{UserID: userID, Name: "ci", Prefix: "dup"})
+		require.NoError(t, err)
+		_, err = apiKeyStorage.AddAPIKey(ctx, model.APIKey{UserID: userID, Name: "ci", Prefix: "dup"})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageInsert))
+	})
+	t.Run("convertation error", func(t *testing.T) {
+		_, err := apiKeyStorage.RevokeAPIKey(ctx, "invalid", primitive.NewObjectID().Hex())
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		key, err := apiKeyStorage.AddAPIKey(ctx, model.APIKey{
+			UserID: userID,
+			Name:   "deploy",
+			Prefix: "abcdefgh",
+			Hash:   "hash",
+			Scopes: []string{"orders:read"},
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, key.ID)
+		require.False(t, key.CreatedAt.IsZero())
+
+		keys, err := apiKeyStorage.FindAPIKeys(ctx, model.APIKeyFindFilter{Prefixes: []string{"abcdefgh"}})
+		require.NoError(t, err)
+		require.Equal(t, []model.APIKey{*key}, keys)
+
+		usedAt := time.Now().UTC().Truncate(time.Millisecond)
+		require.NoError(t, apiKeyStorage.TouchAPIKeys(ctx, map[string]time.Time{key.ID: usedAt}))
+		require.NoError(t, apiKeyStorage.TouchAPIKeys(ctx, map[string]time.Time{key.ID: usedAt.Add(-time.Hour)}))
+		keys, err = apiKeyStorage.FindAPIKeys(ctx, model.APIKeyFindFilter{IDs: []string{key.ID}})
+		require.NoError(t, err)
+		require.Equal(t, usedAt, keys[0].LastUsedAt)
+
+		revoked, err := apiKeyStorage.RevokeAPIKey(ctx, userID, key.ID)
+		require.NoError(t, err)
+		require.False(t, revoked.RevokedAt.IsZero())
+		_, err = apiKeyStorage.RevokeAPIKey(ctx, userID, key.ID)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+
+		keys, err = apiKeyStorage.FindAPIKeys(ctx, model.APIKeyFindFilter{UserIDs: []string{userID}})
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+	})
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+const redactedValue = "xxxxx"
+
+var (
+	credentialsPattern = regexp.MustCompile(`://([^:@/]+):([^@/]*)@`)
+	keyPasswordPattern = regexp.MustCompile(`(?i)(tlsCertificateKeyFilePassword|sslClientCertificateKeyPassword)=[^&]*`)
+)
+
+var (
+	readPreferences = map[string]struct{}{
+		"primary":            {},
+		"primaryPreferred":   {},
+		"secondary":          {},
+		"secondaryPreferred": {},
+		"nearest":            {},
+	}
+	readConcerns = map[string]struct{}{
+		"local":        {},
+		"available":    {},
+		"majority":     {},
+		"linearizable": {},
+		"snapshot":     {},
+	}
+)
+
+// MongoConfig represents mongo storage configuration.
+// Empty options keep the values from the connection string or driver defaults.
+type MongoConfig struct {
+	ConnString string
+	DBName     string
+
+	// ReadPreference is one of primary, primaryPreferred, secondary, secondaryPreferred, nearest.
+	ReadPreference string
+	// ReadConcern is one of local, available, majority, linearizable, snapshot.
+	ReadConcern string
+	// WriteConcern is either majority or the number of acknowledging nodes.
+	WriteConcern string
+
+	MinPoolSize uint64
+	MaxPoolSize uint64
+
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	SocketTimeout          time.Duration
+
+	TLS                           bool
+	TLSCAFile                     string
+	TLSCertificateKeyFile         string
+	TLSCertificateKeyFilePassword string
+	TLSInsecure                   bool
+
+	// AuthMechanism is one of SCRAM-SHA-1, SCRAM-SHA-256, MONGODB-X509, GSSAPI, PLAIN, MONGODB-AWS.
+	AuthMechanism string
+	AuthSource    string
+
+	// PoolMonitor receives connection pool events, e.g. to export pool statistics.
+	PoolMonitor *event.PoolMonitor
+	// CommandMonitor receives command events, e.g. to trace storage operations.
+	CommandMonitor *event.CommandMonitor
+}
+
+// Validate validates the configuration and the combination of its options.
+func (c *MongoConfig) Validate() error {
+	if strings.TrimSpace(c.DBName) == "" {
+		return errors.New("mongo database name is required")
+	}
+	if _, ok := readPreferences[c.ReadPreference]; c.ReadPreference != "" && !ok {
+		return errors.Errorf("unknown read preference %q", c.ReadPreference)
+	}
+	if _, ok := readConcerns[c.ReadConcern]; c.ReadConcern != "" && !ok {
+		return errors.Errorf("unknown read concern %q", c.ReadConcern)
+	}
+	if c.WriteConcern != "" && c.WriteConcern != "majority" {
+		w, err := strconv.Atoi(c.WriteConcern)
+		if err != nil || w < 0 {
+			return errors.Errorf("write concern must be majority or a non-negative number, got %q", c.WriteConcern)
+		}
+	}
+	if c.ReadConcern == "linearizable" && c.ReadPreference != "" && c.ReadPreference != "primary" {
+		return errors.New("linearizable read concern requires primary read preference")
+	}
+	if c.WriteConcern == "0" && c.ReadConcern == "majority" {
+		return errors.New("unacknowledged writes can't be combined with majority read concern")
+	}
+	if c.MaxPoolSize != 0 && c.MinPoolSize > c.MaxPoolSize {
+		return errors.New("min pool size can't be greater than max pool size")
+	}
+	if !c.TLS && (c.TLSCAFile != "" || c.TLSCertificateKeyFile != "" || c.TLSInsecure) {
+		return errors.New("TLS options require TLS to be enabled")
+	}
+	if c.TLSCertificateKeyFilePassword != "" && c.TLSCertificateKeyFile == "" {
+		return errors.New("TLS certificate key password requires TLS certificate key file")
+	}
+	if strings.EqualFold(c.AuthMechanism, "MONGODB-X509") && c.TLSCertificateKeyFile == "" {
+		return errors.New("MONGODB-X509 authentication requires TLS certificate key file")
+	}
+
+	uri, err := c.URI()
+	if err != nil {
+		return err
+	}
+	// SRV records are resolved on connect.
+	if strings.HasPrefix(uri, "mongodb+srv://") {
+		return nil
+	}
+	if _, err := connstring.ParseAndValidate(uri); err != nil {
+		return errors.Wrap(err, "invalid connection string")
+	}
+
+	return nil
+}
+
+// URI returns connection string with all configured options applied.
+// Configured options override the ones from the original connection string.
+func (c *MongoConfig) URI() (string, error) {
+	base, query := c.ConnString, ""
+	if i := strings.Index(base, "?"); i != -1 {
+		base, query = base[:i], base[i+1:]
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse connection string options")
+	}
+
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	setUint := func(key string, value uint64) {
+		if value != 0 {
+			values.Set(key, strconv.FormatUint(value, 10))
+		}
+	}
+	setDuration := func(key string, value time.Duration) {
+		if value != 0 {
+			values.Set(key, strconv.FormatInt(value.Milliseconds(), 10))
+		}
+	}
+	setBool := func(key string, value bool) {
+		if value {
+			values.Set(key, "true")
+		}
+	}
+
+	set("readPreference", c.ReadPreference)
+	set("readConcernLevel", c.ReadConcern)
+	set("w", c.WriteConcern)
+	setUint("minPoolSize", c.MinPoolSize)
+	setUint("maxPoolSize", c.MaxPoolSize)
+	setDuration("connectTimeoutMS", c.ConnectTimeout)
+	setDuration("serverSelectionTimeoutMS", c.ServerSelectionTimeout)
+	setDuration("socketTimeoutMS", c.SocketTimeout)
+	setBool("tls", c.TLS)
+	set("tlsCAFile", c.TLSCAFile)
+	set("tlsCertificateKeyFile", c.TLSCertificateKeyFile)
+	set("tlsCertificateKeyFilePassword", c.TLSCertificateKeyFilePassword)
+	setBool("tlsInsecure", c.TLSInsecure)
+	set("authMechanism", c.AuthMechanism)
+	set("authSource", c.AuthSource)
+
+	if len(values) == 0 {
+		return base, nil
+	}
+	if !strings.Contains(strings.TrimPrefix(strings.TrimPrefix(base, "mongodb+srv://"), "mongodb://"), "/") {
+		// options require the path separator after the hosts.
+		base += "/"
+	}
+	return base + "?" + values.Encode(), nil
+}
+
+// String returns effective connection settings with credentials redacted.
+func (c MongoConfig) String() string {
+	uri, err := c.URI()
+	if err != nil {
+		uri = c.ConnString
+	}
+	return fmt.Sprintf("db=%s uri=%s", c.DBName, redactURI(uri))
+}
+
+func redactURI(uri string) string {
+	uri = credentialsPattern.ReplaceAllString(uri, "://${1}:"+redactedValue+"@")
+	return keyPasswordPattern.ReplaceAllString(uri, "${1}="+redactedValue)
+}
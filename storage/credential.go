@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	credentialCollection = "credential"
+)
+
+// MongoCredentialStorage represents mongo credential storage model.
+type MongoCredentialStorage struct {
+	collection *commonStorage.MongoCollection
+}
+
+// MongoPasswordCredential represents password credential mongo storage model.
+type MongoPasswordCredential struct {
+	UserID    primitive.ObjectID `bson:"_id"`
+	Hash      string             `bson:"password_hash"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+}
+
+// NewMongoCredentialStorage returns new MongoCredentialStorage instance
+// which shares the connection with the user storage.
+func NewMongoCredentialStorage(s *MongoStorage) *MongoCredentialStorage {
+	return &MongoCredentialStorage{
+		collection: &commonStorage.MongoCollection{
+			Collection: s.database.Collection(credentialCollection),
+		},
+	}
+}
+
+// FindPassword returns user's password hash, nil if the user has no password.
+func (s *MongoCredentialStorage) FindPassword(ctx context.Context, userID string) (*model.PasswordCredential, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	var credential MongoPasswordCredential
+	err = s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&credential)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+
+	return credential.ToPasswordCredential(), nil
+}
+
+// PutPassword creates or replaces user's password hash.
+// Update time is set by the storage.
+func (s *MongoCredentialStorage) PutPassword(ctx context.Context, credential model.PasswordCredential) error {
+	mCredential, err := NewMongoPasswordCredential(credential)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+	mCredential.UpdatedAt = now()
+
+	filter := bson.M{
+		"_id": mCredential.UserID,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := s.collection.ReplaceOne(ctx, filter, mCredential, opts); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// ReplacePassword replaces user's password hash if it wasn't changed since it was read,
+// e.g. to rehash it without overwriting the password set meanwhile.
+func (s *MongoCredentialStorage) ReplacePassword(ctx context.Context, credential model.PasswordCredential, previousHash string) error {
+	mCredential, err := NewMongoPasswordCredential(credential)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+	mCredential.UpdatedAt = now()
+
+	filter := bson.M{
+		"_id":           mCredential.UserID,
+		"password_hash": previousHash,
+	}
+	res, err := s.collection.ReplaceOne(ctx, filter, mCredential)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("password not found or was changed")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// DeletePassword removes user's password hash, removing missing one does nothing.
+func (s *MongoCredentialStorage) DeletePassword(ctx context.Context, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	return nil
+}
+
+// ToPasswordCredential converts MongoPasswordCredential model to PasswordCredential model.
+func (m MongoPasswordCredential) ToPasswordCredential() *model.PasswordCredential {
+	return &model.PasswordCredential{
+		UserID:    m.UserID.Hex(),
+		Hash:      m.Hash,
+		UpdatedAt: m.UpdatedAt,
+	}
+}
+
+// NewMongoPasswordCredential converts PasswordCredential model to MongoPasswordCredential model.
+func NewMongoPasswordCredential(c model.PasswordCredential) (*MongoPasswordCredential, error) {
+	id, err := primitive.ObjectIDFromHex(c.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoPasswordCredential{
+		UserID:    id,
+		Hash:      c.Hash,
+		UpdatedAt: c.UpdatedAt,
+	}, nil
+}
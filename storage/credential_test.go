@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoCredentialStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	credentialStorage := NewMongoCredentialStorage(st)
+	defer func() {
+		require.NoError(t, credentialStorage.collection.Drop(ctx))
+	}()
+
+	t.Run("convertation error", func(t *testing.T) {
+		err := credentialStorage.PutPassword(ctx, model.PasswordCredential{UserID: "invalid"})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		userID := primitive.NewObjectID().Hex()
+		credential, err := credentialStorage.FindPassword(ctx, userID)
+		require.NoError(t, err)
+		require.Nil(t, credential)
+
+		require.NoError(t, credentialStorage.PutPassword(ctx, model.PasswordCredential{UserID: userID, Hash: "hash1"}))
+		credential, err = credentialStorage.FindPassword(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, "hash1", credential.Hash)
+		require.False(t, credential.UpdatedAt.IsZero())
+
+		err = credentialStorage.ReplacePassword(ctx, model.PasswordCredential{UserID: userID, Hash: "hash3"}, "hash2")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.NoError(t, credentialStorage.ReplacePassword(ctx, model.PasswordCredential{UserID: userID, Hash: "hash2"}, "hash1"))
+		credential, err = credentialStorage.FindPassword(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, "hash2", credential.Hash)
+
+		require.NoError(t, credentialStorage.DeletePassword(ctx, userID))
+		require.NoError(t, credentialStorage.DeletePassword(ctx, userID))
+		credential, err = credentialStorage.FindPassword(ctx, userID)
+		require.NoError(t, err)
+		require.Nil(t, credential)
+	})
+}
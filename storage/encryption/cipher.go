@@ -0,0 +1,87 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Envelope represents encrypted value together with the ID of the keyring key used to encrypt it.
+type Envelope struct {
+	KeyID string
+	// Data contains the nonce followed by the sealed value.
+	Data []byte
+}
+
+// Cipher represents AES-GCM cipher which encrypts values with the keyring keys directly,
+// there are no per-value data keys.
+type Cipher struct {
+	keys KeyProvider
+}
+
+// NewCipher creates new Cipher instance.
+func NewCipher(keys KeyProvider) *Cipher {
+	return &Cipher{
+		keys: keys,
+	}
+}
+
+// Encrypt encrypts plaintext using the active key.
+// Additional data is authenticated but not encrypted, it must be the same during decryption.
+func (c *Cipher) Encrypt(plaintext, additionalData []byte) (*Envelope, error) {
+	key, err := c.keys.ActiveKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get active key")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, errors.Wrap(err, "could not generate nonce")
+	}
+
+	return &Envelope{
+		KeyID: key.ID,
+		Data:  aead.Seal(nonce, nonce, plaintext, additionalData),
+	}, nil
+}
+
+// Decrypt decrypts envelope using the key it was encrypted with.
+func (c *Cipher) Decrypt(envelope Envelope, additionalData []byte) ([]byte, error) {
+	key, err := c.keys.Key(envelope.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(envelope.Data) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, sealed := envelope.Data[:aead.NonceSize()], envelope.Data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decrypt value")
+	}
+
+	return plaintext, nil
+}
+
+func newAEAD(key Key) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key.Secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid key %s", key.ID)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,88 @@
+package encryption
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testKeys struct {
+	active string
+	keys   map[string]Key
+}
+
+func newTestKeys(active string, ids ...string) *testKeys {
+	k := testKeys{
+		active: active,
+		keys:   make(map[string]Key, len(ids)),
+	}
+	for i := range ids {
+		k.keys[ids[i]] = Key{
+			ID:     ids[i],
+			Secret: bytes.Repeat([]byte{byte(i + 1)}, keySize),
+		}
+	}
+	return &k
+}
+
+func (k *testKeys) ActiveKey() (Key, error) {
+	return k.Key(k.active)
+}
+
+func (k *testKeys) Key(id string) (Key, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return Key{}, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func TestCipher_Encrypt(t *testing.T) {
+	t.Run("no active key", func(t *testing.T) {
+		c := NewCipher(newTestKeys("k2", "k1"))
+		_, err := c.Encrypt([]byte("hello"), nil)
+		require.Error(t, err)
+		require.EqualError(t, err, "could not get active key: "+ErrKeyNotFound.Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		c := NewCipher(newTestKeys("k1", "k1"))
+		env1, err := c.Encrypt([]byte("hello"), []byte("phone"))
+		require.NoError(t, err)
+		require.Equal(t, "k1", env1.KeyID)
+		require.False(t, bytes.Contains(env1.Data, []byte("hello")))
+		env2, err := c.Encrypt([]byte("hello"), []byte("phone"))
+		require.NoError(t, err)
+		require.NotEqual(t, env1.Data, env2.Data)
+	})
+}
+
+func TestCipher_Decrypt(t *testing.T) {
+	keys := newTestKeys("k1", "k1", "k2")
+	c := NewCipher(keys)
+	env, err := c.Encrypt([]byte("hello"), []byte("phone"))
+	require.NoError(t, err)
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := c.Decrypt(Envelope{KeyID: "k3", Data: env.Data}, []byte("phone"))
+		require.Error(t, err)
+	})
+	t.Run("short ciphertext", func(t *testing.T) {
+		_, err := c.Decrypt(Envelope{KeyID: "k1", Data: []byte{1}}, []byte("phone"))
+		require.Error(t, err)
+		require.EqualError(t, err, "ciphertext is too short")
+	})
+	t.Run("additional data mismatch", func(t *testing.T) {
+		_, err := c.Decrypt(*env, []byte("email"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "could not decrypt value")
+	})
+	t.Run("all ok after rotation", func(t *testing.T) {
+		keys.active = "k2"
+		defer func() {
+			keys.active = "k1"
+		}()
+		res, err := c.Decrypt(*env, []byte("phone"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("hello"), res)
+	})
+}
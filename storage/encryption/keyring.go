@@ -0,0 +1,103 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// keySize is the AES-256 key length in bytes.
+const keySize = 32
+
+// ErrKeyNotFound is returned when the key provider doesn't know the requested key.
+var ErrKeyNotFound = errors.New("encryption key not found")
+
+// Key represents data encryption key.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// KeyProvider represents encryption keys source.
+type KeyProvider interface {
+	// ActiveKey returns the key that must be used for new encryptions.
+	ActiveKey() (Key, error)
+	// Key returns the key by its ID. Used to decrypt values encrypted by rotated keys.
+	Key(id string) (Key, error)
+}
+
+// FileKeyring represents local file-based key provider.
+type FileKeyring struct {
+	active string
+	keys   map[string]Key
+}
+
+type keyringFile struct {
+	Active string `json:"active"`
+	Keys   []struct {
+		ID     string `json:"id"`
+		Secret string `json:"secret"`
+	} `json:"keys"`
+}
+
+// NewFileKeyring loads keyring from the JSON file.
+// Key secrets must be base64 encoded 32 bytes values.
+func NewFileKeyring(path string) (*FileKeyring, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s file data", path)
+	}
+
+	var f keyringFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, errors.Wrap(err, "could not parse keyring file")
+	}
+
+	keys := make(map[string]Key, len(f.Keys))
+	for i := range f.Keys {
+		id := strings.TrimSpace(f.Keys[i].ID)
+		if id == "" {
+			return nil, errors.New("key ID is required")
+		}
+		if _, ok := keys[id]; ok {
+			return nil, errors.Errorf("duplicate key %s", id)
+		}
+		secret, err := base64.StdEncoding.DecodeString(f.Keys[i].Secret)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not decode key %s", id)
+		}
+		if len(secret) != keySize {
+			return nil, errors.Errorf("key %s must be %d bytes long", id, keySize)
+		}
+		keys[id] = Key{
+			ID:     id,
+			Secret: secret,
+		}
+	}
+
+	if _, ok := keys[f.Active]; !ok {
+		return nil, errors.Errorf("active key %q is not in the keyring", f.Active)
+	}
+
+	return &FileKeyring{
+		active: f.Active,
+		keys:   keys,
+	}, nil
+}
+
+// ActiveKey returns the key that must be used for new encryptions.
+func (k *FileKeyring) ActiveKey() (Key, error) {
+	return k.Key(k.active)
+}
+
+// Key returns the key by its ID.
+func (k *FileKeyring) Key(id string) (Key, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return Key{}, errors.Wrap(ErrKeyNotFound, id)
+	}
+	return key, nil
+}
@@ -0,0 +1,71 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func writeKeyringFile(t *testing.T, data string) string {
+	dir, err := ioutil.TempDir("", "keyring")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+	fPath := path.Join(dir, "keyring.json")
+	require.NoError(t, ioutil.WriteFile(fPath, []byte(data), os.ModePerm))
+	return fPath
+}
+
+func testSecret(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func Test_NewFileKeyring(t *testing.T) {
+	t.Run("read file error", func(t *testing.T) {
+		_, err := NewFileKeyring("invalid/path.json")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "could not read invalid/path.json file data")
+	})
+	t.Run("parse file error", func(t *testing.T) {
+		_, err := NewFileKeyring(writeKeyringFile(t, "invalid"))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "could not parse keyring file")
+	})
+	t.Run("invalid key size", func(t *testing.T) {
+		_, err := NewFileKeyring(writeKeyringFile(t, `{"active":"k1","keys":[{"id":"k1","secret":"aGVsbG8="}]}`))
+		require.Error(t, err)
+		require.EqualError(t, err, "key k1 must be 32 bytes long")
+	})
+	t.Run("duplicate key", func(t *testing.T) {
+		_, err := NewFileKeyring(writeKeyringFile(t, `{"active":"k1","keys":[{"id":"k1","secret":"`+testSecret(1)+`"},{"id":"k1","secret":"`+testSecret(2)+`"}]}`))
+		require.Error(t, err)
+		require.EqualError(t, err, "duplicate key k1")
+	})
+	t.Run("unknown active key", func(t *testing.T) {
+		_, err := NewFileKeyring(writeKeyringFile(t, `{"active":"k2","keys":[{"id":"k1","secret":"`+testSecret(1)+`"}]}`))
+		require.Error(t, err)
+		require.EqualError(t, err, `active key "k2" is not in the keyring`)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		k, err := NewFileKeyring(writeKeyringFile(t, `{"active":"k2","keys":[{"id":"k1","secret":"`+testSecret(1)+`"},{"id":"k2","secret":"`+testSecret(2)+`"}]}`))
+		require.NoError(t, err)
+		require.NotNil(t, k)
+		key, err := k.ActiveKey()
+		require.NoError(t, err)
+		require.Equal(t, "k2", key.ID)
+		require.Equal(t, bytes.Repeat([]byte{2}, keySize), key.Secret)
+		key, err = k.Key("k1")
+		require.NoError(t, err)
+		require.Equal(t, "k1", key.ID)
+		_, err = k.Key("k3")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrKeyNotFound))
+	})
+}
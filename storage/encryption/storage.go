@@ -0,0 +1,275 @@
+package encryption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	envelopeKeyIDField = "kid"
+	envelopeDataField  = "ct"
+	plainValueField    = "v"
+)
+
+// Storage represents user storage decorator which encrypts configured meta keys
+// before writes and decrypts them on reads. Encrypted values are bound to the user
+// and the meta key, so they can't be moved to another user or key.
+type Storage struct {
+	next   storage.User
+	cipher *Cipher
+	keys   map[string]struct{}
+}
+
+// NewStorage creates new Storage instance.
+func NewStorage(next storage.User, cipher *Cipher, metaKeys []string) *Storage {
+	keys := make(map[string]struct{}, len(metaKeys))
+	for i := range metaKeys {
+		keys[metaKeys[i]] = struct{}{}
+	}
+	return &Storage{
+		next:   next,
+		cipher: cipher,
+		keys:   keys,
+	}
+}
+
+// Disconnect breaks storage connection.
+func (s *Storage) Disconnect(ctx context.Context) error {
+	return s.next.Disconnect(ctx)
+}
+
+// Add encrypts sensitive meta values and adds a new user.
+// The user's ID is generated before the insert, so the values are bound to it.
+func (s *Storage) Add(ctx context.Context, user model.User) (*model.User, error) {
+	if user.ID == "" {
+		user.ID = primitive.NewObjectID().Hex()
+	}
+	meta, err := s.encryptMeta(user.ID, user.Meta)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	user.Meta = meta
+
+	addedUser, err := s.next.Add(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(addedUser)
+}
+
+// Delete removes an existing user by ID.
+func (s *Storage) Delete(ctx context.Context, userID string) error {
+	return s.next.Delete(ctx, userID)
+}
+
+// Anonymise removes user's personal data, the remaining meta is decrypted.
+func (s *Storage) Anonymise(ctx context.Context, user model.User) (*model.User, error) {
+	updatedUser, err := s.next.Anonymise(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(updatedUser)
+}
+
+// TouchActivity moves user's last activity time forward.
+func (s *Storage) TouchActivity(ctx context.Context, userID string, at time.Time) error {
+	return s.next.TouchActivity(ctx, userID, at)
+}
+
+// Update encrypts sensitive meta values and updates an existing user.
+func (s *Storage) Update(ctx context.Context, user model.User) (*model.User, error) {
+	meta, err := s.encryptMeta(user.ID, user.Meta)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	user.Meta = meta
+
+	updatedUser, err := s.next.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(updatedUser)
+}
+
+// ChangeStatus changes user's status and decrypts sensitive meta values.
+func (s *Storage) ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (*model.User, error) {
+	updatedUser, err := s.next.ChangeStatus(ctx, userID, change)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(updatedUser)
+}
+
+// AddStatusSchedule adds status change scheduled for the future and decrypts sensitive meta values.
+func (s *Storage) AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (*model.User, error) {
+	updatedUser, err := s.next.AddStatusSchedule(ctx, userID, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(updatedUser)
+}
+
+// RemoveStatusSchedule removes pending status change and decrypts sensitive meta values.
+func (s *Storage) RemoveStatusSchedule(ctx context.Context, userID, scheduleID string) (*model.User, error) {
+	updatedUser, err := s.next.RemoveStatusSchedule(ctx, userID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(updatedUser)
+}
+
+// Merge encrypts sensitive meta values, merges the source user into the target one
+// and decrypts sensitive meta values of the target user.
+func (s *Storage) Merge(ctx context.Context, merge model.Merge) (*model.User, error) {
+	meta, err := s.encryptMeta(merge.TargetID, merge.Meta)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	merge.Meta = meta
+
+	updatedUser, err := s.next.Merge(ctx, merge)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.decryptUser(updatedUser)
+}
+
+// Find finds users by filter and decrypts sensitive meta values.
+// Encrypted meta keys can't be used in meta patterns.
+func (s *Storage) Find(ctx context.Context, filter model.UserFindFilter) ([]model.User, error) {
+	for k := range filter.MetaPatterns {
+		if _, ok := s.keys[k]; ok {
+			return nil, commonErrors.NewStorageFindError(fmt.Sprintf("meta key %s is encrypted and can't be searched", k))
+		}
+	}
+
+	users, err := s.next.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		user, err := s.decryptUser(&users[i])
+		if err != nil {
+			return nil, err
+		}
+		users[i] = *user
+	}
+
+	return users, nil
+}
+
+// additionalData binds encrypted meta value to the user and the meta key,
+// user IDs are hex strings, so the separator is unambiguous.
+func additionalData(userID, key string) []byte {
+	return []byte(userID + "/" + key)
+}
+
+func (s *Storage) encryptMeta(userID string, meta map[string]interface{}) (map[string]interface{}, error) {
+	if len(meta) == 0 {
+		return meta, nil
+	}
+
+	// copy meta to keep the caller's values untouched.
+	res := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		if _, ok := s.keys[k]; !ok || v == nil {
+			res[k] = v
+			continue
+		}
+		data, err := bson.Marshal(bson.M{plainValueField: v})
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal meta value %s: %w", k, err)
+		}
+		envelope, err := s.cipher.Encrypt(data, additionalData(userID, k))
+		if err != nil {
+			return nil, fmt.Errorf("could not encrypt meta value %s: %w", k, err)
+		}
+		res[k] = map[string]interface{}{
+			envelopeKeyIDField: envelope.KeyID,
+			envelopeDataField:  envelope.Data,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Storage) decryptUser(user *model.User) (*model.User, error) {
+	for k, v := range user.Meta {
+		if _, ok := s.keys[k]; !ok {
+			continue
+		}
+		envelope, ok := parseEnvelope(v)
+		if !ok {
+			// values written before the key was configured stay in plaintext
+			// until the next update.
+			continue
+		}
+		data, err := s.cipher.Decrypt(*envelope, additionalData(user.ID, k))
+		if err != nil {
+			// values encrypted before they were bound to the user are bound to the key only
+			// until the next update.
+			data, err = s.cipher.Decrypt(*envelope, []byte(k))
+		}
+		if err != nil {
+			return nil, commonErrors.NewStorageConvertError(fmt.Sprintf("could not decrypt meta value %s: %v", k, err))
+		}
+		var plain bson.M
+		if err := bson.Unmarshal(data, &plain); err != nil {
+			return nil, commonErrors.NewStorageConvertError(fmt.Sprintf("could not unmarshal meta value %s: %v", k, err))
+		}
+		user.Meta[k] = plain[plainValueField]
+	}
+	return user, nil
+}
+
+func parseEnvelope(value interface{}) (*Envelope, bool) {
+	var doc map[string]interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		doc = v
+	case primitive.M:
+		doc = v
+	case primitive.D:
+		doc = v.Map()
+	default:
+		return nil, false
+	}
+	if len(doc) != 2 {
+		return nil, false
+	}
+
+	keyID, ok := doc[envelopeKeyIDField].(string)
+	if !ok {
+		return nil, false
+	}
+
+	var data []byte
+	switch d := doc[envelopeDataField].(type) {
+	case []byte:
+		data = d
+	case primitive.Binary:
+		data = d.Data
+	default:
+		return nil, false
+	}
+
+	return &Envelope{
+		KeyID: keyID,
+		Data:  data,
+	}, true
+}
@@ -0,0 +1,199 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errMock = errors.New("error")
+
+func TestStorage_Add(t *testing.T) {
+	c := NewCipher(newTestKeys("k1", "k1"))
+	t.Run("storage error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		st.On("Add", mock.Anything, mock.Anything).Return(nil, errMock)
+		_, err := s.Add(context.Background(), model.User{})
+		require.Error(t, err)
+		require.EqualError(t, err, errMock.Error())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		user := model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta: map[string]interface{}{
+				"phone": "+100500",
+				"name":  "john",
+			},
+		}
+		st.On("Add", mock.Anything, mock.MatchedBy(func(u model.User) bool {
+			envelope, ok := parseEnvelope(u.Meta["phone"])
+			_, err := primitive.ObjectIDFromHex(u.ID)
+			return ok && envelope.KeyID == "k1" && u.Meta["name"] == "john" && err == nil
+		})).Return(func(_ context.Context, u model.User) *model.User {
+			return &u
+		}, nil)
+		res, err := s.Add(context.Background(), user)
+		require.NoError(t, err)
+		require.NotEmpty(t, res.ID)
+		require.Equal(t, "+100500", res.Meta["phone"])
+		require.Equal(t, "john", res.Meta["name"])
+		require.Equal(t, "+100500", user.Meta["phone"])
+	})
+}
+
+func TestStorage_Update(t *testing.T) {
+	keys := newTestKeys("k1", "k1", "k2")
+	c := NewCipher(keys)
+	t.Run("encryption error", func(t *testing.T) {
+		s := NewStorage(new(storageMocks.User), NewCipher(newTestKeys("k3")), []string{"phone"})
+		_, err := s.Update(context.Background(), model.User{
+			Meta: map[string]interface{}{
+				"phone": "+100500",
+			},
+		})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		keys.active = "k2"
+		defer func() {
+			keys.active = "k1"
+		}()
+		st.On("Update", mock.Anything, mock.MatchedBy(func(u model.User) bool {
+			envelope, ok := parseEnvelope(u.Meta["phone"])
+			return ok && envelope.KeyID == "k2"
+		})).Return(func(_ context.Context, u model.User) *model.User {
+			return &u
+		}, nil)
+		res, err := s.Update(context.Background(), model.User{
+			ID: "1",
+			Meta: map[string]interface{}{
+				"phone": []interface{}{"+100500", "+100501"},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, primitive.A{"+100500", "+100501"}, res.Meta["phone"])
+	})
+}
+
+func TestStorage_Find(t *testing.T) {
+	c := NewCipher(newTestKeys("k1", "k1"))
+	t.Run("search by encrypted key", func(t *testing.T) {
+		s := NewStorage(new(storageMocks.User), c, []string{"phone"})
+		_, err := s.Find(context.Background(), model.UserFindFilter{
+			MetaPatterns: map[string]string{
+				"phone": "100",
+			},
+		})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageFind))
+	})
+	t.Run("decryption error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{
+			{
+				Meta: map[string]interface{}{
+					"phone": primitive.D{
+						{Key: envelopeKeyIDField, Value: "k1"},
+						{Key: envelopeDataField, Value: primitive.Binary{Data: make([]byte, 32)}},
+					},
+				},
+			},
+		}, nil)
+		_, err := s.Find(context.Background(), model.UserFindFilter{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("value of another user error", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		encrypted, err := s.encryptMeta("1", map[string]interface{}{
+			"phone": "+100500",
+		})
+		require.NoError(t, err)
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{
+			{
+				ID:   "2",
+				Meta: encrypted,
+			},
+		}, nil)
+		_, err = s.Find(context.Background(), model.UserFindFilter{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok (value bound to the key only)", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		data, err := bson.Marshal(bson.M{plainValueField: "+100500"})
+		require.NoError(t, err)
+		envelope, err := c.Encrypt(data, []byte("phone"))
+		require.NoError(t, err)
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{
+			{
+				ID: "1",
+				Meta: map[string]interface{}{
+					"phone": map[string]interface{}{
+						envelopeKeyIDField: envelope.KeyID,
+						envelopeDataField:  envelope.Data,
+					},
+				},
+			},
+		}, nil)
+		res, err := s.Find(context.Background(), model.UserFindFilter{})
+		require.NoError(t, err)
+		require.Equal(t, "+100500", res[0].Meta["phone"])
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s := NewStorage(st, c, []string{"phone"})
+		encrypted, err := s.encryptMeta("1", map[string]interface{}{
+			"phone": "+100500",
+		})
+		require.NoError(t, err)
+		envelope, ok := parseEnvelope(encrypted["phone"])
+		require.True(t, ok)
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{
+			{
+				ID: "1",
+				Meta: map[string]interface{}{
+					"phone": map[string]interface{}{
+						envelopeKeyIDField: envelope.KeyID,
+						envelopeDataField:  primitive.Binary{Data: envelope.Data},
+					},
+				},
+			},
+			{
+				ID: "2",
+				Meta: map[string]interface{}{
+					"phone": "+100501",
+				},
+			},
+		}, nil)
+		res, err := s.Find(context.Background(), model.UserFindFilter{})
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.Equal(t, "+100500", res[0].Meta["phone"])
+		require.Equal(t, "+100501", res[1].Meta["phone"])
+	})
+}
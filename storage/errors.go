@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// There are mongo error labels of transient failures.
+const (
+	labelTransientTransaction = "TransientTransactionError"
+	labelRetryableWrite       = "RetryableWriteError"
+	labelNetworkError         = "NetworkError"
+)
+
+// There are errors of the operations rejected by the storage.
+var (
+	// ErrChallengeLimit is returned when too many verification challenges of the contact were issued.
+	ErrChallengeLimit = errors.New("verification challenge limit is reached")
+	// ErrGroupExists is returned when a group with the same name exists.
+	ErrGroupExists = errors.New("group with the same name exists")
+	// ErrLastLoginMethod is returned when the identity is the user's last login method.
+	ErrLastLoginMethod = errors.New("identity is the user's last login method")
+	// ErrUserChanged is returned when the user was changed since it was read.
+	ErrUserChanged = errors.New("user was changed meanwhile")
+)
+
+// duplicateKeyCode is mongo error code of unique index violations.
+const duplicateKeyCode = 11000
+
+// transientCodes contains mongo error codes caused by network failures and
+// replica set elections.
+var transientCodes = map[int32]struct{}{
+	6:     {}, // HostUnreachable
+	7:     {}, // HostNotFound
+	89:    {}, // NetworkTimeout
+	91:    {}, // ShutdownInProgress
+	189:   {}, // PrimarySteppedDown
+	262:   {}, // ExceededTimeLimit
+	9001:  {}, // SocketException
+	10107: {}, // NotMaster
+	11600: {}, // InterruptedAtShutdown
+	11602: {}, // InterruptedDueToReplStateChange
+	13435: {}, // NotMasterNoSlaveOk
+	13436: {}, // NotMasterOrSecondary
+}
+
+// TransientError represents storage error which may disappear when the operation is retried.
+type TransientError struct {
+	err error
+}
+
+// Error returns error as a string value.
+func (e TransientError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying storage error.
+func (e TransientError) Unwrap() error {
+	return e.err
+}
+
+// NewTransientError marks storage error as transient.
+func NewTransientError(err error) TransientError {
+	return TransientError{err: err}
+}
+
+// IsTransient checks whether the storage error may disappear when the operation is retried.
+func IsTransient(err error) bool {
+	var transientErr TransientError
+	return errors.As(err, &transientErr)
+}
+
+// classify marks storage error as transient if it was caused by transient mongo error.
+func classify(err, cause error) error {
+	if isTransientMongoError(cause) {
+		return NewTransientError(err)
+	}
+	return err
+}
+
+func isTransientMongoError(err error) bool {
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) {
+		if cmdErr.HasErrorLabel(labelTransientTransaction) || cmdErr.HasErrorLabel(labelRetryableWrite) || cmdErr.HasErrorLabel(labelNetworkError) {
+			return true
+		}
+		_, ok := transientCodes[cmdErr.Code]
+		return ok
+	}
+
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		if writeErr.HasErrorLabel(labelRetryableWrite) || writeErr.HasErrorLabel(labelNetworkError) {
+			return true
+		}
+		if writeErr.WriteConcernError != nil {
+			_, ok := transientCodes[int32(writeErr.WriteConcernError.Code)]
+			return ok
+		}
+		return false
+	}
+
+	var connErr topology.ConnectionError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	// server selection errors are not wrapped by the driver.
+	return err != nil && strings.HasPrefix(err.Error(), "server selection error")
+}
+
+// isDuplicateKeyError checks if the operation violated a unique index,
+// the driver version in use has no helper for it.
+func isDuplicateKeyError(err error) bool {
+	var writeErr mongo.WriteException
+	if errors.As(err, &writeErr) {
+		for i := range writeErr.WriteErrors {
+			if writeErr.WriteErrors[i].Code == duplicateKeyCode {
+				return true
+			}
+		}
+		return false
+	}
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && cmdErr.Code == duplicateKeyCode
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func Test_classify(t *testing.T) {
+	tt := []struct {
+		name      string
+		cause     error
+		transient bool
+	}{
+		{
+			name:  "not found",
+			cause: errors.New("user not found"),
+		},
+		{
+			name:  "duplicate key",
+			cause: mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}},
+		},
+		{
+			name:      "retryable write label",
+			cause:     mongo.WriteException{Labels: []string{labelRetryableWrite}},
+			transient: true,
+		},
+		{
+			name:      "write concern stepdown",
+			cause:     mongo.WriteException{WriteConcernError: &mongo.WriteConcernError{Code: 189}},
+			transient: true,
+		},
+		{
+			name:      "not master",
+			cause:     mongo.CommandError{Code: 10107},
+			transient: true,
+		},
+		{
+			name:      "network label",
+			cause:     mongo.CommandError{Labels: []string{labelNetworkError}},
+			transient: true,
+		},
+		{
+			name:  "unknown command error",
+			cause: mongo.CommandError{Code: 2},
+		},
+		{
+			name:      "connection error",
+			cause:     topology.ConnectionError{Wrapped: errors.New("connection refused")},
+			transient: true,
+		},
+		{
+			name:      "server selection",
+			cause:     errors.New("server selection error: server selection timeout, current topology: {}"),
+			transient: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := classify(commonErrors.NewStorageFindError(tc.cause.Error()), tc.cause)
+			require.Equal(t, tc.transient, IsTransient(err))
+			require.True(t, errors.Is(err, commonErrors.ErrStorageFind))
+		})
+	}
+}
+
+func Test_isDuplicateKeyError(t *testing.T) {
+	require.True(t, isDuplicateKeyError(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}))
+	require.True(t, isDuplicateKeyError(mongo.CommandError{Code: 11000}))
+	require.False(t, isDuplicateKeyError(mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 2}}}))
+	require.False(t, isDuplicateKeyError(errors.New("duplicate key")))
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	groupCollection = "group"
+)
+
+// MongoGroupStorage represents mongo group storage model.
+type MongoGroupStorage struct {
+	groupCollection *commonStorage.MongoCollection
+	userCollection  *commonStorage.MongoCollection
+}
+
+// MongoGroup represents group mongo storage model.
+type MongoGroup struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description,omitempty"`
+	CreatedAt   time.Time          `bson:"created_at,omitempty"`
+}
+
+// NewMongoGroupStorage returns new MongoGroupStorage instance
+// which shares the connection with the user storage.
+// Group names are made unique by the index created here.
+func NewMongoGroupStorage(ctx context.Context, s *MongoStorage) (*MongoGroupStorage, error) {
+	collection := s.database.Collection(groupCollection)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"name": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create group name index")
+	}
+	return &MongoGroupStorage{
+		groupCollection: &commonStorage.MongoCollection{
+			Collection: collection,
+		},
+		userCollection: s.userCollection,
+	}, nil
+}
+
+// AddGroup adds a new group, group names are unique, so ErrGroupExists is returned for a taken name.
+func (s *MongoGroupStorage) AddGroup(ctx context.Context, group model.Group) (*model.Group, error) {
+	mGroup, err := NewMongoGroup(group)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	mGroup.CreatedAt = now()
+
+	res, err := s.groupCollection.InsertOne(ctx, mGroup)
+	if isDuplicateKeyError(err) {
+		return nil, ErrGroupExists
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageInsertError(err.Error()), err)
+	}
+
+	mGroup.ID = res.InsertedID.(primitive.ObjectID)
+
+	return mGroup.ToGroup(), nil
+}
+
+// FindGroups finds groups by filter.
+func (s *MongoGroupStorage) FindGroups(ctx context.Context, filter model.GroupFindFilter) ([]model.Group, error) {
+	mongoFilter := bson.M{}
+	if len(filter.IDs) != 0 {
+		ids, err := newObjectIDs(filter.IDs)
+		if err != nil {
+			return nil, commonErrors.NewStorageConvertError(err.Error())
+		}
+		mongoFilter["_id"] = bson.M{
+			"$in": ids,
+		}
+	}
+	if len(filter.Names) != 0 {
+		mongoFilter["name"] = bson.M{
+			"$in": filter.Names,
+		}
+	}
+
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	if filter.Offset != nil {
+		opts.SetSkip(*filter.Offset)
+	}
+	if filter.Limit != nil {
+		opts.SetLimit(*filter.Limit)
+	}
+
+	cursor, err := s.groupCollection.Find(ctx, mongoFilter, opts)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mGroups []MongoGroup
+	if err := cursor.All(ctx, &mGroups); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	groups := make([]model.Group, len(mGroups))
+	for i := range mGroups {
+		groups[i] = *mGroups[i].ToGroup()
+	}
+
+	return groups, nil
+}
+
+// AddMember adds the user to the group.
+func (s *MongoGroupStorage) AddMember(ctx context.Context, groupID, userID string) error {
+	gID, id, err := newMemberIDs(groupID, userID)
+	if err != nil {
+		return err
+	}
+
+	err = s.groupCollection.FindOne(ctx, bson.M{"_id": gID}).Err()
+	if err == mongo.ErrNoDocuments {
+		err = errors.New("group not found")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	filter := bson.M{
+		"_id": id,
+	}
+	update := bson.M{
+		"$addToSet": bson.M{
+			"group_ids": gID,
+		},
+	}
+	res, err := s.userCollection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("user not found")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// RemoveMember removes the user from the group.
+func (s *MongoGroupStorage) RemoveMember(ctx context.Context, groupID, userID string) error {
+	gID, id, err := newMemberIDs(groupID, userID)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{
+		"_id":       id,
+		"group_ids": gID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"group_ids": gID,
+		},
+	}
+	res, err := s.userCollection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("user not found or isn't a member of the group")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// ToGroup converts MongoGroup model to Group model.
+func (m MongoGroup) ToGroup() *model.Group {
+	group := model.Group{
+		Name:        m.Name,
+		Description: m.Description,
+		CreatedAt:   m.CreatedAt,
+	}
+	if !m.ID.IsZero() {
+		group.ID = m.ID.Hex()
+	}
+	return &group
+}
+
+// NewMongoGroup converts Group model to MongoGroup model.
+func NewMongoGroup(g model.Group) (*MongoGroup, error) {
+	group := MongoGroup{
+		Name:        g.Name,
+		Description: g.Description,
+		CreatedAt:   g.CreatedAt,
+	}
+	if g.ID != "" {
+		id, err := primitive.ObjectIDFromHex(g.ID)
+		if err != nil {
+			return nil, err
+		}
+		group.ID = id
+	}
+	return &group, nil
+}
+
+func newMemberIDs(groupID, userID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	gID, err := primitive.ObjectIDFromHex(groupID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, commonErrors.NewStorageConvertError(err.Error())
+	}
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, commonErrors.NewStorageConvertError(err.Error())
+	}
+	return gID, id, nil
+}
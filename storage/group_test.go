@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoGroupStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	groupStorage, err := NewMongoGroupStorage(ctx, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, groupStorage.groupCollection.Drop(ctx))
+	}()
+
+	t.Run("duplicate name error", func(t *testing.T) {
+		_, err := groupStorage.AddGroup(ctx, model.Group{Name: "beta"})
+		require.NoError(t, err)
+		_, err = groupStorage.AddGroup(ctx, model.Group{Name: "beta"})
+		require.Error(t, err)
+		require.Equal(t, ErrGroupExists, err)
+	})
+	t.Run("add member to unknown group error", func(t *testing.T) {
+		err := groupStorage.AddMember(ctx, primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "group not found")
+	})
+	t.Run("convertation error", func(t *testing.T) {
+		err := groupStorage.RemoveMember(ctx, "invalid", primitive.NewObjectID().Hex())
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		group, err := groupStorage.AddGroup(ctx, model.Group{Name: "team", Description: "Core team"})
+		require.NoError(t, err)
+		require.NotEmpty(t, group.ID)
+		require.False(t, group.CreatedAt.IsZero())
+
+		groups, err := groupStorage.FindGroups(ctx, model.GroupFindFilter{Names: []string{"team"}})
+		require.NoError(t, err)
+		require.Equal(t, []model.Group{*group}, groups)
+
+		user, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		require.NoError(t, groupStorage.AddMember(ctx, group.ID, user.ID))
+		// adding a member again does nothing.
+		require.NoError(t, groupStorage.AddMember(ctx, group.ID, user.ID))
+
+		users, err := st.Find(ctx, model.UserFindFilter{GroupIDs: []string{group.ID}})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		require.Equal(t, []string{group.ID}, users[0].GroupIDs)
+
+		require.NoError(t, groupStorage.RemoveMember(ctx, group.ID, user.ID))
+		err = groupStorage.RemoveMember(ctx, group.ID, user.ID)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "isn't a member of the group")
+
+		users, err = st.Find(ctx, model.UserFindFilter{GroupIDs: []string{group.ID}})
+		require.NoError(t, err)
+		require.Empty(t, users)
+	})
+}
+
+func TestMongoGroup_ToGroup(t *testing.T) {
+	group := model.Group{
+		ID:          primitive.NewObjectID().Hex(),
+		Name:        "beta",
+		Description: "Beta program",
+	}
+	mGroup, err := NewMongoGroup(group)
+	require.NoError(t, err)
+	require.Equal(t, group, *mGroup.ToGroup())
+}
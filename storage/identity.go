@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	identityCollection = "identity"
+)
+
+// MongoIdentityStorage represents mongo linked identity storage model.
+type MongoIdentityStorage struct {
+	client               *mongo.Client
+	identityCollection   *commonStorage.MongoCollection
+	credentialCollection *commonStorage.MongoCollection
+	userCollection       *commonStorage.MongoCollection
+}
+
+// MongoIdentity represents linked identity mongo storage model.
+type MongoIdentity struct {
+	Provider string             `bson:"provider"`
+	Subject  string             `bson:"subject"`
+	UserID   primitive.ObjectID `bson:"user_id"`
+	LinkedAt time.Time          `bson:"linked_at"`
+}
+
+// NewMongoIdentityStorage returns new MongoIdentityStorage instance
+// which shares the connection with the user storage.
+// The unique index created here links every identity to one user.
+func NewMongoIdentityStorage(ctx context.Context, s *MongoStorage) (*MongoIdentityStorage, error) {
+	collection := s.database.Collection(identityCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"user_id": 1},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create identity indexes")
+	}
+	return &MongoIdentityStorage{
+		client: s.client,
+		identityCollection: &commonStorage.MongoCollection{
+			Collection: collection,
+		},
+		credentialCollection: &commonStorage.MongoCollection{
+			Collection: s.database.Collection(credentialCollection),
+		},
+		userCollection: s.userCollection,
+	}, nil
+}
+
+// LinkIdentity links the identity to the user unless it's already linked and returns the linked identity,
+// it belongs to another user if the identity was linked to it. Link time is set by the storage.
+func (s *MongoIdentityStorage) LinkIdentity(ctx context.Context, identity model.Identity) (*model.Identity, error) {
+	mIdentity, err := NewMongoIdentity(identity)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"provider": mIdentity.Provider,
+		"subject":  mIdentity.Subject,
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{
+			"user_id":   mIdentity.UserID,
+			"linked_at": now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var linked MongoIdentity
+	if err := s.identityCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&linked); err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return linked.ToIdentity(), nil
+}
+
+// FindIdentity returns nil if the identity isn't linked.
+func (s *MongoIdentityStorage) FindIdentity(ctx context.Context, provider, subject string) (*model.Identity, error) {
+	filter := bson.M{
+		"provider": provider,
+		"subject":  subject,
+	}
+	var mIdentity MongoIdentity
+	err := s.identityCollection.FindOne(ctx, filter).Decode(&mIdentity)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	return mIdentity.ToIdentity(), nil
+}
+
+// ListIdentities returns the user's identities sorted by link time.
+func (s *MongoIdentityStorage) ListIdentities(ctx context.Context, userID string) ([]model.Identity, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	opts := options.Find().SetSort(bson.M{"linked_at": 1})
+	cursor, err := s.identityCollection.Find(ctx, bson.M{"user_id": id}, opts)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mIdentities []MongoIdentity
+	if err := cursor.All(ctx, &mIdentities); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	identities := make([]model.Identity, len(mIdentities))
+	for i := range mIdentities {
+		identities[i] = *mIdentities[i].ToIdentity()
+	}
+
+	return identities, nil
+}
+
+// UnlinkIdentity unlinks the user's identity unless it's the user's last login method,
+// the user's password is a login method too. The check and removal run in a transaction
+// which also updates the user, so concurrent unlinks of the user's identities conflict.
+// ErrLastLoginMethod is returned if the identity is the last login method.
+func (s *MongoIdentityStorage) UnlinkIdentity(ctx context.Context, identity model.Identity) error {
+	mIdentity, err := NewMongoIdentity(identity)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"provider": mIdentity.Provider,
+		"subject":  mIdentity.Subject,
+		"user_id":  mIdentity.UserID,
+	}
+	userUpdate := bson.M{
+		"$set": bson.M{
+			"updated_at": now(),
+		},
+	}
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		res, err := s.userCollection.UpdateOne(sc, bson.M{"_id": mIdentity.UserID}, userUpdate)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errors.New("user not found")
+		}
+		deleted, err := s.identityCollection.DeleteOne(sc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if deleted.DeletedCount == 0 {
+			return nil, errors.New("identity isn't linked to the user")
+		}
+		identities, err := s.identityCollection.CountDocuments(sc, bson.M{"user_id": mIdentity.UserID})
+		if err != nil {
+			return nil, err
+		}
+		passwords, err := s.credentialCollection.CountDocuments(sc, bson.M{"_id": mIdentity.UserID})
+		if err != nil {
+			return nil, err
+		}
+		if identities+passwords == 0 {
+			return nil, ErrLastLoginMethod
+		}
+		return nil, nil
+	})
+	if errors.Is(err, ErrLastLoginMethod) {
+		return ErrLastLoginMethod
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	return nil
+}
+
+// ToIdentity converts MongoIdentity model to Identity model.
+func (m MongoIdentity) ToIdentity() *model.Identity {
+	return &model.Identity{
+		Provider: m.Provider,
+		Subject:  m.Subject,
+		UserID:   m.UserID.Hex(),
+		LinkedAt: m.LinkedAt,
+	}
+}
+
+// NewMongoIdentity converts Identity model to MongoIdentity model.
+func NewMongoIdentity(i model.Identity) (*MongoIdentity, error) {
+	userID, err := primitive.ObjectIDFromHex(i.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoIdentity{
+		Provider: i.Provider,
+		Subject:  i.Subject,
+		UserID:   userID,
+		LinkedAt: i.LinkedAt,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoIdentityStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	identityStorage, err := NewMongoIdentityStorage(ctx, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, identityStorage.identityCollection.Drop(ctx))
+	}()
+
+	t.Run("convertation error", func(t *testing.T) {
+		_, err := identityStorage.ListIdentities(ctx, "invalid")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		user, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		other, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		google := model.Identity{Provider: "google", Subject: "123", UserID: user.ID}
+		github := model.Identity{Provider: "github", Subject: "123", UserID: user.ID}
+
+		linked, err := identityStorage.LinkIdentity(ctx, google)
+		require.NoError(t, err)
+		require.Equal(t, user.ID, linked.UserID)
+		require.False(t, linked.LinkedAt.IsZero())
+		linked, err = identityStorage.LinkIdentity(ctx, model.Identity{Provider: "google", Subject: "123", UserID: other.ID})
+		require.NoError(t, err)
+		require.Equal(t, user.ID, linked.UserID)
+		_, err = identityStorage.LinkIdentity(ctx, github)
+		require.NoError(t, err)
+
+		found, err := identityStorage.FindIdentity(ctx, "github", "123")
+		require.NoError(t, err)
+		require.Equal(t, user.ID, found.UserID)
+		identities, err := identityStorage.ListIdentities(ctx, user.ID)
+		require.NoError(t, err)
+		require.Len(t, identities, 2)
+
+		require.NoError(t, identityStorage.UnlinkIdentity(ctx, github))
+		err = identityStorage.UnlinkIdentity(ctx, github)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageDelete))
+		err = identityStorage.UnlinkIdentity(ctx, google)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrLastLoginMethod))
+
+		found, err = identityStorage.FindIdentity(ctx, "google", "123")
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		found, err = identityStorage.FindIdentity(ctx, "github", "123")
+		require.NoError(t, err)
+		require.Nil(t, found)
+	})
+}
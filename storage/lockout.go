@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	loginFailureCollection = "login_failure"
+	lockoutCollection      = "lockout"
+)
+
+// MongoLockoutStorage represents mongo login lockout storage model.
+type MongoLockoutStorage struct {
+	failureCollection *commonStorage.MongoCollection
+	lockoutCollection *commonStorage.MongoCollection
+}
+
+// MongoLoginFailure represents failed login mongo storage model.
+type MongoLoginFailure struct {
+	Key       string    `bson:"key"`
+	At        time.Time `bson:"at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// MongoLockout represents lockout state mongo storage model.
+type MongoLockout struct {
+	Key         string    `bson:"_id"`
+	Count       int       `bson:"count"`
+	LockedUntil time.Time `bson:"locked_until,omitempty"`
+	ResetAt     time.Time `bson:"reset_at,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// NewMongoLockoutStorage returns new MongoLockoutStorage instance
+// which shares the connection with the user storage.
+// Failures and lockout states are removed by the TTL indexes created here once they expire.
+func NewMongoLockoutStorage(ctx context.Context, s *MongoStorage) (*MongoLockoutStorage, error) {
+	failures := s.database.Collection(loginFailureCollection)
+	_, err := failures.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "key", Value: 1}, {Key: "at", Value: 1}},
+		},
+		{
+			Keys:    bson.M{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create login failure indexes")
+	}
+	lockouts := s.database.Collection(lockoutCollection)
+	_, err = lockouts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create lockout indexes")
+	}
+	return &MongoLockoutStorage{
+		failureCollection: &commonStorage.MongoCollection{
+			Collection: failures,
+		},
+		lockoutCollection: &commonStorage.MongoCollection{
+			Collection: lockouts,
+		},
+	}, nil
+}
+
+// AddLoginFailure records failed login of the key, it's removed once it expires.
+func (s *MongoLockoutStorage) AddLoginFailure(ctx context.Context, key string, at, expiresAt time.Time) error {
+	failure := MongoLoginFailure{
+		Key:       key,
+		At:        at,
+		ExpiresAt: expiresAt,
+	}
+	if _, err := s.failureCollection.InsertOne(ctx, failure); err != nil {
+		return classify(commonErrors.NewStorageInsertError(err.Error()), err)
+	}
+	return nil
+}
+
+// CountLoginFailures counts failed logins of the key since the time.
+func (s *MongoLockoutStorage) CountLoginFailures(ctx context.Context, key string, since time.Time) (int64, error) {
+	filter := bson.M{
+		"key": key,
+		"at": bson.M{
+			"$gt": since,
+		},
+	}
+	count, err := s.failureCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	return count, nil
+}
+
+// FindLockouts returns lockout states of the keys, keys without state are skipped.
+func (s *MongoLockoutStorage) FindLockouts(ctx context.Context, keys []string) ([]model.Lockout, error) {
+	filter := bson.M{
+		"_id": bson.M{
+			"$in": keys,
+		},
+	}
+	cursor, err := s.lockoutCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mLockouts []MongoLockout
+	if err := cursor.All(ctx, &mLockouts); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	lockouts := make([]model.Lockout, len(mLockouts))
+	for i := range mLockouts {
+		lockouts[i] = mLockouts[i].ToLockout()
+	}
+
+	return lockouts, nil
+}
+
+// Lock replaces the lockout state if its lockout count is still the previous one,
+// false is returned if it was changed meanwhile, so concurrent failures lock the key once.
+func (s *MongoLockoutStorage) Lock(ctx context.Context, lockout model.Lockout, previousCount int) (bool, error) {
+	filter := bson.M{
+		"_id":   lockout.Key,
+		"count": previousCount,
+	}
+	opts := options.Replace().SetUpsert(true)
+	// changed state isn't matched, so the upsert fails with a duplicate key error.
+	_, err := s.lockoutCollection.ReplaceOne(ctx, filter, NewMongoLockout(lockout), opts)
+	if isDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return true, nil
+}
+
+// ResetLockout replaces the lockout state with unlocked one, failures before the time aren't counted.
+func (s *MongoLockoutStorage) ResetLockout(ctx context.Context, key string, at, expiresAt time.Time) error {
+	mLockout := MongoLockout{
+		Key:       key,
+		ResetAt:   at,
+		ExpiresAt: expiresAt,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := s.lockoutCollection.ReplaceOne(ctx, bson.M{"_id": key}, mLockout, opts); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// ToLockout converts MongoLockout model to Lockout model.
+func (m MongoLockout) ToLockout() model.Lockout {
+	return model.Lockout{
+		Key:         m.Key,
+		Count:       m.Count,
+		LockedUntil: m.LockedUntil,
+		ResetAt:     m.ResetAt,
+		ExpiresAt:   m.ExpiresAt,
+	}
+}
+
+// NewMongoLockout converts Lockout model to MongoLockout model.
+func NewMongoLockout(l model.Lockout) MongoLockout {
+	return MongoLockout{
+		Key:         l.Key,
+		Count:       l.Count,
+		LockedUntil: l.LockedUntil,
+		ResetAt:     l.ResetAt,
+		ExpiresAt:   l.ExpiresAt,
+	}
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoLockoutStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	lockoutStorage, err := NewMongoLockoutStorage(ctx, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, lockoutStorage.failureCollection.Drop(ctx))
+		require.NoError(t, lockoutStorage.lockoutCollection.Drop(ctx))
+	}()
+
+	t.Run("all ok", func(t *testing.T) {
+		at := time.Now().UTC().Truncate(time.Millisecond)
+		expiresAt := at.Add(time.Hour)
+		require.NoError(t, lockoutStorage.AddLoginFailure(ctx, "user:1", at.Add(-time.Minute), expiresAt))
+		require.NoError(t, lockoutStorage.AddLoginFailure(ctx, "user:1", at, expiresAt))
+		require.NoError(t, lockoutStorage.AddLoginFailure(ctx, "user:2", at, expiresAt))
+		count, err := lockoutStorage.CountLoginFailures(ctx, "user:1", at.Add(-time.Second))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+
+		lockout := model.Lockout{Key: "user:1", Count: 1, LockedUntil: at.Add(time.Minute), ResetAt: at, ExpiresAt: expiresAt}
+		locked, err := lockoutStorage.Lock(ctx, lockout, 0)
+		require.NoError(t, err)
+		require.True(t, locked)
+		// the count was changed by the previous lock.
+		locked, err = lockoutStorage.Lock(ctx, lockout, 0)
+		require.NoError(t, err)
+		require.False(t, locked)
+
+		lockouts, err := lockoutStorage.FindLockouts(ctx, []string{"user:1", "user:2"})
+		require.NoError(t, err)
+		require.Equal(t, []model.Lockout{lockout}, lockouts)
+
+		require.NoError(t, lockoutStorage.ResetLockout(ctx, "user:1", at, expiresAt))
+		lockouts, err = lockoutStorage.FindLockouts(ctx, []string{"user:1"})
+		require.NoError(t, err)
+		require.Equal(t, []model.Lockout{{Key: "user:1", ResetAt: at, ExpiresAt: expiresAt}}, lockouts)
+	})
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	metaSchemaCollection = "meta_schema"
+)
+
+// MongoMetaSchemaStorage represents mongo meta schema storage model.
+type MongoMetaSchemaStorage struct {
+	collection *commonStorage.MongoCollection
+}
+
+// MongoMetaField represents meta schema field mongo storage model.
+type MongoMetaField struct {
+	Key      string `bson:"_id"`
+	Type     string `bson:"type"`
+	Format   string `bson:"format,omitempty"`
+	Required bool   `bson:"required,omitempty"`
+	MaxSize  int    `bson:"max_size,omitempty"`
+}
+
+// NewMongoMetaSchemaStorage returns new MongoMetaSchemaStorage instance
+// which shares the connection with the user storage.
+func NewMongoMetaSchemaStorage(s *MongoStorage) *MongoMetaSchemaStorage {
+	return &MongoMetaSchemaStorage{
+		collection: &commonStorage.MongoCollection{
+			Collection: s.database.Collection(metaSchemaCollection),
+		},
+	}
+}
+
+// ListMetaFields returns all meta schema fields.
+func (s *MongoMetaSchemaStorage) ListMetaFields(ctx context.Context) ([]model.MetaField, error) {
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cursor, err := s.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mFields []MongoMetaField
+	if err := cursor.All(ctx, &mFields); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	fields := make([]model.MetaField, len(mFields))
+	for i := range mFields {
+		fields[i] = mFields[i].ToMetaField()
+	}
+
+	return fields, nil
+}
+
+// PutMetaField creates or replaces meta schema field.
+func (s *MongoMetaSchemaStorage) PutMetaField(ctx context.Context, field model.MetaField) error {
+	mField := NewMongoMetaField(field)
+	filter := bson.M{
+		"_id": mField.Key,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := s.collection.ReplaceOne(ctx, filter, mField, opts); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// DeleteMetaField removes meta schema field by key.
+func (s *MongoMetaSchemaStorage) DeleteMetaField(ctx context.Context, key string) error {
+	filter := bson.M{
+		"_id": key,
+	}
+
+	res, err := s.collection.DeleteOne(ctx, filter)
+	if err == nil && res.DeletedCount == 0 {
+		err = errors.New("meta field not found")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// ToMetaField converts MongoMetaField model to MetaField model.
+func (m MongoMetaField) ToMetaField() model.MetaField {
+	return model.MetaField{
+		Key:      m.Key,
+		Type:     m.Type,
+		Format:   m.Format,
+		Required: m.Required,
+		MaxSize:  m.MaxSize,
+	}
+}
+
+// NewMongoMetaField converts MetaField model to MongoMetaField model.
+func NewMongoMetaField(f model.MetaField) MongoMetaField {
+	return MongoMetaField{
+		Key:      f.Key,
+		Type:     f.Type,
+		Format:   f.Format,
+		Required: f.Required,
+		MaxSize:  f.MaxSize,
+	}
+}
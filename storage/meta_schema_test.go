@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoMetaSchemaStorage(t *testing.T) {
+	st, err := NewMongoStorage(context.Background(), testConfig)
+	require.NoError(t, err)
+	schemaStorage := NewMongoMetaSchemaStorage(st)
+	defer func() {
+		require.NoError(t, schemaStorage.collection.Drop(context.Background()))
+	}()
+
+	t.Run("delete not found error", func(t *testing.T) {
+		err := schemaStorage.DeleteMetaField(context.Background(), "unknown")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageDelete))
+		require.Contains(t, err.Error(), "meta field not found")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		email := model.MetaField{
+			Key:      "email",
+			Type:     "string",
+			Format:   "email",
+			Required: true,
+			MaxSize:  254,
+		}
+		age := model.MetaField{
+			Key:  "age",
+			Type: "int",
+		}
+		require.NoError(t, schemaStorage.PutMetaField(context.Background(), email))
+		require.NoError(t, schemaStorage.PutMetaField(context.Background(), age))
+
+		email.Required = false
+		require.NoError(t, schemaStorage.PutMetaField(context.Background(), email))
+
+		fields, err := schemaStorage.ListMetaFields(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []model.MetaField{age, email}, fields)
+
+		require.NoError(t, schemaStorage.DeleteMetaField(context.Background(), age.Key))
+		fields, err = schemaStorage.ListMetaFields(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, []model.MetaField{email}, fields)
+	})
+}
+
+func TestMongoMetaField_ToMetaField(t *testing.T) {
+	field := model.MetaField{
+		Key:      "email",
+		Type:     "string",
+		Format:   "email",
+		Required: true,
+		MaxSize:  254,
+	}
+	require.Equal(t, field, NewMongoMetaField(field).ToMetaField())
+}
@@ -8,7 +8,7 @@ import (
 	model "github.com/open-Q/user/storage/model"
 	mock "github.com/stretchr/testify/mock"
 
-	storage "github.com/open-Q/user/storage"
+	time "time"
 )
 
 // User is an autogenerated mock type for the User type
@@ -17,15 +17,15 @@ type User struct {
 }
 
 // Add provides a mock function with given fields: ctx, user
-func (_m *User) Add(ctx context.Context, user model.User) (*storage.User, error) {
+func (_m *User) Add(ctx context.Context, user model.User) (*model.User, error) {
 	ret := _m.Called(ctx, user)
 
-	var r0 *storage.User
-	if rf, ok := ret.Get(0).(func(context.Context, model.User) *storage.User); ok {
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) *model.User); ok {
 		r0 = rf(ctx, user)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*storage.User)
+			r0 = ret.Get(0).(*model.User)
 		}
 	}
 
@@ -39,22 +39,68 @@ func (_m *User) Add(ctx context.Context, user model.User) (*storage.User, error)
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, userID
-func (_m *User) Delete(ctx context.Context, userID string) (*storage.User, error) {
-	ret := _m.Called(ctx, userID)
+// AddStatusSchedule provides a mock function with given fields: ctx, userID, schedule
+func (_m *User) AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (*model.User, error) {
+	ret := _m.Called(ctx, userID, schedule)
 
-	var r0 *storage.User
-	if rf, ok := ret.Get(0).(func(context.Context, string) *storage.User); ok {
-		r0 = rf(ctx, userID)
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.StatusSchedule) *model.User); ok {
+		r0 = rf(ctx, userID, schedule)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.StatusSchedule) error); ok {
+		r1 = rf(ctx, userID, schedule)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Anonymise provides a mock function with given fields: ctx, user
+func (_m *User) Anonymise(ctx context.Context, user model.User) (*model.User, error) {
+	ret := _m.Called(ctx, user)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) *model.User); ok {
+		r0 = rf(ctx, user)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.User) error); ok {
+		r1 = rf(ctx, user)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangeStatus provides a mock function with given fields: ctx, userID, change
+func (_m *User) ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (*model.User, error) {
+	ret := _m.Called(ctx, userID, change)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.StatusChange) *model.User); ok {
+		r0 = rf(ctx, userID, change)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*storage.User)
+			r0 = ret.Get(0).(*model.User)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.StatusChange) error); ok {
+		r1 = rf(ctx, userID, change)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -62,6 +108,20 @@ func (_m *User) Delete(ctx context.Context, userID string) (*storage.User, error
 	return r0, r1
 }
 
+// Delete provides a mock function with given fields: ctx, userID
+func (_m *User) Delete(ctx context.Context, userID string) error {
+	ret := _m.Called(ctx, userID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Disconnect provides a mock function with given fields: ctx
 func (_m *User) Disconnect(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -77,15 +137,15 @@ func (_m *User) Disconnect(ctx context.Context) error {
 }
 
 // Find provides a mock function with given fields: ctx, filter
-func (_m *User) Find(ctx context.Context, filter model.UserFindFilter) ([]storage.User, error) {
+func (_m *User) Find(ctx context.Context, filter model.UserFindFilter) ([]model.User, error) {
 	ret := _m.Called(ctx, filter)
 
-	var r0 []storage.User
-	if rf, ok := ret.Get(0).(func(context.Context, model.UserFindFilter) []storage.User); ok {
+	var r0 []model.User
+	if rf, ok := ret.Get(0).(func(context.Context, model.UserFindFilter) []model.User); ok {
 		r0 = rf(ctx, filter)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]storage.User)
+			r0 = ret.Get(0).([]model.User)
 		}
 	}
 
@@ -99,16 +159,76 @@ func (_m *User) Find(ctx context.Context, filter model.UserFindFilter) ([]storag
 	return r0, r1
 }
 
+// Merge provides a mock function with given fields: ctx, merge
+func (_m *User) Merge(ctx context.Context, merge model.Merge) (*model.User, error) {
+	ret := _m.Called(ctx, merge)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, model.Merge) *model.User); ok {
+		r0 = rf(ctx, merge)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.Merge) error); ok {
+		r1 = rf(ctx, merge)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveStatusSchedule provides a mock function with given fields: ctx, userID, scheduleID
+func (_m *User) RemoveStatusSchedule(ctx context.Context, userID string, scheduleID string) (*model.User, error) {
+	ret := _m.Called(ctx, userID, scheduleID)
+
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *model.User); ok {
+		r0 = rf(ctx, userID, scheduleID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.User)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, userID, scheduleID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TouchActivity provides a mock function with given fields: ctx, userID, at
+func (_m *User) TouchActivity(ctx context.Context, userID string, at time.Time) error {
+	ret := _m.Called(ctx, userID, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Time) error); ok {
+		r0 = rf(ctx, userID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: ctx, user
-func (_m *User) Update(ctx context.Context, user model.User) (*storage.User, error) {
+func (_m *User) Update(ctx context.Context, user model.User) (*model.User, error) {
 	ret := _m.Called(ctx, user)
 
-	var r0 *storage.User
-	if rf, ok := ret.Get(0).(func(context.Context, model.User) *storage.User); ok {
+	var r0 *model.User
+	if rf, ok := ret.Get(0).(func(context.Context, model.User) *model.User); ok {
 		r0 = rf(ctx, user)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*storage.User)
+			r0 = ret.Get(0).(*model.User)
 		}
 	}
 
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// APIKey represents user's API key, only a hash of the secret is kept.
+type APIKey struct {
+	ID     string
+	UserID string
+	Name   string
+	// Prefix identifies the key, it's the public part of the secret.
+	Prefix string
+	Hash   string
+	Scopes []string
+	// ExpiresAt is zero for keys that never expire.
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+// APIKeyFindFilter represents filter model for finding API keys.
+type APIKeyFindFilter struct {
+	IDs      []string
+	UserIDs  []string
+	Prefixes []string
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// PasswordCredential represents user's password hash.
+type PasswordCredential struct {
+	UserID string
+	// Hash is encoded with the hashing algorithm and its parameters.
+	Hash      string
+	UpdatedAt time.Time
+}
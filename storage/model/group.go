@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Group represents named group of users, e.g. a team or a beta program.
+type Group struct {
+	ID          string
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
+
+// GroupFindFilter represents filter model for finding groups.
+type GroupFindFilter struct {
+	IDs    []string
+	Names  []string
+	Limit  *int64
+	Offset *int64
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Identity represents user's identity at an external identity provider, e.g. Google or a SAML IdP.
+// Every identity is linked to one user.
+type Identity struct {
+	// Provider names the identity provider, e.g. "google", "github" or the SAML entity ID.
+	Provider string
+	// Subject is the user's stable ID at the provider.
+	Subject  string
+	UserID   string
+	LinkedAt time.Time
+}
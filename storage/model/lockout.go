@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// Lockout represents login lockout state of a user or a login identifier.
+type Lockout struct {
+	Key string
+	// Count is a number of consecutive lockouts, every next lockout lasts longer.
+	Count       int
+	LockedUntil time.Time
+	// ResetAt is when failures were reset, earlier failures aren't counted.
+	ResetAt time.Time
+	// ExpiresAt is when the state is removed, e.g. to forget old lockouts.
+	ExpiresAt time.Time
+}
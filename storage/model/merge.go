@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// Merge represents merge of the source user into the target one.
+type Merge struct {
+	SourceID string
+	TargetID string
+	// Meta and MetaTypes replace the target's meta.
+	Meta      map[string]interface{}
+	MetaTypes map[string]string
+	// GroupIDs are added to the target's groups.
+	GroupIDs []string
+	// Roles are assigned to the target unless it has them for the same resource.
+	Roles []RoleAssignment
+	// SourceUpdatedAt and TargetUpdatedAt are update times of the users the merge
+	// was computed for, the merge fails if any of them was updated since.
+	SourceUpdatedAt time.Time
+	TargetUpdatedAt time.Time
+	// SourceChange moves the source user to the merged status.
+	SourceChange StatusChange
+}
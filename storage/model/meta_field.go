@@ -0,0 +1,12 @@
+package model
+
+// MetaField represents meta schema field definition.
+type MetaField struct {
+	Key    string
+	Type   string
+	Format string
+	// Required fields must be present in every user's meta.
+	Required bool
+	// MaxSize limits length of strings and bytes and number of list and object elements.
+	MaxSize int
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// Role represents named set of permissions.
+type Role struct {
+	Name        string
+	Description string
+	// Permissions are actions the role allows, e.g. "orders:read".
+	Permissions []string
+}
+
+// RoleAssignment represents role assigned to the user.
+type RoleAssignment struct {
+	Role string
+	// Resource limits the role to the resource, the role applies to any resource if empty.
+	Resource   string
+	AssignedAt time.Time
+}
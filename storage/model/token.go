@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// There are token purposes.
+const (
+	// TokenPasswordReset allows to set a new password without the current one.
+	TokenPasswordReset = "password_reset"
+	// TokenMagicLink allows to sign in without a password.
+	TokenMagicLink = "magic_link"
+)
+
+// Token represents user's single-use token of the purpose.
+// The user has at most one token of every purpose, newer tokens replace older ones.
+type Token struct {
+	UserID  string
+	Purpose string
+	// Hash is a hash of the token, tokens aren't kept.
+	Hash      string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// TwoFactor represents user's TOTP two-factor state.
+type TwoFactor struct {
+	UserID string
+	// Secret is the encrypted TOTP secret, SecretKeyID identifies the encryption key.
+	Secret      []byte
+	SecretKeyID string
+	// LastCounter is the time step of the last accepted code, codes of earlier
+	// or the same steps are rejected as replays.
+	LastCounter int64
+	// RecoveryCodes holds hashes of unused recovery codes.
+	RecoveryCodes []string
+	CreatedAt     time.Time
+	// ConfirmedAt is zero until the enrollment is confirmed.
+	ConfirmedAt time.Time
+}
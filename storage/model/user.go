@@ -1,10 +1,68 @@
 package model
 
+import "time"
+
+// StatusDeleted is the status of soft deleted users, changing to it sets the deletion time.
+const StatusDeleted = "ACCOUNT_STATUS_DELETED"
+
 // User represents user storage model.
 type User struct {
 	ID     string
 	Status string
 	Meta   map[string]interface{}
+	// MetaTypes holds protobuf type URLs of the meta values by meta key.
+	MetaTypes map[string]string
+	// StatusHistory holds status transitions in the order they were made.
+	StatusHistory []StatusChange
+	// StatusSchedules holds pending status changes.
+	StatusSchedules []StatusSchedule
+	// GroupIDs holds IDs of the groups the user is a member of.
+	GroupIDs []string
+	// Roles holds roles assigned to the user.
+	Roles []RoleAssignment
+	// TwoFactorEnabled is set once two-factor enrollment is confirmed.
+	TwoFactorEnabled bool
+	// VerifiedContacts holds contact verifications by contact meta key.
+	VerifiedContacts map[string]ContactVerification
+	// MergedInto is the ID of the user this one was merged into, empty if it wasn't merged.
+	MergedInto string
+	MergedAt   time.Time
+	// LastActiveAt is the time of the user's last login, set to the creation time initially.
+	LastActiveAt time.Time
+	// DeletedAt is the time the user was soft deleted, zero unless the user is deleted.
+	DeletedAt time.Time
+	// AnonymisedAt is the time the user's personal data was removed, zero if it wasn't.
+	AnonymisedAt time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// StatusChange represents user's status transition.
+type StatusChange struct {
+	From string
+	To   string
+	// Reason is a reason code of the transition.
+	Reason string
+	// Actor identifies who made the transition.
+	Actor string
+	At    time.Time
+	// ScheduleID is set when the change executes the schedule, which is removed with the change.
+	ScheduleID string
+	// Schedule is added with the change, e.g. to revert a temporary suspension.
+	Schedule *StatusSchedule
+}
+
+// StatusSchedule represents status change scheduled for the future.
+type StatusSchedule struct {
+	ID string
+	// From is the status the user must have when the change is executed, any if empty.
+	From   string
+	To     string
+	Reason string
+	Actor  string
+	// At is the time the change is due.
+	At        time.Time
+	CreatedAt time.Time
 }
 
 // UserFindFilter represents filter model for finding users.
@@ -12,6 +70,18 @@ type UserFindFilter struct {
 	IDs          []string
 	Statuses     []string
 	MetaPatterns map[string]string
-	Limit        *int64
-	Offset       *int64
+	// GroupIDs matches members of any of the groups.
+	GroupIDs []string
+	// UpdatedBefore matches users updated before the provided time,
+	// users without update time aren't matched.
+	UpdatedBefore *time.Time
+	// DeletedBefore matches users soft deleted before the provided time.
+	DeletedBefore *time.Time
+	// InactiveBefore matches users which weren't active since the provided time and weren't anonymised,
+	// users without activity time aren't matched.
+	InactiveBefore *time.Time
+	// ScheduledBefore matches users having status changes due before the provided time.
+	ScheduledBefore *time.Time
+	Limit           *int64
+	Offset          *int64
 }
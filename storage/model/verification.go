@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// There are verification challenge kinds.
+const (
+	// VerificationCode is a short numeric code, e.g. sent by SMS.
+	VerificationCode = "code"
+	// VerificationToken is a long random token, e.g. sent in an email link.
+	VerificationToken = "token"
+)
+
+// VerificationChallenge represents single-use challenge proving the user controls the contact.
+type VerificationChallenge struct {
+	ID     string
+	UserID string
+	// ContactKey is the meta key of the contact.
+	ContactKey string
+	// ValueHash is a hash of the contact value the challenge was sent to.
+	ValueHash string
+	Kind      string
+	// Hash is a hash of the code or token, they aren't kept.
+	Hash string
+	// Attempts is a number of failed confirmations.
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    time.Time
+}
+
+// ContactVerification represents verified contact of the user.
+type ContactVerification struct {
+	// ValueHash is a hash of the verified contact value, so changed contacts aren't verified.
+	ValueHash  string
+	VerifiedAt time.Time
+}
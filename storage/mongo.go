@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"log"
+	"time"
 
 	commonErrors "github.com/open-Q/common/golang/errors"
 	commonStorage "github.com/open-Q/common/golang/storage"
@@ -16,42 +17,139 @@ import (
 
 const (
 	userCollection = "user"
+	// userLockoutKeyPrefix is a prefix of the lockout keys of the users.
+	userLockoutKeyPrefix = "user:"
 )
 
 // MongoStorage represents mongo storage model.
 type MongoStorage struct {
-	db             *commonStorage.MongoStorage
+	client         *mongo.Client
+	database       *mongo.Database
 	userCollection *commonStorage.MongoCollection
 }
 
 // MongoUser represents user mongo storage model.
 type MongoUser struct {
-	ID     primitive.ObjectID     `bson:"_id,omitempty"`
-	Status string                 `bson:"status"`
-	Meta   map[string]interface{} `bson:"meta,omitempty"`
+	ID        primitive.ObjectID     `bson:"_id,omitempty"`
+	Status    string                 `bson:"status"`
+	Meta      map[string]interface{} `bson:"meta,omitempty"`
+	MetaTypes map[string]string      `bson:"meta_types,omitempty"`
+	// StatusHistory is only appended by ChangeStatus.
+	StatusHistory []MongoStatusChange `bson:"status_history,omitempty"`
+	// StatusSchedules are only changed by ChangeStatus and the schedule methods.
+	StatusSchedules []MongoStatusSchedule `bson:"status_schedules,omitempty"`
+	// GroupIDs are only changed by the group storage.
+	GroupIDs []primitive.ObjectID `bson:"group_ids,omitempty"`
+	// Roles are only changed by the role storage.
+	Roles []MongoRoleAssignment `bson:"roles,omitempty"`
+	// TwoFactorEnabled is only changed by the two-factor storage.
+	TwoFactorEnabled bool `bson:"two_factor_enabled,omitempty"`
+	// VerifiedContacts are only changed by the verification storage.
+	VerifiedContacts map[string]MongoContactVerification `bson:"verified_contacts,omitempty"`
+	// MergedInto and MergedAt are only set by Merge.
+	MergedInto primitive.ObjectID `bson:"merged_into,omitempty"`
+	MergedAt   time.Time          `bson:"merged_at,omitempty"`
+	// LastActiveAt is only changed by TouchActivity.
+	LastActiveAt time.Time `bson:"last_active_at,omitempty"`
+	// DeletedAt is only changed by ChangeStatus.
+	DeletedAt time.Time `bson:"deleted_at,omitempty"`
+	// AnonymisedAt is only set by Anonymise.
+	AnonymisedAt time.Time `bson:"anonymised_at,omitempty"`
+	CreatedAt    time.Time `bson:"created_at,omitempty"`
+	UpdatedAt    time.Time `bson:"updated_at,omitempty"`
+}
+
+// MongoStatusChange represents user's status transition mongo storage model.
+type MongoStatusChange struct {
+	From   string    `bson:"from"`
+	To     string    `bson:"to"`
+	Reason string    `bson:"reason"`
+	Actor  string    `bson:"actor"`
+	At     time.Time `bson:"at"`
+}
+
+// MongoStatusSchedule represents scheduled status change mongo storage model.
+type MongoStatusSchedule struct {
+	ID        primitive.ObjectID `bson:"id"`
+	From      string             `bson:"from,omitempty"`
+	To        string             `bson:"to"`
+	Reason    string             `bson:"reason"`
+	Actor     string             `bson:"actor"`
+	At        time.Time          `bson:"at"`
+	CreatedAt time.Time          `bson:"created_at"`
 }
 
 // NewMongoStorage returns new MongoStorage instance.
-func NewMongoStorage(ctx context.Context, connString, dbName string) (*MongoStorage, error) {
-	db, err := commonStorage.NewMongo(ctx, connString, dbName)
+func NewMongoStorage(ctx context.Context, cfg MongoConfig) (*MongoStorage, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid mongo configuration")
+	}
+
+	uri, err := cfg.URI()
 	if err != nil {
 		return nil, err
 	}
 
-	userColl, err := db.Collection(ctx, userCollection)
+	clientOptions := options.Client().ApplyURI(uri)
+	if cfg.PoolMonitor != nil {
+		clientOptions.SetPoolMonitor(cfg.PoolMonitor)
+	}
+	if cfg.CommandMonitor != nil {
+		clientOptions.SetMonitor(cfg.CommandMonitor)
+	}
+
+	client, err := mongo.NewClient(clientOptions)
 	if err != nil {
-		return nil, errors.Wrapf(err, "could not create %s collection", userCollection)
+		return nil, errors.Wrap(err, "could not create mongo client")
+	}
+	if err := client.Connect(ctx); err != nil {
+		return nil, errors.Wrap(err, "could not connect")
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, errors.Wrap(err, "could not ping connection")
 	}
 
+	database := client.Database(cfg.DBName)
+	users := database.Collection(userCollection)
+	// the scheduler finds users by the time of their pending schedules, group members
+	// are found by their groups, retention finds users by status and deletion or activity time.
+	_, err = users.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.M{"status_schedules.at": 1},
+		},
+		{
+			Keys: bson.M{"group_ids": 1},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "deleted_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "last_active_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create user indexes")
+	}
 	return &MongoStorage{
-		db:             db,
-		userCollection: userColl,
+		client:   client,
+		database: database,
+		userCollection: &commonStorage.MongoCollection{
+			Collection: users,
+		},
 	}, nil
 }
 
 // Disconnect breaks storage connection.
 func (s *MongoStorage) Disconnect(ctx context.Context) error {
-	return s.db.Disconnect(ctx)
+	return s.client.Disconnect(ctx)
+}
+
+// Ping checks storage connectivity.
+func (s *MongoStorage) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx, nil); err != nil {
+		return errors.Wrap(err, "could not ping connection")
+	}
+	return nil
 }
 
 // Add adds a new user.
@@ -60,10 +158,13 @@ func (s *MongoStorage) Add(ctx context.Context, user model.User) (*model.User, e
 	if err != nil {
 		return nil, commonErrors.NewStorageConvertError(err.Error())
 	}
+	mUser.CreatedAt = now()
+	mUser.UpdatedAt = mUser.CreatedAt
+	mUser.LastActiveAt = mUser.CreatedAt
 
 	res, err := s.userCollection.InsertOne(ctx, mUser)
 	if err != nil {
-		return nil, commonErrors.NewStorageInsertError(err.Error())
+		return nil, classify(commonErrors.NewStorageInsertError(err.Error()), err)
 	}
 
 	mUser.ID = res.InsertedID.(primitive.ObjectID)
@@ -71,7 +172,10 @@ func (s *MongoStorage) Add(ctx context.Context, user model.User) (*model.User, e
 	return mUser.ToUser(), nil
 }
 
-// Delete removes an existing user by ID.
+// Delete removes the user entirely along with the data kept apart from it:
+// identities, tokens, credentials, API keys, two-factor enrollment,
+// verification challenges and the user's lockouts.
+// Everything is removed in a transaction, so it requires mongo replica set.
 func (s *MongoStorage) Delete(ctx context.Context, userID string) error {
 	id, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
@@ -82,18 +186,140 @@ func (s *MongoStorage) Delete(ctx context.Context, userID string) error {
 		"_id": id,
 	}
 
-	res, err := s.userCollection.DeleteOne(ctx, filter)
-	if res.DeletedCount == 0 && err == nil {
+	session, err := s.client.StartSession()
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		res, err := s.userCollection.DeleteOne(sc, filter)
+		if err != nil {
+			return nil, err
+		}
+		if res.DeletedCount == 0 {
+			return nil, errors.New("user not found")
+		}
+		return nil, s.deleteUserData(sc, id)
+	})
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// Anonymise removes user's meta, verified contacts and the data Delete removes,
+// keeping the user itself. It fails if the user was updated or active since it was read.
+func (s *MongoStorage) Anonymise(ctx context.Context, user model.User) (*model.User, error) {
+	id, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id":            id,
+		"updated_at":     matchTime(user.UpdatedAt),
+		"last_active_at": matchTime(user.LastActiveAt),
+	}
+	anonymisedAt := now()
+	update := bson.M{
+		"$set": bson.M{
+			"updated_at":    anonymisedAt,
+			"anonymised_at": anonymisedAt,
+		},
+		"$unset": bson.M{
+			"meta":               "",
+			"meta_types":         "",
+			"verified_contacts":  "",
+			"two_factor_enabled": "",
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	defer session.EndSession(ctx)
+
+	var updatedUser MongoUser
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		err := s.userCollection.FindOneAndUpdate(sc, filter, update, opts).Decode(&updatedUser)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("user not found or was changed meanwhile")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, s.deleteUserData(sc, id)
+	})
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return updatedUser.ToUser(), nil
+}
+
+// deleteUserData removes the data kept apart from the user.
+// Lockout keys of the user match the lockout package's user keys,
+// identifier lockouts are hashed and can't be linked to the user.
+func (s *MongoStorage) deleteUserData(sc mongo.SessionContext, id primitive.ObjectID) error {
+	lockoutKey := userLockoutKeyPrefix + id.Hex()
+	deletes := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{identityCollection, bson.M{"user_id": id}},
+		{tokenCollection, bson.M{"user_id": id}},
+		{credentialCollection, bson.M{"_id": id}},
+		{apiKeyCollection, bson.M{"user_id": id}},
+		{twoFactorCollection, bson.M{"_id": id}},
+		{verificationCollection, bson.M{"user_id": id}},
+		{verificationRateCollection, bson.M{"user_id": id}},
+		{loginFailureCollection, bson.M{"key": lockoutKey}},
+		{lockoutCollection, bson.M{"_id": lockoutKey}},
+	}
+	for _, d := range deletes {
+		if _, err := s.database.Collection(d.collection).DeleteMany(sc, d.filter); err != nil {
+			return errors.Wrapf(err, "could not delete user's %s data", d.collection)
+		}
+	}
+	return nil
+}
+
+// TouchActivity moves user's last activity time forward to at, earlier times don't overwrite later ones.
+// Update time is kept, so activity doesn't conflict with concurrent updates.
+func (s *MongoStorage) TouchActivity(ctx context.Context, userID string, at time.Time) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+	}
+	update := bson.M{
+		"$max": bson.M{
+			"last_active_at": at.UTC().Truncate(time.Millisecond),
+		},
+	}
+
+	res, err := s.userCollection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
 		err = errors.New("user not found")
 	}
 	if err != nil {
-		return commonErrors.NewStorageDeleteError(err.Error())
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
 	}
 
 	return nil
 }
 
 // Update updates an existing user.
+// User creation time, status and its history are kept untouched,
+// status is changed by ChangeStatus only. The user is updated only if its update time
+// is still the one it was read with, ErrUserChanged is returned otherwise.
 func (s *MongoStorage) Update(ctx context.Context, user model.User) (*model.User, error) {
 	mUser, err := NewMongoUser(user)
 	if err != nil {
@@ -101,17 +327,314 @@ func (s *MongoStorage) Update(ctx context.Context, user model.User) (*model.User
 	}
 
 	filter := bson.M{
-		"_id": mUser.ID,
+		"_id":        mUser.ID,
+		"updated_at": matchTime(user.UpdatedAt),
 	}
-	res, err := s.userCollection.ReplaceOne(ctx, filter, mUser)
-	if res.MatchedCount == 0 && err == nil {
-		err = errors.New("user not found")
+	update := newMetaUpdate(mUser.Meta, mUser.MetaTypes, now())
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updatedUser MongoUser
+	err = s.userCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedUser)
+	if err == mongo.ErrNoDocuments {
+		count, countErr := s.userCollection.CountDocuments(ctx, bson.M{"_id": mUser.ID})
+		switch {
+		case countErr != nil:
+			err = countErr
+		case count != 0:
+			return nil, ErrUserChanged
+		default:
+			err = errors.New("user not found")
+		}
 	}
 	if err != nil {
-		return nil, commonErrors.NewStorageUpdateError(err.Error())
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
 	}
 
-	return mUser.ToUser(), nil
+	return updatedUser.ToUser(), nil
+}
+
+// ChangeStatus applies status change if the user still has the change's From status,
+// so concurrent changes can't overwrite each other. Change time is set to the update time.
+// The executed schedule and the schedules expecting another status are removed and
+// the new schedule is added atomically with the change, so a schedule is never executed twice.
+func (s *MongoStorage) ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (*model.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	change.At = now()
+	filter := bson.M{
+		"_id":    id,
+		"status": change.From,
+	}
+	// the deletion time is kept while the user is deleted only.
+	var deletedAt interface{} = "$$REMOVE"
+	if change.To == model.StatusDeleted {
+		deletedAt = change.At
+	}
+	// schedules without From apply to any status and are kept.
+	keep := bson.A{
+		bson.M{
+			"$eq": bson.A{
+				bson.M{"$ifNull": bson.A{"$$schedule.from", bson.M{"$literal": change.To}}},
+				bson.M{"$literal": change.To},
+			},
+		},
+	}
+	if change.ScheduleID != "" {
+		scheduleID, err := primitive.ObjectIDFromHex(change.ScheduleID)
+		if err != nil {
+			return nil, commonErrors.NewStorageConvertError(err.Error())
+		}
+		filter["status_schedules.id"] = scheduleID
+		keep = append(keep, bson.M{
+			"$ne": bson.A{"$$schedule.id", scheduleID},
+		})
+	}
+	var schedules interface{} = bson.M{
+		"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$status_schedules", bson.A{}}},
+			"as":    "schedule",
+			"cond":  bson.M{"$and": keep},
+		},
+	}
+	if change.Schedule != nil {
+		schedule := newMongoStatusSchedule(*change.Schedule, change.At)
+		schedules = bson.M{
+			"$concatArrays": bson.A{schedules, bson.M{"$literal": bson.A{schedule}}},
+		}
+	}
+	// the update is a pipeline, since mongo can't pull and push the same array
+	// in one update; values are literals, so they are never read as field paths.
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"status":     bson.M{"$literal": change.To},
+			"updated_at": change.At,
+			"status_history": bson.M{
+				"$concatArrays": bson.A{
+					bson.M{"$ifNull": bson.A{"$status_history", bson.A{}}},
+					bson.M{"$literal": bson.A{NewMongoStatusChange(change)}},
+				},
+			},
+			"status_schedules": schedules,
+			"deleted_at":       deletedAt,
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updatedUser MongoUser
+	err = s.userCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedUser)
+	if err == mongo.ErrNoDocuments {
+		err = errors.Errorf("user not found or its status isn't %s anymore", change.From)
+		if change.ScheduleID != "" {
+			err = errors.Errorf("user not found, its status isn't %s anymore or schedule %s was already executed", change.From, change.ScheduleID)
+		}
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return updatedUser.ToUser(), nil
+}
+
+// AddStatusSchedule adds status change scheduled for the future.
+// Schedule ID and creation time are set by the storage.
+func (s *MongoStorage) AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (*model.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+	}
+	update := bson.M{
+		"$push": bson.M{
+			"status_schedules": newMongoStatusSchedule(schedule, now()),
+		},
+	}
+	return s.updateStatusSchedules(ctx, filter, update, "user not found")
+}
+
+// RemoveStatusSchedule removes pending status change.
+func (s *MongoStorage) RemoveStatusSchedule(ctx context.Context, userID, scheduleID string) (*model.User, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	sID, err := primitive.ObjectIDFromHex(scheduleID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id":                 id,
+		"status_schedules.id": sID,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"status_schedules": bson.M{"id": sID},
+		},
+	}
+	return s.updateStatusSchedules(ctx, filter, update, "user or schedule not found")
+}
+
+func (s *MongoStorage) updateStatusSchedules(ctx context.Context, filter, update bson.M, notFound string) (*model.User, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updatedUser MongoUser
+	err := s.userCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedUser)
+	if err == mongo.ErrNoDocuments {
+		err = errors.New(notFound)
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return updatedUser.ToUser(), nil
+}
+
+// Merge merges the source user into the target one in a transaction, so it
+// requires mongo replica set. The source user is marked as merged and loses
+// its pending status changes, the target user gets the merge's meta, groups and roles
+// along with the source's identities, API keys and password.
+// The merge fails if any of the users was updated since the merge was computed.
+func (s *MongoStorage) Merge(ctx context.Context, merge model.Merge) (*model.User, error) {
+	sourceID, err := primitive.ObjectIDFromHex(merge.SourceID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	targetID, err := primitive.ObjectIDFromHex(merge.TargetID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	at := now()
+	change := merge.SourceChange
+	change.At = at
+	sourceFilter := bson.M{
+		"_id":         sourceID,
+		"status":      change.From,
+		"updated_at":  matchTime(merge.SourceUpdatedAt),
+		"merged_into": bson.M{"$exists": false},
+	}
+	sourceUpdate := bson.M{
+		"$set": bson.M{
+			"status":      change.To,
+			"merged_into": targetID,
+			"merged_at":   at,
+			"updated_at":  at,
+		},
+		"$push": bson.M{
+			"status_history": NewMongoStatusChange(change),
+		},
+		"$unset": bson.M{
+			"status_schedules": "",
+		},
+	}
+	targetFilter := bson.M{
+		"_id":         targetID,
+		"updated_at":  matchTime(merge.TargetUpdatedAt),
+		"merged_into": bson.M{"$exists": false},
+	}
+	targetUpdate := newMetaUpdate(merge.Meta, merge.MetaTypes, at)
+	if len(merge.GroupIDs) != 0 {
+		groupIDs, err := newObjectIDs(merge.GroupIDs)
+		if err != nil {
+			return nil, commonErrors.NewStorageConvertError(err.Error())
+		}
+		targetUpdate["$addToSet"] = bson.M{
+			"group_ids": bson.M{"$each": groupIDs},
+		}
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	defer session.EndSession(ctx)
+
+	var updatedUser MongoUser
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		res, err := s.userCollection.UpdateOne(sc, sourceFilter, sourceUpdate)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errors.New("source user not found or was changed since the merge was computed")
+		}
+		if err := s.moveUserData(sc, sourceID, targetID, merge.Roles); err != nil {
+			return nil, err
+		}
+		err = s.userCollection.FindOneAndUpdate(sc, targetFilter, targetUpdate, opts).Decode(&updatedUser)
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("target user not found or was changed since the merge was computed")
+		}
+		return nil, err
+	})
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return updatedUser.ToUser(), nil
+}
+
+// moveUserData moves the source user's identities, API keys and password to the target user
+// and assigns the roles the target user doesn't have for the same resource.
+// The target's own password is kept if it has one.
+func (s *MongoStorage) moveUserData(sc mongo.SessionContext, sourceID, targetID primitive.ObjectID, roles []model.RoleAssignment) error {
+	move := bson.M{
+		"$set": bson.M{
+			"user_id": targetID,
+		},
+	}
+	for _, collection := range []string{identityCollection, apiKeyCollection} {
+		if _, err := s.database.Collection(collection).UpdateMany(sc, bson.M{"user_id": sourceID}, move); err != nil {
+			return errors.Wrapf(err, "could not move user's %s data", collection)
+		}
+	}
+
+	credentials := s.database.Collection(credentialCollection)
+	var credential MongoPasswordCredential
+	err := credentials.FindOneAndDelete(sc, bson.M{"_id": sourceID}).Decode(&credential)
+	switch {
+	case err == mongo.ErrNoDocuments:
+	case err != nil:
+		return errors.Wrap(err, "could not move user's password")
+	default:
+		update := bson.M{
+			"$setOnInsert": bson.M{
+				"password_hash": credential.Hash,
+				"updated_at":    credential.UpdatedAt,
+			},
+		}
+		opts := options.Update().SetUpsert(true)
+		if _, err := credentials.UpdateOne(sc, bson.M{"_id": targetID}, update, opts); err != nil {
+			return errors.Wrap(err, "could not move user's password")
+		}
+	}
+
+	for i := range roles {
+		filter := bson.M{
+			"_id": targetID,
+			"roles": bson.M{
+				"$not": bson.M{
+					"$elemMatch": assignmentFilter(roles[i]),
+				},
+			},
+		}
+		update := bson.M{
+			"$push": bson.M{
+				"roles": NewMongoRoleAssignment(roles[i]),
+			},
+		}
+		if _, err := s.userCollection.UpdateOne(sc, filter, update); err != nil {
+			return errors.Wrap(err, "could not assign user's roles")
+		}
+	}
+	return nil
 }
 
 // Find finds users by filter.
@@ -123,13 +646,13 @@ func (s *MongoStorage) Find(ctx context.Context, filter model.UserFindFilter) ([
 
 	cursor, err := s.userCollection.Find(ctx, mongoFilter, findOptions)
 	if err != nil {
-		return nil, commonErrors.NewStorageFindError(err.Error())
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
 	}
 	defer closeCursor(ctx, cursor)
 
 	var users []MongoUser
 	if err := cursor.All(ctx, &users); err != nil {
-		return nil, commonErrors.NewStorageConvertError(err.Error())
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
 	}
 
 	foundUsers := make([]model.User, len(users))
@@ -143,20 +666,63 @@ func (s *MongoStorage) Find(ctx context.Context, filter model.UserFindFilter) ([
 // ToUser converts MongoUser model to User model.
 func (m MongoUser) ToUser() *model.User {
 	user := model.User{
-		Status: m.Status,
-		Meta:   convertMeta(m.Meta),
+		Status:           m.Status,
+		Meta:             convertMeta(m.Meta),
+		MetaTypes:        m.MetaTypes,
+		TwoFactorEnabled: m.TwoFactorEnabled,
+		LastActiveAt:     m.LastActiveAt,
+		DeletedAt:        m.DeletedAt,
+		AnonymisedAt:     m.AnonymisedAt,
+		CreatedAt:        m.CreatedAt,
+		UpdatedAt:        m.UpdatedAt,
+	}
+	for i := range m.StatusHistory {
+		user.StatusHistory = append(user.StatusHistory, m.StatusHistory[i].ToStatusChange())
+	}
+	for i := range m.StatusSchedules {
+		user.StatusSchedules = append(user.StatusSchedules, m.StatusSchedules[i].ToStatusSchedule())
 	}
 	if !m.ID.IsZero() {
 		user.ID = m.ID.Hex()
 	}
+	for i := range m.GroupIDs {
+		user.GroupIDs = append(user.GroupIDs, m.GroupIDs[i].Hex())
+	}
+	for i := range m.Roles {
+		user.Roles = append(user.Roles, m.Roles[i].ToRoleAssignment())
+	}
+	if !m.MergedInto.IsZero() {
+		user.MergedInto = m.MergedInto.Hex()
+		user.MergedAt = m.MergedAt
+	}
+	if len(m.VerifiedContacts) != 0 {
+		user.VerifiedContacts = make(map[string]model.ContactVerification, len(m.VerifiedContacts))
+		for k, v := range m.VerifiedContacts {
+			user.VerifiedContacts[k] = v.ToContactVerification()
+		}
+	}
 	return &user
 }
 
 // NewMongoUser converts User model to MongoUser model.
 func NewMongoUser(u model.User) (*MongoUser, error) {
 	user := MongoUser{
-		Status: u.Status,
-		Meta:   u.Meta,
+		Status:           u.Status,
+		Meta:             u.Meta,
+		MetaTypes:        u.MetaTypes,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+	}
+	for i := range u.StatusHistory {
+		user.StatusHistory = append(user.StatusHistory, NewMongoStatusChange(u.StatusHistory[i]))
+	}
+	for i := range u.StatusSchedules {
+		schedule, err := NewMongoStatusSchedule(u.StatusSchedules[i])
+		if err != nil {
+			return nil, err
+		}
+		user.StatusSchedules = append(user.StatusSchedules, *schedule)
 	}
 	if u.ID != "" {
 		id, err := primitive.ObjectIDFromHex(u.ID)
@@ -165,10 +731,127 @@ func NewMongoUser(u model.User) (*MongoUser, error) {
 		}
 		user.ID = id
 	}
+	if len(u.GroupIDs) != 0 {
+		groupIDs, err := newObjectIDs(u.GroupIDs)
+		if err != nil {
+			return nil, err
+		}
+		user.GroupIDs = groupIDs
+	}
+	for i := range u.Roles {
+		user.Roles = append(user.Roles, NewMongoRoleAssignment(u.Roles[i]))
+	}
+	if u.MergedInto != "" {
+		id, err := primitive.ObjectIDFromHex(u.MergedInto)
+		if err != nil {
+			return nil, err
+		}
+		user.MergedInto = id
+		user.MergedAt = u.MergedAt
+	}
+	if len(u.VerifiedContacts) != 0 {
+		user.VerifiedContacts = make(map[string]MongoContactVerification, len(u.VerifiedContacts))
+		for k, v := range u.VerifiedContacts {
+			user.VerifiedContacts[k] = NewMongoContactVerification(v)
+		}
+	}
 
 	return &user, nil
 }
 
+// ToStatusChange converts MongoStatusChange model to StatusChange model.
+func (m MongoStatusChange) ToStatusChange() model.StatusChange {
+	return model.StatusChange{
+		From:   m.From,
+		To:     m.To,
+		Reason: m.Reason,
+		Actor:  m.Actor,
+		At:     m.At,
+	}
+}
+
+// NewMongoStatusChange converts StatusChange model to MongoStatusChange model.
+func NewMongoStatusChange(c model.StatusChange) MongoStatusChange {
+	return MongoStatusChange{
+		From:   c.From,
+		To:     c.To,
+		Reason: c.Reason,
+		Actor:  c.Actor,
+		At:     c.At,
+	}
+}
+
+// ToStatusSchedule converts MongoStatusSchedule model to StatusSchedule model.
+func (m MongoStatusSchedule) ToStatusSchedule() model.StatusSchedule {
+	return model.StatusSchedule{
+		ID:        m.ID.Hex(),
+		From:      m.From,
+		To:        m.To,
+		Reason:    m.Reason,
+		Actor:     m.Actor,
+		At:        m.At,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// NewMongoStatusSchedule converts StatusSchedule model to MongoStatusSchedule model.
+func NewMongoStatusSchedule(s model.StatusSchedule) (*MongoStatusSchedule, error) {
+	schedule := MongoStatusSchedule{
+		From:      s.From,
+		To:        s.To,
+		Reason:    s.Reason,
+		Actor:     s.Actor,
+		At:        s.At,
+		CreatedAt: s.CreatedAt,
+	}
+	if s.ID != "" {
+		id, err := primitive.ObjectIDFromHex(s.ID)
+		if err != nil {
+			return nil, err
+		}
+		schedule.ID = id
+	}
+	return &schedule, nil
+}
+
+// newMongoStatusSchedule returns new schedule with generated ID.
+func newMongoStatusSchedule(s model.StatusSchedule, createdAt time.Time) MongoStatusSchedule {
+	return MongoStatusSchedule{
+		ID:        primitive.NewObjectID(),
+		From:      s.From,
+		To:        s.To,
+		Reason:    s.Reason,
+		Actor:     s.Actor,
+		At:        s.At.UTC().Truncate(time.Millisecond),
+		CreatedAt: createdAt,
+	}
+}
+
+// newMetaUpdate returns update which replaces user's meta, empty meta is removed.
+func newMetaUpdate(meta map[string]interface{}, metaTypes map[string]string, updatedAt time.Time) bson.M {
+	set := bson.M{
+		"updated_at": updatedAt,
+	}
+	unset := bson.M{}
+	if len(meta) != 0 {
+		set["meta"] = meta
+	} else {
+		unset["meta"] = ""
+	}
+	if len(metaTypes) != 0 {
+		set["meta_types"] = metaTypes
+	} else {
+		unset["meta_types"] = ""
+	}
+	update := bson.M{
+		"$set": set,
+	}
+	if len(unset) != 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
 func convertMeta(meta map[string]interface{}) map[string]interface{} {
 	for k, v := range meta {
 		meta[k] = spreadPrimitives(v)
@@ -206,19 +889,25 @@ func createUserFindFilter(filter model.UserFindFilter) (bson.M, *options.FindOpt
 	mongoFilter := bson.M{}
 
 	if len(filter.IDs) != 0 {
-		ids := make([]primitive.ObjectID, len(filter.IDs))
-		for i := range filter.IDs {
-			id, err := primitive.ObjectIDFromHex(filter.IDs[i])
-			if err != nil {
-				return nil, nil, commonErrors.NewStorageConvertError(err.Error())
-			}
-			ids[i] = id
+		ids, err := newObjectIDs(filter.IDs)
+		if err != nil {
+			return nil, nil, commonErrors.NewStorageConvertError(err.Error())
 		}
 		mongoFilter["_id"] = bson.M{
 			"$in": ids,
 		}
 	}
 
+	if len(filter.GroupIDs) != 0 {
+		groupIDs, err := newObjectIDs(filter.GroupIDs)
+		if err != nil {
+			return nil, nil, commonErrors.NewStorageConvertError(err.Error())
+		}
+		mongoFilter["group_ids"] = bson.M{
+			"$in": groupIDs,
+		}
+	}
+
 	if len(filter.Statuses) != 0 {
 		mongoFilter["status"] = bson.M{
 			"$in": filter.Statuses,
@@ -236,6 +925,33 @@ func createUserFindFilter(filter model.UserFindFilter) (bson.M, *options.FindOpt
 		}
 	}
 
+	if filter.UpdatedBefore != nil {
+		mongoFilter["updated_at"] = bson.M{
+			"$lt": *filter.UpdatedBefore,
+		}
+	}
+
+	if filter.DeletedBefore != nil {
+		mongoFilter["deleted_at"] = bson.M{
+			"$lt": *filter.DeletedBefore,
+		}
+	}
+
+	if filter.InactiveBefore != nil {
+		mongoFilter["last_active_at"] = bson.M{
+			"$lt": *filter.InactiveBefore,
+		}
+		mongoFilter["anonymised_at"] = bson.M{
+			"$exists": false,
+		}
+	}
+
+	if filter.ScheduledBefore != nil {
+		mongoFilter["status_schedules.at"] = bson.M{
+			"$lte": *filter.ScheduledBefore,
+		}
+	}
+
 	opts := options.Find()
 	if filter.Offset != nil || filter.Limit != nil {
 		opts.SetSort(bson.M{
@@ -252,6 +968,35 @@ func createUserFindFilter(filter model.UserFindFilter) (bson.M, *options.FindOpt
 	return mongoFilter, opts, nil
 }
 
+// newObjectIDs parses hex object IDs.
+func newObjectIDs(hexIDs []string) ([]primitive.ObjectID, error) {
+	ids := make([]primitive.ObjectID, len(hexIDs))
+	for i := range hexIDs {
+		id, err := primitive.ObjectIDFromHex(hexIDs[i])
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
+
+// matchTime returns filter which matches the time read before,
+// zero time matches documents without the time, e.g. legacy users without update time.
+func matchTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return bson.M{
+			"$exists": false,
+		}
+	}
+	return t
+}
+
+// now returns current time in the precision stored by mongo.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
 func closeCursor(ctx context.Context, cursor *mongo.Cursor) {
 	if err := cursor.Close(ctx); err != nil {
 		log.Printf("could not close cursor: %v", err)
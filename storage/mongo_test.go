@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"testing"
+	"time"
 
 	commonErrors "github.com/open-Q/common/golang/errors"
 	"github.com/open-Q/user/storage/model"
@@ -12,31 +13,46 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-const testConnection = "mongodb://127.0.0.1:27017"
+var testConfig = MongoConfig{
+	ConnString: "mongodb://127.0.0.1:27017",
+	DBName:     "test-db",
+}
 
 func Test_NewMongoStorage(t *testing.T) {
-	t.Run("create connection error", func(t *testing.T) {
-		_, err := NewMongoStorage(context.Background(), "invalid", "test-db")
+	t.Run("invalid configuration error", func(t *testing.T) {
+		_, err := NewMongoStorage(context.Background(), MongoConfig{
+			ConnString: "invalid",
+			DBName:     "test-db",
+		})
 		require.Error(t, err)
-		require.EqualError(t, err, "could not create mongo client: error parsing uri: scheme must be \"mongodb\" or \"mongodb+srv\"")
+		require.EqualError(t, err, "invalid mongo configuration: invalid connection string: error parsing uri: scheme must be \"mongodb\" or \"mongodb+srv\"")
 	})
 	t.Run("all ok", func(t *testing.T) {
-		st, err := NewMongoStorage(context.Background(), testConnection, "test-db")
+		st, err := NewMongoStorage(context.Background(), testConfig)
 		require.NoError(t, err)
 		require.NotNil(t, st)
 		defer clearMongoStorage(t, st)
 		require.NotNil(t, st.userCollection)
-		require.NotNil(t, st.db)
+		require.NotNil(t, st.client)
 	})
 }
 
 func TestMongoStorage_Disconnect(t *testing.T) {
-	st, err := NewMongoStorage(context.Background(), testConnection, "test-db")
+	st, err := NewMongoStorage(context.Background(), testConfig)
 	require.NoError(t, err)
 	err = st.Disconnect(context.Background())
 	require.NoError(t, err)
 }
 
+func TestMongoStorage_Ping(t *testing.T) {
+	st, err := NewMongoStorage(context.Background(), testConfig)
+	require.NoError(t, err)
+	require.NoError(t, st.Ping(context.Background()))
+	require.NoError(t, st.Disconnect(context.Background()))
+	err = st.Ping(context.Background())
+	require.Error(t, err)
+}
+
 func Test_NewMongoUser(t *testing.T) {
 	t.Run("parse ID error", func(t *testing.T) {
 		id := "invalid"
@@ -83,6 +99,9 @@ func TestMongoUser_ToUser(t *testing.T) {
 			"hello": "world",
 			"key":   []int{1, 2, 3},
 		},
+		MetaTypes: map[string]string{
+			"hello": "type.googleapis.com/google.protobuf.StringValue",
+		},
 	}
 	res := userMongo.ToUser()
 	require.NotNil(t, res)
@@ -92,7 +111,8 @@ func TestMongoUser_ToUser(t *testing.T) {
 			"hello": "world",
 			"key":   []int{1, 2, 3},
 		},
-		Status: userMongo.Status,
+		MetaTypes: userMongo.MetaTypes,
+		Status:    userMongo.Status,
 	}, *res)
 }
 
@@ -139,6 +159,8 @@ func TestMongoStorage_Add(t *testing.T) {
 		})
 		require.NoError(t, err)
 		require.NotNil(t, user)
+		require.False(t, user.CreatedAt.IsZero())
+		require.Equal(t, user.CreatedAt, user.UpdatedAt)
 		var fUser MongoUser
 		err = st.userCollection.FindOne(context.Background(), bson.M{}).Decode(&fUser)
 		require.NoError(t, err)
@@ -165,6 +187,21 @@ func TestMongoStorage_Delete(t *testing.T) {
 		require.True(t, errors.Is(err, commonErrors.ErrStorageDelete))
 		require.Contains(t, err.Error(), "user not found")
 	})
+	t.Run("user was changed error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user, err := st.Add(ctx, model.User{
+			Status: "some status",
+		})
+		require.NoError(t, err)
+		_, err = st.Update(ctx, model.User{
+			ID:        user.ID,
+			UpdatedAt: user.UpdatedAt.Add(-time.Second),
+		})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrUserChanged))
+	})
 	t.Run("all ok", func(t *testing.T) {
 		st := createTestMongoStorage(t)
 		defer clearMongoStorage(t, st)
@@ -183,6 +220,8 @@ func TestMongoStorage_Delete(t *testing.T) {
 		}
 		_, err := st.userCollection.InsertMany(ctx, docs)
 		require.NoError(t, err)
+		insertUserData(t, st, users[0].ID)
+		insertUserData(t, st, users[1].ID)
 		err = st.Delete(ctx, users[0].ID.Hex())
 		require.NoError(t, err)
 		cur, err := st.userCollection.Find(ctx, bson.M{})
@@ -193,9 +232,159 @@ func TestMongoStorage_Delete(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, len(users)-1, len(results))
 		require.Equal(t, users[1].ID, results[0].ID)
+		requireUserData(t, st, users[0].ID, 0)
+		requireUserData(t, st, users[1].ID, 1)
+	})
+}
+
+func TestMongoStorage_Anonymise(t *testing.T) {
+	t.Run("convertation error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		_, err := st.Anonymise(context.Background(), model.User{ID: "invalid"})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("changed user error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user := MongoUser{
+			ID:        primitive.NewObjectID(),
+			UpdatedAt: now(),
+		}
+		_, err := st.userCollection.InsertOne(ctx, user)
+		require.NoError(t, err)
+		_, err = st.Anonymise(ctx, model.User{ID: user.ID.Hex(), UpdatedAt: user.UpdatedAt.Add(-time.Second)})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "user not found or was changed meanwhile")
+	})
+	t.Run("active user error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user := MongoUser{
+			ID:           primitive.NewObjectID(),
+			LastActiveAt: now(),
+		}
+		_, err := st.userCollection.InsertOne(ctx, user)
+		require.NoError(t, err)
+		_, err = st.Anonymise(ctx, model.User{ID: user.ID.Hex(), LastActiveAt: user.LastActiveAt.Add(-time.Second)})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "user not found or was changed meanwhile")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user := MongoUser{
+			ID:     primitive.NewObjectID(),
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta: map[string]interface{}{
+				"email": "user@example.com",
+			},
+			MetaTypes: map[string]string{
+				"email": "string",
+			},
+			TwoFactorEnabled: true,
+			VerifiedContacts: map[string]MongoContactVerification{
+				"email": {ValueHash: "hash"},
+			},
+		}
+		_, err := st.userCollection.InsertOne(ctx, user)
+		require.NoError(t, err)
+		insertUserData(t, st, user.ID)
+		resp, err := st.Anonymise(ctx, model.User{ID: user.ID.Hex()})
+		require.NoError(t, err)
+		require.Equal(t, user.ID.Hex(), resp.ID)
+		require.Equal(t, user.Status, resp.Status)
+		require.Empty(t, resp.Meta)
+		require.Empty(t, resp.MetaTypes)
+		require.Empty(t, resp.VerifiedContacts)
+		require.False(t, resp.TwoFactorEnabled)
+		require.False(t, resp.UpdatedAt.IsZero())
+		require.Equal(t, resp.UpdatedAt, resp.AnonymisedAt)
+		requireUserData(t, st, user.ID, 0)
+	})
+}
+
+func TestMongoStorage_TouchActivity(t *testing.T) {
+	t.Run("convertation error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		err := st.TouchActivity(context.Background(), "invalid", time.Now())
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("not found error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		err := st.TouchActivity(context.Background(), primitive.NewObjectID().Hex(), time.Now())
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "user not found")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		addedUser, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		require.Equal(t, addedUser.CreatedAt, addedUser.LastActiveAt)
+		activeAt := addedUser.CreatedAt.Add(time.Hour)
+		require.NoError(t, st.TouchActivity(ctx, addedUser.ID, activeAt))
+		require.NoError(t, st.TouchActivity(ctx, addedUser.ID, addedUser.CreatedAt))
+		users, err := st.Find(ctx, model.UserFindFilter{IDs: []string{addedUser.ID}})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		require.Equal(t, activeAt, users[0].LastActiveAt)
+		require.Equal(t, addedUser.UpdatedAt, users[0].UpdatedAt)
 	})
 }
 
+func insertUserData(t *testing.T, st *MongoStorage, id primitive.ObjectID) {
+	ctx := context.Background()
+	lockoutKey := userLockoutKeyPrefix + id.Hex()
+	docs := map[string]bson.M{
+		identityCollection:         {"user_id": id, "provider": "google", "subject": id.Hex()},
+		tokenCollection:            {"user_id": id, "purpose": "password_reset"},
+		credentialCollection:       {"_id": id},
+		apiKeyCollection:           {"user_id": id},
+		twoFactorCollection:        {"_id": id},
+		verificationCollection:     {"user_id": id},
+		verificationRateCollection: {"user_id": id},
+		loginFailureCollection:     {"key": lockoutKey},
+		lockoutCollection:          {"_id": lockoutKey},
+	}
+	for collection, doc := range docs {
+		_, err := st.database.Collection(collection).InsertOne(ctx, doc)
+		require.NoError(t, err)
+	}
+}
+
+func requireUserData(t *testing.T, st *MongoStorage, id primitive.ObjectID, expected int64) {
+	ctx := context.Background()
+	lockoutKey := userLockoutKeyPrefix + id.Hex()
+	filters := map[string]bson.M{
+		identityCollection:         {"user_id": id},
+		tokenCollection:            {"user_id": id},
+		credentialCollection:       {"_id": id},
+		apiKeyCollection:           {"user_id": id},
+		twoFactorCollection:        {"_id": id},
+		verificationCollection:     {"user_id": id},
+		verificationRateCollection: {"user_id": id},
+		loginFailureCollection:     {"key": lockoutKey},
+		lockoutCollection:          {"_id": lockoutKey},
+	}
+	for collection, filter := range filters {
+		count, err := st.database.Collection(collection).CountDocuments(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, expected, count, collection)
+	}
+}
+
 func TestMongoStorage_Update(t *testing.T) {
 	t.Run("convertation error", func(t *testing.T) {
 		st := createTestMongoStorage(t)
@@ -257,15 +446,374 @@ func TestMongoStorage_Update(t *testing.T) {
 					},
 				},
 			},
+			MetaTypes: map[string]string{
+				"key1": "type.googleapis.com/google.protobuf.StringValue",
+				"key4": "type.googleapis.com/google.protobuf.Struct",
+			},
 		}
 		res, err := st.Update(ctx, userToUpdate)
 		require.NoError(t, err)
 		require.NotNil(t, res)
+		require.False(t, res.UpdatedAt.IsZero())
+		userToUpdate.UpdatedAt = res.UpdatedAt
+		// status is changed by ChangeStatus only.
+		userToUpdate.Status = users[0].Status
+		require.Equal(t, userToUpdate, *res)
 		var user MongoUser
-		err = st.userCollection.FindOne(ctx, bson.M{}).Decode(&user)
+		err = st.userCollection.FindOne(ctx, bson.M{"_id": users[0].ID}).Decode(&user)
 		require.NoError(t, err)
 		require.Equal(t, userToUpdate, *user.ToUser())
 	})
+	t.Run("all ok (creation time is kept)", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user, err := st.Add(ctx, model.User{
+			Status: "some status",
+			Meta: map[string]interface{}{
+				"key1": "world",
+			},
+			MetaTypes: map[string]string{
+				"key1": "type.googleapis.com/google.protobuf.StringValue",
+			},
+		})
+		require.NoError(t, err)
+		res, err := st.Update(ctx, model.User{
+			ID:        user.ID,
+			Status:    "new status",
+			UpdatedAt: user.UpdatedAt,
+		})
+		require.NoError(t, err)
+		require.Equal(t, user.CreatedAt, res.CreatedAt)
+		require.False(t, res.UpdatedAt.Before(user.UpdatedAt))
+		require.Nil(t, res.Meta)
+		require.Nil(t, res.MetaTypes)
+	})
+}
+
+func TestMongoStorage_ChangeStatus(t *testing.T) {
+	t.Run("convertation error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		_, err := st.ChangeStatus(context.Background(), "invalid", model.StatusChange{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("status was changed error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		user, err := st.Add(context.Background(), model.User{
+			Status: "ACCOUNT_STATUS_SUSPENDED",
+		})
+		require.NoError(t, err)
+		_, err = st.ChangeStatus(context.Background(), user.ID, model.StatusChange{
+			From: "ACCOUNT_STATUS_ACTIVE",
+			To:   "ACCOUNT_STATUS_BANNED",
+		})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "user not found or its status isn't ACCOUNT_STATUS_ACTIVE anymore")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user, err := st.Add(ctx, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+		})
+		require.NoError(t, err)
+		changes := []model.StatusChange{
+			{From: "ACCOUNT_STATUS_ACTIVE", To: "ACCOUNT_STATUS_SUSPENDED", Reason: "fraud_check", Actor: "admin"},
+			{From: "ACCOUNT_STATUS_SUSPENDED", To: "ACCOUNT_STATUS_ACTIVE", Reason: "cleared", Actor: "admin"},
+		}
+		var res *model.User
+		for i := range changes {
+			res, err = st.ChangeStatus(ctx, user.ID, changes[i])
+			require.NoError(t, err)
+			require.Equal(t, changes[i].To, res.Status)
+			require.Len(t, res.StatusHistory, i+1)
+			require.False(t, res.StatusHistory[i].At.IsZero())
+			changes[i].At = res.StatusHistory[i].At
+		}
+		require.Equal(t, changes, res.StatusHistory)
+		require.Equal(t, changes[1].At, res.UpdatedAt)
+
+		_, err = st.Update(ctx, *res)
+		require.NoError(t, err)
+		users, err := st.Find(ctx, model.UserFindFilter{IDs: []string{user.ID}})
+		require.NoError(t, err)
+		require.Equal(t, changes, users[0].StatusHistory)
+	})
+	t.Run("all ok (deletion time)", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user, err := st.Add(ctx, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+		})
+		require.NoError(t, err)
+		res, err := st.ChangeStatus(ctx, user.ID, model.StatusChange{From: "ACCOUNT_STATUS_ACTIVE", To: model.StatusDeleted})
+		require.NoError(t, err)
+		require.Equal(t, res.UpdatedAt, res.DeletedAt)
+		res, err = st.ChangeStatus(ctx, user.ID, model.StatusChange{From: model.StatusDeleted, To: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		require.True(t, res.DeletedAt.IsZero())
+	})
+	t.Run("all ok (stale schedules)", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user, err := st.Add(ctx, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+		})
+		require.NoError(t, err)
+		at := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+		_, err = st.AddStatusSchedule(ctx, user.ID, model.StatusSchedule{From: "ACCOUNT_STATUS_ACTIVE", To: "ACCOUNT_STATUS_SUSPENDED", At: at})
+		require.NoError(t, err)
+		_, err = st.AddStatusSchedule(ctx, user.ID, model.StatusSchedule{To: "ACCOUNT_STATUS_DELETED", At: at})
+		require.NoError(t, err)
+		res, err := st.ChangeStatus(ctx, user.ID, model.StatusChange{
+			From:   "ACCOUNT_STATUS_ACTIVE",
+			To:     "ACCOUNT_STATUS_BANNED",
+			Reason: "$fraud",
+			Actor:  "admin",
+			Schedule: &model.StatusSchedule{
+				From: "ACCOUNT_STATUS_BANNED",
+				To:   "ACCOUNT_STATUS_ACTIVE",
+				At:   at,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "ACCOUNT_STATUS_BANNED", res.Status)
+		require.Equal(t, "$fraud", res.StatusHistory[0].Reason)
+		require.Len(t, res.StatusSchedules, 2)
+		require.Equal(t, "ACCOUNT_STATUS_DELETED", res.StatusSchedules[0].To)
+		require.Equal(t, "ACCOUNT_STATUS_ACTIVE", res.StatusSchedules[1].To)
+
+		res, err = st.ChangeStatus(ctx, user.ID, model.StatusChange{
+			From:       "ACCOUNT_STATUS_BANNED",
+			To:         "ACCOUNT_STATUS_ACTIVE",
+			ScheduleID: res.StatusSchedules[1].ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, res.StatusSchedules, 1)
+		require.Equal(t, "ACCOUNT_STATUS_DELETED", res.StatusSchedules[0].To)
+	})
+}
+
+func TestMongoStorage_StatusSchedules(t *testing.T) {
+	t.Run("convertation error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		_, err := st.AddStatusSchedule(context.Background(), "invalid", model.StatusSchedule{})
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+		_, err = st.RemoveStatusSchedule(context.Background(), primitive.NewObjectID().Hex(), "invalid")
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+		_, err = st.ChangeStatus(context.Background(), primitive.NewObjectID().Hex(), model.StatusChange{
+			ScheduleID: primitive.NewObjectID().Hex(),
+			Schedule:   &model.StatusSchedule{},
+		})
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("not found error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		_, err := st.AddStatusSchedule(context.Background(), primitive.NewObjectID().Hex(), model.StatusSchedule{})
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "user not found")
+		_, err = st.RemoveStatusSchedule(context.Background(), primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "user or schedule not found")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		user, err := st.Add(ctx, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+		})
+		require.NoError(t, err)
+		at := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+
+		// suspend with the reversal schedule.
+		res, err := st.ChangeStatus(ctx, user.ID, model.StatusChange{
+			From:   "ACCOUNT_STATUS_ACTIVE",
+			To:     "ACCOUNT_STATUS_SUSPENDED",
+			Reason: "abuse",
+			Actor:  "admin",
+			Schedule: &model.StatusSchedule{
+				From:   "ACCOUNT_STATUS_SUSPENDED",
+				To:     "ACCOUNT_STATUS_ACTIVE",
+				Reason: "expired",
+				Actor:  "admin",
+				At:     at,
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, res.StatusSchedules, 1)
+		reversal := res.StatusSchedules[0]
+		require.NotEmpty(t, reversal.ID)
+		require.Equal(t, at, reversal.At)
+
+		// add and cancel another schedule.
+		res, err = st.AddStatusSchedule(ctx, user.ID, model.StatusSchedule{
+			To:     "ACCOUNT_STATUS_BANNED",
+			Reason: "abuse",
+			Actor:  "admin",
+			At:     at.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		require.Len(t, res.StatusSchedules, 2)
+		res, err = st.RemoveStatusSchedule(ctx, user.ID, res.StatusSchedules[1].ID)
+		require.NoError(t, err)
+		require.Equal(t, []model.StatusSchedule{reversal}, res.StatusSchedules)
+
+		// find due schedules.
+		users, err := st.Find(ctx, model.UserFindFilter{ScheduledBefore: &at})
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		before := at.Add(-time.Second)
+		users, err = st.Find(ctx, model.UserFindFilter{ScheduledBefore: &before})
+		require.NoError(t, err)
+		require.Empty(t, users)
+
+		// execute the reversal once.
+		change := model.StatusChange{
+			From:       reversal.From,
+			To:         reversal.To,
+			Reason:     reversal.Reason,
+			Actor:      reversal.Actor,
+			ScheduleID: reversal.ID,
+		}
+		res, err = st.ChangeStatus(ctx, user.ID, change)
+		require.NoError(t, err)
+		require.Equal(t, "ACCOUNT_STATUS_ACTIVE", res.Status)
+		require.Empty(t, res.StatusSchedules)
+		require.Len(t, res.StatusHistory, 2)
+		_, err = st.ChangeStatus(ctx, user.ID, change)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "already executed")
+	})
+}
+
+func TestMongoStorage_Merge(t *testing.T) {
+	t.Run("convertation error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		_, err := st.Merge(context.Background(), model.Merge{SourceID: "invalid"})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("source was changed error", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		source, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		target, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		_, err = st.Merge(ctx, model.Merge{
+			SourceID:        source.ID,
+			TargetID:        target.ID,
+			SourceUpdatedAt: source.UpdatedAt.Add(-time.Second),
+			TargetUpdatedAt: target.UpdatedAt,
+			SourceChange:    model.StatusChange{From: "ACCOUNT_STATUS_ACTIVE", To: "ACCOUNT_STATUS_MERGED"},
+		})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+		require.Contains(t, err.Error(), "source user not found or was changed since the merge was computed")
+	})
+	t.Run("all ok (legacy users)", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		users := []MongoUser{
+			{ID: primitive.NewObjectID(), Status: "ACCOUNT_STATUS_ACTIVE"},
+			{ID: primitive.NewObjectID(), Status: "ACCOUNT_STATUS_ACTIVE"},
+		}
+		_, err := st.userCollection.InsertMany(ctx, []interface{}{users[0], users[1]})
+		require.NoError(t, err)
+		res, err := st.Merge(ctx, model.Merge{
+			SourceID:     users[0].ID.Hex(),
+			TargetID:     users[1].ID.Hex(),
+			SourceChange: model.StatusChange{From: "ACCOUNT_STATUS_ACTIVE", To: "ACCOUNT_STATUS_MERGED"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, users[1].ID.Hex(), res.ID)
+		require.False(t, res.UpdatedAt.IsZero())
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		source, err := st.Add(ctx, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta:   map[string]interface{}{"phone": "+380000000000"},
+		})
+		require.NoError(t, err)
+		target, err := st.Add(ctx, model.User{
+			Status: "ACCOUNT_STATUS_ACTIVE",
+			Meta:   map[string]interface{}{"name": "John"},
+		})
+		require.NoError(t, err)
+		merge := model.Merge{
+			SourceID:        source.ID,
+			TargetID:        target.ID,
+			Meta:            map[string]interface{}{"name": "John", "phone": "+380000000000"},
+			SourceUpdatedAt: source.UpdatedAt,
+			TargetUpdatedAt: target.UpdatedAt,
+			SourceChange: model.StatusChange{
+				From:   "ACCOUNT_STATUS_ACTIVE",
+				To:     "ACCOUNT_STATUS_MERGED",
+				Reason: "merged",
+				Actor:  "support",
+			},
+		}
+		sourceID, err := primitive.ObjectIDFromHex(source.ID)
+		require.NoError(t, err)
+		targetID, err := primitive.ObjectIDFromHex(target.ID)
+		require.NoError(t, err)
+		_, err = st.database.Collection(identityCollection).InsertOne(ctx, bson.M{"user_id": sourceID, "provider": "google", "subject": source.ID})
+		require.NoError(t, err)
+		_, err = st.database.Collection(apiKeyCollection).InsertOne(ctx, bson.M{"user_id": sourceID})
+		require.NoError(t, err)
+		_, err = st.database.Collection(credentialCollection).InsertOne(ctx, MongoPasswordCredential{UserID: sourceID, Hash: "hash"})
+		require.NoError(t, err)
+		_, err = st.userCollection.UpdateOne(ctx, bson.M{"_id": targetID}, bson.M{
+			"$set": bson.M{"roles": []MongoRoleAssignment{{Role: "admin"}}},
+		})
+		require.NoError(t, err)
+		merge.Roles = []model.RoleAssignment{{Role: "admin"}, {Role: "editor", Resource: "blog"}}
+		res, err := st.Merge(ctx, merge)
+		require.NoError(t, err)
+		require.Equal(t, target.ID, res.ID)
+		require.Equal(t, merge.Meta, res.Meta)
+		require.Len(t, res.Roles, 2)
+		require.Equal(t, "editor", res.Roles[1].Role)
+		require.Equal(t, "blog", res.Roles[1].Resource)
+		for _, collection := range []string{identityCollection, apiKeyCollection} {
+			count, err := st.database.Collection(collection).CountDocuments(ctx, bson.M{"user_id": targetID})
+			require.NoError(t, err)
+			require.Equal(t, int64(1), count, collection)
+		}
+		var credential MongoPasswordCredential
+		require.NoError(t, st.database.Collection(credentialCollection).FindOne(ctx, bson.M{"_id": targetID}).Decode(&credential))
+		require.Equal(t, "hash", credential.Hash)
+		count, err := st.database.Collection(credentialCollection).CountDocuments(ctx, bson.M{"_id": sourceID})
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		users, err := st.Find(ctx, model.UserFindFilter{IDs: []string{source.ID}})
+		require.NoError(t, err)
+		require.Equal(t, "ACCOUNT_STATUS_MERGED", users[0].Status)
+		require.Equal(t, target.ID, users[0].MergedInto)
+		require.Equal(t, res.UpdatedAt, users[0].MergedAt)
+		require.Len(t, users[0].StatusHistory, 1)
+
+		// the source user can't be merged twice.
+		_, err = st.Merge(ctx, merge)
+		require.Error(t, err)
+	})
 }
 
 func TestMongoStorage_Find(t *testing.T) {
@@ -448,6 +996,84 @@ func TestMongoStorage_Find(t *testing.T) {
 			require.Contains(t, userIDs, resp[i].ID)
 		}
 	})
+	t.Run("all ok (only update time in the filter)", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		now := time.Now().UTC()
+		users := []MongoUser{
+			{
+				ID:        primitive.NewObjectID(),
+				UpdatedAt: now.Add(-time.Hour),
+			},
+			{
+				ID:        primitive.NewObjectID(),
+				UpdatedAt: now.Add(time.Hour),
+			},
+			{
+				ID: primitive.NewObjectID(),
+			},
+		}
+		docs := make([]interface{}, len(users))
+		for i := range users {
+			docs[i] = users[i]
+		}
+		_, err := st.userCollection.InsertMany(ctx, docs)
+		require.NoError(t, err)
+		limit := int64(10)
+		resp, err := st.Find(context.Background(), model.UserFindFilter{
+			UpdatedBefore: &now,
+			Limit:         &limit,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(resp))
+		require.Equal(t, users[0].ID.Hex(), resp[0].ID)
+	})
+	t.Run("all ok (only retention times in the filter)", func(t *testing.T) {
+		st := createTestMongoStorage(t)
+		defer clearMongoStorage(t, st)
+		ctx := context.Background()
+		now := time.Now().UTC()
+		users := []MongoUser{
+			{
+				ID:        primitive.NewObjectID(),
+				DeletedAt: now.Add(-time.Hour),
+				UpdatedAt: now.Add(time.Hour),
+			},
+			{
+				ID:           primitive.NewObjectID(),
+				LastActiveAt: now.Add(-time.Hour),
+				UpdatedAt:    now.Add(time.Hour),
+			},
+			{
+				ID:           primitive.NewObjectID(),
+				LastActiveAt: now.Add(-time.Hour),
+				AnonymisedAt: now.Add(-time.Minute),
+			},
+			{
+				ID:        primitive.NewObjectID(),
+				UpdatedAt: now.Add(-time.Hour),
+			},
+		}
+		docs := make([]interface{}, len(users))
+		for i := range users {
+			docs[i] = users[i]
+		}
+		_, err := st.userCollection.InsertMany(ctx, docs)
+		require.NoError(t, err)
+		resp, err := st.Find(ctx, model.UserFindFilter{
+			DeletedBefore: &now,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(resp))
+		require.Equal(t, users[0].ID.Hex(), resp[0].ID)
+		resp, err = st.Find(ctx, model.UserFindFilter{
+			InactiveBefore: &now,
+		})
+		require.NoError(t, err)
+		require.Equal(t, 1, len(resp))
+		require.Equal(t, users[1].ID.Hex(), resp[0].ID)
+	})
 	t.Run("all ok (with offset)", func(t *testing.T) {
 		st := createTestMongoStorage(t)
 		defer clearMongoStorage(t, st)
@@ -549,7 +1175,7 @@ func TestMongoStorage_Find(t *testing.T) {
 }
 
 func createTestMongoStorage(t *testing.T) *MongoStorage {
-	st, err := NewMongoStorage(context.Background(), testConnection, "test-db")
+	st, err := NewMongoStorage(context.Background(), testConfig)
 	require.NoError(t, err)
 	require.NotNil(t, st)
 	return st
@@ -0,0 +1,99 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State represents circuit breaker state.
+type State int
+
+// There are circuit breaker states.
+const (
+	// StateClosed lets all calls through.
+	StateClosed State = iota
+	// StateOpen rejects all calls until the cooldown is over.
+	StateOpen
+	// StateHalfOpen lets a single probe call through.
+	StateHalfOpen
+)
+
+// String returns state name.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker represents circuit breaker which opens after the number of
+// consecutive failures and lets a probe call through after the cooldown.
+type Breaker struct {
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+// NewBreaker creates new Breaker instance.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// State returns current breaker state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow checks whether the call can be made.
+// Every allowed call must be followed by Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case StateOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = StateHalfOpen
+		return true
+	case StateHalfOpen:
+		// the probe call is in progress.
+		return false
+	}
+	return true
+}
+
+// Success reports successful call.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = StateClosed
+	b.failures = 0
+}
+
+// Failure reports failed call.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+	}
+}
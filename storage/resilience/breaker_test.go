@@ -0,0 +1,44 @@
+package resilience
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	b := NewBreaker(2, time.Minute)
+	b.now = func() time.Time {
+		return now
+	}
+
+	require.True(t, b.Allow())
+	b.Failure()
+	require.Equal(t, StateClosed, b.State())
+	require.True(t, b.Allow())
+	b.Success()
+	require.True(t, b.Allow())
+	b.Failure()
+	require.True(t, b.Allow())
+	b.Failure()
+	require.Equal(t, StateOpen, b.State())
+	require.False(t, b.Allow())
+
+	// probe call fails.
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+	require.Equal(t, StateHalfOpen, b.State())
+	require.False(t, b.Allow())
+	b.Failure()
+	require.Equal(t, StateOpen, b.State())
+	require.False(t, b.Allow())
+
+	// probe call succeeds.
+	now = now.Add(time.Minute)
+	require.True(t, b.Allow())
+	b.Success()
+	require.Equal(t, StateClosed, b.State())
+	require.True(t, b.Allow())
+}
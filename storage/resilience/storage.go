@@ -0,0 +1,230 @@
+package resilience
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// ErrUnavailable is returned without calling the storage while the circuit breaker is open.
+var ErrUnavailable = errors.New("storage is unavailable")
+
+// There are default resilience settings.
+const (
+	DefaultMaxAttempts      = 3
+	DefaultBaseDelay        = 50 * time.Millisecond
+	DefaultMaxDelay         = time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 10 * time.Second
+)
+
+// Config represents resilience configuration.
+// Zero values are replaced with defaults.
+type Config struct {
+	// MaxAttempts is the maximal number of attempts of idempotent operations.
+	MaxAttempts int
+	// BaseDelay is the backoff delay before the first retry, it doubles with every attempt.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// BreakerThreshold is the number of consecutive failed calls which open the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is a period the breaker stays open before letting a probe call through.
+	BreakerCooldown time.Duration
+}
+
+// Storage represents user storage decorator which retries idempotent
+// operations failed with transient errors and stops calling the storage
+// on sustained failure.
+type Storage struct {
+	next        storage.User
+	breaker     *Breaker
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	sleep       func(ctx context.Context, d time.Duration) error
+}
+
+// NewStorage creates new Storage instance.
+func NewStorage(next storage.User, cfg Config) *Storage {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = DefaultBaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = DefaultMaxDelay
+	}
+	if cfg.BreakerThreshold <= 0 {
+		cfg.BreakerThreshold = DefaultBreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = DefaultBreakerCooldown
+	}
+	return &Storage{
+		next:        next,
+		breaker:     NewBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		maxAttempts: cfg.MaxAttempts,
+		baseDelay:   cfg.BaseDelay,
+		maxDelay:    cfg.MaxDelay,
+		sleep:       sleep,
+	}
+}
+
+// Breaker returns storage circuit breaker.
+func (s *Storage) Breaker() *Breaker {
+	return s.breaker
+}
+
+// Disconnect breaks storage connection.
+func (s *Storage) Disconnect(ctx context.Context) error {
+	return s.next.Disconnect(ctx)
+}
+
+// Add adds a new user. It's never retried since the insert may have been applied.
+func (s *Storage) Add(ctx context.Context, user model.User) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.Add(ctx, user)
+		return err
+	})
+	return
+}
+
+// Delete removes an existing user by ID. It's never retried since a retry
+// of the applied delete reports missing user.
+func (s *Storage) Delete(ctx context.Context, userID string) error {
+	return s.call(ctx, false, func() error {
+		return s.next.Delete(ctx, userID)
+	})
+}
+
+// Anonymise removes user's personal data.
+// It's not retried, the applied anonymisation fails on the update time.
+func (s *Storage) Anonymise(ctx context.Context, user model.User) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.Anonymise(ctx, user)
+		return err
+	})
+	return
+}
+
+// TouchActivity moves user's last activity time forward.
+// It's retried since moving the time forward again changes nothing.
+func (s *Storage) TouchActivity(ctx context.Context, userID string, at time.Time) error {
+	return s.call(ctx, true, func() error {
+		return s.next.TouchActivity(ctx, userID, at)
+	})
+}
+
+// Update updates an existing user.
+// It's not retried, a retry of the applied update fails on the update time.
+func (s *Storage) Update(ctx context.Context, user model.User) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.Update(ctx, user)
+		return err
+	})
+	return
+}
+
+// ChangeStatus changes user's status.
+func (s *Storage) ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.ChangeStatus(ctx, userID, change)
+		return err
+	})
+	return
+}
+
+// AddStatusSchedule adds status change scheduled for the future.
+func (s *Storage) AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.AddStatusSchedule(ctx, userID, schedule)
+		return err
+	})
+	return
+}
+
+// RemoveStatusSchedule removes pending status change.
+func (s *Storage) RemoveStatusSchedule(ctx context.Context, userID, scheduleID string) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.RemoveStatusSchedule(ctx, userID, scheduleID)
+		return err
+	})
+	return
+}
+
+// Merge merges the source user into the target one.
+// It's not retried, the merge fails once any of the users is changed.
+func (s *Storage) Merge(ctx context.Context, merge model.Merge) (res *model.User, err error) {
+	err = s.call(ctx, false, func() error {
+		res, err = s.next.Merge(ctx, merge)
+		return err
+	})
+	return
+}
+
+// Find finds users by filter.
+func (s *Storage) Find(ctx context.Context, filter model.UserFindFilter) (res []model.User, err error) {
+	err = s.call(ctx, true, func() error {
+		res, err = s.next.Find(ctx, filter)
+		return err
+	})
+	return
+}
+
+func (s *Storage) call(ctx context.Context, idempotent bool, operation func() error) error {
+	if !s.breaker.Allow() {
+		return ErrUnavailable
+	}
+
+	attempts := 1
+	if idempotent {
+		attempts = s.maxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if sleepErr := s.sleep(ctx, s.backoff(attempt)); sleepErr != nil {
+				break
+			}
+		}
+		err = operation()
+		if !storage.IsTransient(err) {
+			break
+		}
+	}
+
+	// only transient errors indicate storage failure.
+	if storage.IsTransient(err) {
+		s.breaker.Failure()
+	} else {
+		s.breaker.Success()
+	}
+	return err
+}
+
+// backoff returns exponential backoff delay with full jitter.
+func (s *Storage) backoff(attempt int) time.Duration {
+	delay := s.maxDelay
+	if shift := uint(attempt - 1); shift < 32 && s.baseDelay<<shift < s.maxDelay {
+		delay = s.baseDelay << shift
+	}
+	//nolint:gosec // jitter doesn't need secure random.
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errMock      = errors.New("error")
+	errTransient = storage.NewTransientError(commonErrors.NewStorageFindError("server selection error"))
+)
+
+func newTestStorage(st storage.User) (*Storage, *[]time.Duration) {
+	s := NewStorage(st, Config{
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+	var delays []time.Duration
+	s.sleep = func(_ context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return nil
+	}
+	return s, &delays
+}
+
+func TestStorage_Find(t *testing.T) {
+	t.Run("permanent error is not retried", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s, delays := newTestStorage(st)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errMock).Once()
+		_, err := s.Find(context.Background(), model.UserFindFilter{})
+		require.Error(t, err)
+		require.EqualError(t, err, errMock.Error())
+		require.Empty(t, *delays)
+		require.Equal(t, StateClosed, s.Breaker().State())
+	})
+	t.Run("transient error is retried", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s, delays := newTestStorage(st)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errTransient).Twice()
+		st.On("Find", mock.Anything, mock.Anything).Return([]model.User{{ID: "1"}}, nil).Once()
+		res, err := s.Find(context.Background(), model.UserFindFilter{})
+		require.NoError(t, err)
+		require.Equal(t, []model.User{{ID: "1"}}, res)
+		require.Len(t, *delays, 2)
+		require.True(t, (*delays)[0] <= DefaultBaseDelay)
+		require.True(t, (*delays)[1] <= 2*DefaultBaseDelay)
+	})
+	t.Run("canceled context stops retries", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s, _ := newTestStorage(st)
+		s.sleep = sleep
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errTransient).Once()
+		_, err := s.Find(ctx, model.UserFindFilter{})
+		require.Error(t, err)
+		require.True(t, storage.IsTransient(err))
+	})
+	t.Run("breaker opens on sustained failure", func(t *testing.T) {
+		st := new(storageMocks.User)
+		defer st.AssertExpectations(t)
+		s, _ := newTestStorage(st)
+		st.On("Find", mock.Anything, mock.Anything).Return(nil, errTransient).Times(2 * DefaultMaxAttempts)
+		for i := 0; i < 2; i++ {
+			_, err := s.Find(context.Background(), model.UserFindFilter{})
+			require.Error(t, err)
+			require.True(t, storage.IsTransient(err))
+		}
+		require.Equal(t, StateOpen, s.Breaker().State())
+		_, err := s.Find(context.Background(), model.UserFindFilter{})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, ErrUnavailable))
+	})
+}
+
+func TestStorage_Add(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	s, delays := newTestStorage(st)
+	st.On("Add", mock.Anything, mock.Anything).Return(nil, errTransient).Once()
+	_, err := s.Add(context.Background(), model.User{})
+	require.Error(t, err)
+	require.Empty(t, *delays)
+}
+
+func TestStorage_Delete(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	s, delays := newTestStorage(st)
+	st.On("Delete", mock.Anything, "1").Return(errTransient).Once()
+	err := s.Delete(context.Background(), "1")
+	require.Error(t, err)
+	require.Empty(t, *delays)
+}
+
+func TestStorage_Update(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	s, delays := newTestStorage(st)
+	st.On("Update", mock.Anything, model.User{ID: "1"}).Return(nil, errTransient).Once()
+	_, err := s.Update(context.Background(), model.User{ID: "1"})
+	require.Error(t, err)
+	require.Empty(t, *delays)
+}
+
+func TestStorage_TouchActivity(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	s, delays := newTestStorage(st)
+	at := time.Now()
+	st.On("TouchActivity", mock.Anything, "1", at).Return(errTransient).Once()
+	st.On("TouchActivity", mock.Anything, "1", at).Return(nil).Once()
+	require.NoError(t, s.TouchActivity(context.Background(), "1", at))
+	require.Len(t, *delays, 1)
+}
+
+func TestStorage_backoff(t *testing.T) {
+	s := NewStorage(nil, Config{
+		BaseDelay: 100 * time.Millisecond,
+		MaxDelay:  300 * time.Millisecond,
+	})
+	for i := 0; i < 100; i++ {
+		require.True(t, s.backoff(1) <= 100*time.Millisecond)
+		require.True(t, s.backoff(2) <= 200*time.Millisecond)
+		require.True(t, s.backoff(10) <= 300*time.Millisecond)
+		require.True(t, s.backoff(100) <= 300*time.Millisecond)
+	}
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	roleCollection = "role"
+)
+
+// MongoRoleStorage represents mongo role storage model.
+type MongoRoleStorage struct {
+	roleCollection *commonStorage.MongoCollection
+	userCollection *commonStorage.MongoCollection
+}
+
+// MongoRole represents role mongo storage model.
+type MongoRole struct {
+	Name        string   `bson:"_id"`
+	Description string   `bson:"description,omitempty"`
+	Permissions []string `bson:"permissions"`
+}
+
+// MongoRoleAssignment represents user's role assignment mongo storage model.
+type MongoRoleAssignment struct {
+	Role       string    `bson:"role"`
+	Resource   string    `bson:"resource,omitempty"`
+	AssignedAt time.Time `bson:"assigned_at"`
+}
+
+// NewMongoRoleStorage returns new MongoRoleStorage instance
+// which shares the connection with the user storage.
+func NewMongoRoleStorage(s *MongoStorage) *MongoRoleStorage {
+	return &MongoRoleStorage{
+		roleCollection: &commonStorage.MongoCollection{
+			Collection: s.database.Collection(roleCollection),
+		},
+		userCollection: s.userCollection,
+	}
+}
+
+// ListRoles returns all roles.
+func (s *MongoRoleStorage) ListRoles(ctx context.Context) ([]model.Role, error) {
+	opts := options.Find().SetSort(bson.M{"_id": 1})
+	cursor, err := s.roleCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mRoles []MongoRole
+	if err := cursor.All(ctx, &mRoles); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	roles := make([]model.Role, len(mRoles))
+	for i := range mRoles {
+		roles[i] = mRoles[i].ToRole()
+	}
+
+	return roles, nil
+}
+
+// PutRole creates or replaces role.
+func (s *MongoRoleStorage) PutRole(ctx context.Context, role model.Role) error {
+	mRole := NewMongoRole(role)
+	filter := bson.M{
+		"_id": mRole.Name,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := s.roleCollection.ReplaceOne(ctx, filter, mRole, opts); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// DeleteRole removes role by name, its assignments grant nothing afterwards.
+func (s *MongoRoleStorage) DeleteRole(ctx context.Context, name string) error {
+	filter := bson.M{
+		"_id": name,
+	}
+
+	res, err := s.roleCollection.DeleteOne(ctx, filter)
+	if err == nil && res.DeletedCount == 0 {
+		err = errors.New("role not found")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// AssignRole assigns the role to the user unless it's already assigned for the resource.
+// Assignment time is set by the storage.
+func (s *MongoRoleStorage) AssignRole(ctx context.Context, userID string, assignment model.RoleAssignment) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	assignment.AssignedAt = now()
+	filter := bson.M{
+		"_id": id,
+		"roles": bson.M{
+			"$not": bson.M{
+				"$elemMatch": assignmentFilter(assignment),
+			},
+		},
+	}
+	update := bson.M{
+		"$push": bson.M{
+			"roles": NewMongoRoleAssignment(assignment),
+		},
+	}
+	res, err := s.userCollection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("user not found or the role is already assigned")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// UnassignRole removes user's role assignment for the resource.
+func (s *MongoRoleStorage) UnassignRole(ctx context.Context, userID string, assignment model.RoleAssignment) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+		"roles": bson.M{
+			"$elemMatch": assignmentFilter(assignment),
+		},
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"roles": assignmentFilter(assignment),
+		},
+	}
+	res, err := s.userCollection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("user not found or the role isn't assigned")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return nil
+}
+
+// ToRole converts MongoRole model to Role model.
+func (m MongoRole) ToRole() model.Role {
+	return model.Role{
+		Name:        m.Name,
+		Description: m.Description,
+		Permissions: m.Permissions,
+	}
+}
+
+// NewMongoRole converts Role model to MongoRole model.
+func NewMongoRole(r model.Role) MongoRole {
+	return MongoRole{
+		Name:        r.Name,
+		Description: r.Description,
+		Permissions: r.Permissions,
+	}
+}
+
+// ToRoleAssignment converts MongoRoleAssignment model to RoleAssignment model.
+func (m MongoRoleAssignment) ToRoleAssignment() model.RoleAssignment {
+	return model.RoleAssignment{
+		Role:       m.Role,
+		Resource:   m.Resource,
+		AssignedAt: m.AssignedAt,
+	}
+}
+
+// NewMongoRoleAssignment converts RoleAssignment model to MongoRoleAssignment model.
+func NewMongoRoleAssignment(a model.RoleAssignment) MongoRoleAssignment {
+	return MongoRoleAssignment{
+		Role:       a.Role,
+		Resource:   a.Resource,
+		AssignedAt: a.AssignedAt,
+	}
+}
+
+// assignmentFilter matches role assignments of the same role and resource.
+func assignmentFilter(a model.RoleAssignment) bson.M {
+	filter := bson.M{
+		"role": a.Role,
+	}
+	if a.Resource != "" {
+		filter["resource"] = a.Resource
+	} else {
+		filter["resource"] = bson.M{"$exists": false}
+	}
+	return filter
+}
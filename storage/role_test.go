@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoRoleStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	roleStorage := NewMongoRoleStorage(st)
+	defer func() {
+		require.NoError(t, roleStorage.roleCollection.Drop(ctx))
+	}()
+
+	t.Run("delete not found error", func(t *testing.T) {
+		err := roleStorage.DeleteRole(ctx, "unknown")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageDelete))
+		require.Contains(t, err.Error(), "role not found")
+	})
+	t.Run("convertation error", func(t *testing.T) {
+		err := roleStorage.AssignRole(ctx, "invalid", model.RoleAssignment{Role: "admin"})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		admin := model.Role{Name: "admin", Permissions: []string{"*"}}
+		editor := model.Role{Name: "editor", Description: "Edits orders", Permissions: []string{"orders:read", "orders:write"}}
+		require.NoError(t, roleStorage.PutRole(ctx, admin))
+		require.NoError(t, roleStorage.PutRole(ctx, editor))
+		roles, err := roleStorage.ListRoles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []model.Role{admin, editor}, roles)
+
+		user, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		global := model.RoleAssignment{Role: "editor"}
+		scoped := model.RoleAssignment{Role: "editor", Resource: "shop/1"}
+		require.NoError(t, roleStorage.AssignRole(ctx, user.ID, global))
+		require.NoError(t, roleStorage.AssignRole(ctx, user.ID, scoped))
+		err = roleStorage.AssignRole(ctx, user.ID, scoped)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the role is already assigned")
+
+		users, err := st.Find(ctx, model.UserFindFilter{IDs: []string{user.ID}})
+		require.NoError(t, err)
+		require.Len(t, users[0].Roles, 2)
+		require.Equal(t, "shop/1", users[0].Roles[1].Resource)
+
+		require.NoError(t, roleStorage.UnassignRole(ctx, user.ID, global))
+		err = roleStorage.UnassignRole(ctx, user.ID, global)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "the role isn't assigned")
+		users, err = st.Find(ctx, model.UserFindFilter{IDs: []string{user.ID}})
+		require.NoError(t, err)
+		require.Len(t, users[0].Roles, 1)
+		require.Equal(t, scoped.Resource, users[0].Roles[0].Resource)
+
+		require.NoError(t, roleStorage.DeleteRole(ctx, admin.Name))
+		roles, err = roleStorage.ListRoles(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []model.Role{editor}, roles)
+	})
+}
+
+func TestMongoRole_ToRole(t *testing.T) {
+	role := model.Role{
+		Name:        "editor",
+		Description: "Edits orders",
+		Permissions: []string{"orders:read"},
+	}
+	require.Equal(t, role, NewMongoRole(role).ToRole())
+}
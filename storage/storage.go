@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/open-Q/user/storage/model"
 )
@@ -9,8 +10,140 @@ import (
 // User represents user's storage layer interface.
 type User interface {
 	Disconnect(ctx context.Context) error
-	Add(ctx context.Context, user model.User) (*User, error)
-	Delete(ctx context.Context, userID string) (*User, error)
-	Update(ctx context.Context, user model.User) (*User, error)
-	Find(ctx context.Context, filter model.UserFindFilter) ([]User, error)
+	Add(ctx context.Context, user model.User) (*model.User, error)
+	// Delete removes the user along with the data kept apart from it.
+	Delete(ctx context.Context, userID string) error
+	// Anonymise removes user's personal data, including the data kept apart from it,
+	// keeping the user itself. It fails if the user was updated or active since it was read.
+	Anonymise(ctx context.Context, user model.User) (*model.User, error)
+	// TouchActivity moves user's last activity time forward to at, the update time is kept.
+	TouchActivity(ctx context.Context, userID string, at time.Time) error
+	// Update replaces user's meta, ErrUserChanged is returned if the user was updated
+	// since user.UpdatedAt.
+	Update(ctx context.Context, user model.User) (*model.User, error)
+	// ChangeStatus applies status change if the user still has the change's From status.
+	ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (*model.User, error)
+	AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (*model.User, error)
+	RemoveStatusSchedule(ctx context.Context, userID, scheduleID string) (*model.User, error)
+	// Merge atomically merges the source user into the target one and returns the target user.
+	Merge(ctx context.Context, merge model.Merge) (*model.User, error)
+	Find(ctx context.Context, filter model.UserFindFilter) ([]model.User, error)
+}
+
+// Group represents group's storage layer interface.
+// Memberships are kept with the users, so members are found by the User's Find.
+type Group interface {
+	// AddGroup returns ErrGroupExists if a group with the same name exists.
+	AddGroup(ctx context.Context, group model.Group) (*model.Group, error)
+	FindGroups(ctx context.Context, filter model.GroupFindFilter) ([]model.Group, error)
+	// AddMember adds the user to the group, adding a member again does nothing.
+	AddMember(ctx context.Context, groupID, userID string) error
+	RemoveMember(ctx context.Context, groupID, userID string) error
+}
+
+// Role represents role's storage layer interface.
+// Assignments are kept with the users.
+type Role interface {
+	ListRoles(ctx context.Context) ([]model.Role, error)
+	PutRole(ctx context.Context, role model.Role) error
+	DeleteRole(ctx context.Context, name string) error
+	AssignRole(ctx context.Context, userID string, assignment model.RoleAssignment) error
+	UnassignRole(ctx context.Context, userID string, assignment model.RoleAssignment) error
+}
+
+// Credential represents credential's storage layer interface.
+// Credentials are kept apart from the users, so they never leak with user's data.
+type Credential interface {
+	// FindPassword returns nil if the user has no password.
+	FindPassword(ctx context.Context, userID string) (*model.PasswordCredential, error)
+	PutPassword(ctx context.Context, credential model.PasswordCredential) error
+	// ReplacePassword replaces the password hash only if it's still the previous one.
+	ReplacePassword(ctx context.Context, credential model.PasswordCredential, previousHash string) error
+	DeletePassword(ctx context.Context, userID string) error
+}
+
+// APIKey represents API key's storage layer interface.
+type APIKey interface {
+	AddAPIKey(ctx context.Context, key model.APIKey) (*model.APIKey, error)
+	FindAPIKeys(ctx context.Context, filter model.APIKeyFindFilter) ([]model.APIKey, error)
+	// RevokeAPIKey revokes user's key and returns it, revoked keys are kept.
+	RevokeAPIKey(ctx context.Context, userID, keyID string) (*model.APIKey, error)
+	// TouchAPIKeys records last usage times by key ID, earlier times don't overwrite later ones.
+	TouchAPIKeys(ctx context.Context, lastUsed map[string]time.Time) error
+}
+
+// TwoFactor represents two-factor's storage layer interface.
+type TwoFactor interface {
+	// FindTwoFactor returns nil if the user hasn't enrolled.
+	FindTwoFactor(ctx context.Context, userID string) (*model.TwoFactor, error)
+	// PutTwoFactor starts enrollment, it replaces unconfirmed one only.
+	PutTwoFactor(ctx context.Context, twoFactor model.TwoFactor) error
+	// ConfirmTwoFactor confirms enrollment and enables two-factor on the user.
+	ConfirmTwoFactor(ctx context.Context, userID string, counter int64, recoveryCodes []string) error
+	// UseTOTPCounter accepts the time step if it's later than the last accepted one.
+	UseTOTPCounter(ctx context.Context, userID string, counter int64) (bool, error)
+	// UseRecoveryCode removes the recovery code hash if it's unused.
+	UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID string, recoveryCodes []string) error
+	// DeleteTwoFactor removes enrollment and disables two-factor on the user.
+	DeleteTwoFactor(ctx context.Context, userID string) error
+}
+
+// Verification represents verification challenge's storage layer interface.
+type Verification interface {
+	// AddChallenge atomically adds a new challenge unless limit challenges of the user's contact
+	// were issued since the time, ErrChallengeLimit is returned then.
+	AddChallenge(ctx context.Context, challenge model.VerificationChallenge, limit int, since time.Time) (*model.VerificationChallenge, error)
+	// FindChallenges returns unused challenges of the user's contact not expired at the time, newest first.
+	FindChallenges(ctx context.Context, userID, contactKey string, at time.Time) ([]model.VerificationChallenge, error)
+	// AddChallengeAttempts counts failed confirmation of every unused challenge of the user's contact not expired at the time.
+	AddChallengeAttempts(ctx context.Context, userID, contactKey string, at time.Time) error
+	// UseChallenge marks the challenge used and records the user's contact verification.
+	UseChallenge(ctx context.Context, challenge model.VerificationChallenge, verification model.ContactVerification) error
+}
+
+// Token represents single-use token's storage layer interface.
+type Token interface {
+	// PutToken replaces the user's token of the same purpose.
+	PutToken(ctx context.Context, token model.Token) (*model.Token, error)
+	// UseToken removes the token of the purpose and hash not expired at the time and returns it,
+	// nil is returned if there is no such token.
+	UseToken(ctx context.Context, purpose, hash string, at time.Time) (*model.Token, error)
+	// DeleteTokens removes all tokens of the user.
+	DeleteTokens(ctx context.Context, userID string) error
+}
+
+// Identity represents linked external identity's storage layer interface.
+type Identity interface {
+	// LinkIdentity links the identity to the user unless it's already linked and returns the linked identity,
+	// it belongs to another user if the identity was linked to it.
+	LinkIdentity(ctx context.Context, identity model.Identity) (*model.Identity, error)
+	// FindIdentity returns nil if the identity isn't linked.
+	FindIdentity(ctx context.Context, provider, subject string) (*model.Identity, error)
+	ListIdentities(ctx context.Context, userID string) ([]model.Identity, error)
+	// UnlinkIdentity unlinks the user's identity unless it's the user's last login method,
+	// the user's password is a login method too. ErrLastLoginMethod is returned then.
+	UnlinkIdentity(ctx context.Context, identity model.Identity) error
+}
+
+// Lockout represents login failure and lockout state's storage layer interface.
+type Lockout interface {
+	// AddLoginFailure records failed login of the key, it's removed once it expires.
+	AddLoginFailure(ctx context.Context, key string, at, expiresAt time.Time) error
+	// CountLoginFailures counts failed logins of the key since the time.
+	CountLoginFailures(ctx context.Context, key string, since time.Time) (int64, error)
+	// FindLockouts returns lockout states of the keys, keys without state are skipped.
+	FindLockouts(ctx context.Context, keys []string) ([]model.Lockout, error)
+	// Lock replaces the lockout state if its lockout count is still the previous one,
+	// false is returned if it was changed meanwhile.
+	Lock(ctx context.Context, lockout model.Lockout, previousCount int) (bool, error)
+	// ResetLockout replaces the lockout state with unlocked one.
+	ResetLockout(ctx context.Context, key string, at, expiresAt time.Time) error
+}
+
+// MetaSchema represents meta schema storage layer interface.
+type MetaSchema interface {
+	ListMetaFields(ctx context.Context) ([]model.MetaField, error)
+	PutMetaField(ctx context.Context, field model.MetaField) error
+	DeleteMetaField(ctx context.Context, key string) error
 }
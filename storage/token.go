@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	tokenCollection = "token"
+)
+
+// MongoTokenStorage represents mongo token storage model.
+type MongoTokenStorage struct {
+	tokenCollection *commonStorage.MongoCollection
+}
+
+// MongoToken represents token mongo storage model.
+type MongoToken struct {
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Purpose   string             `bson:"purpose"`
+	Hash      string             `bson:"hash"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// NewMongoTokenStorage returns new MongoTokenStorage instance
+// which shares the connection with the user storage.
+// Expired tokens are removed by the TTL index created here.
+func NewMongoTokenStorage(ctx context.Context, s *MongoStorage) (*MongoTokenStorage, error) {
+	collection := s.database.Collection(tokenCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.M{"hash": 1},
+		},
+		{
+			Keys:    bson.M{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create token indexes")
+	}
+	return &MongoTokenStorage{
+		tokenCollection: &commonStorage.MongoCollection{
+			Collection: collection,
+		},
+	}, nil
+}
+
+// PutToken replaces the user's token of the same purpose, so older tokens can't be used.
+// Creation time is set by the storage.
+func (s *MongoTokenStorage) PutToken(ctx context.Context, token model.Token) (*model.Token, error) {
+	mToken, err := NewMongoToken(token)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	mToken.CreatedAt = now()
+
+	filter := bson.M{
+		"user_id": mToken.UserID,
+		"purpose": mToken.Purpose,
+	}
+	opts := options.Replace().SetUpsert(true)
+	if _, err := s.tokenCollection.ReplaceOne(ctx, filter, mToken, opts); err != nil {
+		return nil, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+
+	return mToken.ToToken(), nil
+}
+
+// UseToken removes the token of the purpose and hash not expired at the time and returns it,
+// nil is returned if there is no such token. Removal is atomic, so every token is used once.
+func (s *MongoTokenStorage) UseToken(ctx context.Context, purpose, hash string, at time.Time) (*model.Token, error) {
+	filter := bson.M{
+		"hash":    hash,
+		"purpose": purpose,
+		"expires_at": bson.M{
+			"$gt": at,
+		},
+	}
+	var mToken MongoToken
+	err := s.tokenCollection.FindOneAndDelete(ctx, filter).Decode(&mToken)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	return mToken.ToToken(), nil
+}
+
+// DeleteTokens removes all tokens of the user.
+func (s *MongoTokenStorage) DeleteTokens(ctx context.Context, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	if _, err := s.tokenCollection.DeleteMany(ctx, bson.M{"user_id": id}); err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	return nil
+}
+
+// ToToken converts MongoToken model to Token model.
+func (m MongoToken) ToToken() *model.Token {
+	return &model.Token{
+		UserID:    m.UserID.Hex(),
+		Purpose:   m.Purpose,
+		Hash:      m.Hash,
+		ExpiresAt: m.ExpiresAt,
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// NewMongoToken converts Token model to MongoToken model.
+func NewMongoToken(t model.Token) (*MongoToken, error) {
+	userID, err := primitive.ObjectIDFromHex(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoToken{
+		UserID:    userID,
+		Purpose:   t.Purpose,
+		Hash:      t.Hash,
+		ExpiresAt: t.ExpiresAt,
+		CreatedAt: t.CreatedAt,
+	}, nil
+}
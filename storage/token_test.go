@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoTokenStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	tokenStorage, err := NewMongoTokenStorage(ctx, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, tokenStorage.tokenCollection.Drop(ctx))
+	}()
+
+	t.Run("convertation error", func(t *testing.T) {
+		err := tokenStorage.DeleteTokens(ctx, "invalid")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		userID := "5f8d0d55b54764421b7156c3"
+		expiresAt := time.Now().Add(time.Hour)
+		_, err := tokenStorage.PutToken(ctx, model.Token{UserID: userID, Purpose: model.TokenPasswordReset, Hash: "hash1", ExpiresAt: expiresAt})
+		require.NoError(t, err)
+		token, err := tokenStorage.PutToken(ctx, model.Token{UserID: userID, Purpose: model.TokenPasswordReset, Hash: "hash2", ExpiresAt: expiresAt})
+		require.NoError(t, err)
+		require.False(t, token.CreatedAt.IsZero())
+
+		// the newer token replaces the older one.
+		used, err := tokenStorage.UseToken(ctx, model.TokenPasswordReset, "hash1", time.Now())
+		require.NoError(t, err)
+		require.Nil(t, used)
+		used, err = tokenStorage.UseToken(ctx, model.TokenMagicLink, "hash2", time.Now())
+		require.NoError(t, err)
+		require.Nil(t, used)
+		used, err = tokenStorage.UseToken(ctx, model.TokenPasswordReset, "hash2", expiresAt)
+		require.NoError(t, err)
+		require.Nil(t, used)
+
+		used, err = tokenStorage.UseToken(ctx, model.TokenPasswordReset, "hash2", time.Now())
+		require.NoError(t, err)
+		require.Equal(t, userID, used.UserID)
+		used, err = tokenStorage.UseToken(ctx, model.TokenPasswordReset, "hash2", time.Now())
+		require.NoError(t, err)
+		require.Nil(t, used)
+
+		_, err = tokenStorage.PutToken(ctx, model.Token{UserID: userID, Purpose: model.TokenMagicLink, Hash: "hash3", ExpiresAt: expiresAt})
+		require.NoError(t, err)
+		require.NoError(t, tokenStorage.DeleteTokens(ctx, userID))
+		used, err = tokenStorage.UseToken(ctx, model.TokenMagicLink, "hash3", time.Now())
+		require.NoError(t, err)
+		require.Nil(t, used)
+	})
+}
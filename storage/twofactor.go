@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	twoFactorCollection = "two_factor"
+)
+
+// MongoTwoFactorStorage represents mongo two-factor storage model.
+type MongoTwoFactorStorage struct {
+	client              *mongo.Client
+	twoFactorCollection *commonStorage.MongoCollection
+	userCollection      *commonStorage.MongoCollection
+}
+
+// MongoTwoFactor represents two-factor mongo storage model.
+type MongoTwoFactor struct {
+	UserID        primitive.ObjectID `bson:"_id"`
+	Secret        []byte             `bson:"secret"`
+	SecretKeyID   string             `bson:"secret_key_id"`
+	LastCounter   int64              `bson:"last_counter"`
+	RecoveryCodes []string           `bson:"recovery_codes,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at,omitempty"`
+	ConfirmedAt   time.Time          `bson:"confirmed_at,omitempty"`
+}
+
+// NewMongoTwoFactorStorage returns new MongoTwoFactorStorage instance
+// which shares the connection with the user storage.
+func NewMongoTwoFactorStorage(s *MongoStorage) *MongoTwoFactorStorage {
+	return &MongoTwoFactorStorage{
+		client: s.client,
+		twoFactorCollection: &commonStorage.MongoCollection{
+			Collection: s.database.Collection(twoFactorCollection),
+		},
+		userCollection: s.userCollection,
+	}
+}
+
+// FindTwoFactor returns user's two-factor state, nil if the user hasn't enrolled.
+func (s *MongoTwoFactorStorage) FindTwoFactor(ctx context.Context, userID string) (*model.TwoFactor, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	var twoFactor MongoTwoFactor
+	err = s.twoFactorCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&twoFactor)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+
+	return twoFactor.ToTwoFactor(), nil
+}
+
+// PutTwoFactor starts enrollment replacing unconfirmed one, confirmed enrollment is kept.
+// Creation time is set by the storage.
+func (s *MongoTwoFactorStorage) PutTwoFactor(ctx context.Context, twoFactor model.TwoFactor) error {
+	mTwoFactor, err := NewMongoTwoFactor(twoFactor)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+	mTwoFactor.CreatedAt = now()
+	mTwoFactor.ConfirmedAt = time.Time{}
+
+	filter := bson.M{
+		"_id": mTwoFactor.UserID,
+		"confirmed_at": bson.M{
+			"$exists": false,
+		},
+	}
+	opts := options.Replace().SetUpsert(true)
+	// confirmed enrollment isn't matched, so the upsert fails with a duplicate key error.
+	if _, err := s.twoFactorCollection.ReplaceOne(ctx, filter, mTwoFactor, opts); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// ConfirmTwoFactor confirms enrollment, accepts the first code's time step
+// and enables two-factor on the user.
+func (s *MongoTwoFactorStorage) ConfirmTwoFactor(ctx context.Context, userID string, counter int64, recoveryCodes []string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+		"confirmed_at": bson.M{
+			"$exists": false,
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"confirmed_at":   now(),
+			"last_counter":   counter,
+			"recovery_codes": recoveryCodes,
+		},
+	}
+	err = s.withUser(ctx, id, true, func(sc mongo.SessionContext) error {
+		res, err := s.twoFactorCollection.UpdateOne(sc, filter, update)
+		if err == nil && res.MatchedCount == 0 {
+			err = errors.New("two-factor enrollment not found or is already confirmed")
+		}
+		return err
+	})
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// UseTOTPCounter accepts the time step of a code if it's later than the last accepted one,
+// so every code is accepted once.
+func (s *MongoTwoFactorStorage) UseTOTPCounter(ctx context.Context, userID string, counter int64) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+		"confirmed_at": bson.M{
+			"$exists": true,
+		},
+		"last_counter": bson.M{
+			"$lt": counter,
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"last_counter": counter,
+		},
+	}
+	res, err := s.twoFactorCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return res.ModifiedCount != 0, nil
+}
+
+// UseRecoveryCode removes the recovery code hash, so every recovery code is accepted once.
+func (s *MongoTwoFactorStorage) UseRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+		"confirmed_at": bson.M{
+			"$exists": true,
+		},
+		"recovery_codes": codeHash,
+	}
+	update := bson.M{
+		"$pull": bson.M{
+			"recovery_codes": codeHash,
+		},
+	}
+	res, err := s.twoFactorCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return res.ModifiedCount != 0, nil
+}
+
+// ReplaceRecoveryCodes replaces recovery code hashes of the confirmed enrollment.
+func (s *MongoTwoFactorStorage) ReplaceRecoveryCodes(ctx context.Context, userID string, recoveryCodes []string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	filter := bson.M{
+		"_id": id,
+		"confirmed_at": bson.M{
+			"$exists": true,
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"recovery_codes": recoveryCodes,
+		},
+	}
+	res, err := s.twoFactorCollection.UpdateOne(ctx, filter, update)
+	if err == nil && res.MatchedCount == 0 {
+		err = errors.New("two-factor isn't enabled")
+	}
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// DeleteTwoFactor removes enrollment and disables two-factor on the user,
+// removing missing one does nothing.
+func (s *MongoTwoFactorStorage) DeleteTwoFactor(ctx context.Context, userID string) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	err = s.withUser(ctx, id, false, func(sc mongo.SessionContext) error {
+		_, err := s.twoFactorCollection.DeleteOne(sc, bson.M{"_id": id})
+		return err
+	})
+	if err != nil {
+		return classify(commonErrors.NewStorageDeleteError(err.Error()), err)
+	}
+	return nil
+}
+
+// withUser runs fn and sets user's two-factor flag in a single transaction.
+func (s *MongoTwoFactorStorage) withUser(ctx context.Context, userID primitive.ObjectID, enabled bool, fn func(sc mongo.SessionContext) error) error {
+	var update bson.M
+	if enabled {
+		update = bson.M{
+			"$set": bson.M{"two_factor_enabled": true},
+		}
+	} else {
+		update = bson.M{
+			"$unset": bson.M{"two_factor_enabled": ""},
+		}
+	}
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if err := fn(sc); err != nil {
+			return nil, err
+		}
+		_, err := s.userCollection.UpdateOne(sc, bson.M{"_id": userID}, update)
+		return nil, err
+	})
+	return err
+}
+
+// ToTwoFactor converts MongoTwoFactor model to TwoFactor model.
+func (m MongoTwoFactor) ToTwoFactor() *model.TwoFactor {
+	return &model.TwoFactor{
+		UserID:        m.UserID.Hex(),
+		Secret:        m.Secret,
+		SecretKeyID:   m.SecretKeyID,
+		LastCounter:   m.LastCounter,
+		RecoveryCodes: m.RecoveryCodes,
+		CreatedAt:     m.CreatedAt,
+		ConfirmedAt:   m.ConfirmedAt,
+	}
+}
+
+// NewMongoTwoFactor converts TwoFactor model to MongoTwoFactor model.
+func NewMongoTwoFactor(t model.TwoFactor) (*MongoTwoFactor, error) {
+	id, err := primitive.ObjectIDFromHex(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoTwoFactor{
+		UserID:        id,
+		Secret:        t.Secret,
+		SecretKeyID:   t.SecretKeyID,
+		LastCounter:   t.LastCounter,
+		RecoveryCodes: t.RecoveryCodes,
+		CreatedAt:     t.CreatedAt,
+		ConfirmedAt:   t.ConfirmedAt,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoTwoFactorStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	twoFactorStorage := NewMongoTwoFactorStorage(st)
+	defer func() {
+		require.NoError(t, twoFactorStorage.twoFactorCollection.Drop(ctx))
+	}()
+
+	t.Run("convertation error", func(t *testing.T) {
+		_, err := twoFactorStorage.UseTOTPCounter(ctx, "invalid", 1)
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		user, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		twoFactor, err := twoFactorStorage.FindTwoFactor(ctx, user.ID)
+		require.NoError(t, err)
+		require.Nil(t, twoFactor)
+
+		require.NoError(t, twoFactorStorage.PutTwoFactor(ctx, model.TwoFactor{UserID: user.ID, Secret: []byte("old"), SecretKeyID: "k1"}))
+		require.NoError(t, twoFactorStorage.PutTwoFactor(ctx, model.TwoFactor{UserID: user.ID, Secret: []byte("secret"), SecretKeyID: "k1"}))
+		require.NoError(t, twoFactorStorage.ConfirmTwoFactor(ctx, user.ID, 10, []string{"code1", "code2"}))
+		require.Error(t, twoFactorStorage.ConfirmTwoFactor(ctx, user.ID, 10, nil))
+		require.Error(t, twoFactorStorage.PutTwoFactor(ctx, model.TwoFactor{UserID: user.ID, Secret: []byte("other")}))
+
+		twoFactor, err = twoFactorStorage.FindTwoFactor(ctx, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, []byte("secret"), twoFactor.Secret)
+		require.False(t, twoFactor.ConfirmedAt.IsZero())
+		users, err := st.Find(ctx, model.UserFindFilter{IDs: []string{user.ID}})
+		require.NoError(t, err)
+		require.True(t, users[0].TwoFactorEnabled)
+
+		for counter, accepted := range map[int64]bool{10: false, 9: false, 11: true} {
+			ok, err := twoFactorStorage.UseTOTPCounter(ctx, user.ID, counter)
+			require.NoError(t, err)
+			require.Equal(t, accepted, ok, counter)
+		}
+		ok, err := twoFactorStorage.UseRecoveryCode(ctx, user.ID, "code1")
+		require.NoError(t, err)
+		require.True(t, ok)
+		ok, err = twoFactorStorage.UseRecoveryCode(ctx, user.ID, "code1")
+		require.NoError(t, err)
+		require.False(t, ok)
+		require.NoError(t, twoFactorStorage.ReplaceRecoveryCodes(ctx, user.ID, []string{"code3"}))
+		twoFactor, err = twoFactorStorage.FindTwoFactor(ctx, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"code3"}, twoFactor.RecoveryCodes)
+
+		require.NoError(t, twoFactorStorage.DeleteTwoFactor(ctx, user.ID))
+		twoFactor, err = twoFactorStorage.FindTwoFactor(ctx, user.ID)
+		require.NoError(t, err)
+		require.Nil(t, twoFactor)
+		users, err = st.Find(ctx, model.UserFindFilter{IDs: []string{user.ID}})
+		require.NoError(t, err)
+		require.False(t, users[0].TwoFactorEnabled)
+	})
+}
@@ -0,0 +1,346 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	commonStorage "github.com/open-Q/common/golang/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	verificationCollection     = "verification"
+	verificationRateCollection = "verification_rate"
+	// ChallengeRetention is how long challenges are kept after they expire,
+	// so they are counted by rate limits.
+	ChallengeRetention = 24 * time.Hour
+)
+
+// MongoVerificationStorage represents mongo verification storage model.
+type MongoVerificationStorage struct {
+	client                 *mongo.Client
+	verificationCollection *commonStorage.MongoCollection
+	rateCollection         *commonStorage.MongoCollection
+	userCollection         *commonStorage.MongoCollection
+}
+
+// MongoVerificationChallenge represents verification challenge mongo storage model.
+type MongoVerificationChallenge struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `bson:"user_id"`
+	ContactKey string             `bson:"contact_key"`
+	ValueHash  string             `bson:"value_hash"`
+	Kind       string             `bson:"kind"`
+	Hash       string             `bson:"hash"`
+	Attempts   int                `bson:"attempts"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+	CreatedAt  time.Time          `bson:"created_at,omitempty"`
+	UsedAt     time.Time          `bson:"used_at,omitempty"`
+}
+
+// MongoVerificationRate represents issued challenges of the user's contact mongo storage model.
+// It keeps creation times of the latest challenges up to the rate limit.
+type MongoVerificationRate struct {
+	UserID     primitive.ObjectID `bson:"user_id"`
+	ContactKey string             `bson:"contact_key"`
+	IssuedAt   []time.Time        `bson:"issued_at"`
+	ExpiresAt  time.Time          `bson:"expires_at"`
+}
+
+// MongoContactVerification represents user's contact verification mongo storage model.
+type MongoContactVerification struct {
+	ValueHash  string    `bson:"value_hash"`
+	VerifiedAt time.Time `bson:"verified_at"`
+}
+
+// NewMongoVerificationStorage returns new MongoVerificationStorage instance
+// which shares the connection with the user storage.
+// Challenges are removed by the TTL index created here once ChallengeRetention passes after they expire.
+func NewMongoVerificationStorage(ctx context.Context, s *MongoStorage) (*MongoVerificationStorage, error) {
+	collection := s.database.Collection(verificationCollection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "contact_key", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    bson.M{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(ChallengeRetention / time.Second)),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create verification indexes")
+	}
+	rates := s.database.Collection(verificationRateCollection)
+	_, err = rates.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "contact_key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.M{"expires_at": 1},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create verification rate indexes")
+	}
+	return &MongoVerificationStorage{
+		client: s.client,
+		verificationCollection: &commonStorage.MongoCollection{
+			Collection: collection,
+		},
+		rateCollection: &commonStorage.MongoCollection{
+			Collection: rates,
+		},
+		userCollection: s.userCollection,
+	}, nil
+}
+
+// AddChallenge adds a new verification challenge unless limit challenges of the user's contact
+// were issued since the time, ErrChallengeLimit is returned then. Creation time is set by the storage.
+// Issue times are kept in a single document per contact updated in the same transaction,
+// so concurrent challenges can't exceed the limit.
+func (s *MongoVerificationStorage) AddChallenge(ctx context.Context, challenge model.VerificationChallenge, limit int, since time.Time) (*model.VerificationChallenge, error) {
+	mChallenge, err := NewMongoVerificationChallenge(challenge)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+	mChallenge.CreatedAt = now()
+
+	// the contact is limited once it has limit issue times and the oldest one is within the window,
+	// the limited contact doesn't match and the upsert violates the unique index.
+	rateFilter := bson.M{
+		"user_id":     mChallenge.UserID,
+		"contact_key": mChallenge.ContactKey,
+		"$or": bson.A{
+			bson.M{fmt.Sprintf("issued_at.%d", limit-1): bson.M{"$exists": false}},
+			bson.M{"issued_at.0": bson.M{"$lte": since}},
+		},
+	}
+	rateUpdate := bson.M{
+		"$push": bson.M{
+			"issued_at": bson.M{
+				"$each":  bson.A{mChallenge.CreatedAt},
+				"$slice": -limit,
+			},
+		},
+		"$set": bson.M{
+			"expires_at": mChallenge.CreatedAt.Add(ChallengeRetention),
+		},
+	}
+	opts := options.Update().SetUpsert(true)
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageInsertError(err.Error()), err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		if _, err := s.rateCollection.UpdateOne(sc, rateFilter, rateUpdate, opts); err != nil {
+			if isDuplicateKeyError(err) {
+				return nil, ErrChallengeLimit
+			}
+			return nil, err
+		}
+		res, err := s.verificationCollection.InsertOne(sc, mChallenge)
+		if err != nil {
+			return nil, err
+		}
+		mChallenge.ID = res.InsertedID.(primitive.ObjectID)
+		return nil, nil
+	})
+	if errors.Is(err, ErrChallengeLimit) {
+		return nil, ErrChallengeLimit
+	}
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageInsertError(err.Error()), err)
+	}
+
+	return mChallenge.ToVerificationChallenge(), nil
+}
+
+// FindChallenges returns unused challenges of the user's contact not expired at the time, newest first.
+func (s *MongoVerificationStorage) FindChallenges(ctx context.Context, userID, contactKey string, at time.Time) ([]model.VerificationChallenge, error) {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := s.verificationCollection.Find(ctx, activeChallengesFilter(id, contactKey, at), opts)
+	if err != nil {
+		return nil, classify(commonErrors.NewStorageFindError(err.Error()), err)
+	}
+	defer closeCursor(ctx, cursor)
+
+	var mChallenges []MongoVerificationChallenge
+	if err := cursor.All(ctx, &mChallenges); err != nil {
+		return nil, classify(commonErrors.NewStorageConvertError(err.Error()), err)
+	}
+
+	challenges := make([]model.VerificationChallenge, len(mChallenges))
+	for i := range mChallenges {
+		challenges[i] = *mChallenges[i].ToVerificationChallenge()
+	}
+
+	return challenges, nil
+}
+
+// AddChallengeAttempts counts failed confirmation of every unused challenge
+// of the user's contact not expired at the time.
+func (s *MongoVerificationStorage) AddChallengeAttempts(ctx context.Context, userID, contactKey string, at time.Time) error {
+	id, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	update := bson.M{
+		"$inc": bson.M{
+			"attempts": 1,
+		},
+	}
+	if _, err := s.verificationCollection.UpdateMany(ctx, activeChallengesFilter(id, contactKey, at), update); err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// UseChallenge marks the challenge used and records the user's contact verification
+// in a single transaction, so every challenge is used once.
+func (s *MongoVerificationStorage) UseChallenge(ctx context.Context, challenge model.VerificationChallenge, verification model.ContactVerification) error {
+	ids, err := newObjectIDs([]string{challenge.ID, challenge.UserID})
+	if err != nil {
+		return commonErrors.NewStorageConvertError(err.Error())
+	}
+
+	at := now()
+	verification.VerifiedAt = at
+	challengeFilter := bson.M{
+		"_id": ids[0],
+		"used_at": bson.M{
+			"$exists": false,
+		},
+	}
+	challengeUpdate := bson.M{
+		"$set": bson.M{
+			"used_at": at,
+		},
+	}
+	userUpdate := bson.M{
+		"$set": bson.M{
+			"verified_contacts." + challenge.ContactKey: NewMongoContactVerification(verification),
+		},
+	}
+
+	session, err := s.client.StartSession()
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		res, err := s.verificationCollection.UpdateOne(sc, challengeFilter, challengeUpdate)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errors.New("challenge not found or is already used")
+		}
+		res, err = s.userCollection.UpdateOne(sc, bson.M{"_id": ids[1]}, userUpdate)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, errors.New("user not found")
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return classify(commonErrors.NewStorageUpdateError(err.Error()), err)
+	}
+	return nil
+}
+
+// activeChallengesFilter returns filter of unused challenges of the user's contact not expired at the time.
+func activeChallengesFilter(userID primitive.ObjectID, contactKey string, at time.Time) bson.M {
+	return bson.M{
+		"user_id":     userID,
+		"contact_key": contactKey,
+		"expires_at": bson.M{
+			"$gt": at,
+		},
+		"used_at": bson.M{
+			"$exists": false,
+		},
+	}
+}
+
+// ToVerificationChallenge converts MongoVerificationChallenge model to VerificationChallenge model.
+func (m MongoVerificationChallenge) ToVerificationChallenge() *model.VerificationChallenge {
+	challenge := model.VerificationChallenge{
+		UserID:     m.UserID.Hex(),
+		ContactKey: m.ContactKey,
+		ValueHash:  m.ValueHash,
+		Kind:       m.Kind,
+		Hash:       m.Hash,
+		Attempts:   m.Attempts,
+		ExpiresAt:  m.ExpiresAt,
+		CreatedAt:  m.CreatedAt,
+		UsedAt:     m.UsedAt,
+	}
+	if !m.ID.IsZero() {
+		challenge.ID = m.ID.Hex()
+	}
+	return &challenge
+}
+
+// NewMongoVerificationChallenge converts VerificationChallenge model to MongoVerificationChallenge model.
+func NewMongoVerificationChallenge(c model.VerificationChallenge) (*MongoVerificationChallenge, error) {
+	userID, err := primitive.ObjectIDFromHex(c.UserID)
+	if err != nil {
+		return nil, err
+	}
+	challenge := MongoVerificationChallenge{
+		UserID:     userID,
+		ContactKey: c.ContactKey,
+		ValueHash:  c.ValueHash,
+		Kind:       c.Kind,
+		Hash:       c.Hash,
+		Attempts:   c.Attempts,
+		ExpiresAt:  c.ExpiresAt,
+		CreatedAt:  c.CreatedAt,
+		UsedAt:     c.UsedAt,
+	}
+	if c.ID != "" {
+		id, err := primitive.ObjectIDFromHex(c.ID)
+		if err != nil {
+			return nil, err
+		}
+		challenge.ID = id
+	}
+	return &challenge, nil
+}
+
+// ToContactVerification converts MongoContactVerification model to ContactVerification model.
+func (m MongoContactVerification) ToContactVerification() model.ContactVerification {
+	return model.ContactVerification{
+		ValueHash:  m.ValueHash,
+		VerifiedAt: m.VerifiedAt,
+	}
+}
+
+// NewMongoContactVerification converts ContactVerification model to MongoContactVerification model.
+func NewMongoContactVerification(v model.ContactVerification) MongoContactVerification {
+	return MongoContactVerification{
+		ValueHash:  v.ValueHash,
+		VerifiedAt: v.VerifiedAt,
+	}
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	commonErrors "github.com/open-Q/common/golang/errors"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoVerificationStorage(t *testing.T) {
+	ctx := context.Background()
+	st := createTestMongoStorage(t)
+	defer clearMongoStorage(t, st)
+	verificationStorage, err := NewMongoVerificationStorage(ctx, st)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, verificationStorage.verificationCollection.Drop(ctx))
+		require.NoError(t, verificationStorage.rateCollection.Drop(ctx))
+	}()
+
+	t.Run("convertation error", func(t *testing.T) {
+		err := verificationStorage.AddChallengeAttempts(ctx, "invalid", "email", time.Now())
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageConvert))
+	})
+	t.Run("all ok", func(t *testing.T) {
+		user, err := st.Add(ctx, model.User{Status: "ACCOUNT_STATUS_ACTIVE"})
+		require.NoError(t, err)
+		since := time.Now().Add(-time.Minute)
+		expired, err := verificationStorage.AddChallenge(ctx, model.VerificationChallenge{
+			UserID:     user.ID,
+			ContactKey: "email",
+			Kind:       model.VerificationToken,
+			Hash:       "hash1",
+			ExpiresAt:  time.Now().Add(-time.Second),
+		}, 2, since)
+		require.NoError(t, err)
+		challenge, err := verificationStorage.AddChallenge(ctx, model.VerificationChallenge{
+			UserID:     user.ID,
+			ContactKey: "email",
+			ValueHash:  "value",
+			Kind:       model.VerificationToken,
+			Hash:       "hash2",
+			ExpiresAt:  time.Now().Add(time.Hour),
+		}, 2, since)
+		require.NoError(t, err)
+		require.NotEqual(t, expired.ID, challenge.ID)
+
+		_, err = verificationStorage.AddChallenge(ctx, model.VerificationChallenge{
+			UserID:     user.ID,
+			ContactKey: "email",
+			Kind:       model.VerificationToken,
+			Hash:       "hash3",
+			ExpiresAt:  time.Now().Add(time.Hour),
+		}, 2, since)
+		require.Equal(t, ErrChallengeLimit, err)
+		_, err = verificationStorage.AddChallenge(ctx, model.VerificationChallenge{
+			UserID:     user.ID,
+			ContactKey: "phone",
+			Kind:       model.VerificationCode,
+			Hash:       "hash4",
+			ExpiresAt:  time.Now().Add(time.Hour),
+		}, 2, since)
+		require.NoError(t, err)
+
+		require.NoError(t, verificationStorage.AddChallengeAttempts(ctx, user.ID, "email", time.Now()))
+		challenges, err := verificationStorage.FindChallenges(ctx, user.ID, "email", time.Now())
+		require.NoError(t, err)
+		require.Len(t, challenges, 1)
+		require.Equal(t, 1, challenges[0].Attempts)
+
+		require.NoError(t, verificationStorage.UseChallenge(ctx, *challenge, model.ContactVerification{ValueHash: "value"}))
+		err = verificationStorage.UseChallenge(ctx, *challenge, model.ContactVerification{ValueHash: "value"})
+		require.Error(t, err)
+		require.True(t, errors.Is(err, commonErrors.ErrStorageUpdate))
+
+		challenges, err = verificationStorage.FindChallenges(ctx, user.ID, "email", time.Now())
+		require.NoError(t, err)
+		require.Empty(t, challenges)
+		users, err := st.Find(ctx, model.UserFindFilter{IDs: []string{user.ID}})
+		require.NoError(t, err)
+		require.Equal(t, "value", users[0].VerifiedContacts["email"].ValueHash)
+		require.False(t, users[0].VerifiedContacts["email"].VerifiedAt.IsZero())
+	})
+}
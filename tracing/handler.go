@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/micro/go-micro/v2/metadata"
+	"github.com/micro/go-micro/v2/server"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HandlerWrapper returns go-micro handler wrapper which continues the trace
+// from the request metadata and creates a span for every handled request.
+func HandlerWrapper(tp trace.TracerProvider) server.HandlerWrapper {
+	tracer := tp.Tracer(instrumentationName)
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx context.Context, req server.Request, rsp interface{}) error {
+			md, _ := metadata.FromContext(ctx)
+			ctx = propagator.Extract(ctx, metadataCarrier(md))
+
+			ctx, span := tracer.Start(ctx, req.Endpoint(),
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.RPCSystemKey.String("go-micro"),
+					semconv.RPCServiceKey.String(req.Service()),
+					semconv.RPCMethodKey.String(req.Method()),
+				),
+			)
+			defer span.End()
+
+			err := next(ctx, req, rsp)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return err
+		}
+	}
+}
+
+// metadataCarrier adapts go-micro metadata to the propagation carrier.
+type metadataCarrier metadata.Metadata
+
+// Get returns the value of the metadata key.
+func (c metadataCarrier) Get(key string) string {
+	v, _ := metadata.Metadata(c).Get(key)
+	return v
+}
+
+// Set sets the metadata key.
+func (c metadataCarrier) Set(key, value string) {
+	metadata.Metadata(c).Set(key, value)
+}
+
+// Keys lists the metadata keys.
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/micro/go-micro/v2/metadata"
+	"github.com/micro/go-micro/v2/server"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testRequest struct {
+	server.Request
+}
+
+func (testRequest) Service() string  { return "user" }
+func (testRequest) Method() string   { return "User.Create" }
+func (testRequest) Endpoint() string { return "User.Create" }
+
+func TestHandlerWrapper(t *testing.T) {
+	t.Run("trace is continued", func(t *testing.T) {
+		tp, sr := newTestProvider()
+		ctx := metadata.NewContext(context.Background(), metadata.Metadata{
+			"Traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		})
+		handler := HandlerWrapper(tp)(func(ctx context.Context, _ server.Request, _ interface{}) error {
+			require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			return nil
+		})
+		require.NoError(t, handler(ctx, testRequest{}, nil))
+
+		spans := sr.Ended()
+		require.Len(t, spans, 1)
+		require.Equal(t, "User.Create", spans[0].Name())
+		require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+		require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+		require.True(t, spans[0].Parent().IsRemote())
+	})
+	t.Run("error is recorded", func(t *testing.T) {
+		tp, sr := newTestProvider()
+		handler := HandlerWrapper(tp)(func(context.Context, server.Request, interface{}) error {
+			return errors.New("error")
+		})
+		require.Error(t, handler(context.Background(), testRequest{}, nil))
+
+		spans := sr.Ended()
+		require.Len(t, spans, 1)
+		require.False(t, spans[0].Parent().IsValid())
+		require.Equal(t, codes.Error, spans[0].Status().Code)
+		require.Equal(t, "error", spans[0].Status().Description)
+	})
+}
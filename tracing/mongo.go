@@ -0,0 +1,83 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// commandMonitor creates a span for every mongo command.
+type commandMonitor struct {
+	tracer trace.Tracer
+	spans  sync.Map
+}
+
+// CommandMonitor returns mongo command monitor which creates a span for every
+// command with the command name and collection.
+func CommandMonitor(tp trace.TracerProvider) *event.CommandMonitor {
+	m := commandMonitor{
+		tracer: tp.Tracer(instrumentationName),
+	}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+	}
+}
+
+func (m *commandMonitor) started(ctx context.Context, e *event.CommandStartedEvent) {
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemMongoDB,
+		semconv.DBNameKey.String(e.DatabaseName),
+		semconv.DBOperationKey.String(e.CommandName),
+		semconv.NetPeerNameKey.String(peerName(e.ConnectionID)),
+	}
+	name := e.CommandName
+	if collection := commandCollection(e); collection != "" {
+		attrs = append(attrs, semconv.DBMongoDBCollectionKey.String(collection))
+		name += " " + collection
+	}
+
+	_, span := m.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	m.spans.Store(e.RequestID, span)
+}
+
+func (m *commandMonitor) succeeded(_ context.Context, e *event.CommandSucceededEvent) {
+	if span, ok := m.spans.LoadAndDelete(e.RequestID); ok {
+		span.(trace.Span).End()
+	}
+}
+
+func (m *commandMonitor) failed(_ context.Context, e *event.CommandFailedEvent) {
+	if span, ok := m.spans.LoadAndDelete(e.RequestID); ok {
+		span.(trace.Span).SetStatus(codes.Error, e.Failure)
+		span.(trace.Span).End()
+	}
+}
+
+// commandCollection returns the collection the command is applied to.
+func commandCollection(e *event.CommandStartedEvent) string {
+	if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+		return collection
+	}
+	// getMore keeps the cursor ID under the command name.
+	collection, _ := e.Command.Lookup("collection").StringValueOK()
+	return collection
+}
+
+// peerName returns server address of the connection ID in the host:port[-N] format.
+func peerName(connectionID string) string {
+	if i := strings.LastIndex(connectionID, "["); i != -1 {
+		return connectionID[:i]
+	}
+	return connectionID
+}
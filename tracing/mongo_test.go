@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newCommandStartedEvent(t *testing.T, requestID int64, name string, command bson.D) *event.CommandStartedEvent {
+	raw, err := bson.Marshal(command)
+	require.NoError(t, err)
+	return &event.CommandStartedEvent{
+		Command:      raw,
+		DatabaseName: "test-db",
+		CommandName:  name,
+		RequestID:    requestID,
+		ConnectionID: "127.0.0.1:27017[-3]",
+	}
+}
+
+func TestCommandMonitor(t *testing.T) {
+	tp, sr := newTestProvider()
+	monitor := CommandMonitor(tp)
+
+	monitor.Started(context.Background(), newCommandStartedEvent(t, 1, "insert", bson.D{{Key: "insert", Value: "user"}}))
+	monitor.Started(context.Background(), newCommandStartedEvent(t, 2, "getMore", bson.D{
+		{Key: "getMore", Value: int64(42)},
+		{Key: "collection", Value: "user"},
+	}))
+	monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1},
+	})
+	monitor.Failed(context.Background(), &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2},
+		Failure:              "cursor not found",
+	})
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "insert user", spans[0].Name())
+	require.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	require.Subset(t, spans[0].Attributes(), []interface{}{
+		semconv.DBSystemMongoDB,
+		semconv.DBNameKey.String("test-db"),
+		semconv.DBOperationKey.String("insert"),
+		semconv.DBMongoDBCollectionKey.String("user"),
+		semconv.NetPeerNameKey.String("127.0.0.1:27017"),
+	})
+	require.Equal(t, "getMore user", spans[1].Name())
+	require.Equal(t, codes.Error, spans[1].Status().Code)
+	require.Equal(t, "cursor not found", spans[1].Status().Description)
+}
@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	userIDKey       = attribute.Key("user.id")
+	sourceUserIDKey = attribute.Key("user.source_id")
+)
+
+// Storage represents user storage decorator which creates a span for every storage call.
+type Storage struct {
+	next   storage.User
+	tracer trace.Tracer
+}
+
+// NewStorage creates new Storage instance.
+func NewStorage(next storage.User, tp trace.TracerProvider) *Storage {
+	return &Storage{
+		next:   next,
+		tracer: tp.Tracer(instrumentationName),
+	}
+}
+
+// Disconnect breaks storage connection.
+func (s *Storage) Disconnect(ctx context.Context) error {
+	return s.next.Disconnect(ctx)
+}
+
+// Add adds a new user.
+func (s *Storage) Add(ctx context.Context, user model.User) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.Add")
+	defer end(span, &err)
+	return s.next.Add(ctx, user)
+}
+
+// Delete removes an existing user by ID.
+func (s *Storage) Delete(ctx context.Context, userID string) (err error) {
+	ctx, span := s.start(ctx, "storage.Delete", userIDKey.String(userID))
+	defer end(span, &err)
+	return s.next.Delete(ctx, userID)
+}
+
+// Anonymise removes user's personal data.
+func (s *Storage) Anonymise(ctx context.Context, user model.User) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.Anonymise", userIDKey.String(user.ID))
+	defer end(span, &err)
+	return s.next.Anonymise(ctx, user)
+}
+
+// TouchActivity moves user's last activity time forward.
+func (s *Storage) TouchActivity(ctx context.Context, userID string, at time.Time) (err error) {
+	ctx, span := s.start(ctx, "storage.TouchActivity", userIDKey.String(userID))
+	defer end(span, &err)
+	return s.next.TouchActivity(ctx, userID, at)
+}
+
+// Update updates an existing user.
+func (s *Storage) Update(ctx context.Context, user model.User) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.Update", userIDKey.String(user.ID))
+	defer end(span, &err)
+	return s.next.Update(ctx, user)
+}
+
+// ChangeStatus changes user's status.
+func (s *Storage) ChangeStatus(ctx context.Context, userID string, change model.StatusChange) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.ChangeStatus", userIDKey.String(userID))
+	defer end(span, &err)
+	return s.next.ChangeStatus(ctx, userID, change)
+}
+
+// AddStatusSchedule adds status change scheduled for the future.
+func (s *Storage) AddStatusSchedule(ctx context.Context, userID string, schedule model.StatusSchedule) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.AddStatusSchedule", userIDKey.String(userID))
+	defer end(span, &err)
+	return s.next.AddStatusSchedule(ctx, userID, schedule)
+}
+
+// RemoveStatusSchedule removes pending status change.
+func (s *Storage) RemoveStatusSchedule(ctx context.Context, userID, scheduleID string) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.RemoveStatusSchedule", userIDKey.String(userID))
+	defer end(span, &err)
+	return s.next.RemoveStatusSchedule(ctx, userID, scheduleID)
+}
+
+// Merge merges the source user into the target one.
+func (s *Storage) Merge(ctx context.Context, merge model.Merge) (res *model.User, err error) {
+	ctx, span := s.start(ctx, "storage.Merge", userIDKey.String(merge.TargetID), sourceUserIDKey.String(merge.SourceID))
+	defer end(span, &err)
+	return s.next.Merge(ctx, merge)
+}
+
+// Find finds users by filter.
+func (s *Storage) Find(ctx context.Context, filter model.UserFindFilter) (res []model.User, err error) {
+	ctx, span := s.start(ctx, "storage.Find")
+	defer end(span, &err)
+	return s.next.Find(ctx, filter)
+}
+
+func (s *Storage) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+func end(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
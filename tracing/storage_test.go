@@ -0,0 +1,52 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStorage_Update(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	tp, sr := newTestProvider()
+	s := NewStorage(st, tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "User.Update")
+	st.On("Update", mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanContextFromContext(ctx).IsValid()
+	}), model.User{ID: "1"}).Return(&model.User{ID: "1"}, nil).Once()
+	res, err := s.Update(ctx, model.User{ID: "1"})
+	require.NoError(t, err)
+	require.Equal(t, "1", res.ID)
+	parent.End()
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "storage.Update", spans[0].Name())
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	require.Contains(t, spans[0].Attributes(), userIDKey.String("1"))
+}
+
+func TestStorage_Find(t *testing.T) {
+	st := new(storageMocks.User)
+	defer st.AssertExpectations(t)
+	tp, sr := newTestProvider()
+	s := NewStorage(st, tp)
+
+	st.On("Find", mock.Anything, mock.Anything).Return(nil, errors.New("error")).Once()
+	_, err := s.Find(context.Background(), model.UserFindFilter{})
+	require.Error(t, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "storage.Find", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
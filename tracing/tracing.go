@@ -0,0 +1,98 @@
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// There are supported span exporters.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterFile   = "file"
+	ExporterStdout = "stdout"
+)
+
+const instrumentationName = "github.com/open-Q/user"
+
+// propagator reads W3C trace context and baggage from request metadata.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Config represents tracing configuration.
+type Config struct {
+	// Exporter is one of otlp, file, stdout.
+	Exporter string
+	// Endpoint is the OTLP/gRPC collector address, e.g. localhost:4317.
+	Endpoint string
+	// Insecure disables TLS of the OTLP exporter connection.
+	Insecure bool
+	// File is a file the spans are appended to by the file exporter.
+	File string
+	// SampleRatio is the ratio of sampled traces started by the service, all traces are sampled if zero.
+	SampleRatio    float64
+	ServiceName    string
+	ServiceVersion string
+}
+
+// NewProvider creates new tracer provider which exports spans with the configured exporter.
+func NewProvider(cfg Config) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(cfg.ServiceName),
+			semconv.ServiceVersionKey.String(cfg.ServiceVersion),
+		)),
+	), nil
+}
+
+func newExporter(cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		if cfg.Endpoint == "" {
+			return nil, errors.New("otlp exporter requires an endpoint")
+		}
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		// the connection is established in the background, so the exporter is created at once.
+		return otlptracegrpc.New(context.Background(), opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		if cfg.File == "" {
+			return nil, errors.New("file exporter requires a file")
+		}
+		//nolint:gosec // file path comes from the service configuration.
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open spans file")
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+		return &fileExporter{Exporter: exporter, file: file}, nil
+	}
+	return nil, errors.Errorf("unknown exporter %q", cfg.Exporter)
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)), sr
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Run("unknown exporter", func(t *testing.T) {
+		_, err := NewProvider(Config{Exporter: "jaeger"})
+		require.Error(t, err)
+		require.EqualError(t, err, `unknown exporter "jaeger"`)
+	})
+	t.Run("otlp exporter without endpoint", func(t *testing.T) {
+		_, err := NewProvider(Config{Exporter: ExporterOTLP})
+		require.Error(t, err)
+		require.EqualError(t, err, "otlp exporter requires an endpoint")
+	})
+	t.Run("all ok (otlp exporter)", func(t *testing.T) {
+		tp, err := NewProvider(Config{
+			Exporter: ExporterOTLP,
+			Endpoint: "localhost:4317",
+			Insecure: true,
+		})
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		// nothing was exported, so shutdown doesn't wait for the collector.
+		require.NoError(t, tp.Shutdown(ctx))
+	})
+	t.Run("file exporter without file", func(t *testing.T) {
+		_, err := NewProvider(Config{Exporter: ExporterFile})
+		require.Error(t, err)
+		require.EqualError(t, err, "file exporter requires a file")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "spans.json")
+		tp, err := NewProvider(Config{
+			Exporter:    ExporterFile,
+			File:        file,
+			ServiceName: "user",
+		})
+		require.NoError(t, err)
+		_, span := tp.Tracer("test").Start(context.Background(), "User.Create")
+		span.End()
+		require.NoError(t, tp.Shutdown(context.Background()))
+
+		data, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+		require.Contains(t, string(data), `"Name":"User.Create"`)
+	})
+}
@@ -0,0 +1,246 @@
+package verification
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	"github.com/open-Q/user/storage/model"
+	"github.com/pkg/errors"
+)
+
+// There are default verification settings.
+const (
+	DefaultCodeTTL     = 10 * time.Minute
+	DefaultTokenTTL    = 24 * time.Hour
+	DefaultRateLimit   = 5
+	DefaultRateWindow  = time.Hour
+	DefaultMaxAttempts = 5
+)
+
+// There are verification secret settings.
+const (
+	codeDigits  = 6
+	tokenLength = 32
+)
+
+// There are verification errors.
+var (
+	ErrRateLimited    = errors.New("too many verification challenges, try again later")
+	ErrUnknownContact = errors.New("contact key isn't verifiable")
+	ErrNoContact      = errors.New("user has no such contact")
+	ErrUnknownKind    = errors.New("unknown verification kind")
+)
+
+// Config represents verification manager configuration.
+type Config struct {
+	Storage  storage.Verification
+	Notifier Notifier
+	// ContactKeys are meta keys holding contacts which may be verified, e.g. "email" or "phone".
+	ContactKeys []string
+	// CodeTTL and TokenTTL limit challenge lifetime, DefaultCodeTTL and DefaultTokenTTL are used if empty.
+	CodeTTL  time.Duration
+	TokenTTL time.Duration
+	// RateLimit is a number of challenges of the user's contact issued within RateWindow,
+	// DefaultRateLimit and DefaultRateWindow are used if empty.
+	RateLimit  int
+	RateWindow time.Duration
+	// MaxAttempts is a number of failed confirmations invalidating the challenge,
+	// DefaultMaxAttempts is used if empty.
+	MaxAttempts int
+}
+
+// Manager issues and confirms contact verification challenges.
+type Manager struct {
+	storage     storage.Verification
+	notifier    Notifier
+	contactKeys map[string]struct{}
+	codeTTL     time.Duration
+	tokenTTL    time.Duration
+	rateLimit   int
+	rateWindow  time.Duration
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewManager creates new Manager instance.
+func NewManager(cfg Config) (*Manager, error) {
+	m := Manager{
+		storage:     cfg.Storage,
+		notifier:    cfg.Notifier,
+		contactKeys: make(map[string]struct{}, len(cfg.ContactKeys)),
+		codeTTL:     cfg.CodeTTL,
+		tokenTTL:    cfg.TokenTTL,
+		rateLimit:   cfg.RateLimit,
+		rateWindow:  cfg.RateWindow,
+		maxAttempts: cfg.MaxAttempts,
+		now:         time.Now,
+	}
+	for i := range cfg.ContactKeys {
+		m.contactKeys[cfg.ContactKeys[i]] = struct{}{}
+	}
+	if m.codeTTL <= 0 {
+		m.codeTTL = DefaultCodeTTL
+	}
+	if m.tokenTTL <= 0 {
+		m.tokenTTL = DefaultTokenTTL
+	}
+	if m.rateLimit <= 0 {
+		m.rateLimit = DefaultRateLimit
+	}
+	if m.rateWindow <= 0 {
+		m.rateWindow = DefaultRateWindow
+	}
+	if m.maxAttempts <= 0 {
+		m.maxAttempts = DefaultMaxAttempts
+	}
+	if m.rateWindow > storage.ChallengeRetention {
+		return nil, errors.Errorf("rate window must not be longer than %s", storage.ChallengeRetention)
+	}
+	return &m, nil
+}
+
+// Issue sends new challenge to the user's contact and returns it.
+func (m *Manager) Issue(ctx context.Context, user model.User, contactKey, kind string) (*model.VerificationChallenge, error) {
+	contact, err := m.contact(user, contactKey)
+	if err != nil {
+		return nil, err
+	}
+	var secret string
+	var ttl time.Duration
+	switch kind {
+	case model.VerificationCode:
+		secret, err = newCode()
+		ttl = m.codeTTL
+	case model.VerificationToken:
+		secret, err = newToken()
+		ttl = m.tokenTTL
+	default:
+		return nil, ErrUnknownKind
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	challenge, err := m.storage.AddChallenge(ctx, model.VerificationChallenge{
+		UserID:     user.ID,
+		ContactKey: contactKey,
+		ValueHash:  ValueHash(contact),
+		Kind:       kind,
+		Hash:       hashSecret(secret),
+		ExpiresAt:  now.Add(ttl),
+	}, m.rateLimit, now.Add(-m.rateWindow))
+	if errors.Is(err, storage.ErrChallengeLimit) {
+		return nil, ErrRateLimited
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not add verification challenge")
+	}
+	if err := m.notifier.Notify(ctx, Message{
+		UserID:     user.ID,
+		ContactKey: contactKey,
+		Contact:    contact,
+		Kind:       kind,
+		Secret:     secret,
+		ExpiresAt:  challenge.ExpiresAt,
+	}); err != nil {
+		return nil, errors.Wrap(err, "could not send verification message")
+	}
+	return challenge, nil
+}
+
+// Confirm verifies the user's contact if the secret matches a challenge sent to its current value.
+// Every challenge is used once, and it's invalidated after too many failed confirmations
+// of any of the contact's challenges.
+func (m *Manager) Confirm(ctx context.Context, user model.User, contactKey, secret string) (bool, error) {
+	contact, err := m.contact(user, contactKey)
+	if err != nil {
+		return false, err
+	}
+	now := m.now()
+	challenges, err := m.storage.FindChallenges(ctx, user.ID, contactKey, now)
+	if err != nil {
+		return false, errors.Wrap(err, "could not find verification challenges")
+	}
+	valueHash := ValueHash(contact)
+	hash := hashSecret(strings.TrimSpace(secret))
+	for i := range challenges {
+		if challenges[i].Attempts >= m.maxAttempts {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hash), []byte(challenges[i].Hash)) != 1 || challenges[i].ValueHash != valueHash {
+			continue
+		}
+		err := m.storage.UseChallenge(ctx, challenges[i], model.ContactVerification{ValueHash: valueHash})
+		if err != nil {
+			return false, errors.Wrap(err, "could not use verification challenge")
+		}
+		return true, nil
+	}
+	// failures are counted by every active challenge, so codes can't be guessed endlessly
+	// by spreading guesses over several challenges.
+	if len(challenges) != 0 {
+		if err := m.storage.AddChallengeAttempts(ctx, user.ID, contactKey, now); err != nil {
+			return false, errors.Wrap(err, "could not count verification attempt")
+		}
+	}
+	return false, nil
+}
+
+// Verified returns verification times of the user's contacts which weren't changed since verified.
+func Verified(user model.User) map[string]time.Time {
+	verified := map[string]time.Time{}
+	for key, verification := range user.VerifiedContacts {
+		contact, ok := user.Meta[key].(string)
+		if ok && ValueHash(contact) == verification.ValueHash {
+			verified[key] = verification.VerifiedAt
+		}
+	}
+	return verified
+}
+
+// ValueHash hashes the contact value, so verifications don't keep contacts in plaintext.
+func ValueHash(contact string) string {
+	return hashSecret(contact)
+}
+
+func (m *Manager) contact(user model.User, contactKey string) (string, error) {
+	if _, ok := m.contactKeys[contactKey]; !ok {
+		return "", ErrUnknownContact
+	}
+	contact, ok := user.Meta[contactKey].(string)
+	if !ok || strings.TrimSpace(contact) == "" {
+		return "", ErrNoContact
+	}
+	return contact, nil
+}
+
+func newCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", errors.Wrap(err, "could not generate verification code")
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n.Int64()), nil
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "could not generate verification token")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
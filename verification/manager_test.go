@@ -0,0 +1,160 @@
+package verification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-Q/user/storage"
+	storageMocks "github.com/open-Q/user/storage/mocks"
+	"github.com/open-Q/user/storage/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type testNotifier struct {
+	messages []Message
+	err      error
+}
+
+func (n *testNotifier) Notify(_ context.Context, message Message) error {
+	n.messages = append(n.messages, message)
+	return n.err
+}
+
+func newTestManager(t *testing.T, st *storageMocks.Verification, notifier Notifier) *Manager {
+	m, err := NewManager(Config{
+		Storage:     st,
+		Notifier:    notifier,
+		ContactKeys: []string{"email"},
+	})
+	require.NoError(t, err)
+	return m
+}
+
+func TestNewManager(t *testing.T) {
+	_, err := NewManager(Config{RateWindow: 48 * time.Hour})
+	require.EqualError(t, err, "rate window must not be longer than 24h0m0s")
+}
+
+func TestManager_Issue(t *testing.T) {
+	user := model.User{ID: "1", Meta: map[string]interface{}{"email": "john@example.com", "age": 30}}
+	t.Run("unknown contact", func(t *testing.T) {
+		_, err := newTestManager(t, new(storageMocks.Verification), nil).Issue(context.Background(), user, "age", model.VerificationCode)
+		require.Equal(t, ErrUnknownContact, err)
+	})
+	t.Run("no contact", func(t *testing.T) {
+		_, err := newTestManager(t, new(storageMocks.Verification), nil).Issue(context.Background(), model.User{ID: "1"}, "email", model.VerificationCode)
+		require.Equal(t, ErrNoContact, err)
+	})
+	t.Run("unknown kind", func(t *testing.T) {
+		_, err := newTestManager(t, new(storageMocks.Verification), nil).Issue(context.Background(), user, "email", "pigeon")
+		require.Equal(t, ErrUnknownKind, err)
+	})
+	t.Run("rate limited", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		st.On("AddChallenge", mock.Anything, mock.Anything, DefaultRateLimit, mock.Anything).Return(nil, storage.ErrChallengeLimit)
+		_, err := newTestManager(t, st, nil).Issue(context.Background(), user, "email", model.VerificationCode)
+		require.Equal(t, ErrRateLimited, err)
+	})
+	t.Run("notify error", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		st.On("AddChallenge", mock.Anything, mock.Anything, DefaultRateLimit, mock.Anything).Return(&model.VerificationChallenge{ID: "c1"}, nil)
+		_, err := newTestManager(t, st, &testNotifier{err: errors.New("smtp error")}).Issue(context.Background(), user, "email", model.VerificationCode)
+		require.EqualError(t, err, "could not send verification message: smtp error")
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		defer st.AssertExpectations(t)
+		notifier := new(testNotifier)
+		m := newTestManager(t, st, notifier)
+		now := time.Now()
+		m.now = func() time.Time {
+			return now
+		}
+		st.On("AddChallenge", mock.Anything, mock.Anything, DefaultRateLimit, now.Add(-DefaultRateWindow)).Return(func(_ context.Context, c model.VerificationChallenge, _ int, _ time.Time) *model.VerificationChallenge {
+			c.ID = "c1"
+			return &c
+		}, nil)
+		challenge, err := m.Issue(context.Background(), user, "email", model.VerificationCode)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(DefaultCodeTTL), challenge.ExpiresAt)
+		require.Equal(t, ValueHash("john@example.com"), challenge.ValueHash)
+		require.Len(t, notifier.messages, 1)
+		require.Len(t, notifier.messages[0].Secret, 6)
+		require.Equal(t, hashSecret(notifier.messages[0].Secret), challenge.Hash)
+	})
+}
+
+func TestManager_Confirm(t *testing.T) {
+	user := model.User{ID: "1", Meta: map[string]interface{}{"email": "john@example.com"}}
+	challenge := model.VerificationChallenge{
+		ID:         "c1",
+		UserID:     "1",
+		ContactKey: "email",
+		ValueHash:  ValueHash("john@example.com"),
+		Hash:       hashSecret("123456"),
+	}
+	t.Run("wrong secret", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		defer st.AssertExpectations(t)
+		st.On("FindChallenges", mock.Anything, "1", "email", mock.Anything).Return([]model.VerificationChallenge{challenge}, nil)
+		st.On("AddChallengeAttempts", mock.Anything, "1", "email", mock.Anything).Return(nil)
+		ok, err := newTestManager(t, st, nil).Confirm(context.Background(), user, "email", "654321")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+	t.Run("changed contact", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		st.On("FindChallenges", mock.Anything, "1", "email", mock.Anything).Return([]model.VerificationChallenge{challenge}, nil)
+		st.On("AddChallengeAttempts", mock.Anything, "1", "email", mock.Anything).Return(nil)
+		changed := model.User{ID: "1", Meta: map[string]interface{}{"email": "jane@example.com"}}
+		ok, err := newTestManager(t, st, nil).Confirm(context.Background(), changed, "email", "123456")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+	t.Run("too many attempts", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		exhausted := challenge
+		exhausted.Attempts = DefaultMaxAttempts
+		st.On("FindChallenges", mock.Anything, "1", "email", mock.Anything).Return([]model.VerificationChallenge{exhausted}, nil)
+		st.On("AddChallengeAttempts", mock.Anything, "1", "email", mock.Anything).Return(nil)
+		ok, err := newTestManager(t, st, nil).Confirm(context.Background(), user, "email", "123456")
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+	t.Run("older challenge", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		defer st.AssertExpectations(t)
+		newer := challenge
+		newer.ID = "c2"
+		newer.Hash = hashSecret("111111")
+		st.On("FindChallenges", mock.Anything, "1", "email", mock.Anything).Return([]model.VerificationChallenge{newer, challenge}, nil)
+		st.On("UseChallenge", mock.Anything, challenge, model.ContactVerification{ValueHash: challenge.ValueHash}).Return(nil)
+		ok, err := newTestManager(t, st, nil).Confirm(context.Background(), user, "email", "123456")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+	t.Run("all ok", func(t *testing.T) {
+		st := new(storageMocks.Verification)
+		defer st.AssertExpectations(t)
+		st.On("FindChallenges", mock.Anything, "1", "email", mock.Anything).Return([]model.VerificationChallenge{challenge}, nil)
+		st.On("UseChallenge", mock.Anything, challenge, model.ContactVerification{ValueHash: challenge.ValueHash}).Return(nil)
+		ok, err := newTestManager(t, st, nil).Confirm(context.Background(), user, "email", " 123456 ")
+		require.NoError(t, err)
+		require.True(t, ok)
+	})
+}
+
+func TestVerified(t *testing.T) {
+	verifiedAt := time.Now()
+	user := model.User{
+		Meta: map[string]interface{}{"email": "john@example.com", "phone": "+100"},
+		VerifiedContacts: map[string]model.ContactVerification{
+			"email": {ValueHash: ValueHash("john@example.com"), VerifiedAt: verifiedAt},
+			"phone": {ValueHash: ValueHash("+200"), VerifiedAt: verifiedAt},
+		},
+	}
+	require.Equal(t, map[string]time.Time{"email": verifiedAt}, Verified(user))
+}
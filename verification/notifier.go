@@ -0,0 +1,89 @@
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	commonLog "github.com/open-Q/common/golang/log"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Message represents verification message sent to the contact.
+// Kind is the verification kind or, for tokens, the token purpose.
+type Message struct {
+	UserID     string    `json:"user_id"`
+	ContactKey string    `json:"contact_key"`
+	Contact    string    `json:"contact"`
+	Kind       string    `json:"kind"`
+	Secret     string    `json:"secret"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Notifier delivers verification messages, e.g. by email or SMS.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// LogNotifier writes verification messages into the service log.
+// It's meant for local runs, secrets are logged in plaintext.
+type LogNotifier struct {
+	logger *commonLog.Logger
+}
+
+// NewLogNotifier creates new LogNotifier instance.
+func NewLogNotifier(logger *commonLog.Logger) *LogNotifier {
+	return &LogNotifier{
+		logger: logger,
+	}
+}
+
+// Notify writes verification message into the log.
+func (n *LogNotifier) Notify(_ context.Context, message Message) error {
+	n.logger.WithFields(logrus.Fields{
+		"user_id":     message.UserID,
+		"contact_key": message.ContactKey,
+		"contact":     message.Contact,
+		"kind":        message.Kind,
+		"secret":      message.Secret,
+		"expires_at":  message.ExpiresAt,
+	}).Info("verification message")
+	return nil
+}
+
+// FileNotifier appends verification messages to the file as JSON lines.
+// It's meant for local runs, secrets are written in plaintext.
+type FileNotifier struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileNotifier creates new FileNotifier instance.
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{
+		path: path,
+	}
+}
+
+// Notify appends verification message to the file.
+func (n *FileNotifier) Notify(_ context.Context, message Message) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal verification message")
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	//nolint:gosec // file path comes from the service configuration.
+	f, err := os.OpenFile(n.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "could not open verification messages file")
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "could not write verification message")
+	}
+	return f.Close()
+}
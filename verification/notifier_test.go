@@ -0,0 +1,28 @@
+package verification
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileNotifier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "messages.json")
+	n := NewFileNotifier(path)
+	require.NoError(t, n.Notify(context.Background(), Message{UserID: "1", Secret: "123456"}))
+	require.NoError(t, n.Notify(context.Background(), Message{UserID: "2", Secret: "654321"}))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	var message Message
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &message))
+	require.Equal(t, "2", message.UserID)
+	require.Equal(t, "654321", message.Secret)
+}